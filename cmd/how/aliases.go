@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/shell"
+)
+
+const (
+	// aliasTTL is how long a dump of the user's aliases is reused, since
+	// starting an interactive shell can take a second.
+	aliasTTL     = time.Hour
+	aliasTimeout = 3 * time.Second
+)
+
+var (
+	loadedAliases map[string]string
+	aliasesLoaded bool
+)
+
+// userAliases returns the aliases defined by the user's $SHELL when the
+// context.aliases setting is on, dumping them at most once per aliasTTL.
+func userAliases(ctx context.Context) map[string]string {
+	if aliasesLoaded {
+		return loadedAliases
+	}
+	aliasesLoaded = true
+	cfg, err := cachedConfig()
+	shellPath := os.Getenv("SHELL")
+	if err != nil || !cfg.Context.Aliases || shellPath == "" {
+		return nil
+	}
+
+	cachePath := ""
+	if dir, err := config.ConfigDir(); err == nil {
+		cachePath = filepath.Join(dir, "aliases-"+filepath.Base(shellPath))
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < aliasTTL {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				loadedAliases = shell.ParseAliases(string(data))
+				return loadedAliases
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, aliasTimeout)
+	defer cancel()
+	aliases, err := shell.DumpAliases(ctx, shellPath)
+	if err != nil {
+		slog.Info("aliases unavailable", "error", err)
+		return nil
+	}
+	if cachePath != "" {
+		var b strings.Builder
+		for name, value := range aliases {
+			b.WriteString(name + "=" + shell.Quote(value) + "\n")
+		}
+		_ = os.MkdirAll(filepath.Dir(cachePath), 0o755)
+		_ = os.WriteFile(cachePath, []byte(b.String()), 0o600)
+	}
+	loadedAliases = aliases
+	return aliases
+}
+
+// withoutAliases drops the user's aliases from a missing-command error,
+// returning nil if they were all that was missing.
+func withoutAliases(ctx context.Context, err error) error {
+	var missing *shell.MissingError
+	if !errors.As(err, &missing) {
+		return err
+	}
+	aliases := userAliases(ctx)
+	names := slices.DeleteFunc(slices.Clone(missing.Names), func(n string) bool {
+		_, ok := aliases[n]
+		return ok
+	})
+	if len(names) == 0 {
+		return nil
+	}
+	return &shell.MissingError{Names: names, Host: missing.Host}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// alternativesOffered is set once alternatives have been fetched, so
+// picking one doesn't fetch alternatives to it in turn.
+var alternativesOffered bool
+
+// alternatives are other commands for a question, fetched in the
+// background while the user reads the first suggestion.
+type alternatives struct {
+	done    chan struct{}
+	results []ui.Result
+	err     error
+	// cancel stops the request once it's no longer wanted.
+	cancel context.CancelFunc
+
+	// The provider, prompt and question the alternatives were asked with,
+	// for repairing the one picked.
+	ctx       context.Context
+	provider  llm.Provider
+	sysPrompt string
+	question  string
+}
+
+// prefetchAlternatives starts asking for cfg.Alternatives commands other
+// than result's, or returns nil when there's nobody at a terminal to pick
+// one, the setting is 0 or they've been offered already. The caller must
+// call cancel when it's done with them.
+func prefetchAlternatives(ctx context.Context, cfg *config.Config, question string, result ui.Result) *alternatives {
+	if cfg.Alternatives <= 0 || alternativesOffered || targetHost != nil || !canCompose() {
+		return nil
+	}
+	alternativesOffered = true
+	ctx, cancel := context.WithCancel(ctx)
+	a := &alternatives{
+		done:     make(chan struct{}),
+		cancel:   cancel,
+		ctx:      ctx,
+		question: question,
+	}
+	go func() {
+		defer close(a.done)
+		a.sysPrompt = systemPrompt(cfg) + machineContext(ctx, cfg) + clockContext()
+		provider, err := newProvider(cfg)
+		if err != nil {
+			a.err = err
+			return
+		}
+		a.provider = provider
+		response, err := provider.Complete(ctx, a.sysPrompt, prompt.AlternativesQuery(question, result.Command, cfg.Alternatives))
+		if err != nil {
+			a.err = err
+			return
+		}
+		for _, alt := range ui.ParseAlternatives(response) {
+			if alt.Command != result.Command && len(a.results) < cfg.Alternatives {
+				a.results = append(a.results, alt)
+			}
+		}
+	}()
+	return a
+}
+
+// wait returns the alternatives, showing a spinner if they're still on
+// their way.
+func (a *alternatives) wait() ([]ui.Result, error) {
+	select {
+	case <-a.done:
+	default:
+		stop := ui.StartSpinner("Fetching alternatives…")
+		<-a.done
+		stop()
+	}
+	return a.results, a.err
+}
+
+// validate checks and repairs the alternative picked, as the first
+// suggestion was.
+func (a *alternatives) validate(alt ui.Result) (ui.Result, error) {
+	alt = markInjections(alt, a.sysPrompt, a.question)
+	stop := ui.StartSpinner("Checking…")
+	defer stop()
+	return validate(a.ctx, a.provider, a.sysPrompt, a.question, alt)
+}
+
+// confirmOrAlternative asks whether to run command, offering the
+// prefetched alternatives. It returns the alternative chosen instead, if
+// any, or whether command ran. The alternatives are cancelled once the
+// prompt is answered with y or n.
+func confirmOrAlternative(command string, alts *alternatives) (ran bool, chosen *ui.Result, err error) {
+	if alts == nil {
+		ran, err = confirmAndRun(command)
+		return ran, nil, err
+	}
+	answer, err := ui.ConfirmOrAlternatives("Run this command?")
+	switch {
+	case err != nil || answer == 'n':
+		alts.cancel()
+		return false, nil, err
+	case answer == 'y':
+		alts.cancel()
+		return true, nil, runCommand(command)
+	}
+
+	results, err := alts.wait()
+	if err != nil || len(results) == 0 {
+		slog.Warn("fetching alternatives", "error", err)
+		ui.DisplayError("no alternatives are available")
+		ran, err = confirmAndRun(command)
+		return ran, nil, err
+	}
+	i, err := ui.ChooseAlternative(results)
+	if err != nil || i < 0 {
+		return false, nil, err
+	}
+	alt, err := alts.validate(results[i])
+	if err != nil {
+		displayAskError(err)
+		return false, nil, err
+	}
+	return false, &alt, nil
+}
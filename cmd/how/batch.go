@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/batch"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newBatchCmd() *cobra.Command {
+	var (
+		output string
+		opts   batch.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "batch [file]",
+		Short: "Answer many queries from a file, one per line",
+		Long: `Answer many queries in one run, reading one query per line from a file
+(or stdin when the file is "-"). Blank lines and lines starting with # are skipped.`,
+		Example: `  how batch queries.txt --output jsonl > commands.jsonl`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ui.CheckListFormat(output); err != nil {
+				return fail("%w", err)
+			}
+
+			var in io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fail("opening queries: %w", err)
+				}
+				defer f.Close() //nolint:errcheck
+				in = f
+			}
+			queries, err := batch.ReadQueries(in)
+			if err != nil {
+				return fail("%w", err)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			provider, err := newProvider(cfg)
+			if err != nil {
+				ui.DisplayError(fmt.Sprintf("initializing provider: %v", err))
+				return err
+			}
+
+			sysPrompt := systemPrompt(cfg)
+			ask := func(ctx context.Context, q string) (string, string, error) {
+				result, err := suggest(ctx, provider, sysPrompt, q)
+				return result.Command, result.Explanation, err
+			}
+
+			failed := 0
+			list := ui.NewListWriter(os.Stdout, output)
+			var writeErr error
+			handle := func(item batch.Item) {
+				if item.Error != "" {
+					failed++
+				}
+				if output == "text" {
+					displayBatchItem(item)
+				} else if err := list.Write(item); err != nil && writeErr == nil {
+					writeErr = err
+				}
+			}
+			if list.Streams() {
+				// Each answer is written as it arrives, in completion order
+				batch.Stream(context.Background(), queries, opts, ask, handle)
+			} else {
+				for _, item := range batch.Run(context.Background(), queries, opts, ask) {
+					handle(item)
+				}
+			}
+			if writeErr == nil {
+				writeErr = list.Flush()
+			}
+			if writeErr != nil {
+				return fail("writing output: %w", writeErr)
+			}
+
+			if failed > 0 {
+				return fail("%d of %d queries failed", failed, len(queries))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text, jsonl, yaml, csv or table")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 4, "Maximum number of queries in flight")
+	cmd.Flags().Float64Var(&opts.Rate, "rate", 0, "Maximum queries started per second (0 for unlimited)")
+	return cmd
+}
+
+func displayBatchItem(item batch.Item) {
+	fmt.Printf("# %s\n", item.Query)
+	if item.Error != "" {
+		ui.DisplayError(item.Error)
+		return
+	}
+	if flagQuiet {
+		fmt.Printf("%s\n\n", item.Command)
+		return
+	}
+	ui.Display(ui.Result{Command: item.Command, Explanation: item.Explanation})
+}
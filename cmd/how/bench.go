@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/bench"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// benchTarget is one provider and model to benchmark.
+type benchTarget struct {
+	name string
+	cfg  *config.Config
+}
+
+// benchResult summarizes one target's run.
+type benchResult struct {
+	Target       string  `json:"target"`
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Correct      int     `json:"correct"`
+	Errors       int     `json:"errors"`
+	Total        int     `json:"total"`
+	MedianMS     int64   `json:"median_ms"`
+	P95MS        int64   `json:"p95_ms"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost_usd"`
+	Priced       bool    `json:"priced"`
+	Error        string  `json:"error,omitempty"`
+}
+
+func newBenchCmd() *cobra.Command {
+	var (
+		models []string
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Compare the latency, cost and correctness of providers and models",
+		Long: `Ask a standard set of everyday questions with known right answers and report,
+for each model, how many answers were right, the median and 95th percentile
+latency, and the token cost at list prices. By default the configured model
+and every profile are benchmarked; pick others with --model.`,
+		Example: `  how bench
+  how bench --model anthropic:claude-haiku-4-5 --model openai:gpt-4o-mini`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ui.CheckListFormat(output); err != nil {
+				return fail("%w", err)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			targets, err := benchTargets(cfg, models)
+			if err != nil {
+				return fail("%w", err)
+			}
+
+			ctx := context.Background()
+			results := make([]benchResult, len(targets))
+			for i, t := range targets {
+				if output == "text" {
+					ui.DisplayNotice("Benchmarking " + t.name + "...")
+				}
+				results[i] = runBench(ctx, t)
+			}
+
+			if output != "text" {
+				list := ui.NewListWriter(os.Stdout, output)
+				for _, r := range results {
+					if err := list.Write(r); err != nil {
+						return fail("writing output: %w", err)
+					}
+				}
+				if err := list.Flush(); err != nil {
+					return fail("writing output: %w", err)
+				}
+				return nil
+			}
+			displayBench(results)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&models, "model", nil, `Model to benchmark, as "provider:model" or a model of the configured provider (repeatable)`)
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text, jsonl, yaml, csv or table")
+	return cmd
+}
+
+// benchTargets returns the configured model and each profile, or the
+// models named with --model.
+func benchTargets(cfg *config.Config, models []string) ([]benchTarget, error) {
+	var targets []benchTarget
+	add := func(name string, c *config.Config) {
+		for _, t := range targets {
+			if t.cfg.Provider == c.Provider && t.cfg.Model() == c.Model() && t.cfg.SystemPrompt == c.SystemPrompt && t.cfg.PromptAdditions == c.PromptAdditions {
+				return
+			}
+		}
+		targets = append(targets, benchTarget{name: name, cfg: c})
+	}
+
+	if len(models) > 0 {
+		for _, m := range models {
+			c := *cfg
+			provider, model, ok := strings.Cut(m, ":")
+			if !ok || !isProvider(provider) {
+				provider, model = cfg.Provider, m
+			}
+			c.Overlay(config.Profile{Provider: provider, Model: model})
+			add(provider+":"+model, &c)
+		}
+		return targets, nil
+	}
+
+	base, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	add(cfg.Provider+":"+cfg.Model(), cfg)
+	names := make([]string, 0, len(base.Profiles))
+	for name := range base.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := *base
+		if err := c.ApplyProfile(name); err != nil {
+			return nil, err
+		}
+		add(fmt.Sprintf("%s (%s:%s)", name, c.Provider, c.Model()), &c)
+	}
+	return targets, nil
+}
+
+func isProvider(name string) bool {
+	return name == "anthropic" || name == "openai" || name == "ollama"
+}
+
+// runBench asks every standard query one at a time, so latencies aren't
+// skewed by concurrent requests.
+func runBench(ctx context.Context, t benchTarget) benchResult {
+	r := benchResult{Target: t.name, Provider: t.cfg.Provider, Model: t.cfg.Model(), Total: len(bench.Queries)}
+	provider, err := llm.NewProvider(t.cfg)
+	if err != nil {
+		r.Error = err.Error()
+		r.Errors = r.Total
+		return r
+	}
+
+	ctx, usage := llm.WithUsage(ctx)
+	sysPrompt := systemPrompt(t.cfg)
+	var latencies []time.Duration
+	for _, q := range bench.Queries {
+		start := time.Now()
+		result, err := suggest(ctx, provider, sysPrompt, q.Question)
+		if exitCode(err) == exitProvider {
+			// The rest would most likely fail the same way
+			r.Errors = r.Total - len(latencies)
+			r.Error = err.Error()
+			break
+		}
+		latencies = append(latencies, time.Since(start))
+		// Answers without a command count as wrong
+		if err == nil && q.Correct(result.Command) {
+			r.Correct++
+		}
+	}
+
+	r.MedianMS = bench.Percentile(latencies, 50).Milliseconds()
+	r.P95MS = bench.Percentile(latencies, 95).Milliseconds()
+	r.InputTokens, r.OutputTokens = usage.Tokens()
+	if price, ok := bench.PriceFor(r.Provider, r.Model); ok {
+		r.Cost, r.Priced = price.Cost(r.InputTokens, r.OutputTokens), true
+	}
+	return r
+}
+
+func displayBench(results []benchResult) {
+	rows := make([][]string, len(results))
+	for i, r := range results {
+		cost := "unknown"
+		if r.Priced {
+			cost = fmt.Sprintf("$%.4f", r.Cost)
+		}
+		median, p95 := "-", "-"
+		if r.Errors < r.Total {
+			median = fmt.Sprintf("%.1fs", float64(r.MedianMS)/1000)
+			p95 = fmt.Sprintf("%.1fs", float64(r.P95MS)/1000)
+		}
+		rows[i] = []string{
+			r.Target,
+			fmt.Sprintf("%d/%d", r.Correct, r.Total),
+			median,
+			p95,
+			fmt.Sprintf("%d/%d", r.InputTokens, r.OutputTokens),
+			cost,
+		}
+		if r.Errors > 0 {
+			rows[i][1] += fmt.Sprintf(" (%d errors)", r.Errors)
+		}
+	}
+	fmt.Println()
+	ui.DisplayTable([]string{"MODEL", "CORRECT", "MEDIAN", "P95", "TOKENS IN/OUT", "COST"}, rows, false)
+	for _, r := range results {
+		if r.Error != "" {
+			ui.DisplayError(fmt.Sprintf("%s: %s", r.Target, r.Error))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/swibrow/how/internal/bench"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// budgetWarned is set once the over-budget notice has been shown, so a
+// batch or REPL session shows it once.
+var budgetWarned bool
+
+// newProvider creates the provider for cfg, or for the budget's fallback
+// profile once this month's budget is spent. What each request uses is
+// recorded against the profile it was made under.
+func newProvider(cfg *config.Config) (llm.Provider, error) {
+	cfg = withinBudget(cfg)
+	p, err := llm.NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return llm.WithMeter(p, func(input, output int64) {
+		recordSpend(cfg, input, output)
+	}), nil
+}
+
+// budgetedProvider checks the budget before every request, for
+// long-running processes such as how daemon and how serve that would
+// otherwise keep the provider they started with once the month's budget
+// is spent.
+type budgetedProvider struct {
+	cfg *config.Config
+
+	mu       sync.Mutex
+	profile  string
+	provider llm.Provider
+}
+
+// newBudgetedProvider is newProvider for long-running processes.
+func newBudgetedProvider(cfg *config.Config) (llm.Provider, error) {
+	b := &budgetedProvider{cfg: cfg}
+	if _, err := b.current(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// current returns the provider for the profile requests use now, making a
+// new one when the budget moves them to another profile.
+func (b *budgetedProvider) current() (llm.Provider, error) {
+	selected, notice := budgetProfile(b.cfg)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	profile := spendProfile(selected)
+	if b.provider != nil && profile == b.profile {
+		return b.provider, nil
+	}
+	p, err := llm.NewProvider(selected)
+	if err != nil {
+		return nil, err
+	}
+	if notice != "" {
+		slog.Warn("over budget", "notice", notice)
+	}
+	b.profile = profile
+	b.provider = llm.WithMeter(p, func(input, output int64) {
+		recordSpend(selected, input, output)
+	})
+	return b.provider, nil
+}
+
+func (b *budgetedProvider) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	p, err := b.current()
+	if err != nil {
+		return "", err
+	}
+	return p.Complete(ctx, systemPrompt, userQuery)
+}
+
+func (b *budgetedProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	p, err := b.current()
+	if err != nil {
+		return nil, err
+	}
+	return llm.Embed(ctx, p, text)
+}
+
+// warmProvider connects to the provider cfg's requests use in the
+// background, so the first request to a long-running process doesn't wait
+// for the TLS handshake.
+func warmProvider(cfg *config.Config) {
+	selected, _ := budgetProfile(cfg)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+		defer cancel()
+		if err := llm.Ping(ctx, selected); err != nil {
+			slog.Debug("warming provider", "error", err)
+		}
+	}()
+}
+
+// spendProfile names the profile cfg's spend is tracked under.
+func spendProfile(cfg *config.Config) string {
+	if cfg.ActiveProfile == "" {
+		return "default"
+	}
+	return cfg.ActiveProfile
+}
+
+// withinBudget returns cfg, or the fallback profile's config if cfg's
+// monthly budget has been reached. Without a fallback, a warning is shown
+// and cfg is used anyway.
+func withinBudget(cfg *config.Config) *config.Config {
+	selected, notice := budgetProfile(cfg)
+	if notice != "" {
+		warnBudget(notice)
+	}
+	return selected
+}
+
+// budgetProfile returns the config requests under cfg use, which is the
+// fallback profile's once cfg's monthly budget has been reached, with a
+// notice saying so. The notice is empty while within budget.
+func budgetProfile(cfg *config.Config) (*config.Config, string) {
+	b := cfg.Budget
+	if b.Monthly <= 0 && b.Tokens <= 0 {
+		return cfg, ""
+	}
+	store, err := openMemoryStore()
+	if err != nil {
+		slog.Warn("checking budget", "error", err)
+		return cfg, ""
+	}
+	defer store.Close() //nolint:errcheck
+
+	spent, err := store.MonthlySpend(context.Background(), spendProfile(cfg), time.Now())
+	if err != nil {
+		slog.Warn("checking budget", "error", err)
+		return cfg, ""
+	}
+	if !overBudget(b, spent) {
+		return cfg, ""
+	}
+
+	used := fmt.Sprintf("The %s profile has used $%.2f and %d tokens this month, over its budget", spendProfile(cfg), spent.Cost, spent.Tokens())
+	if b.Fallback == "" || b.Fallback == cfg.ActiveProfile {
+		return cfg, used
+	}
+	fallback, err := config.LoadProfile(b.Fallback)
+	if err != nil {
+		return cfg, fmt.Sprintf("%s, and the fallback can't be used: %v", used, err)
+	}
+	return fallback, fmt.Sprintf("%s; using the %s profile (%s %s)", used, b.Fallback, fallback.Provider, fallback.Model())
+}
+
+// overBudget reports whether spent has reached either of b's limits.
+func overBudget(b config.BudgetConfig, spent memory.Spend) bool {
+	return (b.Monthly > 0 && spent.Cost >= b.Monthly) || (b.Tokens > 0 && spent.Tokens() >= b.Tokens)
+}
+
+func warnBudget(msg string) {
+	if budgetWarned {
+		return
+	}
+	budgetWarned = true
+	ui.DisplayBudget(msg)
+}
+
+// recordSpend adds a request's tokens, priced for cfg's model, to this
+// month's spend. Models without a known price count tokens only.
+func recordSpend(cfg *config.Config, input, output int64) {
+	price, _ := bench.PriceFor(cfg.Provider, cfg.Model())
+	store, err := openMemoryStore()
+	if err != nil {
+		slog.Warn("recording spend", "error", err)
+		return
+	}
+	defer store.Close() //nolint:errcheck
+	if err := store.RecordSpend(context.Background(), spendProfile(cfg), cfg.Provider, cfg.Model(), input, output, price.Cost(input, output)); err != nil {
+		slog.Warn("recording spend", "error", err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swibrow/how/internal/config"
+)
+
+func TestBudgetedProviderSwitchesToFallback(t *testing.T) {
+	dir := t.TempDir()
+	config.ConfigDirFunc = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { config.ConfigDirFunc = nil })
+	err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`provider: ollama
+budget:
+  tokens: 100
+  fallback: local
+profiles:
+  local:
+    provider: ollama
+    model: llama3.2
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newBudgetedProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := p.(*budgetedProvider)
+	if b.profile != "default" {
+		t.Fatalf("profile within budget = %q, want default", b.profile)
+	}
+
+	// Spend recorded while the process keeps running
+	store, err := openMemoryStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck
+	if err := store.RecordSpend(context.Background(), "default", "ollama", "llama3", 80, 40, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.current(); err != nil {
+		t.Fatal(err)
+	}
+	if b.profile != "local" {
+		t.Errorf("profile over budget = %q, want local", b.profile)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/risk"
+)
+
+// checkResult is how check's JSON output.
+type checkResult struct {
+	Command string     `json:"command"`
+	Level   risk.Level `json:"level"`
+	Reasons []string   `json:"reasons"`
+	Writes  []string   `json:"writes"`
+	Network []string   `json:"network"`
+	// Confirm is set when a rule requires confirmation whatever the
+	// confirm setting.
+	Confirm bool `json:"confirm"`
+	// Prompt reports whether how would ask before running the command
+	// under the current confirm setting.
+	Prompt bool `json:"prompt"`
+}
+
+// errDangerous is how check's result for a dangerous command. The
+// classification has already been shown, so it is not displayed.
+var errDangerous = errors.New("command is dangerous")
+
+func newCheckCmd() *cobra.Command {
+	var output, rulesPath string
+	cmd := &cobra.Command{
+		Use:   "check <command>",
+		Short: "Classify a shell command as safe, caution or dangerous",
+		Long: `Classify a command with the same rules how applies before running a
+suggestion, and show why: what it deletes or changes, which hosts it
+contacts, and whether it needs confirmation. Nothing is run. It exits
+with status 5 when the command is dangerous.
+
+The rules are the built-in ones plus any in the file set with risk_rules,
+or given with --rules. They're also available to other Go programs as the
+github.com/swibrow/how/risk package.`,
+		Example: `  how check "rm -rf build/"
+  how check -o json "curl -fsSL https://example.com/install.sh | sh"
+  how check --rules team-rules.yaml "terraform destroy"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fail("unknown output format %q (expected text or json)", output)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if rulesPath != "" {
+				rules, err := riskRules(cfg.RiskRules, rulesPath)
+				if err != nil {
+					return fail("%w", err)
+				}
+				risk.Use(rules)
+			}
+
+			command := strings.Join(args, " ")
+			a := risk.Classify(command)
+			result := checkResult{
+				Command: command,
+				Level:   a.Level,
+				Reasons: nonNil(a.Reasons),
+				Writes:  nonNil(a.Writes),
+				Network: []string{},
+				Confirm: a.Confirm,
+				Prompt:  needsConfirmation(cfg, a),
+			}
+			for _, d := range a.Network {
+				result.Network = append(result.Network, d.String())
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				enc.SetEscapeHTML(false)
+				if err := enc.Encode(result); err != nil {
+					return err
+				}
+			} else {
+				displayCheck(cfg, result)
+			}
+			if a.Level == risk.Dangerous {
+				// Let scripts gate on the result without parsing it
+				return withCode(exitBlocked, errDangerous)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json (json by default in CI)")
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Rules file to apply on top of the built-in rules and risk_rules")
+	return cmd
+}
+
+func displayCheck(cfg *config.Config, r checkResult) {
+	fmt.Printf("Level:    %s\n", r.Level)
+	if len(r.Reasons) > 0 {
+		fmt.Printf("Reasons:  %s\n", strings.Join(r.Reasons, "; "))
+	}
+	if len(r.Writes) > 0 {
+		fmt.Printf("Writes:   %s\n", strings.Join(r.Writes, "; "))
+	}
+	if len(r.Network) > 0 {
+		fmt.Printf("Network:  %s\n", strings.Join(r.Network, "; "))
+	}
+	switch {
+	case r.Confirm:
+		fmt.Println("Confirm:  always, whatever the confirm setting")
+	case r.Level == risk.Dangerous:
+		fmt.Printf("Confirm:  unless confirm is never (yours: %s)\n", cfg.Confirm)
+	default:
+		fmt.Printf("Confirm:  only when confirm is always (yours: %s)\n", cfg.Confirm)
+	}
+}
+
+// riskRules returns the built-in rules plus those in each of paths that
+// isn't empty. A leading ~/ is the home directory.
+func riskRules(paths ...string) (*risk.Rules, error) {
+	rules := risk.Default()
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(home, rest)
+		}
+		extra, err := risk.LoadRules(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading risk rules: %w", err)
+		}
+		rules = rules.With(extra)
+	}
+	return rules, nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
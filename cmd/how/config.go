@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or manage configuration",
+	}
+
+	configShowCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show current configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := config.Show()
+			if err != nil {
+				return err
+			}
+			fmt.Println(output)
+			return nil
+		},
+	}
+
+	configInitCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a default configuration file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.DefaultConfig()
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("saving config: %w", err)
+			}
+			fmt.Println("Default config created at ~/.config/how/config.yaml")
+			fmt.Println("Edit it to add your API keys and select a provider.")
+			return nil
+		},
+	}
+
+	configPathCmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file location",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.Path()
+			if err != nil {
+				return fail("%w", err)
+			}
+			fmt.Println(path)
+			return nil
+		},
+	}
+
+	var showSecrets bool
+	configListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all settings and their effective values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.ActiveProfile != "" {
+				fmt.Printf("# profile: %s\n", cfg.ActiveProfile)
+			}
+			for _, key := range config.Keys() {
+				value, _ := config.Get(cfg, key)
+				if value != "" && config.IsSecret(key) && !showSecrets {
+					value = "********"
+				}
+				fmt.Printf("%s=%s\n", key, value)
+			}
+			return nil
+		},
+	}
+	configListCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print API keys and tokens unmasked")
+
+	configGetCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			value, err := config.Get(cfg, args[0])
+			if err != nil {
+				return fail("%w", err)
+			}
+			fmt.Println(value)
+			return nil
+		},
+	}
+
+	configSetCmd := &cobra.Command{
+		Use:     "set <key> <value>",
+		Short:   "Change a setting in the config file",
+		Example: "  how config set provider openai\n  how config set openai.model gpt-4o-mini",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load without env overrides so env secrets aren't written to disk
+			cfg, err := config.LoadFile()
+			if err != nil {
+				return fail("loading config: %w", err)
+			}
+			if err := config.Set(cfg, args[0], args[1]); err != nil {
+				return fail("%w", err)
+			}
+			if err := config.Save(cfg); err != nil {
+				return fail("saving config: %w", err)
+			}
+			return nil
+		},
+	}
+
+	configEditCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $EDITOR",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.Path()
+			if err != nil {
+				return fail("%w", err)
+			}
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				if err := config.Save(config.DefaultConfig()); err != nil {
+					return fail("saving config: %w", err)
+				}
+			}
+
+			if err := openEditor(path); err != nil {
+				return fail("running editor: %w", err)
+			}
+			if err := config.Validate(); err != nil {
+				return fail("%w (run 'how config edit' to fix)", err)
+			}
+			return nil
+		},
+	}
+
+	configCmd.AddCommand(configShowCmd, configInitCmd, configPathCmd, configListCmd, configGetCmd, configSetCmd, configEditCmd)
+	return configCmd
+}
+
+// openEditor opens path in $VISUAL or $EDITOR, falling back to vi.
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors with arguments (e.g. "code -w") work
+	c := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/swibrow/how/internal/bench"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// replyTokens is the output assumed when estimating a request's cost. A
+// command with its explanation rarely runs longer.
+const replyTokens = 200
+
+// previewCost estimates what sending sysPrompt and query will cost, showing
+// it with --verbose or when it's over cost_warning.
+func previewCost(cfg *config.Config, sysPrompt, query string) {
+	if !flagVerbose && cfg.CostWarning <= 0 {
+		return
+	}
+	tokens := llm.EstimateTokens(sysPrompt) + llm.EstimateTokens(query)
+	price, priced := bench.PriceFor(cfg.Provider, cfg.Model())
+	cost := price.Cost(tokens, replyTokens)
+	over := priced && cfg.CostWarning > 0 && cost > cfg.CostWarning
+	if flagVerbose || over {
+		ui.DisplayEstimate(tokens, cost, priced, over)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/rpc"
+	"github.com/swibrow/how/internal/ui"
+)
+
+const (
+	// daemonContextTTL is how long the daemon reuses collected machine
+	// context for a directory.
+	daemonContextTTL = time.Minute
+
+	// daemonDialTimeout bounds connecting to the daemon, so a wedged daemon
+	// costs the CLI little before it falls back to a direct request.
+	daemonDialTimeout = 50 * time.Millisecond
+
+	// rpcCodeBase offsets exit codes into the JSON-RPC server error range
+	// (-32001 to -32099).
+	rpcCodeBase = -32000
+)
+
+// daemonSuggestParams is the request the CLI sends to the daemon.
+type daemonSuggestParams struct {
+	Query   string `json:"query"`
+	Dir     string `json:"dir"`
+	Profile string `json:"profile"`
+}
+
+// daemonStatus is the daemon's reply to status.
+type daemonStatus struct {
+	PID     int    `json:"pid"`
+	Profile string `json:"profile"`
+	Uptime  string `json:"uptime"`
+}
+
+// daemonSocket returns the unix socket the daemon listens on.
+func daemonSocket() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+func newDaemonCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run a background daemon that keeps suggestions warm",
+		Long: `Run a daemon that holds the provider, memory and collected machine context,
+listening on a unix socket in the config directory. While it runs, how sends
+suggestions through it instead of starting from scratch. Stop it with Ctrl-C,
+SIGTERM or "how daemon stop".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return runDaemon(cfg)
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Report whether the daemon is running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var status daemonStatus
+			if err := callDaemon("status", struct{}{}, &status, time.Second); err != nil {
+				fmt.Println("Daemon is not running.")
+				return nil
+			}
+			fmt.Printf("Daemon is running (pid %d, profile %q, up %s).\n", status.PID, status.Profile, status.Uptime)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stop",
+		Short: "Stop the running daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := callDaemon("shutdown", struct{}{}, nil, time.Second); err != nil {
+				return fail("daemon is not running")
+			}
+			fmt.Println("Daemon stopped.")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// runDaemon serves suggest, status and shutdown on the daemon socket until
+// interrupted or asked to shut down.
+func runDaemon(cfg *config.Config) error {
+	sock, err := daemonSocket()
+	if err != nil {
+		return fail("%w", err)
+	}
+	if conn, err := net.DialTimeout("unix", sock, daemonDialTimeout); err == nil {
+		_ = conn.Close()
+		return fail("daemon already running on %s", sock)
+	}
+	// A socket nobody answers on is left over from a crash
+	_ = os.Remove(sock)
+	if err := os.MkdirAll(filepath.Dir(sock), 0o755); err != nil {
+		return fail("creating config directory: %w", err)
+	}
+
+	provider, err := newBudgetedProvider(cfg)
+	if err != nil {
+		return fail("initializing provider: %w", err)
+	}
+	warmProvider(cfg)
+	store := openMemoryIfEnabled(cfg)
+	if store != nil {
+		defer store.Close() //nolint:errcheck
+	}
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return fail("listening on %s: %w", sock, err)
+	}
+	if err := os.Chmod(sock, 0o600); err != nil {
+		_ = ln.Close()
+		return fail("securing socket: %w", err)
+	}
+	defer os.Remove(sock) //nolint:errcheck
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	started := time.Now()
+	basePrompt := systemPrompt(cfg)
+	facts := newFactCache(collect.Select(cfg.Context.Collectors), collect.Hooks(cfg.ContextHooks))
+
+	handlers := map[string]rpc.HandlerFunc{
+		"suggest": func(ctx context.Context, params json.RawMessage) (any, error) {
+			var p daemonSuggestParams
+			if err := decodeRequired(params, &p, &p.Query, "query"); err != nil {
+				return nil, err
+			}
+			if p.Profile != cfg.ActiveProfile {
+				return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: fmt.Sprintf("daemon serves profile %q, not %q", cfg.ActiveProfile, p.Profile)}
+			}
+
+			sysPrompt := basePrompt + facts.get(ctx, p.Dir) + clockContext()
+			if store != nil {
+				if past, err := store.Search(ctx, p.Query, 10); err == nil && len(past) > 0 {
+					sysPrompt += prompt.FormatMemoryContext(past)
+				}
+				sysPrompt += feedbackContext(ctx, cfg, provider, store, p.Query)
+			}
+			result, err := suggest(ctx, provider, sysPrompt, p.Query)
+			if err != nil {
+				return nil, &rpc.Error{Code: rpcCodeBase - exitCode(err), Message: err.Error()}
+			}
+			return newRPCResult(result), nil
+		},
+		"status": func(context.Context, json.RawMessage) (any, error) {
+			return map[string]any{
+				"pid":     os.Getpid(),
+				"profile": cfg.ActiveProfile,
+				"uptime":  time.Since(started).Round(time.Second).String(),
+			}, nil
+		},
+		"shutdown": func(context.Context, json.RawMessage) (any, error) {
+			// Reply first; the listener closes once stop cancels ctx
+			time.AfterFunc(10*time.Millisecond, stop)
+			return map[string]bool{"ok": true}, nil
+		},
+	}
+
+	slog.Info("daemon listening", "socket", sock)
+	ui.DisplayNotice("Daemon listening on " + sock)
+	if err := rpc.ServeListener(ctx, ln, handlers); err != nil {
+		return fail("%w", err)
+	}
+	return nil
+}
+
+// callDaemon makes a single call to the running daemon.
+func callDaemon(method string, params, result any, timeout time.Duration) error {
+	sock, err := daemonSocket()
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", sock, daemonDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	return rpc.Call(conn, method, params, result)
+}
+
+// suggestViaDaemon asks the daemon for a suggestion. ok is false if no
+// daemon is running or it can't serve this request, in which case the
+// caller should query the provider directly.
+func suggestViaDaemon(cfg *config.Config, question string) (result ui.Result, ok bool, err error) {
+	dir, _ := os.Getwd()
+	params := daemonSuggestParams{Query: question, Dir: dir, Profile: cfg.ActiveProfile}
+
+	var r rpcResult
+	err = callDaemon("suggest", params, &r, 2*time.Minute)
+	if err == nil {
+		return ui.Result{Command: r.Command, Explanation: r.Explanation, Warning: r.Warning, Injections: r.Injections}, true, nil
+	}
+
+	var rpcErr *rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.Code < rpcCodeBase && rpcErr.Code > rpcCodeBase-100 {
+		// The daemon reached the provider; report its failure as ours
+		return ui.Result{}, true, withCode(rpcCodeBase-rpcErr.Code, errors.New(rpcErr.Message))
+	}
+	slog.Debug("daemon unavailable", "error", err)
+	return ui.Result{}, false, nil
+}
+
+// factCache holds collected machine context per directory for
+// daemonContextTTL.
+type factCache struct {
+	collectors []collect.Collector
+	hooks      []collect.Collector
+
+	mu      sync.Mutex
+	entries map[string]factEntry
+}
+
+type factEntry struct {
+	text    string
+	fetched time.Time
+}
+
+func newFactCache(collectors, hooks []collect.Collector) *factCache {
+	return &factCache{collectors: collectors, hooks: hooks, entries: map[string]factEntry{}}
+}
+
+func (c *factCache) get(ctx context.Context, dir string) string {
+	c.mu.Lock()
+	e, ok := c.entries[dir]
+	c.mu.Unlock()
+	if ok && time.Since(e.fetched) < daemonContextTTL {
+		return e.text
+	}
+
+	text := prompt.FormatMachineContext(collect.GatherWithHooks(collect.WithDir(ctx, dir), c.collectors, c.hooks))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for d, e := range c.entries {
+		if time.Since(e.fetched) >= daemonContextTTL {
+			delete(c.entries, d)
+		}
+	}
+	c.entries[dir] = factEntry{text: text, fetched: time.Now()}
+	return text
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/prompt"
+)
+
+// dockerDetailsBudget bounds listing containers, images and compose
+// services for `how docker`.
+const dockerDetailsBudget = 2 * time.Second
+
+func newDockerCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "docker <what you want to do>",
+		Short: "Get docker commands that use your real container names",
+		Long: `Ask how to do something with your containers. Running and stopped containers,
+local images and the compose project in the current directory are sent with
+the question, so "tail the logs of the api container" uses the real name.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := exec.LookPath("docker"); err != nil {
+				return fail("docker is not installed")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			question := strings.Join(args, " ")
+			facts := collect.GatherWithin(ctx, collect.DockerDetails, dockerDetailsBudget)
+			return runMode(ctx, cfg, prompt.DockerPrompt(facts), question, "docker: "+question)
+		},
+	}
+}
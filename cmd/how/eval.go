@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/batch"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/eval"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newEvalCmd() *cobra.Command {
+	var (
+		model, promptFile, output string
+		suitePath                 string
+		minPass                   float64
+		opts                      batch.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Replay the eval set or a test suite against a model and prompt and report the pass rate",
+		Long: `Ask every question in the eval set built with "how feedback" and compare the
+suggested command to the right one. Use --profile or --model to pick the model
+and --system-prompt to try a custom prompt. Memory and machine context are left
+out so runs are comparable.
+
+With --suite, ask the questions in a YAML suite instead and check each
+suggestion against the case's assertions:
+
+  cases:
+    - query: tar a directory but exclude node_modules
+      expect:
+        matches: '^tar '            # regular expression the command must match
+        not_matches: '--no-recursion'
+        uses: [tar]                 # tools it must run
+        avoids: [sudo]              # tools it mustn't run
+        max_risk: caution           # safe, caution or dangerous
+    - query: list listening ports
+      expect:
+        equals: ss -tlnp            # the exact command`,
+		Example: `  how eval --model gpt-4o-mini
+  how eval --system-prompt my-prompt.txt --min-pass 90
+  how eval --suite suite.yaml --system-prompt my-prompt.txt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ui.CheckListFormat(output); err != nil {
+				return fail("%w", err)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			override := config.Profile{Model: model}
+			if promptFile != "" {
+				data, err := os.ReadFile(promptFile)
+				if err != nil {
+					return fail("reading system prompt: %w", err)
+				}
+				override.SystemPrompt = string(data)
+			}
+			cfg.Overlay(override)
+
+			cases, score, err := evalCases(suitePath)
+			if err != nil {
+				return fail("%w", err)
+			}
+			questions := make([]string, len(cases))
+			for i, c := range cases {
+				questions[i] = c.Question
+			}
+
+			provider, err := newProvider(cfg)
+			if err != nil {
+				ui.DisplayError(fmt.Sprintf("initializing provider: %v", err))
+				return withCode(exitProvider, err)
+			}
+
+			sysPrompt := systemPrompt(cfg)
+			items := batch.Run(context.Background(), questions, opts, func(ctx context.Context, q string) (string, string, error) {
+				result, err := suggest(ctx, provider, sysPrompt, q)
+				return result.Command, result.Explanation, err
+			})
+
+			results := make([]eval.Result, len(items))
+			list := ui.NewListWriter(os.Stdout, output)
+			for i, item := range items {
+				if item.Error != "" {
+					results[i] = eval.Result{Case: cases[i], Error: item.Error}
+				} else {
+					results[i] = score(i, item.Command)
+				}
+				if output != "text" {
+					if err := list.Write(results[i]); err != nil {
+						return fail("writing output: %w", err)
+					}
+					continue
+				}
+				displayEvalResult(results[i])
+			}
+			if err := list.Flush(); err != nil {
+				return fail("writing output: %w", err)
+			}
+
+			summary := eval.Summarize(results)
+			if output == "text" {
+				fmt.Printf("%s %s: passed %d of %d (%.1f%%)", cfg.Provider, cfg.Model(), summary.Passed, summary.Total, 100*summary.Rate())
+				if summary.Errors > 0 {
+					fmt.Printf(", %d errors", summary.Errors)
+				}
+				if summary.Repeated > 0 {
+					fmt.Printf(", %d repeated a known wrong answer", summary.Repeated)
+				}
+				fmt.Println()
+			}
+			if rate := 100 * summary.Rate(); rate < minPass {
+				return fail("pass rate %.1f%% is below %.1f%%", rate, minPass)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&model, "model", "", "Model to evaluate (default the configured model)")
+	cmd.Flags().StringVar(&suitePath, "suite", "", "YAML suite of questions and assertions to run instead of the eval set")
+	cmd.Flags().StringVar(&promptFile, "system-prompt", "", "File with a custom system prompt to evaluate")
+	cmd.Flags().Float64Var(&minPass, "min-pass", 0, "Fail when the pass rate, in percent, is below this")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text, jsonl, yaml, csv or table")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 4, "Maximum number of questions in flight")
+	return cmd
+}
+
+// evalCases returns the cases in the suite at path, or in the eval set if
+// path is empty, and scores the command suggested for case i.
+func evalCases(path string) ([]eval.Case, func(i int, got string) eval.Result, error) {
+	if path != "" {
+		suite, err := eval.LoadSuite(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		cases := make([]eval.Case, len(suite.Cases))
+		for i, c := range suite.Cases {
+			cases[i] = eval.Case{Question: c.Query}
+		}
+		return cases, func(i int, got string) eval.Result { return suite.Cases[i].Check(got) }, nil
+	}
+
+	store, err := openMemoryStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer store.Close() //nolint:errcheck
+	stored, err := store.EvalCases(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stored) == 0 {
+		return nil, nil, errors.New("the eval set is empty; add cases with how feedback, or pass --suite")
+	}
+	cases := make([]eval.Case, len(stored))
+	for i, c := range stored {
+		cases[i] = eval.Case{Question: c.Question, Expected: c.Expected, Wrong: c.Wrong}
+	}
+	return cases, func(i int, got string) eval.Result { return eval.Score(cases[i], got) }, nil
+}
+
+func displayEvalResult(r eval.Result) {
+	switch {
+	case r.Error != "":
+		fmt.Printf("  ERROR %s\n        %s\n", r.Question, r.Error)
+	case r.Pass:
+		fmt.Printf("  PASS  %s\n", r.Question)
+	case len(r.Failures) > 0:
+		fmt.Printf("  FAIL  %s\n", r.Question)
+		for _, f := range r.Failures {
+			fmt.Printf("        %s\n", f)
+		}
+		fmt.Printf("        got:  %s\n", r.Got)
+	default:
+		fmt.Printf("  FAIL  %s\n        want: %s\n        got:  %s\n", r.Question, r.Expected, r.Got)
+		if r.Repeated {
+			fmt.Println("        (the known wrong answer)")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/policy"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// Process exit codes. When a suggested command is run, its own exit code is
+// used instead.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitProvider = 2
+	exitNoParse  = 3
+	exitDeclined = 4
+	exitBlocked  = 5
+)
+
+// errDeclined is returned when the user does not confirm a command. It is
+// not displayed.
+var errDeclined = errors.New("command not run")
+
+// codedError attaches a process exit code to an error.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode wraps err so that the process exits with code.
+func withCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorObject describes err for --output json, classified by its exit
+// code.
+func errorObject(err error) ui.ErrorObject {
+	e := ui.ErrorObject{Type: "error", Message: err.Error()}
+	switch exitCode(err) {
+	case exitProvider:
+		e.Type = "provider"
+		e.Retryable = llm.Retryable(err)
+		if cfg, cfgErr := cachedConfig(); cfgErr == nil {
+			e.Provider = cfg.Provider
+		}
+	case exitNoParse:
+		e.Type = "no_command"
+	case exitDeclined:
+		e.Type = "declined"
+	case exitBlocked:
+		e.Type = "blocked"
+	}
+	return e
+}
+
+// exitCode returns the process exit status for err, 0 for nil.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var (
+		coded   *codedError
+		blocked *policy.BlockedError
+		exitErr *exec.ExitError
+	)
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, errDeclined), errors.Is(err, ui.ErrUnattended):
+		return exitDeclined
+	case errors.As(err, &blocked):
+		return exitBlocked
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	}
+	return exitError
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/policy"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// useConfig makes cachedConfig return cfg for the rest of the test.
+func useConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	loadedConfig, loadedConfigErr, configLoaded = cfg, nil, true
+	t.Cleanup(func() { loadedConfig, loadedConfigErr, configLoaded = nil, nil, false })
+}
+
+func TestExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands run with sh")
+	}
+	cfg := config.DefaultConfig()
+	cfg.Provider = "openai"
+	cfg.Hooks.Pre = []string{"exit 1"}
+	useConfig(t, cfg)
+
+	_, hookErr := hooked("rm -rf build", func() (int, error) {
+		t.Fatal("the pre hook should have stopped the command")
+		return 0, nil
+	})
+	exitErr := exec.Command("sh", "-c", "exit 7").Run()
+
+	cases := []struct {
+		name      string
+		err       error
+		code      int
+		errType   string
+		retryable bool
+		provider  string
+	}{
+		{"nil", nil, exitOK, "", false, ""},
+		{"plain", errors.New("boom"), exitError, "error", false, ""},
+		{"provider", withCode(exitProvider, fmt.Errorf("LLM request failed: %w", context.DeadlineExceeded)), exitProvider, "provider", true, "openai"},
+		{"provider not retryable", withCode(exitProvider, errors.New("invalid API key")), exitProvider, "provider", false, "openai"},
+		{"no command", withCode(exitNoParse, errors.New("no command in the response")), exitNoParse, "no_command", false, ""},
+		{"declined", errDeclined, exitDeclined, "declined", false, ""},
+		{"unattended", fmt.Errorf("%w, but running unattended in CI", ui.ErrUnattended), exitDeclined, "declined", false, ""},
+		{"policy", fmt.Errorf("running: %w", &policy.BlockedError{Command: "rm -rf /", Rule: "rm -rf /"}), exitBlocked, "blocked", false, ""},
+		{"pre hook", hookErr, exitBlocked, "blocked", false, ""},
+		{"command exit status", exitErr, 7, "error", false, ""},
+		{"coded wins", withCode(exitError, errDeclined), exitError, "error", false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.err); got != tc.code {
+				t.Errorf("exitCode(%v) = %d, want %d", tc.err, got, tc.code)
+			}
+			if tc.err == nil {
+				return
+			}
+			want := ui.ErrorObject{Type: tc.errType, Message: tc.err.Error(), Provider: tc.provider, Retryable: tc.retryable}
+			if got := errorObject(tc.err); got != want {
+				t.Errorf("errorObject(%v) = %+v, want %+v", tc.err, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/fav"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// favTimeout bounds a pull or push.
+const favTimeout = 2 * time.Minute
+
+func newFavCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fav",
+		Short: "Save favorite commands and share them with your team",
+		Long: `Favorites are commands worth keeping, saved by name in favorites.yaml in the
+config directory. Set favorites.remote to a git repository or an
+s3://bucket/key to build a library with your team: fav pull brings in the
+shared favorites and fav push publishes yours.`,
+		Args: cobra.NoArgs,
+	}
+
+	var description string
+	addCmd := &cobra.Command{
+		Use:   "add <name> [command]",
+		Short: "Save a command, or the last one run through how, as a favorite",
+		Example: `  how fav add pods kubectl get pods -A -o wide
+  how fav add -d "Largest files under the current directory" big du -ah . | sort -rh | head -20
+  how fav add cleanup`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if !fav.ValidName(name) {
+				return fail("invalid name %q: use letters, digits, '.', '_' and '-'", name)
+			}
+			command := strings.Join(args[1:], " ")
+			if command == "" {
+				store, err := openMemoryStore()
+				if err != nil {
+					return fail("%w", err)
+				}
+				defer store.Close() //nolint:errcheck
+				last, err := store.Last(context.Background())
+				if err != nil {
+					return fail("reading history: %w", err)
+				}
+				if last == nil {
+					return fail("no commands have been run through how yet; give the command to save")
+				}
+				command = last.Command
+			}
+
+			path, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			replaced := lib.Add(fav.Favorite{Name: name, Command: command, Description: description})
+			if err := lib.Save(path); err != nil {
+				return fail("saving favorites: %w", err)
+			}
+			if replaced {
+				fmt.Printf("Replaced %s: %s\n", name, command)
+			} else {
+				fmt.Printf("Saved %s: %s\n", name, command)
+			}
+			return nil
+		},
+	}
+	addCmd.Flags().StringVarP(&description, "description", "d", "", "What the command does")
+	// Flags after the name belong to the command being saved
+	addCmd.Flags().SetInterspersed(false)
+
+	listCmd := &cobra.Command{
+		Use:   "list [filter]",
+		Short: "List favorites, or those whose name, command or description contains filter",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, f := range lib.Favorites {
+				if len(args) == 1 && !favMatches(f, args[0]) {
+					continue
+				}
+				rows = append(rows, []string{f.Name, f.Command, f.Description})
+			}
+			if len(rows) == 0 {
+				if len(args) == 1 {
+					fmt.Printf("No favorites match %q.\n", args[0])
+				} else {
+					fmt.Println("No favorites yet. Save one with how fav add, or fetch your team's with how fav pull.")
+				}
+				return nil
+			}
+			slices.SortFunc(rows, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+			ui.DisplayTable([]string{"NAME", "COMMAND", "DESCRIPTION"}, rows, false)
+			return nil
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a favorite from your library",
+		Long: `Delete a favorite from your library. The shared library keeps it; remove it
+there by editing favorites.yaml in the repository or bucket.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			if !lib.Remove(args[0]) {
+				return fail("no favorite named %q", args[0])
+			}
+			if err := lib.Save(path); err != nil {
+				return fail("saving favorites: %w", err)
+			}
+			fmt.Printf("Removed %s.\n", args[0])
+			return nil
+		},
+	}
+
+	runCmd := &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a favorite, with the usual risk checks and confirmation",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			f, ok := lib.Get(args[0])
+			if !ok {
+				return fail("no favorite named %q", args[0])
+			}
+			result := ui.Result{Command: f.Command, Explanation: f.Description}
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+			ui.Display(result)
+			return execute(context.Background(), cfg, store, "fav: "+f.Name, result)
+		},
+	}
+
+	pullCmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Add the shared library's favorites to yours",
+		Long: `Fetch the shared library from favorites.remote and add its favorites to yours.
+A shared favorite replaces yours when they have the same name.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote, _, err := favRemote()
+			if err != nil {
+				return err
+			}
+			path, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), favTimeout)
+			defer cancel()
+			shared, err := remote.Pull(ctx)
+			if err != nil {
+				return fail("%w", err)
+			}
+
+			added, changed := lib.Merge(shared)
+			if len(added)+len(changed) == 0 {
+				fmt.Println("Your favorites are up to date.")
+				return nil
+			}
+			if err := lib.Save(path); err != nil {
+				return fail("saving favorites: %w", err)
+			}
+			displayMerge(added, changed)
+			return nil
+		},
+	}
+
+	pushCmd := &cobra.Command{
+		Use:   "push",
+		Short: "Add your favorites to the shared library",
+		Long: `Add your favorites to the shared library at favorites.remote, replacing
+shared ones with the same name. Favorites you've removed aren't removed
+from the shared library.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote, url, err := favRemote()
+			if err != nil {
+				return err
+			}
+			_, lib, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), favTimeout)
+			defer cancel()
+			shared, err := remote.Pull(ctx)
+			if err != nil {
+				return fail("%w", err)
+			}
+
+			added, changed := shared.Merge(lib)
+			if len(added)+len(changed) == 0 {
+				fmt.Println("The shared library already has your favorites.")
+				return nil
+			}
+			displayMerge(added, changed)
+			question := fmt.Sprintf("Push %s to %s?", count(len(added)+len(changed), "favorite"), url)
+			if ok, err := confirmStep(question); !ok || err != nil {
+				return declined(err)
+			}
+			message := "Update favorites: " + strings.Join(append(added, changed...), ", ")
+			if err := remote.Push(ctx, lib, message); err != nil {
+				return fail("%w", err)
+			}
+			fmt.Printf("Pushed to %s.\n", url)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(addCmd, listCmd, removeCmd, runCmd, pullCmd, pushCmd)
+	return cmd
+}
+
+// loadFavorites returns the path of the favorites library and its contents.
+func loadFavorites() (string, *fav.Library, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", nil, fail("%w", err)
+	}
+	path := filepath.Join(dir, fav.File)
+	lib, err := fav.Load(path)
+	if err != nil {
+		return "", nil, fail("%w", err)
+	}
+	return path, lib, nil
+}
+
+// favRemote returns the shared library set with favorites.remote, and its
+// URL.
+func favRemote() (fav.Remote, string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	url := cfg.Favorites.Remote
+	if url == "" {
+		return nil, "", fail("no shared library set; point favorites.remote at a git repository or s3://bucket/key with how config set")
+	}
+	return fav.NewRemote(url), url, nil
+}
+
+func favMatches(f fav.Favorite, filter string) bool {
+	filter = strings.ToLower(filter)
+	for _, s := range []string{f.Name, f.Command, f.Description} {
+		if strings.Contains(strings.ToLower(s), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+func displayMerge(added, changed []string) {
+	if len(added) > 0 {
+		fmt.Printf("New: %s\n", strings.Join(added, ", "))
+	}
+	if len(changed) > 0 {
+		fmt.Printf("Updated: %s\n", strings.Join(changed, ", "))
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+)
+
+const (
+	// feedbackExamples is how many rated answers are added to a prompt.
+	feedbackExamples = 3
+
+	// embedTimeout bounds embedding a question, so a slow embeddings
+	// endpoint only costs the keyword fallback.
+	embedTimeout = 2 * time.Second
+)
+
+// embedQuestion embeds question for matching feedback, or returns nil when
+// the provider can't, in which case feedback is matched by keywords.
+func embedQuestion(ctx context.Context, cfg *config.Config, provider llm.Provider, question string) []float32 {
+	if provider == nil {
+		var err error
+		if provider, err = llm.NewProvider(cfg); err != nil {
+			return nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, embedTimeout)
+	defer cancel()
+	v, err := llm.Embed(ctx, provider, question)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// feedbackContext returns the user's ratings of answers to questions like
+// question as prompt context. provider may be nil.
+func feedbackContext(ctx context.Context, cfg *config.Config, provider llm.Provider, store *memory.Store, question string) string {
+	if store == nil || !cfg.Memory.Feedback {
+		return ""
+	}
+	if has, err := store.HasFeedback(ctx); err != nil || !has {
+		return ""
+	}
+	examples, err := store.Examples(ctx, question, embedQuestion(ctx, cfg, provider, question), feedbackExamples)
+	if err != nil {
+		slog.Info("reading feedback failed", "error", err)
+		return ""
+	}
+	return prompt.FormatFeedback(examples)
+}
+
+// askFeedback asks whether command, which just ran, was a good answer to
+// question, and records the rating. Nothing is asked with --yes or --quiet.
+func askFeedback(ctx context.Context, cfg *config.Config, store *memory.Store, question, command string) {
+	if store == nil || !cfg.Memory.Feedback || flagYes || flagQuiet || ui.Unattended != "" {
+		return
+	}
+	key, err := ui.ReadKey("Good answer? [y] yes  [n] no  [any key] skip")
+	if err != nil {
+		return
+	}
+	var accepted bool
+	switch key {
+	case 'y', 'Y', '+':
+		accepted = true
+	case 'n', 'N', '-':
+	default:
+		return
+	}
+	if err := store.RecordFeedback(ctx, question, command, accepted, embedQuestion(ctx, cfg, nil, question)); err != nil {
+		slog.Info("recording feedback failed", "error", err)
+	}
+}
+
+func newFeedbackCmd() *cobra.Command {
+	var right, wrong string
+
+	cmd := &cobra.Command{
+		Use:   "feedback [question]",
+		Short: "Record the right command for a question the model got wrong",
+		Long: `Record the command that should have been suggested for a question. Without a
+question, the last command run through how is taken as the wrong answer to its
+question. Corrections are added to the eval set replayed by "how eval", and are
+used as examples for similar questions.`,
+		Example: `  how feedback --command "ss -tlnp"
+  how feedback "list listening ports" --command "ss -tlnp" --wrong "netstat -a"`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+
+			question := strings.Join(args, " ")
+			if question == "" {
+				last, err := store.Last(ctx)
+				if err != nil {
+					return fail("reading history: %w", err)
+				}
+				if last == nil {
+					return fail("no commands have been run through how yet; give the question as an argument")
+				}
+				question = last.Question
+				if wrong == "" {
+					wrong = last.Command
+				}
+				fmt.Printf("  Q: %s\n  $ %s\n", question, wrong)
+			}
+			if right == "" {
+				right = askLine(bufio.NewReader(os.Stdin), "Right command", "")
+			}
+			if right == "" {
+				return fail("no command given (use --command)")
+			}
+
+			id, err := store.AddEvalCase(ctx, question, right, wrong)
+			if err != nil {
+				return fail("%w", err)
+			}
+			if cfg.Memory.Feedback {
+				embedding := embedQuestion(ctx, cfg, nil, question)
+				if wrong != "" {
+					_ = store.RecordFeedback(ctx, question, wrong, false, embedding)
+				}
+				_ = store.RecordFeedback(ctx, question, right, true, embedding)
+			}
+			fmt.Printf("Added eval case %d.\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&right, "command", "c", "", "The right command (prompted for when omitted)")
+	cmd.Flags().StringVar(&wrong, "wrong", "", "The wrong command that was suggested (default the last command run, without a question)")
+
+	var listOutput string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the eval set",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ui.CheckListFormat(listOutput); err != nil {
+				return fail("%w", err)
+			}
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+
+			cases, err := store.EvalCases(context.Background())
+			if err != nil {
+				return fail("%w", err)
+			}
+			if listOutput != "text" {
+				list := ui.NewListWriter(os.Stdout, listOutput)
+				for _, c := range cases {
+					if err := list.Write(c); err != nil {
+						return fail("writing output: %w", err)
+					}
+				}
+				return list.Flush()
+			}
+			if len(cases) == 0 {
+				fmt.Println("No eval cases yet. Add one with how feedback.")
+				return nil
+			}
+			for _, c := range cases {
+				fmt.Printf("  #%d Q: %s\n     $ %s\n", c.ID, c.Question, c.Expected)
+				if c.Wrong != "" {
+					fmt.Printf("     not: %s\n", c.Wrong)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+
+	rmCmd := &cobra.Command{
+		Use:   "rm <id>",
+		Short: "Remove a case from the eval set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil {
+				return fail("invalid eval case id %q", args[0])
+			}
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+
+			ok, err := store.DeleteEvalCase(context.Background(), id)
+			if err != nil {
+				return fail("%w", err)
+			}
+			if !ok {
+				return fail("no eval case %d", id)
+			}
+			fmt.Printf("Removed eval case %d.\n", id)
+			return nil
+		},
+	}
+
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "text", "Output format: text, jsonl, yaml, csv or table")
+	cmd.AddCommand(listCmd, rmCmd)
+	return cmd
+}
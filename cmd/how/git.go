@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/prompt"
+)
+
+// gitDetailsBudget bounds collecting repository state for `how git`.
+const gitDetailsBudget = 2 * time.Second
+
+func newGitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "git <what you want to do>",
+		Short: "Get git commands that account for this repository's state",
+		Long: `Ask how to do something in the current repository. Its status, recent branch
+graph, stashes, reflog and any rebase, merge or bisect in progress are sent
+with the question, so answers use your real branches and commits.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if !collect.InGitRepo(ctx) {
+				return fail("not inside a git repository")
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			question := strings.Join(args, " ")
+			facts := collect.GatherWithin(ctx, collect.GitDetails, gitDetailsBudget)
+			return runMode(ctx, cfg, prompt.GitPrompt(facts), question, "git: "+question)
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/swibrow/how/internal/hook"
+)
+
+// hookEvent describes command to the configured hooks.
+func hookEvent(command string) hook.Event {
+	ev := hook.Event{Command: command}
+	if targetHost != nil {
+		ev.Host = targetHost.Target
+	}
+	if cfg, err := cachedConfig(); err == nil {
+		ev.Profile = cfg.ActiveProfile
+	}
+	return ev
+}
+
+// runPreHooks runs the configured pre hooks, returning an error that
+// exits with exitBlocked when one stops the command.
+func runPreHooks(ev hook.Event) error {
+	cfg, err := cachedConfig()
+	if err != nil || len(cfg.Hooks.Pre) == 0 {
+		return nil
+	}
+	if err := hook.Pre(context.Background(), cfg.Hooks.Pre, ev, os.Stderr); err != nil {
+		return withCode(exitBlocked, fail("%w", err))
+	}
+	return nil
+}
+
+// runPostHooks runs the configured post hooks. Their failures are logged,
+// since the command has already run.
+func runPostHooks(ev hook.Event) {
+	cfg, err := cachedConfig()
+	if err != nil || len(cfg.Hooks.Post) == 0 {
+		return
+	}
+	if err := hook.Post(context.Background(), cfg.Hooks.Post, ev, os.Stderr); err != nil {
+		slog.Warn("post hook failed", "error", err)
+	}
+}
+
+// hooked runs command with run, which returns its exit status, between the
+// configured pre and post hooks. Every path that executes a command goes
+// through here, so a pre hook can stop any of them; hooks_test.go fails if
+// a new one doesn't.
+func hooked(command string, run func() (int, error)) (int, error) {
+	ev := hookEvent(command)
+	if err := runPreHooks(ev); err != nil {
+		return exitBlocked, err
+	}
+	start := time.Now()
+	code, err := run()
+	ev.ExitCode, ev.Duration = code, time.Since(start)
+	runPostHooks(ev)
+	return code, err
+}
+
+// stoppedByHook reports whether err is hooked refusing to run a command
+// because of a pre hook, rather than the command failing.
+func stoppedByHook(err error) bool {
+	var coded *codedError
+	return errors.As(err, &coded) && coded.code == exitBlocked
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runners are the calls that execute a command. They must only be made
+// from a function passed to hooked, or from helpers that are themselves
+// only called that way.
+var runners = map[string]bool{
+	"ui.RunCommand":     true,
+	"ui.RunWith":        true,
+	"ui.RunInteractive": true,
+	"ui.RunRemote":      true,
+	"ui.RunStaged":      true,
+	"preview.Rehearse":  true,
+	"session.Run":       true,
+	"runStages":         true,
+	"runWatched":        true,
+}
+
+// underHooks are the helpers whose bodies may call runners directly.
+var underHooks = map[string]bool{
+	"runStages":  true,
+	"runWatched": true,
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return fn.Name
+	case *ast.SelectorExpr:
+		if x, ok := fn.X.(*ast.Ident); ok {
+			return x.Name + "." + fn.Sel.Name
+		}
+	}
+	return ""
+}
+
+func TestEveryRunnerIsHooked(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || underHooks[fn.Name.Name] {
+				continue
+			}
+			// hookedLits are the function literals passed to hooked
+			hookedLits := map[*ast.FuncLit]bool{}
+			var stack []ast.Node
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if n == nil {
+					stack = stack[:len(stack)-1]
+					return true
+				}
+				stack = append(stack, n)
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				name := callName(call)
+				if name == "hooked" && len(call.Args) == 2 {
+					if lit, ok := call.Args[1].(*ast.FuncLit); ok {
+						hookedLits[lit] = true
+					}
+				}
+				if !runners[name] {
+					return true
+				}
+				for _, outer := range stack {
+					if lit, ok := outer.(*ast.FuncLit); ok && hookedLits[lit] {
+						return true
+					}
+				}
+				t.Errorf("%s: %s calls %s outside hooked, bypassing pre and post hooks", fset.Position(call.Pos()), fn.Name.Name, name)
+				return true
+			})
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swibrow/how/internal/clipboard"
+	"github.com/swibrow/how/internal/llm"
+)
+
+// queryImages are attached to every provider request made by this process,
+// from --image.
+var queryImages []llm.Image
+
+// loadImages reads each --image argument, where "clipboard" means the image
+// currently on the clipboard.
+func loadImages(ctx context.Context, args []string) ([]llm.Image, error) {
+	images := make([]llm.Image, 0, len(args))
+	for _, arg := range args {
+		if arg != "clipboard" {
+			img, err := llm.LoadImage(arg)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, img)
+			continue
+		}
+		data, err := clipboard.ReadImage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading image from clipboard: %w", err)
+		}
+		img, err := llm.NewImage(data)
+		if err != nil {
+			return nil, fmt.Errorf("clipboard: %w", err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+	"golang.org/x/term"
+)
+
+func newJQCmd() *cobra.Command {
+	var (
+		yq      bool
+		execute bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "jq [what you want]",
+		Short: "Build a jq/yq expression from a sample of piped JSON or YAML",
+		Long: `Build a jq or yq expression for the data piped on stdin.
+
+A truncated sample of the input is included in the prompt so the expression
+matches its real structure. YAML input is detected automatically and switches
+to yq.`,
+		Example: `  kubectl get pods -o json | how jq "names of pods not in Running phase" --run`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if term.IsTerminal(int(os.Stdin.Fd())) {
+				return fail("no input: pipe JSON or YAML on stdin")
+			}
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fail("reading stdin: %w", err)
+			}
+
+			tool, format := "jq", "JSON"
+			if yq || !json.Valid(input) {
+				tool, format = "yq", "YAML"
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			sysPrompt := prompt.QueryPrompt(tool, format, string(input))
+			result, err := complete(context.Background(), cfg, sysPrompt, strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+			} else {
+				ui.Display(ui.Result{
+					Command:     tool + " " + shellQuote(result.Command),
+					Explanation: result.Explanation,
+				})
+			}
+
+			if !execute {
+				return nil
+			}
+			return runFilter(tool, result.Command, input)
+		},
+	}
+
+	cmd.Flags().BoolVar(&yq, "yq", false, "Force yq even if the input is valid JSON")
+	cmd.Flags().BoolVarP(&execute, "run", "r", false, "Run the expression against the piped input")
+	return cmd
+}
+
+// runFilter runs tool with expr as its only argument, feeding it input.
+// The expression is passed directly rather than through a shell so it
+// never needs quoting.
+func runFilter(tool, expr string, input []byte) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		ui.DisplayError(fmt.Sprintf("%s is not installed", tool))
+		return err
+	}
+
+	c := exec.Command(tool, expr)
+	c.Stdin = bytes.NewReader(input)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+// shellQuote wraps s in single quotes for display as a shell argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
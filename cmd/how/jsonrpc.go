@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/rpc"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// rpcResult is the result object returned by every JSON-RPC method.
+type rpcResult struct {
+	Command     string   `json:"command"`
+	Explanation string   `json:"explanation,omitempty"`
+	Warning     string   `json:"warning,omitempty"`
+	Injections  []string `json:"injections,omitempty"`
+}
+
+func newRPCResult(r ui.Result) rpcResult {
+	return rpcResult{Command: r.Command, Explanation: r.Explanation, Warning: r.Warning, Injections: r.Injections}
+}
+
+// runJSONRPC serves suggest, explain and fix over newline-delimited
+// JSON-RPC 2.0 on stdin/stdout until stdin is closed. Config and the
+// provider are initialized once, so editor plugins pay no per-call startup.
+func runJSONRPC(cfg *config.Config) error {
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return fail("initializing provider: %w", err)
+	}
+
+	suggestPrompt := systemPrompt(cfg)
+	explainPrompt := prompt.ExplainPrompt()
+	fixPrompt := prompt.FixPrompt()
+
+	answer := func(ctx context.Context, fn askFunc, sysPrompt, query string) (any, error) {
+		result, err := fn(ctx, provider, sysPrompt, query)
+		if err != nil {
+			return nil, err
+		}
+		return newRPCResult(result), nil
+	}
+
+	handlers := map[string]rpc.HandlerFunc{
+		// suggest {"query": "..."}
+		"suggest": func(ctx context.Context, params json.RawMessage) (any, error) {
+			var p struct {
+				Query string `json:"query"`
+			}
+			if err := decodeRequired(params, &p, &p.Query, "query"); err != nil {
+				return nil, err
+			}
+			return answer(ctx, suggest, suggestPrompt, p.Query)
+		},
+		// explain {"command": "..."}
+		"explain": func(ctx context.Context, params json.RawMessage) (any, error) {
+			var p struct {
+				Command string `json:"command"`
+			}
+			if err := decodeRequired(params, &p, &p.Command, "command"); err != nil {
+				return nil, err
+			}
+			return answer(ctx, ask, explainPrompt, p.Command)
+		},
+		// fix {"command": "...", "error": "..."}
+		"fix": func(ctx context.Context, params json.RawMessage) (any, error) {
+			var p struct {
+				Command string `json:"command"`
+				Error   string `json:"error"`
+			}
+			if err := decodeRequired(params, &p, &p.Command, "command"); err != nil {
+				return nil, err
+			}
+			return answer(ctx, suggest, fixPrompt, prompt.FixQuery(p.Command, p.Error))
+		},
+	}
+
+	if err := rpc.Serve(context.Background(), os.Stdin, os.Stdout, handlers); err != nil {
+		ui.DisplayError(err.Error())
+		return err
+	}
+	return nil
+}
+
+// decodeRequired decodes params into v and checks that the named field is set.
+func decodeRequired(params json.RawMessage, v any, field *string, name string) error {
+	if err := rpc.DecodeParams(params, v); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*field) == "" {
+		return &rpc.Error{Code: rpc.CodeInvalidParams, Message: name + " is required"}
+	}
+	return nil
+}
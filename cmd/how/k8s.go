@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/k8s"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+// k8sLookupTimeout bounds each kubectl call made to build the prompt.
+const k8sLookupTimeout = 5 * time.Second
+
+func newK8sCmd() *cobra.Command {
+	var kubeContext, namespace string
+
+	cmd := &cobra.Command{
+		Use:   "k8s <question>",
+		Short: "Troubleshoot Kubernetes with kubectl commands scoped to your cluster",
+		Long: `Ask about a problem in your cluster. The current context, namespace and recent
+events are sent with the question, and every kubectl command in the answer is
+pinned to that context and namespace. Deleting, scaling and draining always
+require typing the namespace to confirm, even with --yes.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			question := strings.Join(args, " ")
+			ctx := context.Background()
+
+			lookupCtx, cancel := context.WithTimeout(ctx, k8sLookupTimeout)
+			defer cancel()
+			target, err := k8s.Current(lookupCtx, kubeContext, namespace)
+			if err != nil {
+				return fail("%w", err)
+			}
+			events, err := k8s.RecentEvents(lookupCtx, target, question)
+			if err != nil {
+				slog.Warn("could not read events", "error", err)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			result, err := completeCommand(ctx, cfg, prompt.K8sPrompt(target), prompt.K8sQuery(question, events))
+			if err != nil {
+				return err
+			}
+			result.Command = k8s.Scope(result.Command, target)
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+			fmt.Printf("\n  Cluster: context %s, namespace %s\n", target.Context, target.Namespace)
+			ui.Display(result)
+
+			store := openMemoryIfEnabled(cfg)
+			if store != nil {
+				defer store.Close() //nolint:errcheck
+			}
+			question = "k8s: " + question
+
+			reasons := k8s.Guard(result.Command)
+			if len(reasons) == 0 {
+				return execute(ctx, cfg, store, question, result)
+			}
+
+			// Disruptive operations skip the confirm policy and --yes
+			if result.Command, err = applyPolicy(cfg, result.Command); err != nil {
+				return err
+			}
+			ui.DisplayRisk(risk.Assessment{Level: risk.Dangerous, Reasons: reasons})
+			confirmed, err := ui.ConfirmTyped(fmt.Sprintf("This acts on context %s.", target.Context), target.Namespace)
+			if !confirmed || err != nil {
+				return recordRun(ctx, store, question, result, false, err)
+			}
+			return recordRun(ctx, store, question, result, true, runCommand(result.Command))
+		},
+	}
+
+	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubernetes context to use (default: current context)")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to use (default: the context's namespace)")
+	return cmd
+}
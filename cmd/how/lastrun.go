@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+
+	"github.com/swibrow/how/internal/prompt"
+)
+
+// lastRunQuery adds the last command run through how, and the output it
+// captured, to question for --from-last-run.
+func lastRunQuery(ctx context.Context, question string) (string, error) {
+	store, err := openMemoryStore()
+	if err != nil {
+		return "", fail("%w", err)
+	}
+	defer store.Close() //nolint:errcheck
+
+	last, err := store.Last(ctx)
+	if err != nil {
+		return "", fail("reading history: %w", err)
+	}
+	if last == nil {
+		return "", fail("no commands have been run through how yet")
+	}
+	return prompt.LastRunQuery(question, last.Command, last.ExitCode, last.Output), nil
+}
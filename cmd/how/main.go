@@ -2,21 +2,55 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/clipboard"
+	"github.com/swibrow/how/internal/collect"
 	"github.com/swibrow/how/internal/config"
 	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/logging"
 	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/policy"
 	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/redact"
+	"github.com/swibrow/how/internal/shell"
 	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
 )
 
 var (
-	flagYes   bool
-	flagQuiet bool
+	flagYes           bool
+	flagQuiet         bool
+	flagTeach         bool
+	flagRPC           bool
+	flagClip          bool
+	flagCopy          bool
+	flagRehearse      bool
+	flagFromLastRun   bool
+	flagQueryFile     string
+	flagImages        []string
+	flagHost          string
+	flagRaw           bool
+	flagOutput        string
+	flagNotify        bool
+	flagWatch         time.Duration
+	flagInstalledOnly bool
+	flagProfile       string
+	flagLogLevel      string
+	flagVerbose       bool
+	flagWithRisk      bool
+	flagExplainStderr bool
+	flagOut           string
+	flagAppend        bool
+	flagOutExplain    bool
 )
 
 func main() {
@@ -24,56 +58,51 @@ func main() {
 		Use:           "how [question]",
 		Short:         "Smart terminal cheatsheet — ask a question, get a command",
 		Long:          "Ask a natural language question and get back a shell command with explanation.",
-		Args:          cobra.MinimumNArgs(1),
+		Args:          cobra.ArbitraryArgs,
 		RunE:          run,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		Version:       version,
 	}
 
-	rootCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Run the command without confirmation")
-	rootCmd.Flags().BoolVarP(&flagQuiet, "quiet", "q", false, "Output only the command (for piping)")
-
-	configCmd := &cobra.Command{
-		Use:   "config",
-		Short: "Show or manage configuration",
-	}
-
-	configShowCmd := &cobra.Command{
-		Use:   "show",
-		Short: "Show current configuration",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			output, err := config.Show()
-			if err != nil {
-				return err
-			}
-			fmt.Println(output)
-			return nil
-		},
-	}
-
-	configInitCmd := &cobra.Command{
-		Use:   "init",
-		Short: "Create a default configuration file",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.DefaultConfig()
-			if err := config.Save(cfg); err != nil {
-				return fmt.Errorf("saving config: %w", err)
-			}
-			fmt.Println("Default config created at ~/.config/how/config.yaml")
-			fmt.Println("Edit it to add your API keys and select a provider.")
-			return nil
-		},
-	}
+	rootCmd.PersistentFlags().BoolVarP(&flagYes, "yes", "y", false, "Run the command without confirmation")
+	rootCmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "Output only the command (for piping; the default when a question's stdout is a pipe, unless --quiet=false or --yes)")
+	rootCmd.PersistentFlags().BoolVar(&flagWithRisk, "with-risk", false, "With --quiet, print the command's risk level (safe, caution or dangerous) and a tab before it")
+	rootCmd.PersistentFlags().BoolVar(&flagExplainStderr, "explain-to-stderr", false, "With --quiet, write the explanation and any warning to stderr while the command goes to stdout")
+	rootCmd.PersistentFlags().BoolVar(&flagNotify, "notify", false, "Show a desktop notification with the exit status when a command finishes")
+	rootCmd.PersistentFlags().DurationVar(&flagWatch, "watch", 0, "Re-run a read-only command at this interval (e.g. 5s) until interrupted")
+	rootCmd.PersistentFlags().BoolVar(&flagInstalledOnly, "installed-only", false, "Only accept commands whose tools are all installed, asking the model for substitutes")
+	rootCmd.PersistentFlags().StringVar(&flagLogLevel, "log-level", os.Getenv("HOW_LOG_LEVEL"), "Log level: debug, info, warn or error (logs go to $HOW_LOG_FILE or stderr)")
+	rootCmd.PersistentFlags().BoolVar(&flagVerbose, "verbose", false, "Show the estimated tokens and cost of each request before it's sent")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "Config profile to use (default $HOW_PROFILE or default_profile)")
+	rootCmd.Flags().BoolVar(&flagRPC, "stdio-jsonrpc", false, "Serve suggest/explain/fix as JSON-RPC on stdin/stdout (for editor plugins)")
+	rootCmd.Flags().BoolVar(&flagClip, "from-clipboard", false, "Use the clipboard contents (e.g. a copied error message) as the question or its context")
+	rootCmd.Flags().BoolVar(&flagCopy, "copy", false, "Copy the suggested command to the clipboard instead of running it (over SSH, via the terminal with OSC 52)")
+	rootCmd.Flags().StringVar(&flagOut, "out", "", "Write the suggested command to a file instead of running it, e.g. to build up a runbook")
+	rootCmd.Flags().BoolVar(&flagAppend, "append", false, "With --out, append to the file instead of replacing it")
+	rootCmd.Flags().BoolVar(&flagOutExplain, "with-explanation", false, "With --out, write the question, explanation, warning and risk as comments above the command")
+	rootCmd.Flags().StringVar(&flagQueryFile, "query-file", "", `Read the question from a file ("-" for stdin), so it can span lines and needs no quoting`)
+	rootCmd.Flags().BoolVar(&flagFromLastRun, "from-last-run", false, "Include the last command run through how and its output, to build the next stage of a pipeline")
+	rootCmd.Flags().BoolVar(&flagRehearse, "rehearse", false, "Run the command in a sandbox on temporary copies of the files it names and show what changed before asking to run it for real")
+	rootCmd.Flags().StringArrayVar(&flagImages, "image", nil, `Attach an image, such as a screenshot of an error, for vision-capable models ("clipboard" reads the clipboard)`)
+	rootCmd.Flags().StringVar(&flagHost, "host", "", "Suggest and run the command on a remote host over SSH (user@server)")
+	rootCmd.Flags().BoolVar(&flagRaw, "raw", false, "Stream a free-form answer instead of a command, for explanations and comparisons")
+	rootCmd.Flags().StringVarP(&flagOutput, "output", "o", "text", "Output format: text, json, or raycast, alfred-json or rofi for launcher integrations (json by default in CI, unless --yes or --quiet)")
+	rootCmd.Flags().BoolVar(&flagTeach, "teach", false, "Walk through a step-by-step tutorial instead of a single command")
 
 	memoryCmd := &cobra.Command{
 		Use:   "memory",
 		Short: "Manage command memory",
 	}
 
+	var memoryOutput string
 	memoryListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List remembered commands",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ui.CheckListFormat(memoryOutput); err != nil {
+				return fail("%w", err)
+			}
 			store, err := openMemoryStore()
 			if err != nil {
 				return err
@@ -85,6 +114,15 @@ func main() {
 				return fmt.Errorf("listing memory: %w", err)
 			}
 
+			if memoryOutput != "text" {
+				list := ui.NewListWriter(os.Stdout, memoryOutput)
+				for _, ix := range interactions {
+					if err := list.Write(ix); err != nil {
+						return fail("writing output: %w", err)
+					}
+				}
+				return list.Flush()
+			}
 			if len(interactions) == 0 {
 				fmt.Println("No remembered commands yet.")
 				return nil
@@ -119,13 +157,78 @@ func main() {
 		},
 	}
 
+	memoryListCmd.Flags().StringVarP(&memoryOutput, "output", "o", "text", "Output format: text, jsonl, yaml, csv or table")
 	memoryCmd.AddCommand(memoryListCmd, memoryClearCmd)
-	configCmd.AddCommand(configShowCmd, configInitCmd)
-	rootCmd.AddCommand(configCmd, memoryCmd)
+	rootCmd.AddCommand(newConfigCmd(), memoryCmd, newRegexCmd(), newJQCmd(), newUndoCmd(), newOptimizeCmd(), newTranslateCmd(), newBatchCmd(), newReplCmd(), newServeCmd(), newSetupCmd(), newUpgradeCmd(), newDaemonCmd(), newInitCmd(), newWhyCmd(), newK8sCmd(), newGitCmd(), newDockerCmd(), newSystemdCmd(), newMediaCmd(), newSQLCmd(), newFeedbackCmd(), newEvalCmd(), newBenchCmd(), newPluginsCmd(), newStatsCmd(), newStatusCmd(), newPackCmd(), newCheckCmd(), newScriptCmd(), newSessionCmd(), newShareCmd(), newFavCmd())
+	if cmd := pluginCmd(rootCmd, os.Args[1:]); cmd != nil {
+		rootCmd.AddCommand(cmd)
+	}
+
+	closeLog := func() error { return nil }
+	notify := false
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		closer, err := logging.Setup(flagLogLevel, os.Getenv("HOW_LOG_FILE"))
+		if err != nil {
+			return fail("%w", err)
+		}
+		closeLog = closer
+		ui.Unattended = collect.CI()
+		if ciOutput(rootCmd, cmd) {
+			_ = cmd.Flags().Set("output", "json")
+		}
+		if autoQuiet(rootCmd, cmd) {
+			flagQuiet = true
+		}
+		ui.WithRisk = flagWithRisk
+		ui.ExplainToStderr = flagExplainStderr
+		notify = startUpdateCheck(cmd)
+		return nil
+	}
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	err := rootCmd.Execute()
+	if notify {
+		showUpdateNotice()
+	}
+	_ = closeLog()
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+// actionFlags choose what the root command does with a suggestion, so
+// setting one turns off autoQuiet and ciOutput.
+var actionFlags = []string{"yes", "copy", "out", "output", "raw", "teach", "rehearse", "watch", "host", "stdio-jsonrpc"}
+
+// autoQuiet reports whether cmd should print only the command without
+// --quiet: a plain question whose stdout is piped or captured, so
+// how ... | pbcopy and $(how ...) just work. Subcommands, redirects to a
+// file and explicit actions such as --yes keep their usual behaviour.
+func autoQuiet(root, cmd *cobra.Command) bool {
+	if cmd != root || cmd.Flags().Changed("quiet") || !stdoutIsPipe() {
+		return false
+	}
+	for _, name := range actionFlags {
+		if cmd.Flags().Changed(name) {
+			return false
+		}
 	}
+	return true
+}
+
+// ciOutput reports whether cmd should default to --output json: a plain
+// question or how check running unattended in CI, where the result is
+// read by the job rather than a person. --quiet, --output text and the
+// action flags keep their usual behaviour.
+func ciOutput(root, cmd *cobra.Command) bool {
+	if ui.Unattended == "" || (cmd != root && cmd.Name() != "check") || cmd.Flags().Changed("quiet") {
+		return false
+	}
+	for _, name := range actionFlags {
+		if cmd.Flags().Changed(name) {
+			return false
+		}
+	}
+	return true
 }
 
 func openMemoryStore() (*memory.Store, error) {
@@ -140,71 +243,736 @@ func openMemoryStore() (*memory.Store, error) {
 	return store, nil
 }
 
-func run(cmd *cobra.Command, args []string) error {
-	question := strings.Join(args, " ")
+// startTime is when the process started, for startup-overhead logging.
+var startTime = time.Now()
+
+var (
+	loadedConfig    *config.Config
+	loadedConfigErr error
+	configLoaded    bool
+)
+
+// cachedConfig loads the configuration with the selected profile applied,
+// once per process, so the update check and the command share one load.
+func cachedConfig() (*config.Config, error) {
+	if !configLoaded {
+		loadedConfig, loadedConfigErr = config.LoadProfile(flagProfile)
+		configLoaded = true
+		if loadedConfig != nil {
+			ui.ShellHistory = loadedConfig.ShellHistory
+			ui.SpacePrefix = loadedConfig.SpacePrefix
+			ui.ExplainToStderr = flagExplainStderr || loadedConfig.ExplainToStderr
+			ui.Provenance = loadedConfig.Provenance
+			ui.CaptureOutput = loadedConfig.Memory.Enabled && loadedConfig.Memory.CaptureOutput
+			if err := ui.SetTheme(loadedConfig.Theme); err != nil {
+				ui.DisplayError(err.Error())
+			}
+			if err := ui.SetIcons(loadedConfig.Icons); err != nil {
+				ui.DisplayError(err.Error())
+			}
+			// A broken rules file is an error rather than a warning, since
+			// falling back to the built-in rules would drop the file's.
+			if loadedConfig.RiskRules != "" {
+				if rules, err := riskRules(loadedConfig.RiskRules); err != nil {
+					loadedConfigErr = err
+				} else {
+					risk.Use(rules)
+				}
+			}
+		}
+	}
+	return loadedConfig, loadedConfigErr
+}
 
-	cfg, err := config.Load()
+// loadConfig loads the configuration with the selected profile applied,
+// displaying any error.
+func loadConfig() (*config.Config, error) {
+	cfg, err := cachedConfig()
 	if err != nil {
 		ui.DisplayError(fmt.Sprintf("loading config: %v", err))
-		return err
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// systemPrompt builds the suggestion prompt from config, including any
+// prompt additions from the active profile. Rules about this machine's
+// shell, session, root access and aliases are left out for --host and
+// how serve, whose commands run elsewhere.
+func systemPrompt(cfg *config.Config) string {
+	p := prompt.SystemPrompt(cfg.SystemPrompt)
+	if targetHost != nil {
+		p = prompt.RemoteSystemPrompt(cfg.SystemPrompt, targetHost.Target, targetHost.Describe())
+	}
+	local := targetHost == nil && !serving
+	if local {
+		p += prompt.ShellRule(userShell())
+		p += prompt.SessionRule(collect.Session())
+		if runtime.GOOS != "windows" {
+			p += prompt.EscalationRule(shell.Escalator())
+		}
+	}
+	p += prompt.CIRule(ui.Unattended)
+	p += prompt.FormatPreferences(cfg.Prefer)
+	if local && posixShell() {
+		p += prompt.FormatAliases(userAliases(context.Background()))
+	}
+	if cfg.PromptAdditions != "" {
+		p += "\n" + cfg.PromptAdditions
+	}
+	return p
+}
+
+// machineContext collects the configured facts about this machine for the
+// suggestion prompt, within collect.Budget.
+func machineContext(ctx context.Context, cfg *config.Config) string {
+	if targetHost != nil {
+		// Local facts don't apply to a remote host
+		return ""
+	}
+	return prompt.FormatMachineContext(collect.GatherWithHooks(ctx, collect.Select(cfg.Context.Collectors), collect.Hooks(cfg.ContextHooks)))
+}
+
+// clockContext tells the model the local time, time zone and locale, or
+// nothing when the command runs on another host.
+func clockContext() string {
+	if targetHost != nil {
+		return ""
+	}
+	return prompt.FormatClock(time.Now(), collect.TimeZone(), collect.Locale())
+}
+
+// fail displays an error to the user and returns it so the command exits non-zero.
+func fail(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	ui.DisplayError(err.Error())
+	return err
+}
+
+// complete sends a single request to the configured provider and parses the
+// response. Errors are displayed to the user before being returned.
+func complete(ctx context.Context, cfg *config.Config, sysPrompt, query string) (ui.Result, error) {
+	return completeWith(ctx, cfg, sysPrompt, query, ask)
+}
+
+// completeCommand is complete for answers that are shell commands, which
+// are validated and repaired with suggest.
+func completeCommand(ctx context.Context, cfg *config.Config, sysPrompt, query string) (ui.Result, error) {
+	return completeWith(ctx, cfg, sysPrompt, query, suggest)
+}
+
+type askFunc func(ctx context.Context, provider llm.Provider, sysPrompt, query string) (ui.Result, error)
+
+func completeWith(ctx context.Context, cfg *config.Config, sysPrompt, query string, fn askFunc) (ui.Result, error) {
+	provider, err := newProvider(cfg)
+	if err != nil {
+		ui.DisplayError(fmt.Sprintf("initializing provider: %v", err))
+		return ui.Result{}, withCode(exitProvider, err)
+	}
+
+	if len(queryImages) > 0 {
+		provider = llm.WithImages(provider, queryImages)
 	}
 
-	// Open memory store (non-fatal on failure)
-	var store *memory.Store
-	if cfg.Memory.Enabled {
-		store, err = openMemoryStore()
+	rounds := cfg.ClarifyRounds
+	if rounds > 0 && canClarify() {
+		sysPrompt += "\n\n" + prompt.ClarifyInstruction
+	}
+	previewCost(cfg, sysPrompt, query)
+	stop := ui.StartSpinner("Thinking…")
+	result, route, err := askTriaged(ctx, cfg, provider, sysPrompt, query, fn)
+	stop()
+	if flagVerbose && route != "" && err == nil {
+		ui.DisplayRoute(route)
+	}
+
+	// Each answer goes back with every earlier one; the last round is
+	// asked without leave to ask again
+	var transcript []prompt.Clarification
+	for err == nil && result.Command == "" && result.Question != "" && len(transcript) < rounds {
+		answer, aerr := ui.Clarify(result.Question)
+		if aerr != nil {
+			return ui.Result{}, fail("%w", aerr)
+		}
+		transcript = append(transcript, prompt.Clarification{Question: result.Question, Answer: answer})
+		if len(transcript) == rounds {
+			sysPrompt = strings.TrimSuffix(sysPrompt, "\n\n"+prompt.ClarifyInstruction)
+		}
+		stop := ui.StartSpinner("Thinking…")
+		result, err = fn(ctx, provider, sysPrompt, prompt.ClarifiedQuery(query, transcript))
+		stop()
+	}
+	if flagVerbose && len(transcript) > 0 {
+		ui.DisplayTranscript(transcript)
+	}
+	if err != nil {
+		displayAskError(err)
+		return ui.Result{}, err
+	}
+	return result, nil
+}
+
+// canClarify reports whether the model may ask a clarifying question: the
+// user is at a terminal to answer it and the output is for them, not a
+// script.
+func canClarify() bool {
+	return canCompose() && flagOutput == "text" && !flagQuiet
+}
+
+// noCommandError reports a response with no usable command, even after
+// re-prompting. Reasoning holds whatever the model said instead.
+type noCommandError struct {
+	Reasoning string
+}
+
+func (e *noCommandError) Error() string {
+	return "the model did not return a command"
+}
+
+// displayAskError shows an error from ask, including the model's reasoning
+// when it refused or answered in prose.
+func displayAskError(err error) {
+	var noCmd *noCommandError
+	if errors.As(err, &noCmd) && noCmd.Reasoning != "" {
+		ui.DisplayReasoning(noCmd.Reasoning)
+	}
+	ui.DisplayError(err.Error())
+}
+
+// ask sends a query to provider and parses the response. If the model
+// refuses or answers without a command, it is re-prompted once with a
+// stricter instruction before failing with a *noCommandError.
+func ask(ctx context.Context, provider llm.Provider, sysPrompt, query string) (ui.Result, error) {
+	ctx = logging.WithRequestID(ctx)
+	log := slog.With("request_id", logging.RequestID(ctx))
+
+	response, err := provider.Complete(ctx, sysPrompt, query)
+	if err != nil {
+		return ui.Result{}, withCode(exitProvider, fmt.Errorf("LLM request failed: %w", err))
+	}
+
+	result := ui.ParseResponse(response)
+	if !hasCommand(result) && result.Question != "" && prompt.Clarifying(sysPrompt) {
+		log.Info("model asked a clarifying question", "question", result.Question)
+		return result, nil
+	}
+	if !hasCommand(result) {
+		log.Info("no command in response, re-prompting", "response", response)
+		response, err = provider.Complete(ctx, sysPrompt+"\n\n"+prompt.StrictFormatReminder, query)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: memory disabled: %v\n", err)
-		} else {
-			defer store.Close() //nolint:errcheck
+			return ui.Result{}, withCode(exitProvider, fmt.Errorf("LLM request failed: %w", err))
 		}
+		result = ui.ParseResponse(response)
 	}
+	if !hasCommand(result) {
+		log.Warn("no command in response", "response", response)
+		return ui.Result{}, withCode(exitNoParse, &noCommandError{Reasoning: ui.Reasoning(response)})
+	}
+	result = markInjections(result, sysPrompt, query)
+	log.Debug("parsed response", "command", result.Command, "explanation", result.Explanation, "warning", result.Warning)
+	return result, nil
+}
 
-	// Build system prompt, enriching with memory context if available
-	ctx := context.Background()
-	sysPrompt := prompt.SystemPrompt(cfg.SystemPrompt)
-	if store != nil {
-		if past, err := store.Search(ctx, question, 10); err == nil && len(past) > 0 {
-			sysPrompt += prompt.FormatMemoryContext(past)
+// markInjections warns about a result whose prompt carried text that looks
+// like instructions to the model, and records what was found.
+func markInjections(result ui.Result, sysPrompt, query string) ui.Result {
+	if found := prompt.Injections(sysPrompt, query); len(found) > 0 {
+		slog.Info("possible prompt injection in context", "phrases", found)
+		result.Injections = found
+		result.Warning = strings.TrimSpace(fmt.Sprintf("The context sent with this question contains text that looks like instructions to the model (%q); check the command before running it. %s", found[0], result.Warning))
+	}
+	return result
+}
+
+// maxInstalledRepairs is how many corrections are asked for when commands
+// must use only installed tools.
+const maxInstalledRepairs = 3
+
+// suggest is ask for shell commands, with the answer checked and repaired
+// by validate.
+func suggest(ctx context.Context, provider llm.Provider, sysPrompt, query string) (ui.Result, error) {
+	result, err := ask(ctx, provider, sysPrompt, query)
+	if err != nil || result.Question != "" {
+		return result, err
+	}
+	return validate(ctx, provider, sysPrompt, query, result)
+}
+
+// validate checks a suggested command: one that fails shell.Check is sent
+// back to the model with the problem and any installed alternatives to
+// missing tools. If the correction still fails, the better of the two is
+// returned with the problem as a warning; with --installed-only, a command
+// that still needs missing tools is an error instead.
+func validate(ctx context.Context, provider llm.Provider, sysPrompt, query string, result ui.Result) (ui.Result, error) {
+	result.Command = preferTools(ctx, result.Command)
+	problem := checkCommand(ctx, result.Command)
+
+	strict := installedOnly()
+	repairs := 1
+	if strict {
+		repairs = maxInstalledRepairs
+	}
+	for i := 0; problem != nil && i < repairs; i++ {
+		slog.Info("command failed validation, asking for a correction", "command", result.Command, "problem", problem)
+		repaired, err := ask(ctx, provider, sysPrompt, prompt.RepairQuery(query, result.Command, describeProblem(ctx, problem)))
+		if err != nil || repaired.Question != "" {
+			break
 		}
+		result = repaired
+		result.Command = preferTools(ctx, result.Command)
+		problem = checkCommand(ctx, result.Command)
+	}
+
+	var missing *shell.MissingError
+	if strict && errors.As(problem, &missing) {
+		return ui.Result{}, withCode(exitNoParse, fmt.Errorf("no suggestion uses only installed tools (%w)", problem))
 	}
+	if problem != nil {
+		result.Warning = strings.TrimSpace(result.Warning + " " + capitalize(problem.Error()) + ".")
+	}
+	return result, nil
+}
 
-	provider, err := llm.NewProvider(cfg)
+// installedOnly reports whether suggestions must use only installed tools,
+// from --installed-only or the installed_only setting.
+func installedOnly() bool {
+	if flagInstalledOnly {
+		return true
+	}
+	cfg, err := cachedConfig()
+	return err == nil && cfg.InstalledOnly
+}
+
+// preferTools swaps tools in command for the ones the prefer setting
+// names, where those are installed, and sudo for doas or sudo-rs on
+// machines that have one of those instead.
+func preferTools(ctx context.Context, command string) string {
+	cfg, err := cachedConfig()
+	if err != nil {
+		return command
+	}
+	prefer := cfg.Prefer
+	if esc, _ := shell.Escalator(); targetHost == nil && !serving && esc != "" && esc != "sudo" && prefer["sudo"] == "" {
+		prefer = map[string]string{"sudo": esc}
+		for from, to := range cfg.Prefer {
+			prefer[from] = to
+		}
+	}
+	if len(prefer) == 0 {
+		return command
+	}
+	out, changes := shell.Substitute(command, prefer, func(tool string) bool {
+		return len(installedAlternatives(ctx, []string{tool})) > 0
+	})
+	if len(changes) > 0 {
+		slog.Info("substituted preferred tools", "command", command, "changes", changes)
+	}
+	return out
+}
+
+// describeProblem explains a failed check for the model, naming installed
+// tools that could replace missing ones.
+func describeProblem(ctx context.Context, problem error) string {
+	var missing *shell.MissingError
+	if !errors.As(problem, &missing) {
+		return problem.Error()
+	}
+	var hints []string
+	for _, name := range missing.Names {
+		if alts := installedAlternatives(ctx, shell.Alternatives(name)); len(alts) > 0 {
+			hints = append(hints, fmt.Sprintf("instead of %s, installed: %s", name, strings.Join(alts, ", ")))
+		}
+	}
+	if len(hints) == 0 {
+		return problem.Error()
+	}
+	return fmt.Sprintf("%s (%s)", problem, strings.Join(hints, "; "))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// hasCommand reports whether result holds a real command rather than an
+// empty line or a refusal dressed up as one.
+func hasCommand(result ui.Result) bool {
+	return result.Command != "" && !ui.IsRefusal(result.Command)
+}
+
+// openMemoryIfEnabled opens the memory store when enabled in config.
+// Failures are non-fatal: a warning is printed and nil is returned.
+func openMemoryIfEnabled(cfg *config.Config) *memory.Store {
+	if !cfg.Memory.Enabled {
+		return nil
+	}
+	store, err := openMemoryStore()
+	if err != nil {
+		slog.Warn("memory disabled", "error", err)
+		return nil
+	}
+	return store
+}
+
+func run(cmd *cobra.Command, args []string) (retErr error) {
+	if flagOutput != "text" {
+		if flagOutput != "json" && !slices.Contains(ui.LauncherFormats, flagOutput) {
+			return fail("unknown output format %q (expected text, json, %s)", flagOutput, strings.Join(ui.LauncherFormats, ", "))
+		}
+		if flagRaw || flagTeach || flagRPC || flagHost != "" {
+			return fail("--output %s can't be combined with --raw, --teach, --stdio-jsonrpc or --host", flagOutput)
+		}
+		defer func() {
+			switch {
+			case retErr == nil:
+			case flagOutput == "json":
+				ui.DisplayErrorJSON(errorObject(retErr))
+			default:
+				// Launchers show stdout, so errors are rendered there too
+				fmt.Print(ui.FormatLauncherError(flagOutput, retErr.Error()))
+			}
+		}()
+		ui.JSONErrors = flagOutput == "json"
+	}
+	if flagOut == "" && (flagAppend || flagOutExplain) {
+		return fail("--append and --with-explanation only apply with --out")
+	}
+	if flagOut != "" && (flagOutput != "text" || flagCopy || flagRaw || flagTeach || flagRPC || flagWatch != 0) {
+		return fail("--out can't be combined with --output, --copy, --raw, --teach, --stdio-jsonrpc or --watch")
+	}
+	if flagQueryFile != "" {
+		if len(args) > 0 {
+			return fail("give the question as arguments or with --query-file, not both")
+		}
+		query, err := readQuery(flagQueryFile)
+		if err != nil {
+			return fail("reading --query-file: %w", err)
+		}
+		args = []string{query}
+	} else if !flagRPC && !flagClip && len(flagImages) == 0 && len(args) == 0 {
+		var (
+			query string
+			err   error
+		)
+		switch {
+		case !stdinIsTerminal():
+			// A heredoc or pipe: how <<'EOF' ... EOF
+			query, err = readQuery("-")
+		case canCompose():
+			query, err = composeQuery()
+		default:
+			err = cobra.MinimumNArgs(1)(cmd, args)
+		}
+		if err != nil {
+			return fail("%w", err)
+		}
+		args = []string{query}
+	}
+	if len(args) > 0 && strings.TrimSpace(strings.Join(args, "")) == "" {
+		return fail("empty query, nothing to ask")
+	}
+	question := strings.Join(args, " ")
+	asked := question
+	if flagClip {
+		text, err := clipboard.ReadText(context.Background())
+		if err != nil {
+			return fail("%w", err)
+		}
+		question = prompt.ClipboardQuery(question, text)
+	}
+	if flagFromLastRun {
+		q, err := lastRunQuery(context.Background(), question)
+		if err != nil {
+			return err
+		}
+		question = q
+	}
+	if len(flagImages) > 0 {
+		images, err := loadImages(context.Background(), flagImages)
+		if err != nil {
+			return fail("%w", err)
+		}
+		queryImages = images
+		question = prompt.ImageQuery(question)
+	}
+
+	cfg, err := loadConfig()
 	if err != nil {
-		ui.DisplayError(fmt.Sprintf("initializing provider: %v", err))
 		return err
 	}
 
-	response, err := provider.Complete(ctx, sysPrompt, question)
+	if flagRPC {
+		return runJSONRPC(cfg)
+	}
+
+	if needsSetup(cfg) {
+		if flagOutput != "text" {
+			return fail("no provider is configured; run how setup first")
+		}
+		if cfg, err = runSetupWizard(); err != nil {
+			return err
+		}
+	}
+
+	if flagRaw {
+		return runRaw(context.Background(), cfg, question)
+	}
+	if flagTeach && flagHost != "" {
+		return fail("--teach can't be combined with --host")
+	}
+	if flagTeach && flagWatch != 0 {
+		return fail("--teach can't be combined with --watch")
+	}
+	if flagTeach {
+		return runTeach(context.Background(), cfg, question)
+	}
+	if flagRehearse && flagHost != "" {
+		return fail("--rehearse can't be combined with --host")
+	}
+	if flagRehearse && flagWatch != 0 {
+		return fail("--rehearse can't be combined with --watch")
+	}
+
+	ctx := context.Background()
+	var (
+		result    ui.Result
+		viaDaemon bool
+	)
+	if flagHost != "" {
+		if targetHost, err = connectHost(ctx, flagHost); err != nil {
+			return fail("%w", err)
+		}
+	}
+	// The daemon doesn't see --installed-only
+	if len(queryImages) == 0 && targetHost == nil && !flagInstalledOnly {
+		result, viaDaemon, err = suggestViaDaemon(cfg, question)
+	}
 	if err != nil {
-		ui.DisplayError(fmt.Sprintf("LLM request failed: %v", err))
+		ui.DisplayError(err.Error())
 		return err
 	}
 
-	result := ui.ParseResponse(response)
-	if result.Command == "" {
-		ui.DisplayError("could not parse a command from the response")
-		return fmt.Errorf("no command in response")
+	store := openMemoryIfEnabled(cfg)
+	if store != nil {
+		defer store.Close() //nolint:errcheck
+	}
+
+	if !viaDaemon {
+		// Build system prompt, enriching with memory context if available
+		sysPrompt := systemPrompt(cfg) + machineContext(ctx, cfg) + clockContext()
+		if store != nil {
+			if past, err := store.Search(ctx, question, 10); err == nil && len(past) > 0 {
+				sysPrompt += prompt.FormatMemoryContext(past)
+			}
+			sysPrompt += feedbackContext(ctx, cfg, nil, store, question)
+		}
+
+		slog.Debug("sending query", "startup_ms", float64(time.Since(startTime).Microseconds())/1000)
+		if result, err = completeCommand(ctx, cfg, sysPrompt, question); err != nil {
+			return err
+		}
 	}
 
+	if flagOutput != "text" {
+		var out string
+		if flagOutput == "json" {
+			out, err = ui.FormatJSON(result)
+		} else {
+			out, err = ui.FormatLauncher(flagOutput, result)
+		}
+		if err != nil {
+			return fail("%w", err)
+		}
+		fmt.Print(out)
+		return nil
+	}
+	if flagCopy {
+		return copyCommand(ctx, result)
+	}
+	if flagOut != "" {
+		return writeOut(asked, result)
+	}
 	if flagQuiet {
 		ui.DisplayQuiet(result)
 		return nil
 	}
 
 	ui.Display(result)
+	return execute(ctx, cfg, store, question, result)
+}
 
-	if flagYes {
-		err := ui.RunCommand(result.Command)
-		if err == nil && store != nil {
-			_ = store.Save(ctx, question, result.Command, result.Explanation)
-		}
+// writeOut writes the result's command to the --out file, rather than
+// running it, and shows it unless --quiet was given.
+func writeOut(question string, result ui.Result) error {
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if flagAppend {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(flagOut, mode, 0o644)
+	if err != nil {
+		return fail("%w", err)
+	}
+	if _, err := f.WriteString(ui.FormatRunbook(question, result, flagOutExplain, time.Now())); err != nil {
+		f.Close() //nolint:errcheck
+		return fail("writing %s: %w", flagOut, err)
+	}
+	if err := f.Close(); err != nil {
+		return fail("writing %s: %w", flagOut, err)
+	}
+	if flagQuiet {
+		return nil
+	}
+	ui.Display(result)
+	if flagAppend {
+		fmt.Printf("  Appended to %s.\n", flagOut)
+	} else {
+		fmt.Printf("  Written to %s.\n", flagOut)
+	}
+	return nil
+}
+
+// copyCommand puts the result's command on the clipboard and shows it,
+// rather than running it.
+func copyCommand(ctx context.Context, result ui.Result) error {
+	method, err := clipboard.WriteText(ctx, result.Command)
+	if err != nil {
+		return fail("%w", err)
+	}
+	if flagQuiet {
+		ui.DisplayQuiet(result)
+		return nil
+	}
+	ui.Display(result)
+	ui.DisplayCopied(method)
+	return nil
+}
+
+// execute runs the result's command, with confirmation unless --yes was given,
+// and records it in memory. store may be nil when memory is disabled.
+func execute(ctx context.Context, cfg *config.Config, store *memory.Store, question string, result ui.Result) error {
+	command, err := applyPolicy(cfg, result.Command)
+	if err != nil {
 		return err
 	}
+	result.Command = command
 
-	confirmed, err := ui.ConfirmAndRun(result.Command)
-	if confirmed && err == nil && store != nil {
-		_ = store.Save(ctx, question, result.Command, result.Explanation)
+	assessment := risk.Classify(result.Command)
+	ui.DisplayRisk(assessment)
+	if targetHost == nil {
+		if dir, err := os.Getwd(); err == nil {
+			ui.DisplayPaths(risk.AffectedPaths(result.Command, dir))
+		}
+	}
+
+	if flagWatch != 0 {
+		return watch(ctx, cfg, store, question, result, assessment)
+	}
+	if stages := shell.Stages(result.Command); len(stages) > 1 && targetHost == nil && posixShell() {
+		ui.DisplayStages(stages)
+	}
+
+	// Suspected injections and rehearsed commands are confirmed even with --yes
+	confirm := flagRehearse || len(result.Injections) > 0 || needsConfirmation(cfg, assessment)
+	var alts *alternatives
+	if confirm {
+		if alts = prefetchAlternatives(ctx, cfg, question, result); alts != nil {
+			defer alts.cancel()
+		}
+	}
+
+	// A command that may carry injected instructions isn't run at all,
+	// not even in the sandbox
+	if flagRehearse && len(result.Injections) > 0 {
+		ui.DisplayNotice("Not rehearsing: the suggestion may contain injected instructions.")
+	} else if flagRehearse {
+		if err := rehearse(ctx, result.Command); err != nil {
+			return err
+		}
+	}
+
+	var ran bool
+	if confirm {
+		if !flagRehearse && targetHost == nil && posixShell() {
+			previewEdit(ctx, result.Command)
+		}
+		var chosen *ui.Result
+		ran, chosen, err = confirmOrAlternative(result.Command, alts)
+		if chosen != nil {
+			ui.Display(*chosen)
+			return execute(ctx, cfg, store, question, *chosen)
+		}
+	} else {
+		ran, err = true, runCommand(result.Command)
+	}
+	if ran {
+		askFeedback(ctx, cfg, store, question, result.Command)
+	}
+
+	return recordRun(ctx, store, question, result, ran, err)
+}
+
+// recordRun saves a command that ran, with its captured output, in memory
+// and maps a declined prompt (ran false, err nil) to errDeclined. Secrets
+// in the output are masked first, since it is later sent to the provider.
+func recordRun(ctx context.Context, store *memory.Store, question string, result ui.Result, ran bool, err error) error {
+	if ran && store != nil {
+		var secrets []string
+		if cfg, cfgErr := cachedConfig(); cfgErr == nil {
+			secrets = configSecrets(cfg)
+		}
+		output, _ := redact.Redact(ui.LastOutput(), secrets...)
+		_ = store.Record(ctx, question, result.Command, exitCode(err), output)
+		if err == nil {
+			_ = store.Save(ctx, question, result.Command, result.Explanation)
+		}
+	}
+	if !ran && err == nil {
+		return errDeclined
 	}
 	return err
 }
+
+// needsConfirmation applies the confirm policy: "never" runs everything,
+// "destructive-only" prompts only for dangerous commands, and "always"
+// prompts for everything. Commands matching a risk rule with confirm: true
+// prompt under any policy. --yes skips the prompt.
+func needsConfirmation(cfg *config.Config, a risk.Assessment) bool {
+	if flagYes {
+		return false
+	}
+	if a.Confirm {
+		return true
+	}
+	switch cfg.Confirm {
+	case config.ConfirmNever:
+		return false
+	case config.ConfirmDestructiveOnly:
+		return a.Destructive()
+	default:
+		return true
+	}
+}
+
+// applyPolicy returns command as rewritten by the config's policy, showing
+// each change, or an error, already displayed, if the policy forbids
+// running it. Deny rules apply before and after rewriting.
+func applyPolicy(cfg *config.Config, command string) (string, error) {
+	p, err := policy.New(cfg.Policy)
+	if err != nil {
+		return "", fail("%w", err)
+	}
+	if err := p.Check(command); err != nil {
+		return "", fail("%w", err)
+	}
+	rewritten, notes := p.Rewrite(command)
+	if len(notes) == 0 {
+		return command, nil
+	}
+	if err := p.Check(rewritten); err != nil {
+		return "", fail("%w", err)
+	}
+	ui.DisplayRewrite(rewritten, notes)
+	return rewritten, nil
+}
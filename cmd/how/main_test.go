@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func TestCIOutput(t *testing.T) {
+	newRoot := func() (root, check, fav *cobra.Command) {
+		root = &cobra.Command{Use: "how"}
+		root.PersistentFlags().BoolP("quiet", "q", false, "")
+		root.Flags().BoolP("yes", "y", false, "")
+		root.Flags().StringP("output", "o", "text", "")
+		check = &cobra.Command{Use: "check"}
+		check.Flags().StringP("output", "o", "text", "")
+		fav = &cobra.Command{Use: "fav"}
+		root.AddCommand(check, fav)
+		return root, check, fav
+	}
+
+	cases := []struct {
+		name string
+		ci   string
+		cmd  string
+		args []string
+		want bool
+	}{
+		{"question", "GitHub Actions", "how", nil, true},
+		{"check", "GitHub Actions", "check", nil, true},
+		{"not in CI", "", "how", nil, false},
+		{"yes", "GitHub Actions", "how", []string{"--yes"}, false},
+		{"quiet", "GitHub Actions", "how", []string{"-q"}, false},
+		{"output", "GitHub Actions", "check", []string{"-o", "text"}, false},
+		{"other subcommand", "GitHub Actions", "fav", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, check, fav := newRoot()
+			cmd := map[string]*cobra.Command{"how": root, "check": check, "fav": fav}[tc.cmd]
+			if err := cmd.ParseFlags(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			defer func(old string) { ui.Unattended = old }(ui.Unattended)
+			ui.Unattended = tc.ci
+			if got := ciOutput(root, cmd); got != tc.want {
+				t.Errorf("ciOutput(%s %q) = %v, want %v", tc.cmd, tc.args, got, tc.want)
+			}
+		})
+	}
+}
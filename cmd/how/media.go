@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/prompt"
+)
+
+// mediaProbeBudget bounds probing the input files for `how media`.
+const mediaProbeBudget = 5 * time.Second
+
+func newMediaCmd() *cobra.Command {
+	var files []string
+
+	cmd := &cobra.Command{
+		Use:   "media <task> --file <input>",
+		Short: "Get ffmpeg and ImageMagick commands tailored to your input files",
+		Long: `Describe a conversion or edit. Each --file is probed with ffprobe or identify,
+and its container, codecs, resolution and frame rate are sent with the task.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, f := range files {
+				if _, err := os.Stat(f); err != nil {
+					return fail("%w", err)
+				}
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			task := strings.Join(args, " ")
+			facts := collect.GatherWithin(ctx, collect.MediaDetails(files), mediaProbeBudget)
+			return runMode(ctx, cfg, prompt.MediaPrompt(facts), task, "media: "+task)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&files, "file", "f", nil, "Input file to probe (repeatable)")
+	return cmd
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// runMode asks a subcommand's question with its own system prompt, then
+// shows and runs the suggestion like the root command. label is recorded
+// in memory as the question.
+func runMode(ctx context.Context, cfg *config.Config, sysPrompt, query, label string) error {
+	result, err := completeCommand(ctx, cfg, sysPrompt, query)
+	if err != nil {
+		return err
+	}
+
+	if flagQuiet {
+		ui.DisplayQuiet(result)
+		return nil
+	}
+	ui.Display(result)
+
+	store := openMemoryIfEnabled(cfg)
+	if store != nil {
+		defer store.Close() //nolint:errcheck
+	}
+	return execute(ctx, cfg, store, label, result)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/swibrow/how/internal/notify"
+)
+
+// maxNotifyCommand bounds how much of the command a notification shows.
+const maxNotifyCommand = 120
+
+// notifyFinished sends a desktop notification that command finished after
+// elapsed, with its exit status.
+func notifyFinished(command string, elapsed time.Duration, err error) {
+	title := "Command finished"
+	if code := exitCode(err); code != exitOK {
+		title = fmt.Sprintf("Command failed (exit %d)", code)
+	}
+	if targetHost != nil {
+		title += " on " + targetHost.Target
+	}
+	if len(command) > maxNotifyCommand {
+		command = command[:maxNotifyCommand-3] + "..."
+	}
+	body := fmt.Sprintf("%s\n%s", command, elapsed.Round(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notify.Send(ctx, "how: "+title, body); err != nil {
+		slog.Info("notification failed", "error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newOptimizeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "optimize [command]",
+		Short:   "Suggest a faster, safer or more idiomatic version of a command",
+		Example: `  how optimize "find . -name '*.log' | xargs rm"`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			original := strings.Join(args, " ")
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			store := openMemoryIfEnabled(cfg)
+			if store != nil {
+				defer store.Close() //nolint:errcheck
+			}
+
+			ctx := context.Background()
+			result, err := completeCommand(ctx, cfg, prompt.OptimizePrompt(), original)
+			if err != nil {
+				return err
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+
+			fmt.Println()
+			ui.DisplayDiff(original, result.Command)
+			ui.Display(result)
+			if result.Command == original {
+				return nil
+			}
+			return execute(ctx, cfg, store, "optimize: "+original, result)
+		},
+	}
+}
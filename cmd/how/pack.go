@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/pack"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// packTimeout bounds fetching one pack.
+const packTimeout = 2 * time.Minute
+
+func newPackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack",
+		Short: "Install and update prompt packs of profiles, examples and deny rules",
+		Long: `Prompt packs bundle profiles, few-shot examples and deny rules for a domain,
+such as Kubernetes, Postgres or ffmpeg, so they can be shared. A pack is a
+pack.yaml at the root of a git repository or gzipped tarball. Installed packs'
+profiles are used with --profile, and their deny rules always apply. Packs
+can't rewrite commands. Each pack is pinned to the commit or tarball checksum
+it was installed from.`,
+		Args: cobra.NoArgs,
+	}
+
+	var ref string
+	installCmd := &cobra.Command{
+		Use:   "install <git-url|tarball>",
+		Short: "Install a pack from a git repository or a .tar.gz",
+		Example: `  how pack install https://github.com/example/how-pack-k8s
+  how pack install https://github.com/example/how-pack-k8s --ref v1.2.0
+  how pack install https://example.com/packs/ffmpeg.tar.gz`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), packTimeout)
+			defer cancel()
+			p, err := pack.Fetch(ctx, args[0], ref)
+			if err != nil {
+				return fail("%w", err)
+			}
+			old, err := installedPack(p.Name)
+			if err != nil {
+				return fail("%w", err)
+			}
+			if err := config.SavePack(p); err != nil {
+				return fail("installing pack: %w", err)
+			}
+			if old != nil {
+				fmt.Printf("Replaced %s %s with %s%s.\n", p.Name, old.Version, p.Version, packPin(p))
+			} else {
+				fmt.Printf("Installed %s %s%s.\n", p.Name, p.Version, packPin(p))
+			}
+			describePack(p)
+			return nil
+		},
+	}
+	installCmd.Flags().StringVar(&ref, "ref", "", "Git branch or tag to install, such as a version tag (default the default branch)")
+
+	updateCmd := &cobra.Command{
+		Use:   "update [name...]",
+		Short: "Fetch installed packs again and install any new versions",
+		Long: `Fetch each installed pack, or the named ones, from where it was installed and
+replace it when its commit or tarball has changed, showing what changed.
+Packs installed at a --ref stay at that ref; install again with a new --ref
+to move them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			packs, err := config.Packs()
+			if err != nil {
+				return fail("%w", err)
+			}
+			if len(args) > 0 {
+				packs, err = selectPacks(packs, args)
+				if err != nil {
+					return fail("%w", err)
+				}
+			}
+			if len(packs) == 0 {
+				fmt.Println("No packs installed. Add one with how pack install.")
+				return nil
+			}
+
+			var failed []string
+			for _, old := range packs {
+				if err := updatePack(old); err != nil {
+					ui.DisplayError(fmt.Sprintf("updating %s: %v", old.Name, err))
+					failed = append(failed, old.Name)
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("couldn't update %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed packs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			packs, err := config.Packs()
+			if err != nil {
+				return fail("%w", err)
+			}
+			if len(packs) == 0 {
+				fmt.Println("No packs installed. Add one with how pack install.")
+				return nil
+			}
+			rows := make([][]string, len(packs))
+			for i, p := range packs {
+				source := p.Source
+				if p.Ref != "" {
+					source += " @ " + p.Ref
+				}
+				rows[i] = []string{p.Name, p.Version, strings.Join(packProfiles(p), ", "), source}
+			}
+			ui.DisplayTable([]string{"NAME", "VERSION", "PROFILES", "SOURCE"}, rows, false)
+			return nil
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Uninstall a pack",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.RemovePack(args[0]); err != nil {
+				return fail("%w", err)
+			}
+			fmt.Printf("Removed %s.\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.AddCommand(installCmd, updateCmd, listCmd, removeCmd)
+	return cmd
+}
+
+// updatePack fetches old from its source again and installs it if its
+// commit or tarball has changed, listing what changed.
+func updatePack(old *config.Pack) error {
+	if old.Source == "" {
+		return errors.New("it has no recorded source; install it again")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), packTimeout)
+	defer cancel()
+	p, err := pack.Fetch(ctx, old.Source, old.Ref)
+	if err != nil {
+		return err
+	}
+	if p.Name != old.Name {
+		return fmt.Errorf("%s now holds the pack %s; install it under its new name", old.Source, p.Name)
+	}
+	if p.Commit == old.Commit && p.Checksum == old.Checksum {
+		fmt.Printf("%s is up to date (%s%s).\n", p.Name, p.Version, packPin(p))
+		return nil
+	}
+	if err := config.SavePack(p); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s %s%s → %s%s.\n", p.Name, old.Version, packPin(old), p.Version, packPin(p))
+	for _, change := range packChanges(old, p) {
+		fmt.Printf("  %s\n", change)
+	}
+	return nil
+}
+
+// packPin describes the commit or tarball a pack was installed from, as
+// in " at 1a2b3c4d5e6f", or "" for packs installed before either was
+// recorded.
+func packPin(p *config.Pack) string {
+	pin := p.Commit
+	if pin == "" {
+		pin = p.Checksum
+	}
+	if pin == "" {
+		return ""
+	}
+	return " at " + pin[:min(12, len(pin))]
+}
+
+// packChanges lists what differs between two versions of a pack.
+func packChanges(old, p *config.Pack) []string {
+	var changes []string
+	for _, name := range packProfiles(p) {
+		before, ok := old.Profiles[name]
+		switch {
+		case !ok:
+			changes = append(changes, "Added profile "+name)
+		case !reflect.DeepEqual(before, p.Profiles[name]):
+			changes = append(changes, "Changed profile "+name)
+		}
+	}
+	for _, name := range packProfiles(old) {
+		if _, ok := p.Profiles[name]; !ok {
+			changes = append(changes, "Removed profile "+name)
+		}
+	}
+	for _, rule := range p.Policy.Deny {
+		if !slices.Contains(old.Policy.Deny, rule) {
+			changes = append(changes, "Added deny rule "+rule)
+		}
+	}
+	for _, rule := range old.Policy.Deny {
+		if !slices.Contains(p.Policy.Deny, rule) {
+			changes = append(changes, "Removed deny rule "+rule)
+		}
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "No changes to its profiles or deny rules")
+	}
+	return changes
+}
+
+// installedPack returns the installed pack called name, or nil.
+func installedPack(name string) (*config.Pack, error) {
+	packs, err := config.Packs()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func selectPacks(packs []*config.Pack, names []string) ([]*config.Pack, error) {
+	byName := map[string]*config.Pack{}
+	for _, p := range packs {
+		byName[p.Name] = p
+	}
+	var selected []*config.Pack
+	for _, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("no pack named %q is installed", name)
+		}
+		selected = append(selected, p)
+	}
+	return selected, nil
+}
+
+// describePack shows what an installed pack adds, and any of its profiles
+// hidden by a profile of the same name in the config file.
+func describePack(p *config.Pack) {
+	if p.Description != "" {
+		fmt.Printf("  %s\n", p.Description)
+	}
+	examples := 0
+	for _, profile := range p.Profiles {
+		examples += len(profile.Examples)
+	}
+	if names := packProfiles(p); len(names) > 0 {
+		fmt.Printf("  Profiles: %s, with %s; use one with --profile %s\n", strings.Join(names, ", "), count(examples, "example"), names[0])
+	}
+	if len(p.Policy.Deny) > 0 {
+		fmt.Printf("  Policy: %s, applied to every command\n", count(len(p.Policy.Deny), "deny rule"))
+	}
+	if local, err := config.LoadFile(); err == nil {
+		for _, name := range packProfiles(p) {
+			if _, ok := local.Profiles[name]; ok {
+				fmt.Printf("  Your config's own %s profile takes precedence over the pack's.\n", name)
+			}
+		}
+	}
+}
+
+func packProfiles(p *config.Pack) []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// count formats n things, as in "1 example" or "3 examples".
+func count(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
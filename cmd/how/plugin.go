@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/plugin"
+)
+
+// pluginCmd returns a command running the how-<name> plugin named by the
+// first argument that isn't a flag, or nil when it names a built-in
+// command or no plugin. Built-ins always win over plugins of the same name.
+func pluginCmd(root *cobra.Command, args []string) *cobra.Command {
+	if c, _, err := root.Find(args); err != nil || c != root {
+		return nil
+	}
+	name := firstArg(root, args)
+	if name == "" || name == "help" || name == "completion" {
+		return nil
+	}
+	path, ok := plugin.Find(name)
+	if !ok {
+		return nil
+	}
+	return &cobra.Command{
+		Use:                name,
+		Short:              "Plugin at " + path,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlugin(context.Background(), name, path, args)
+		},
+	}
+}
+
+// firstArg returns the first of args that isn't one of root's flags or a
+// flag's value, or "" if there is none.
+func firstArg(root *cobra.Command, args []string) string {
+	lookup := func(name string) *pflag.Flag {
+		if f := root.Flags().Lookup(name); f != nil {
+			return f
+		}
+		return root.PersistentFlags().Lookup(name)
+	}
+	shorthand := func(c string) *pflag.Flag {
+		if f := root.Flags().ShorthandLookup(c); f != nil {
+			return f
+		}
+		return root.PersistentFlags().ShorthandLookup(c)
+	}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		var f *pflag.Flag
+		switch {
+		case a == "--":
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		case !strings.HasPrefix(a, "-") || a == "-":
+			return a
+		case strings.Contains(a, "="):
+			continue
+		case strings.HasPrefix(a, "--"):
+			f = lookup(a[2:])
+		case len(a) == 2:
+			f = shorthand(a[1:])
+		}
+		// A flag that takes a value consumes the next argument
+		if f != nil && f.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return ""
+}
+
+// runPlugin runs the plugin name at path with args, passing the active
+// config and machine context on its stdin. The API key is only included
+// when the plugin's pass_credentials is set. The plugin's exit code
+// becomes how's.
+func runPlugin(ctx context.Context, name, path string, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	dir, _ := os.Getwd()
+	req := plugin.Request{
+		Version:  version,
+		Args:     args,
+		Profile:  cfg.ActiveProfile,
+		Provider: cfg.Provider,
+		Model:    cfg.Model(),
+		Shell:    userShell(),
+		Dir:      dir,
+		Context:  machineContext(ctx, cfg) + clockContext(),
+	}
+	if cfg.Plugins[name].PassCredentials {
+		req.APIKey = apiKey(cfg)
+	}
+	if cfg.Provider == "ollama" {
+		req.URL = cfg.Ollama.URL
+	}
+	cmd, err := plugin.Command(ctx, path, args, req)
+	if err != nil {
+		return fail("%w", err)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if cmd.ProcessState != nil {
+			return err
+		}
+		return fail("running plugin %s: %w", path, err)
+	}
+	return nil
+}
+
+// apiKey returns the API key for the active provider, if it has one.
+func apiKey(cfg *config.Config) string {
+	switch cfg.Provider {
+	case "anthropic":
+		return cfg.Anthropic.APIKey
+	case "openai":
+		return cfg.OpenAI.APIKey
+	}
+	return ""
+}
+
+func newPluginsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "plugins",
+		Short: "List how-<name> plugins found on PATH",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := plugin.List()
+			if len(plugins) == 0 {
+				fmt.Println("No plugins found. Add an executable named how-<name> to your PATH.")
+				return nil
+			}
+			for _, name := range plugin.Names(plugins) {
+				fmt.Printf("  %-16s %s\n", name, plugins[name])
+			}
+			return nil
+		},
+	}
+}
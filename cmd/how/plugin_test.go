@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFirstArg(t *testing.T) {
+	root := &cobra.Command{Use: "how"}
+	root.PersistentFlags().StringP("profile", "p", "", "")
+	root.Flags().BoolP("yes", "y", false, "")
+	root.Flags().String("model", "", "")
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"terraform", "plan"}, "terraform"},
+		{[]string{"-y", "terraform"}, "terraform"},
+		{[]string{"-p", "work", "terraform"}, "terraform"},
+		{[]string{"--profile", "work", "terraform"}, "terraform"},
+		{[]string{"--profile=work", "terraform"}, "terraform"},
+		{[]string{"--model", "gpt-4o", "--", "terraform"}, "terraform"},
+		{[]string{"-y"}, ""},
+		{nil, ""},
+	}
+	for _, tc := range cases {
+		if got := firstArg(root, tc.args); got != tc.want {
+			t.Errorf("firstArg(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/swibrow/how/internal/preview"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// previewEdit shows the diff an in-place sed, perl or gawk edit would
+// make, from a run against copies of the files, before it's confirmed.
+// Other commands show nothing.
+func previewEdit(ctx context.Context, command string) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	changes, ok, err := preview.InPlace(ctx, command, dir)
+	if !ok {
+		return
+	}
+	ui.DisplayEdits(changes, err)
+}
+
+// rehearse runs command against temporary copies of the files it touches
+// and shows what it did, for --rehearse.
+func rehearse(ctx context.Context, command string) error {
+	if !posixShell() {
+		return fail("--rehearse needs a POSIX shell (bash, zsh or sh)")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return fail("%w", err)
+	}
+	var r *preview.Rehearsal
+	if _, err := hooked(command, func() (int, error) {
+		var err error
+		r, err = preview.Rehearse(ctx, command, dir)
+		return exitCode(err), err
+	}); err != nil {
+		if stoppedByHook(err) {
+			return err
+		}
+		return fail("can't rehearse: %w", err)
+	}
+	ui.DisplayRehearsal(r)
+	return nil
+}
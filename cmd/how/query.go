@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/swibrow/how/internal/ui"
+	"golang.org/x/term"
+)
+
+// maxQueryBytes bounds a query read from a file or stdin.
+const maxQueryBytes = 1 << 20
+
+// queryTemplate is the text the editor opens with when composing a query.
+const queryTemplate = `
+
+# Describe what you want a command for; it can span several lines.
+# Lines starting with # are ignored, and an empty query cancels.
+`
+
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// stdoutIsPipe reports whether stdout is a pipe, as in how ... | pbcopy or
+// $(how ...), rather than a terminal or a file.
+func stdoutIsPipe() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeNamedPipe != 0
+}
+
+// canCompose reports whether a query can be composed interactively: both
+// stdin and stdout are a terminal and nobody is running how unattended.
+func canCompose() bool {
+	return ui.Unattended == "" && stdinIsTerminal() && stdoutIsTerminal()
+}
+
+// composeQuery lets the user write a longer query, in $VISUAL or $EDITOR
+// when one is set, or else typed inline over several lines.
+func composeQuery() (string, error) {
+	if os.Getenv("VISUAL") == "" && os.Getenv("EDITOR") == "" {
+		return ui.ReadLines("Describe what you want, then an empty line (or Ctrl-D) to send:")
+	}
+
+	f, err := os.CreateTemp("", "how-query-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	_, err = f.WriteString(queryTemplate)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := openEditor(f.Name()); err != nil {
+		return "", fmt.Errorf("running editor: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return stripComments(string(data)), nil
+}
+
+// stripComments drops lines starting with # and surrounding blank lines.
+func stripComments(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// readQuery reads a query verbatim from path, or from stdin when path is
+// "-", so it needs no shell quoting. Surrounding whitespace is trimmed.
+func readQuery(path string) (string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close() //nolint:errcheck
+		in = f
+	}
+	data, err := io.ReadAll(io.LimitReader(in, maxQueryBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxQueryBytes {
+		return "", fmt.Errorf("query is over %d KiB", maxQueryBytes>>10)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/prompt"
+)
+
+// runRaw streams a free-form answer to question, without parsing it for a
+// command.
+func runRaw(ctx context.Context, cfg *config.Config, question string) error {
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return withCode(exitProvider, fail("initializing provider: %w", err))
+	}
+	if len(queryImages) > 0 {
+		provider = llm.WithImages(provider, queryImages)
+	}
+
+	sysPrompt := prompt.RawPrompt() + machineContext(ctx, cfg) + clockContext()
+	previewCost(cfg, sysPrompt, question)
+	if !flagQuiet {
+		fmt.Println()
+	}
+	err = llm.Stream(ctx, provider, sysPrompt, question, func(text string) {
+		fmt.Print(text)
+	})
+	fmt.Println()
+	if err != nil {
+		return withCode(exitProvider, fail("LLM request failed: %w", err))
+	}
+	return nil
+}
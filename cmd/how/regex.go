@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/regex"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newRegexCmd() *cobra.Command {
+	var (
+		dialect string
+		sample  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "regex [description]",
+		Short: "Generate a regular expression and test it against sample input",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dialect = strings.ToLower(dialect)
+			if !regex.ValidDialect(dialect) {
+				return fail("unknown dialect %q (expected one of: %s)", dialect, strings.Join(regex.Dialects, ", "))
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			result, err := complete(context.Background(), cfg, prompt.RegexPrompt(dialect), strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+			ui.Display(result)
+
+			if sample == "" {
+				return nil
+			}
+
+			re, err := regex.Compile(result.Command, dialect)
+			if err != nil {
+				ui.DisplayError(err.Error())
+				return err
+			}
+			ui.DisplayMatches(regex.FindMatches(re, readSample(sample)))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&dialect, "dialect", "d", "pcre", "Regex dialect: pcre, posix or go")
+	cmd.Flags().StringVarP(&sample, "test", "t", "", "File or literal string to test the regex against")
+	return cmd
+}
+
+// readSample returns the contents of path if it names a readable file,
+// otherwise the argument itself is treated as the sample text.
+func readSample(arg string) string {
+	if data, err := os.ReadFile(arg); err == nil {
+		return string(data)
+	}
+	return arg
+}
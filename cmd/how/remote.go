@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/remote"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+	"golang.org/x/term"
+)
+
+// targetHost is the --host that suggestions are made for and run on, or nil
+// to run locally.
+var targetHost *remote.Host
+
+// connectHost connects to target and detects its OS.
+func connectHost(ctx context.Context, target string) (*remote.Host, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	h := remote.New(target, filepath.Join(dir, "ssh"))
+	if err := h.Detect(ctx); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// userShell is the shell local commands are written for and run in: the
+// shell setting, or else $SHELL.
+func userShell() string {
+	if cfg, err := cachedConfig(); err == nil && cfg.Shell != "" {
+		return shell.Normalize(cfg.Shell)
+	}
+	return shell.Normalize(filepath.Base(os.Getenv("SHELL")))
+}
+
+// posixShell reports whether local commands run with sh, rather than in a
+// shell with its own syntax such as Nushell or PowerShell.
+func posixShell() bool {
+	return shell.Command(userShell(), "") == nil
+}
+
+// checkCommand is shell.Check, run against targetHost when set. Commands
+// for shells that aren't POSIX only get that shell's syntax check, where
+// it has one.
+func checkCommand(ctx context.Context, command string) error {
+	if targetHost != nil {
+		return targetHost.Check(ctx, command)
+	}
+	if name := userShell(); !posixShell() {
+		if err := shell.Validate(name, command); err != nil && !errors.Is(err, shell.ErrNoValidator) {
+			return err
+		}
+		return nil
+	}
+	return withoutAliases(ctx, shell.Check(command))
+}
+
+// installedAlternatives returns the names that are installed locally, or on
+// targetHost when set.
+func installedAlternatives(ctx context.Context, names []string) []string {
+	if targetHost == nil || len(names) == 0 {
+		return shell.Installed(names)
+	}
+	missing, err := targetHost.Missing(ctx, names)
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(names), func(n string) bool { return slices.Contains(missing, n) })
+}
+
+// runCommand runs command locally, in the user's shell if that isn't POSIX
+// or the command uses their aliases, and a stage at a time if it chains
+// stages with &&, or on targetHost when set, notifying when it finishes
+// with --notify. Configured pre hooks may stop it; post hooks always run.
+func runCommand(command string) error {
+	_, err := hooked(command, func() (int, error) {
+		start := time.Now()
+		var err error
+		argv := shell.Command(userShell(), command)
+		switch {
+		case targetHost != nil:
+			tty := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			err = ui.RunRemote(targetHost.Command(command, tty), command, targetHost.InstallHint)
+		case argv != nil:
+			err = ui.RunWith(argv, command)
+		case len(shell.UsedAliases(command, userAliases(context.Background()))) > 0:
+			// Aliases only expand in the shell that defines them
+			err = ui.RunInteractive(os.Getenv("SHELL"), command)
+		default:
+			if stages := shell.Stages(command); len(stages) > 1 {
+				err = runStages(command, stages)
+			} else {
+				err = ui.RunCommand(command)
+			}
+		}
+		if flagNotify {
+			notifyFinished(command, time.Since(start), err)
+		}
+		return exitCode(err), err
+	})
+	return err
+}
+
+// confirmAndRun is ui.ConfirmAndRun for runCommand, naming the host when the
+// command runs remotely.
+func confirmAndRun(command string) (bool, error) {
+	question := "Run this command?"
+	if targetHost != nil {
+		question = fmt.Sprintf("Run this command on %s?", targetHost.Target)
+	}
+	confirmed, err := ui.Confirm(question)
+	if !confirmed || err != nil {
+		return false, err
+	}
+	return true, runCommand(command)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+	"golang.org/x/term"
+)
+
+func newReplCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repl",
+		Short: "Ask questions in a loop, running accepted commands in one shell",
+		Long: `Start an interactive loop where each line is a question.
+
+Accepted commands run in a single persistent shell, so the working directory
+and exported variables carry over between steps. Type "exit" or press Ctrl-D
+to quit.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			provider, err := newProvider(cfg)
+			if err != nil {
+				return fail("initializing provider: %w", err)
+			}
+
+			store := openMemoryIfEnabled(cfg)
+			if store != nil {
+				defer store.Close() //nolint:errcheck
+			}
+
+			session, err := shell.NewSession()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer session.Close() //nolint:errcheck
+			if term.IsTerminal(int(os.Stdin.Fd())) {
+				session.CommandStdin = "/dev/tty"
+			}
+
+			ctx := context.Background()
+			basePrompt := systemPrompt(cfg) + machineContext(ctx, cfg)
+			scanner := bufio.NewScanner(os.Stdin)
+
+			for {
+				fmt.Print("how> ")
+				if !scanner.Scan() {
+					fmt.Println()
+					return scanner.Err()
+				}
+
+				question := strings.TrimSpace(scanner.Text())
+				switch question {
+				case "":
+					continue
+				case "exit", "quit":
+					return nil
+				}
+
+				sysPrompt := basePrompt + clockContext()
+				if store != nil {
+					if past, err := store.Search(ctx, question, 10); err == nil && len(past) > 0 {
+						sysPrompt += prompt.FormatMemoryContext(past)
+					}
+					sysPrompt += feedbackContext(ctx, cfg, provider, store, question)
+				}
+
+				result, err := suggest(ctx, provider, sysPrompt, question)
+				if err != nil {
+					displayAskError(err)
+					continue
+				}
+				ui.Display(result)
+
+				if result.Command, err = applyPolicy(cfg, result.Command); err != nil {
+					continue
+				}
+				assessment := risk.Classify(result.Command)
+				ui.DisplayRisk(assessment)
+				if needsConfirmation(cfg, assessment) {
+					confirmed, err := ui.Confirm("Run this command?")
+					if err != nil {
+						return fail("%w", err)
+					}
+					if !confirmed {
+						continue
+					}
+				}
+
+				fmt.Println()
+				code, err := hooked(result.Command, func() (int, error) { return session.Run(result.Command) })
+				if stoppedByHook(err) {
+					continue
+				}
+				if err != nil {
+					return fail("%w", err)
+				}
+				if code != 0 {
+					ui.DisplayError(fmt.Sprintf("command exited with status %d", code))
+				}
+				askFeedback(ctx, cfg, store, question, result.Command)
+				if store != nil {
+					_ = store.Record(ctx, question, result.Command, code, "")
+					if code == 0 {
+						_ = store.Save(ctx, question, result.Command, result.Explanation)
+					}
+				}
+				fmt.Println()
+			}
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/script"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newScriptCmd() *cobra.Command {
+	var posix bool
+	var saveAs string
+
+	cmd := &cobra.Command{
+		Use:   "script <description>",
+		Short: "Generate a shell script, check it with shellcheck and offer to save it",
+		Long: `Describe a task and get a script for it. Before it's shown, the script is
+formatted as shfmt would, checked with shellcheck when it's installed, and
+given a header recording the query, model and how version it came from.
+You're then asked whether to save it (--yes saves without asking).`,
+		Example: `  how script rotate the logs in /var/log/myapp, keeping a week of them
+  how script --posix back up ~/notes to a dated tarball
+  how script -q check every host in hosts.txt answers on port 22 > check.sh`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			provider, err := newProvider(cfg)
+			if err != nil {
+				return withCode(exitProvider, fail("initializing provider: %w", err))
+			}
+
+			query := strings.Join(args, " ")
+			response, err := provider.Complete(context.Background(), prompt.ScriptPrompt(posix), query)
+			if err != nil {
+				return withCode(exitProvider, fail("LLM request failed: %w", err))
+			}
+			s, err := script.Parse(response)
+			if err != nil {
+				return withCode(exitNoParse, fail("%w", err))
+			}
+			if saveAs != "" {
+				s.Name = saveAs
+			}
+
+			content, parseErr := script.Sanitize(s.Content, script.Provenance{
+				Query:   query,
+				Version: version,
+				Model:   cfg.Provider + " " + cfg.Model(),
+				Time:    time.Now(),
+			})
+			if flagQuiet {
+				fmt.Print(content)
+				return nil
+			}
+
+			fmt.Println()
+			ui.DisplayFile(s.Name, content)
+			if s.Explanation != "" {
+				fmt.Printf("  %s\n\n", s.Explanation)
+			}
+			if parseErr != nil {
+				ui.DisplayReasoning("The script doesn't parse, so it wasn't formatted: " + parseErr.Error())
+			}
+			displayFindings(content)
+
+			question := "Save to " + s.Name + "?"
+			if _, err := os.Stat(s.Name); err == nil {
+				question = "Overwrite " + s.Name + "?"
+			}
+			if ok, err := confirmStep(question); !ok || err != nil {
+				return declined(err)
+			}
+			if err := os.WriteFile(s.Name, []byte(content), 0o755); err != nil {
+				return fail("saving script: %w", err)
+			}
+			fmt.Printf("Saved %s.\n", s.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&posix, "posix", false, "Write a POSIX sh script instead of a bash one")
+	cmd.Flags().StringVar(&saveAs, "save-as", "", "File to save the script to (default the name the model suggests)")
+	return cmd
+}
+
+// displayFindings shows what shellcheck reports for content, or that it
+// couldn't be run.
+func displayFindings(content string) {
+	findings, err := script.Lint(content)
+	switch {
+	case errors.Is(err, script.ErrNoShellcheck):
+		ui.DisplayReasoning("shellcheck isn't installed, so the script wasn't checked.")
+	case err != nil:
+		ui.DisplayReasoning(err.Error())
+	case len(findings) == 0:
+		fmt.Println("  shellcheck found no problems.")
+		fmt.Println()
+	default:
+		lines := make([]string, len(findings))
+		for i, f := range findings {
+			lines[i] = f.String()
+		}
+		ui.DisplayReasoning("shellcheck:\n  " + strings.Join(lines, "\n  "))
+	}
+}
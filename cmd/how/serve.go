@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/server"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// serving is set by how serve, whose suggestions run on its clients'
+// machines, so nothing about this one should shape them.
+var serving bool
+
+func newServeCmd() *cobra.Command {
+	var (
+		listen     string
+		grpcListen string
+		noAuth     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve suggestions over an HTTP API",
+		Long: `Serve suggestions over HTTP so other tools can reuse the same prompt and
+provider configuration.
+
+  POST /v1/suggest  {"query": "..."}  ->  {"command": "...", "explanation": "..."}
+  GET  /            web playground for asking from a browser
+  GET  /healthz
+  GET  /metrics     Prometheus metrics
+  POST /slack/command  Slack slash command, when server.slack is configured
+
+Requests must send "Authorization: Bearer <token>", where the token comes from
+server.token in the config or HOW_SERVER_TOKEN; /healthz and the playground
+page need none, and /metrics needs server.metrics_token if it is set.
+Each entry in server.tenants adds a token answered with its own profile's
+provider and key, and rate_limit caps a token's queries a minute; tenants
+require server.metrics_token, since the metrics are labelled by tenant. Slack
+commands are verified with server.slack.signing_secret or
+HOW_SLACK_SIGNING_SECRET instead of a token.
+
+With --grpc-listen, suggest, explain and fix are also served over gRPC, as
+described by proto/how/v1/how.proto, with the token in "authorization" metadata.
+The gRPC server is only in builds made with -tags grpc.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if listen == "" {
+				listen = cfg.Server.Listen
+			}
+			if grpcListen == "" {
+				grpcListen = cfg.Server.GRPCListen
+			}
+			if noAuth && len(cfg.Server.Tenants) > 0 {
+				return fail("--no-auth can't be used with server.tenants")
+			}
+			if cfg.Server.Token == "" && len(cfg.Server.Tenants) == 0 && !noAuth {
+				return fail("no server token configured (set HOW_SERVER_TOKEN, server.token or server.tenants, or pass --no-auth)")
+			}
+			if len(cfg.Server.Tenants) > 0 && cfg.Server.MetricsToken == "" {
+				return fail("server.tenants needs server.metrics_token, since /metrics is labelled by tenant")
+			}
+
+			serving = true
+			tenants, err := serverTenants(cfg)
+			if err != nil {
+				return fail("%w", err)
+			}
+			srv := server.NewMultiTenant(tenants)
+			if cfg.Server.MetricsToken != "" {
+				srv.RequireMetricsToken(cfg.Server.MetricsToken)
+			}
+			if slack := cfg.Server.Slack; slack.SigningSecret != "" {
+				tenant := slack.Tenant
+				if tenant == "" {
+					tenant = "default"
+				}
+				if err := srv.EnableSlack(slack.SigningSecret, tenant); err != nil {
+					return fail("%w", err)
+				}
+			}
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fail("listening on %s: %w", listen, err)
+			}
+
+			httpServer := &http.Server{
+				Handler:           srv.Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			grpcErr := make(chan error, 1)
+			if grpcListen != "" {
+				if !grpcBuilt {
+					return fail("this build of how has no gRPC server; build it with -tags grpc to use --grpc-listen or server.grpc_listen")
+				}
+				grpcLn, err := net.Listen("tcp", grpcListen)
+				if err != nil {
+					return fail("listening on %s: %w", grpcListen, err)
+				}
+				go func() {
+					grpcErr <- serveGRPC(ctx, srv, grpcLn)
+					stop()
+				}()
+				slog.Info("serving gRPC", "addr", grpcLn.Addr().String())
+				ui.DisplayNotice("Serving gRPC on " + grpcLn.Addr().String())
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = httpServer.Shutdown(shutdownCtx)
+			}()
+
+			slog.Info("serving HTTP", "addr", ln.Addr().String())
+			ui.DisplayNotice("Listening on http://" + ln.Addr().String())
+			if err := httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				return fail("serving: %w", err)
+			}
+			select {
+			case err := <-grpcErr:
+				if err != nil {
+					return fail("serving gRPC: %w", err)
+				}
+			default:
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "", "Address to listen on (default from config, 127.0.0.1:8080)")
+	cmd.Flags().StringVar(&grpcListen, "grpc-listen", "", "Also serve the gRPC API in proto/how/v1 on this address")
+	cmd.Flags().BoolVar(&noAuth, "no-auth", false, "Allow requests without a bearer token")
+	return cmd
+}
+
+// serverTenants returns a tenant for server.token, if set, and one for
+// each of server.tenants, answering with the provider of its profile.
+func serverTenants(cfg *config.Config) ([]server.Tenant, error) {
+	var tenants []server.Tenant
+	explainPrompt := prompt.ExplainPrompt()
+	fixPrompt := prompt.FixPrompt()
+	add := func(name, token string, rateLimit int, tcfg *config.Config) error {
+		for _, t := range tenants {
+			if token != "" && t.Token == token {
+				return fmt.Errorf("tenants %s and %s share a token", t.Name, name)
+			}
+		}
+		provider, err := newBudgetedProvider(tcfg)
+		if err != nil {
+			return fmt.Errorf("initializing provider for tenant %s: %w", name, err)
+		}
+		warmProvider(tcfg)
+		sysPrompt := systemPrompt(tcfg)
+		tenants = append(tenants, server.Tenant{
+			Name:      name,
+			Token:     token,
+			RateLimit: rateLimit,
+			Suggest: func(ctx context.Context, query string) (ui.Result, error) {
+				return suggest(ctx, provider, sysPrompt, query)
+			},
+			Explain: func(ctx context.Context, command string) (ui.Result, error) {
+				return ask(ctx, provider, explainPrompt, command)
+			},
+			Fix: func(ctx context.Context, command, errOutput string) (ui.Result, error) {
+				return suggest(ctx, provider, fixPrompt, prompt.FixQuery(command, errOutput))
+			},
+		})
+		return nil
+	}
+
+	if cfg.Server.Token != "" || len(cfg.Server.Tenants) == 0 {
+		if err := add("default", cfg.Server.Token, cfg.Server.RateLimit, cfg); err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, 0, len(cfg.Server.Tenants))
+	for name := range cfg.Server.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := cfg.Server.Tenants[name]
+		if t.Token == "" {
+			return nil, fmt.Errorf("tenant %s has no token", name)
+		}
+		// Loaded afresh, so nothing from the server's own profile, such as
+		// its key, prompt additions or budget, carries over to the tenant
+		tcfg, err := config.LoadProfile(t.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		if err := add(name, t.Token, t.RateLimit, tcfg); err != nil {
+			return nil, err
+		}
+	}
+	return tenants, nil
+}
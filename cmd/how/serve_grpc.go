@@ -0,0 +1,23 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"net"
+
+	"github.com/swibrow/how/internal/server"
+)
+
+// grpcBuilt reports whether this build can serve gRPC.
+const grpcBuilt = true
+
+// serveGRPC serves srv's gRPC API on ln until ctx is done.
+func serveGRPC(ctx context.Context, srv *server.Server, ln net.Listener) error {
+	g := srv.GRPC()
+	go func() {
+		<-ctx.Done()
+		g.GracefulStop()
+	}()
+	return g.Serve(ln)
+}
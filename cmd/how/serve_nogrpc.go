@@ -0,0 +1,19 @@
+//go:build !grpc
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/swibrow/how/internal/server"
+)
+
+// grpcBuilt reports whether this build can serve gRPC. The gRPC server
+// and its dependencies are left out unless built with -tags grpc.
+const grpcBuilt = false
+
+func serveGRPC(context.Context, *server.Server, net.Listener) error {
+	return errors.New("built without gRPC")
+}
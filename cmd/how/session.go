@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/runbook"
+)
+
+func newSessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Export the commands run in this shell session",
+		Long: `A session is the commands run through how from one shell: the shell how was
+started from, or everything run with the same $HOW_SESSION, so setting
+HOW_SESSION=incident-4521 in each terminal of an incident groups them.
+Commands are recorded while memory is enabled.`,
+		Args: cobra.NoArgs,
+	}
+
+	var (
+		format, session, title, out string
+		since                       time.Duration
+		all                         bool
+	)
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the session's questions, commands, outputs and exit codes as a runbook",
+		Example: `  how session export > runbook.md
+  how session export --since 2h --title "Incident 4521: API latency"
+  HOW_SESSION=incident-4521 how session export --out postmortem.md`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "markdown" {
+				return fail("unknown format %q (expected markdown)", format)
+			}
+			if all && session != "" {
+				return fail("--all and --session can't be combined")
+			}
+			if !all && session == "" {
+				session = memory.Session()
+			}
+			entries, err := sessionEntries(session, since)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fail("no commands were run through how in this session; they're recorded while memory is enabled")
+			}
+			if title == "" {
+				title = "Runbook"
+			}
+			doc := runbook.Markdown(title, entries, time.Local)
+
+			if out == "" {
+				fmt.Print(doc)
+				return nil
+			}
+			if err := os.WriteFile(out, []byte(doc), 0o644); err != nil {
+				return fail("%w", err)
+			}
+			fmt.Printf("Wrote %s to %s.\n", count(len(entries), "command"), out)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", "markdown", "Output format: markdown")
+	exportCmd.Flags().StringVar(&session, "session", "", "Session to export (default this shell's, or $HOW_SESSION)")
+	exportCmd.Flags().BoolVar(&all, "all", false, "Export commands from every session")
+	exportCmd.Flags().DurationVar(&since, "since", 0, "Only export commands run within this long, e.g. 2h")
+	exportCmd.Flags().StringVar(&title, "title", "", `Heading for the runbook (default "Runbook")`)
+	exportCmd.Flags().StringVar(&out, "out", "", "Write the runbook to a file instead of stdout")
+
+	cmd.AddCommand(exportCmd)
+	return cmd
+}
+
+// sessionEntries returns the commands run in session, or every session
+// when it's empty, within since, oldest first.
+func sessionEntries(session string, since time.Duration) ([]memory.HistoryEntry, error) {
+	store, err := openMemoryStore()
+	if err != nil {
+		return nil, fail("%w", err)
+	}
+	defer store.Close() //nolint:errcheck
+
+	var from time.Time
+	if since > 0 {
+		from = time.Now().Add(-since)
+	}
+	entries, err := store.SessionHistory(context.Background(), session, from)
+	if err != nil {
+		return nil, fail("%w", err)
+	}
+	return entries, nil
+}
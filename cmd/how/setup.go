@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/ui"
+	"golang.org/x/term"
+)
+
+func newSetupCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "setup",
+		Short: "Interactively choose a provider, API key and model",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := runSetupWizard()
+			return err
+		},
+	}
+}
+
+// needsSetup reports whether to offer the setup wizard: there is no config
+// file yet, the default provider can't be initialized (typically a missing
+// API key), and we can ask the user interactively.
+func needsSetup(cfg *config.Config) bool {
+	if config.Exists() || ui.Unattended != "" || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	_, err := llm.NewProvider(cfg)
+	return err != nil
+}
+
+// runSetupWizard walks the user through picking a provider, entering an API
+// key and model, sends a test request, and saves the result.
+func runSetupWizard() (*config.Config, error) {
+	cfg, err := config.LoadFile()
+	if err != nil {
+		return nil, fail("loading config: %w", err)
+	}
+	in := bufio.NewReader(os.Stdin)
+
+	fmt.Println()
+	fmt.Println("  Welcome to how! Let's set up a provider.")
+	fmt.Println()
+	fmt.Println("    1) anthropic")
+	fmt.Println("    2) openai")
+	fmt.Println("    3) ollama (local, no API key)")
+	fmt.Println()
+
+	providers := map[string]string{"1": "anthropic", "2": "openai", "3": "ollama"}
+	for {
+		choice := askLine(in, "Provider", "1")
+		if p, ok := providers[choice]; ok {
+			cfg.Provider = p
+			break
+		}
+		if _, ok := providerModel(cfg, choice); ok {
+			cfg.Provider = choice
+			break
+		}
+		fmt.Println("  Please enter 1, 2 or 3.")
+	}
+
+	switch cfg.Provider {
+	case "anthropic":
+		if key, err := readSecret("Anthropic API key (leave empty to use $ANTHROPIC_API_KEY)"); err != nil {
+			return nil, fail("%w", err)
+		} else if key != "" {
+			cfg.Anthropic.APIKey = key
+		}
+	case "openai":
+		if key, err := readSecret("OpenAI API key (leave empty to use $OPENAI_API_KEY)"); err != nil {
+			return nil, fail("%w", err)
+		} else if key != "" {
+			cfg.OpenAI.APIKey = key
+		}
+	case "ollama":
+		cfg.Ollama.URL = askLine(in, "Ollama URL", cfg.Ollama.URL)
+	}
+
+	model, _ := providerModel(cfg, cfg.Provider)
+	*model = askLine(in, "Model", *model)
+
+	// Test with env overrides applied, but save only what the user entered
+	testCfg := *cfg
+	if testCfg.Anthropic.APIKey == "" {
+		testCfg.Anthropic.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if testCfg.OpenAI.APIKey == "" {
+		testCfg.OpenAI.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	fmt.Print("\n  Sending a test request... ")
+	if err := testProvider(&testCfg); err != nil {
+		fmt.Println("failed.")
+		return nil, fail("test request failed: %w (nothing was saved; run 'how setup' to try again)", err)
+	}
+	fmt.Println("ok.")
+
+	if err := config.Save(cfg); err != nil {
+		return nil, fail("saving config: %w", err)
+	}
+	path, _ := config.Path()
+	fmt.Printf("  Saved to %s\n\n", path)
+
+	return config.LoadProfile(flagProfile)
+}
+
+// providerModel returns a pointer to the model setting for the named provider.
+func providerModel(cfg *config.Config, provider string) (*string, bool) {
+	switch provider {
+	case "anthropic":
+		return &cfg.Anthropic.Model, true
+	case "openai":
+		return &cfg.OpenAI.Model, true
+	case "ollama":
+		return &cfg.Ollama.Model, true
+	default:
+		return nil, false
+	}
+}
+
+func testProvider(cfg *config.Config) error {
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err = provider.Complete(ctx, "Reply with the single word OK.", "ping")
+	return err
+}
+
+// askLine asks for a line of input, returning def if the user enters nothing.
+func askLine(in *bufio.Reader, label, def string) string {
+	if def != "" {
+		fmt.Printf("  %s [%s]: ", label, def)
+	} else {
+		fmt.Printf("  %s: ", label)
+	}
+	line, _ := in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// readSecret reads a line without echoing it to the terminal.
+func readSecret(label string) (string, error) {
+	fmt.Printf("  %s: ", label)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("reading input: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/redact"
+	"github.com/swibrow/how/internal/runbook"
+	"github.com/swibrow/how/internal/share"
+)
+
+func newShareCmd() *cobra.Command {
+	var (
+		title           string
+		since           time.Duration
+		session, dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "Upload the last command, or this session's, to a gist or paste service",
+		Long: `Share the last command run through how, with its question, output and exit
+code, or with --session the whole session's transcript, and print the URL.
+
+Before anything is uploaded, API keys, tokens, passwords and private keys
+are masked, including the keys in your config; --dry-run shows exactly
+what would be sent. Uploads go to a secret GitHub gist, using share.token,
+$GITHUB_TOKEN, $GH_TOKEN or the gh CLI's login, unless share.service is
+paste, which posts to share.url.`,
+		Example: `  how share
+  how share --session --since 1h --title "Fixing the build"
+  how share --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if since > 0 && !session {
+				return fail("--since only applies with --session")
+			}
+
+			entries, err := shareEntries(session, since)
+			if err != nil {
+				return err
+			}
+			if title == "" {
+				title = "Runbook"
+			}
+			doc, masked := redact.Redact(runbook.Markdown(title, entries, time.Local), configSecrets(cfg)...)
+
+			if dryRun {
+				fmt.Print(doc)
+				return nil
+			}
+			if masked > 0 {
+				fmt.Printf("Masked %s.\n", count(masked, "secret"))
+			}
+
+			target := "a secret gist"
+			switch {
+			case cfg.Share.Service == "paste":
+				if cfg.Share.URL == "" {
+					return fail("share.service is paste but share.url isn't set")
+				}
+				target = cfg.Share.URL
+			case cfg.Share.Public:
+				target = "a public gist"
+			}
+			if ok, err := confirmStep(fmt.Sprintf("Upload %s to %s?", count(len(entries), "command"), target)); !ok || err != nil {
+				return declined(err)
+			}
+
+			url, err := upload(cfg.Share, title, doc)
+			if err != nil {
+				return fail("%w", err)
+			}
+			fmt.Println(url)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&session, "session", false, "Share this shell's session, or $HOW_SESSION's, instead of the last command")
+	cmd.Flags().DurationVar(&since, "since", 0, "With --session, only share commands run within this long, e.g. 2h")
+	cmd.Flags().StringVar(&title, "title", "", `Heading for the transcript (default "Runbook")`)
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print what would be uploaded, after masking, without uploading it")
+	return cmd
+}
+
+// shareEntries returns the last command run through how, or with session
+// the commands of the current session run within since.
+func shareEntries(session bool, since time.Duration) ([]memory.HistoryEntry, error) {
+	if session {
+		entries, err := sessionEntries(memory.Session(), since)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return nil, fail("no commands were run through how in this session; they're recorded while memory is enabled")
+		}
+		return entries, nil
+	}
+
+	store, err := openMemoryStore()
+	if err != nil {
+		return nil, fail("%w", err)
+	}
+	defer store.Close() //nolint:errcheck
+	last, err := store.Last(context.Background())
+	if err != nil {
+		return nil, fail("reading history: %w", err)
+	}
+	if last == nil {
+		return nil, fail("no commands have been run through how yet")
+	}
+	return []memory.HistoryEntry{*last}, nil
+}
+
+// upload sends doc to the configured service and returns its URL.
+func upload(cfg config.ShareConfig, title, doc string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := share.NewClient()
+	if cfg.Service == "paste" {
+		return client.Paste(ctx, cfg.URL, cfg.Token, doc)
+	}
+	if cfg.URL != "" {
+		client.GistAPI = cfg.URL
+	}
+	return client.Gist(ctx, gistToken(cfg.Token), "how.md", "how: "+title, doc, cfg.Public)
+}
+
+// gistToken returns the first of token, $GITHUB_TOKEN, $GH_TOKEN and the gh
+// CLI's token that's set.
+func gistToken(token string) string {
+	for _, t := range []string{token, os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN")} {
+		if t != "" {
+			return t
+		}
+	}
+	out, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// configSecrets returns the credentials in cfg, which are masked wherever
+// they appear in shared text.
+func configSecrets(cfg *config.Config) []string {
+	secrets := []string{
+		cfg.Anthropic.APIKey,
+		cfg.OpenAI.APIKey,
+		cfg.Server.Token,
+		cfg.Server.MetricsToken,
+		cfg.Server.Slack.SigningSecret,
+		cfg.Share.Token,
+		os.Getenv("GITHUB_TOKEN"),
+		os.Getenv("GH_TOKEN"),
+	}
+	for _, t := range cfg.Server.Tenants {
+		secrets = append(secrets, t.Token)
+	}
+	return secrets
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/sqldb"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// sqlTimeout bounds introspecting the schema and running the preview.
+const sqlTimeout = 30 * time.Second
+
+func newSQLCmd() *cobra.Command {
+	var (
+		dsn   string
+		run   bool
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sql --db <url> <question>",
+		Short: "Write a SQL query from the database's schema, and preview its results",
+		Long: `Ask a question about a database. Its table and column names (never its data)
+are sent with the question. The query can then be run in a read-only
+transaction, always rolled back, to preview the first rows.
+
+Supported URLs: postgres://, mysql:// and sqlite:// (or a path to a SQLite
+file). --db defaults to $DATABASE_URL.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dsn == "" {
+				return fail("no database given (pass --db or set DATABASE_URL)")
+			}
+			db, err := sqldb.Open(dsn)
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer db.Close() //nolint:errcheck
+
+			ctx, cancel := context.WithTimeout(context.Background(), sqlTimeout)
+			defer cancel()
+			tables, err := db.Schema(ctx)
+			if err != nil {
+				return fail("%s: %w", sqldb.Redact(dsn), err)
+			}
+			if len(tables) == 0 {
+				return fail("no tables found in %s", sqldb.Redact(dsn))
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			result, err := complete(ctx, cfg, prompt.SQLPrompt(db.Dialect, sqldb.FormatSchema(tables)), strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+			ui.Display(result)
+
+			if !run && !flagYes {
+				confirmed, err := ui.Confirm("Run this query read-only?")
+				if err != nil {
+					return fail("%w", err)
+				}
+				if !confirmed {
+					return errDeclined
+				}
+			}
+			res, err := db.QueryReadOnly(ctx, result.Command, limit)
+			if err != nil {
+				return fail("query failed: %w", err)
+			}
+			fmt.Println()
+			ui.DisplayTable(res.Columns, res.Rows, res.More)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dsn, "db", os.Getenv("DATABASE_URL"), "Database URL (postgres://, mysql://, sqlite:// or a SQLite file)")
+	cmd.Flags().BoolVar(&run, "run", false, "Run the query read-only without asking")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Rows to show in the preview")
+	return cmd
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+// runStages runs command, a chain of stages joined with &&, so that a
+// failure names its stage and, once the model suggests a fix for it, the
+// chain resumes from there rather than from the start.
+func runStages(command string, stages []string) error {
+	state, err := os.CreateTemp("", "how-stages-*")
+	if err != nil {
+		return ui.RunCommand(command)
+	}
+	statePath := state.Name()
+	_ = state.Close()
+	defer os.Remove(statePath) //nolint:errcheck
+
+	// first is the number of stages[0] in the original chain, which
+	// changes as the chain resumes in dir
+	first, total, dir := 1, len(stages), ""
+	for resumed := false; ; resumed = true {
+		_ = os.Truncate(statePath, 0)
+		chain := strings.Join(stages, " && ")
+		var stderr string
+		run := func() (int, error) {
+			var err error
+			stderr, err = ui.RunStaged(shell.StagedScript(stages, dir, statePath), chain)
+			return exitCode(err), err
+		}
+		// The first run is already inside runCommand's hooks; a resumed
+		// chain starts with a new command, so it's hooked on its own.
+		var err error
+		if resumed {
+			_, err = hooked(chain, run)
+		} else {
+			_, err = run()
+		}
+		if err == nil || stoppedByHook(err) {
+			return err
+		}
+		n, at, readErr := shell.ReadStageFailure(statePath)
+		if readErr != nil || n == 0 {
+			return err
+		}
+		ui.DisplayStageFailure(first+n-1, total, stages[n-1], exitCode(err))
+		fix, ok := stageFix(stages, n, first+n-1, exitCode(err), stderr)
+		if !ok {
+			return err
+		}
+		stages = append([]string{fix}, stages[n:]...)
+		first, dir = first+n-1, at
+	}
+}
+
+// stageFix offers to ask the model for a replacement for stage n of
+// stages, which is stage shown of the original chain, and returns it if
+// the user agrees to run it.
+func stageFix(stages []string, n, shown, status int, stderr string) (string, bool) {
+	if ui.Unattended != "" {
+		return "", false
+	}
+	if ok, err := ui.Confirm(fmt.Sprintf("Ask for a fix and resume from stage %d?", shown)); !ok || err != nil {
+		return "", false
+	}
+	cfg, err := cachedConfig()
+	if err != nil {
+		return "", false
+	}
+	result, err := completeCommand(context.Background(), cfg, prompt.WhyPrompt(), prompt.StageFixQuery(stages, n, status, stderr))
+	if err != nil {
+		return "", false
+	}
+	if strings.EqualFold(result.Command, "NONE") {
+		result.Command = "(no command to run)"
+		ui.Display(result)
+		return "", false
+	}
+	ui.Display(result)
+
+	fix, err := applyPolicy(cfg, result.Command)
+	if err != nil {
+		return "", false
+	}
+	ui.DisplayRisk(risk.Classify(fix))
+	question := "Run it?"
+	if rest := len(stages) - n; rest > 0 {
+		question = fmt.Sprintf("Run it and the remaining %d stage(s)?", rest)
+	}
+	if ok, err := ui.Confirm(question); !ok || err != nil {
+		return "", false
+	}
+	return fix, true
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newStatsCmd() *cobra.Command {
+	var (
+		provenance bool
+		last       int
+	)
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report how many recent commands came from how",
+		Long: `Report how many of the last commands run through how succeeded.
+
+With --provenance, report instead how many recent entries in your shell
+history were generated by how, counting those marked "` + ui.ProvenanceMarker + `"
+(set provenance: true to mark them).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if last <= 0 {
+				return fail("--last must be positive")
+			}
+			if provenance {
+				if _, err := cachedConfig(); err != nil {
+					return fail("loading config: %w", err)
+				}
+				marked, total, file, err := ui.HistoryProvenance(last)
+				if err != nil {
+					return fail("reading shell history: %w", err)
+				}
+				fmt.Printf("%d of the last %d entries in %s came from how (%s)\n", marked, total, file, percent(marked, total))
+				if !ui.Provenance {
+					fmt.Println("provenance is off, so new commands aren't being marked: how config set provenance true")
+				}
+				return nil
+			}
+
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+			entries, err := store.History(context.Background(), last)
+			if err != nil {
+				return fail("reading history: %w", err)
+			}
+			succeeded := 0
+			for _, e := range entries {
+				if e.ExitCode == 0 {
+					succeeded++
+				}
+			}
+			fmt.Printf("%d commands run through how, %d succeeded (%s)\n", len(entries), succeeded, percent(succeeded, len(entries)))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&provenance, "provenance", false, "Report the share of recent shell history generated by how")
+	cmd.Flags().IntVar(&last, "last", 1000, "How many recent entries to count")
+	return cmd
+}
+
+// percent formats n as a whole percentage of total.
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", n*100/total)
+}
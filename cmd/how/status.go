@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// statusTimeout bounds each provider check.
+const statusTimeout = 10 * time.Second
+
+// statusTarget is a provider checked by how status.
+type statusTarget struct {
+	role string
+	cfg  *config.Config
+
+	latency time.Duration
+	err     error
+}
+
+func newStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Check the configured providers and show which one is in use",
+		Long: `Check every configured provider by looking up its model, which uses no
+tokens, and report the round-trip latency and whether the API key and model
+are accepted. The provider marked "selected" is the one suggestions use right
+now: the active profile's, or the budget's fallback profile once this month's
+budget is spent. Exits with status 2 when the selected provider can't be used.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			selected, notice := budgetProfile(cfg)
+
+			profile := spendProfile(cfg)
+			fmt.Printf("Profile: %s (%s %s)\n", profile, cfg.Provider, cfg.Model())
+			switch {
+			case notice != "":
+				fmt.Printf("Budget:  %s\n", notice)
+			case cfg.Budget.Monthly > 0 || cfg.Budget.Tokens > 0:
+				fmt.Println("Budget:  within this month's budget")
+			}
+			var daemon daemonStatus
+			if err := callDaemon("status", struct{}{}, &daemon, time.Second); err != nil {
+				fmt.Println("Daemon:  not running")
+			} else {
+				fmt.Printf("Daemon:  running (pid %d, profile %q, up %s)\n", daemon.PID, daemon.Profile, daemon.Uptime)
+			}
+
+			targets := statusTargets(cfg, selected)
+			checkProviders(targets)
+			displayStatus(targets)
+
+			if err := targets[0].err; err != nil {
+				return withCode(exitProvider, fail("%s isn't usable: %s", selected.Provider, pingStatus(err)))
+			}
+			return nil
+		},
+	}
+}
+
+// errNoAPIKey marks a hosted provider without an API key.
+var errNoAPIKey = errors.New("no API key")
+
+// statusTargets returns the selected provider first, then the active
+// profile's if the budget moved away from it, then any other hosted
+// provider with an API key.
+func statusTargets(cfg, selected *config.Config) []*statusTarget {
+	targets := []*statusTarget{{role: "selected", cfg: selected}}
+	if selected != cfg {
+		targets = append(targets, &statusTarget{role: "over budget", cfg: cfg})
+	}
+	for _, name := range []string{"anthropic", "openai"} {
+		c := *cfg
+		c.Provider = name
+		if hasTarget(targets, name) || !hasAPIKey(&c) {
+			continue
+		}
+		targets = append(targets, &statusTarget{role: "configured", cfg: &c})
+	}
+	return targets
+}
+
+func hasTarget(targets []*statusTarget, provider string) bool {
+	for _, t := range targets {
+		if t.cfg.Provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAPIKey reports whether cfg's provider has the key it needs; Ollama
+// needs none.
+func hasAPIKey(cfg *config.Config) bool {
+	switch cfg.Provider {
+	case "anthropic":
+		return cfg.Anthropic.APIKey != ""
+	case "openai":
+		return cfg.OpenAI.APIKey != ""
+	}
+	return true
+}
+
+// checkProviders pings every target at once.
+func checkProviders(targets []*statusTarget) {
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if !hasAPIKey(t.cfg) {
+			t.err = errNoAPIKey
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+			defer cancel()
+			start := time.Now()
+			t.err = llm.Ping(ctx, t.cfg)
+			t.latency = time.Since(start)
+		}()
+	}
+	wg.Wait()
+}
+
+func displayStatus(targets []*statusTarget) {
+	rows := make([][]string, len(targets))
+	for i, t := range targets {
+		latency := "-"
+		if llm.StatusCode(t.err) != 0 || t.err == nil {
+			latency = fmt.Sprintf("%dms", t.latency.Milliseconds())
+		}
+		rows[i] = []string{t.cfg.Provider, t.cfg.Model(), t.role, latency, pingStatus(t.err)}
+	}
+	fmt.Println()
+	ui.DisplayTable([]string{"PROVIDER", "MODEL", "ROLE", "LATENCY", "STATUS"}, rows, false)
+	for _, t := range targets {
+		if t.err != nil && llm.StatusCode(t.err) == 0 && !errors.Is(t.err, errNoAPIKey) {
+			ui.DisplayError(fmt.Sprintf("%s: %v", t.cfg.Provider, t.err))
+		}
+	}
+}
+
+// pingStatus describes the outcome of llm.Ping in a few words.
+func pingStatus(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	switch code := llm.StatusCode(err); code {
+	case 0:
+		if errors.Is(err, errNoAPIKey) {
+			return "no API key"
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "timed out"
+		}
+		return "unreachable"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "API key rejected"
+	case http.StatusNotFound:
+		return "model not found"
+	case http.StatusTooManyRequests:
+		return "rate limited"
+	default:
+		return fmt.Sprintf("HTTP %d", code)
+	}
+}
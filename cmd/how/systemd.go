@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/systemd"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+func newSystemdCmd() *cobra.Command {
+	var user, install bool
+
+	cmd := &cobra.Command{
+		Use:   "systemd <description>",
+		Short: "Generate systemd service and timer units, and optionally install them",
+		Long: `Describe a service or scheduled job and get the unit files for it. With
+--install, the commands the units run are checked against policy, risk
+rules and pre hooks, then each unit is written to the unit directory,
+systemd is reloaded, and the unit is enabled and started, confirming each
+step (--yes skips the prompts for user units only).`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			provider, err := newProvider(cfg)
+			if err != nil {
+				return withCode(exitProvider, fail("initializing provider: %w", err))
+			}
+
+			ctx := context.Background()
+			response, err := provider.Complete(ctx, prompt.SystemdPrompt(user), strings.Join(args, " "))
+			if err != nil {
+				return withCode(exitProvider, fail("LLM request failed: %w", err))
+			}
+			plan, err := systemd.Parse(response)
+			if err != nil {
+				return withCode(exitNoParse, fail("%w", err))
+			}
+
+			dir, err := systemd.UnitDir(user)
+			if err != nil {
+				return fail("%w", err)
+			}
+
+			if flagQuiet {
+				for _, u := range plan.Units {
+					fmt.Printf("# %s\n%s", filepath.Join(dir, u.Name), u.Content)
+				}
+				return nil
+			}
+
+			fmt.Println()
+			for _, u := range plan.Units {
+				ui.DisplayFile(filepath.Join(dir, u.Name), u.Content)
+			}
+			if plan.Explanation != "" {
+				ui.Display(ui.Result{Command: systemd.Systemctl(user, "enable", "--now", plan.Enable), Explanation: plan.Explanation})
+			}
+			if !install {
+				fmt.Println("  Run again with --install to write the units and enable them.")
+				return nil
+			}
+			return installUnits(cfg, plan, dir, user)
+		},
+	}
+
+	cmd.Flags().BoolVar(&user, "user", false, "Generate user units for systemctl --user instead of system units")
+	cmd.Flags().BoolVar(&install, "install", false, "Write the units, reload systemd and enable them, confirming each step")
+	return cmd
+}
+
+// installUnits writes plan's units to dir, reloads systemd and enables the
+// plan's unit, asking before each step. The commands in the units' Exec
+// directives are checked first, since enabling the unit runs them. System
+// units are confirmed even with --yes, as they run as root.
+func installUnits(cfg *config.Config, plan systemd.Plan, dir string, user bool) error {
+	if err := checkUnitCommands(cfg, plan); err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp("", "how-systemd-")
+	if err != nil {
+		return fail("%w", err)
+	}
+	defer os.RemoveAll(staging) //nolint:errcheck
+
+	var staged []string
+	for _, u := range plan.Units {
+		path := filepath.Join(staging, u.Name)
+		if err := os.WriteFile(path, []byte(u.Content), 0o644); err != nil {
+			return fail("%w", err)
+		}
+		staged = append(staged, path)
+	}
+	if err := systemd.Verify(staged...); err != nil {
+		ui.DisplayReasoning(err.Error())
+	}
+
+	for i, u := range plan.Units {
+		dest := filepath.Join(dir, u.Name)
+		question := "Write " + dest + "?"
+		if _, err := os.Stat(dest); err == nil {
+			question = "Overwrite " + dest + "?"
+		}
+		write := "install -D -m 0644 " + shell.Quote(staged[i]) + " " + shell.Quote(dest)
+		if systemd.NeedsSudo(user) {
+			write = "sudo " + write
+		}
+		if err := installStep(cfg, write, question, user); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range []string{
+		systemd.Systemctl(user, "daemon-reload"),
+		systemd.Systemctl(user, "enable", "--now", plan.Enable),
+	} {
+		if err := installStep(cfg, step, "Run this command?", user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUnitCommands puts each command in plan's Exec directives through
+// policy, the risk classifier and pre hooks, as if how were running it.
+// Policy rewrites can't be applied inside a unit file, so a command the
+// policy would rewrite is refused instead.
+func checkUnitCommands(cfg *config.Config, plan systemd.Plan) error {
+	for _, u := range plan.Units {
+		for _, command := range u.Commands() {
+			checked, err := applyPolicy(cfg, command)
+			if err != nil {
+				return err
+			}
+			if checked != command {
+				return withCode(exitBlocked, fail("policy rewrites %s's command %q; edit the unit instead", u.Name, command))
+			}
+			if a := risk.Classify(command); a.Level > risk.Safe || len(a.Network) > 0 {
+				fmt.Printf("  %s runs: %s\n", u.Name, command)
+				ui.DisplayRisk(a)
+			}
+			if err := runPreHooks(hookEvent(command)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// installStep shows command, puts it through policy and the risk
+// classifier, confirms it with question and runs it. Steps for system
+// units are confirmed even with --yes.
+func installStep(cfg *config.Config, command, question string, user bool) error {
+	ui.Display(ui.Result{Command: command})
+	command, err := applyPolicy(cfg, command)
+	if err != nil {
+		return err
+	}
+	ui.DisplayRisk(risk.Classify(command))
+
+	confirm := confirmStep
+	if !user {
+		confirm = ui.Confirm
+	}
+	if ok, err := confirm(question); !ok || err != nil {
+		return declined(err)
+	}
+	return runCommand(command)
+}
+
+// confirmStep asks before one step of a multi-step action, unless --yes
+// was given.
+func confirmStep(question string) (bool, error) {
+	if flagYes {
+		return true, nil
+	}
+	return ui.Confirm(question)
+}
+
+// declined maps a declined confirmation to errDeclined.
+func declined(err error) error {
+	if err != nil {
+		return fail("%w", err)
+	}
+	return errDeclined
+}
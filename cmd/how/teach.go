@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+	"golang.org/x/term"
+)
+
+// runTeach answers question with a guided sequence of steps, letting the
+// user run each one in turn. Steps share a shell session so a cd or export
+// in one step is visible to the next.
+func runTeach(ctx context.Context, cfg *config.Config, question string) error {
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return fail("initializing provider: %w", err)
+	}
+
+	response, err := provider.Complete(ctx, prompt.TeachPrompt(), question)
+	if err != nil {
+		return fail("LLM request failed: %w", err)
+	}
+
+	steps := ui.ParseSteps(response)
+	if len(steps) == 0 {
+		return fail("could not parse any steps from the response")
+	}
+
+	if flagQuiet {
+		for _, step := range steps {
+			ui.DisplayQuiet(step)
+		}
+		return nil
+	}
+
+	fmt.Println()
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		for i, step := range steps {
+			ui.DisplayStep(i+1, len(steps), step)
+		}
+		return nil
+	}
+
+	session, err := shell.NewSession()
+	if err != nil {
+		return fail("%w", err)
+	}
+	defer session.Close() //nolint:errcheck
+	session.CommandStdin = "/dev/tty"
+
+	for i, step := range steps {
+		ui.DisplayStep(i+1, len(steps), step)
+		command, err := applyPolicy(cfg, step.Command)
+		if err != nil {
+			fmt.Println()
+			continue
+		}
+		step.Command = command
+		ui.DisplayRisk(risk.Classify(step.Command))
+
+		if !flagYes {
+			key, err := ui.ReadKey("[enter] run  [s] skip  [q] quit")
+			if err != nil {
+				return fail("%w", err)
+			}
+			switch key {
+			case 'q', 'Q', 3: // 3 is Ctrl-C in raw mode
+				return nil
+			case 's', 'S':
+				fmt.Println()
+				continue
+			}
+		}
+
+		fmt.Println()
+		code, err := hooked(step.Command, func() (int, error) { return session.Run(step.Command) })
+		if stoppedByHook(err) {
+			return err
+		}
+		if err != nil {
+			return fail("%w", err)
+		}
+		fmt.Println()
+		if code != 0 {
+			ui.DisplayError(fmt.Sprintf("step %d exited with status %d", i+1, code))
+		}
+	}
+	return nil
+}
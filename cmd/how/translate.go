@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newTranslateCmd() *cobra.Command {
+	var from, to string
+
+	cmd := &cobra.Command{
+		Use:     "translate [command]",
+		Short:   "Translate a command between bash, zsh, fish, PowerShell and cmd",
+		Example: `  how translate --to powershell "find . -name '*.log' -mtime +7 -delete"`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to = shell.Normalize(from), shell.Normalize(to)
+			for _, name := range []string{from, to} {
+				if !shell.Valid(name) {
+					return fail("unknown shell %q (expected one of: %s)", name, strings.Join(shell.Names, ", "))
+				}
+			}
+			if from == to {
+				return fail("--from and --to are both %s", to)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			result, err := complete(context.Background(), cfg, prompt.TranslatePrompt(from, to), strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+
+			if err := shell.Validate(to, result.Command); err != nil && !errors.Is(err, shell.ErrNoValidator) {
+				if result.Warning != "" {
+					result.Warning += "; "
+				}
+				result.Warning += err.Error()
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+			ui.Display(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "bash", "Shell the command is written for")
+	cmd.Flags().StringVar(&to, "to", "", "Shell to translate into (required)")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
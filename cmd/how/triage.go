@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/triage"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// askTriaged asks fn with provider, or, when triage is on and query is
+// simple, with the local Ollama model first, escalating to provider if
+// that fails or gives no command. route says which model answered and
+// why, and is empty when triage is off.
+func askTriaged(ctx context.Context, cfg *config.Config, provider llm.Provider, sysPrompt, query string, fn askFunc) (result ui.Result, route string, err error) {
+	if !cfg.Triage || cfg.Provider == "ollama" || len(queryImages) > 0 {
+		result, err = fn(ctx, provider, sysPrompt, query)
+		return result, "", err
+	}
+
+	reason := "not a simple question"
+	if triage.Simple(query) {
+		local := *cfg
+		local.Provider = "ollama"
+		reason = "the local model couldn't answer"
+		if p, err := llm.NewProvider(&local); err == nil {
+			result, err := fn(ctx, p, sysPrompt, query)
+			if err == nil && result.Command != "" {
+				return result, fmt.Sprintf("%s %s (simple question)", local.Provider, local.Model()), nil
+			}
+			slog.Info("escalating to the hosted model", "error", err)
+		}
+	}
+	result, err = fn(ctx, provider, sysPrompt, query)
+	return result, fmt.Sprintf("%s %s (%s)", cfg.Provider, cfg.Model(), reason), err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/ui"
+)
+
+func newUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo",
+		Short: "Suggest how to reverse the last command run through how",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			store, err := openMemoryStore()
+			if err != nil {
+				return fail("%w", err)
+			}
+			defer store.Close() //nolint:errcheck
+
+			last, err := store.Last(ctx)
+			if err != nil {
+				return fail("reading history: %w", err)
+			}
+			if last == nil {
+				fmt.Println("No commands have been run through how yet.")
+				return nil
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			query := fmt.Sprintf("Undo this command: %s", last.Command)
+			if last.ExitCode != 0 {
+				query += fmt.Sprintf("\nNote: it exited with status %d, so it may only have partially completed.", last.ExitCode)
+			}
+
+			result, err := complete(ctx, cfg, prompt.UndoPrompt(), query)
+			if err != nil {
+				return err
+			}
+
+			if strings.EqualFold(result.Command, "NONE") {
+				if flagQuiet {
+					return fail("no undo available: %s", result.Warning)
+				}
+				result.Command = "(no undo available)"
+				ui.Display(result)
+				return nil
+			}
+
+			if flagQuiet {
+				ui.DisplayQuiet(result)
+				return nil
+			}
+			fmt.Printf("\n  Undoing: %s\n", last.Command)
+			ui.Display(result)
+			return execute(ctx, cfg, store, "undo: "+last.Command, result)
+		},
+	}
+}
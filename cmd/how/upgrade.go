@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/internal/update"
+	"golang.org/x/term"
+)
+
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+func newUpgradeCmd() *cobra.Command {
+	var checkOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade how to the latest release",
+		Long:  "Download the latest release from GitHub, verify its checksum against the minisign-signed checksums file and replace the running binary.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			client := update.NewClient()
+
+			rel, err := client.Latest(ctx)
+			if err != nil {
+				return fail("%w", err)
+			}
+			latest := rel.Version()
+
+			if !update.Newer(latest, version) {
+				if version == "dev" {
+					fmt.Printf("Latest release is %s; this is a development build.\n", latest)
+				} else {
+					fmt.Printf("how %s is up to date.\n", version)
+				}
+				return nil
+			}
+			if checkOnly {
+				fmt.Printf("how %s is available (current %s). Run `how upgrade` to install it.\n", latest, version)
+				return nil
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return fail("locating executable: %w", err)
+			}
+			if exe, err = filepath.EvalSymlinks(exe); err != nil {
+				return fail("locating executable: %w", err)
+			}
+			if strings.Contains(exe, "/Cellar/") {
+				return fail("how was installed with Homebrew; run `brew upgrade how` instead")
+			}
+
+			fmt.Printf("Downloading how %s...\n", latest)
+			binary, err := client.Download(ctx, rel)
+			if err != nil {
+				return fail("%w", err)
+			}
+			if err := update.Replace(exe, binary); err != nil {
+				return fail("%w", err)
+			}
+			fmt.Printf("Upgraded how %s → %s\n", version, latest)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&checkOnly, "check", false, "Only report whether a newer version is available")
+	return cmd
+}
+
+// startUpdateCheck refreshes the cached latest version in the background
+// when the weekly check is due, and reports whether a notice may be shown
+// for this command. It never blocks or fails the command.
+func startUpdateCheck(cmd *cobra.Command) bool {
+	if !updateNoticeEnabled(cmd) {
+		return false
+	}
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return false
+	}
+	if !update.Due(dir) {
+		return true
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := update.NewClient().Refresh(ctx, dir); err != nil {
+			slog.Debug("update check failed", "error", err)
+		}
+	}()
+	return true
+}
+
+// showUpdateNotice prints a one-line notice when the last background check
+// found a newer release.
+func showUpdateNotice() {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return
+	}
+	if latest := update.Available(dir, version); latest != "" {
+		ui.DisplayNotice(fmt.Sprintf("how %s is available (current %s). Run `how upgrade` or set update.check: false to silence.", latest, version))
+	}
+}
+
+func updateNoticeEnabled(cmd *cobra.Command) bool {
+	if version == "dev" || flagQuiet || flagRPC || cmd.Name() == "upgrade" || ui.Unattended != "" {
+		return false
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return false
+	}
+	cfg, err := cachedConfig()
+	return err == nil && cfg.Update.Check
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/memory"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+// minWatchInterval is the shortest --watch interval, as for watch(1).
+const minWatchInterval = 100 * time.Millisecond
+
+// watch re-runs a read-only command every flagWatch until interrupted.
+// Commands that change state, or aren't known to only read it, are
+// refused, since repeating them compounds the change.
+func watch(ctx context.Context, cfg *config.Config, store *memory.Store, question string, result ui.Result, a risk.Assessment) error {
+	if flagWatch < minWatchInterval {
+		return fail("--watch interval must be at least %s", minWatchInterval)
+	}
+	if !a.ReadOnly() {
+		return fail("refusing to watch a command that changes state: it %s", strings.Join(append(a.Reasons, a.Writes...), ", "))
+	}
+	if !risk.OnlyReads(result.Command) {
+		return fail("refusing to watch a command that isn't known to only read state, such as ls, kubectl get or git status")
+	}
+	if len(result.Injections) > 0 || needsConfirmation(cfg, a) {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Run this command every %s?", flagWatch))
+		if err != nil {
+			return fail("%w", err)
+		}
+		if !confirmed {
+			return errDeclined
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	where := ""
+	if targetHost != nil {
+		where = targetHost.Target
+	}
+	var lastErr error
+	for {
+		ui.DisplayWatchHeader(result.Command, flagWatch, time.Now(), where, exitCode(lastErr))
+		_, lastErr = hooked(result.Command, func() (int, error) {
+			err := runWatched(result.Command)
+			return exitCode(err), err
+		})
+		if stoppedByHook(lastErr) {
+			return lastErr
+		}
+		select {
+		case <-ctx.Done():
+			_ = recordRun(context.Background(), store, question, result, true, lastErr)
+			return nil
+		case <-time.After(flagWatch):
+		}
+	}
+}
+
+// runWatched runs command once without stdin, locally or on targetHost.
+func runWatched(command string) error {
+	cmd := exec.Command("sh", "-c", command)
+	if targetHost != nil {
+		cmd = targetHost.Command(command, false)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/internal/ui"
+)
+
+// shellStateDir is where the shell integration records the last command.
+func shellStateDir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shell"), nil
+}
+
+func newInitCmd() *cobra.Command {
+	var captureStderr bool
+
+	cmd := &cobra.Command{
+		Use:   "init <bash|zsh|fish>",
+		Short: "Print shell integration for your rc file",
+		Long: `Print hooks that record each command and its exit status so "how why" can
+explain failures. Add to your shell's rc file:
+
+  eval "$(how init zsh)"             # ~/.zshrc
+  eval "$(how init bash)"            # ~/.bashrc
+  how init fish | source             # ~/.config/fish/config.fish
+
+With --capture-stderr (bash and zsh), error output is also recorded. This
+routes stderr through tee, so some tools stop colorizing their errors.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: shell.IntegrationShells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := shellStateDir()
+			if err != nil {
+				return fail("%w", err)
+			}
+			script, err := shell.Integration(args[0], dir, captureStderr)
+			if err != nil {
+				return fail("%w", err)
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&captureStderr, "capture-stderr", false, "Also record each command's error output (bash and zsh)")
+	return cmd
+}
+
+func newWhyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "why",
+		Short: "Explain why the previous shell command failed",
+		Long:  `Explain what went wrong with the previous command in this shell and suggest a fix. Requires the shell integration from "how init".`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := shellStateDir()
+			if err != nil {
+				return fail("%w", err)
+			}
+			last, err := shell.ReadLastRun(dir, shell.SessionID())
+			if err != nil {
+				return fail("%w", err)
+			}
+			if last == nil {
+				return fail(`no previous command recorded; add eval "$(how init <shell>)" to your shell's rc file`)
+			}
+			if last.ExitCode == 0 && strings.TrimSpace(last.Stderr) == "" {
+				fmt.Printf("The last command succeeded: %s\n", last.Command)
+				return nil
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			query := prompt.WhyQuery(last.Command, last.ExitCode, last.Stderr)
+			result, err := complete(ctx, cfg, prompt.WhyPrompt(), query)
+			if err != nil {
+				return err
+			}
+
+			noFix := strings.EqualFold(result.Command, "NONE")
+			if flagQuiet {
+				if noFix {
+					return fail("%s", result.Explanation)
+				}
+				ui.DisplayQuiet(result)
+				return nil
+			}
+
+			fmt.Printf("\n  Failed: %s (exit %d)\n", last.Command, last.ExitCode)
+			if noFix {
+				result.Command = "(no command to run)"
+				ui.Display(result)
+				return nil
+			}
+			ui.Display(result)
+
+			store := openMemoryIfEnabled(cfg)
+			if store != nil {
+				defer store.Close() //nolint:errcheck
+			}
+			return execute(ctx, cfg, store, "why: "+last.Command, result)
+		},
+	}
+}
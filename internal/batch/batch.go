@@ -0,0 +1,121 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options controls how a batch is processed.
+type Options struct {
+	// Concurrency is the maximum number of queries in flight; values below 1 mean 1.
+	Concurrency int
+	// Rate is the maximum number of queries started per second; 0 means unlimited.
+	Rate float64
+}
+
+// Item is the outcome of a single query.
+type Item struct {
+	Index       int    `json:"index"`
+	Query       string `json:"query"`
+	Command     string `json:"command,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// AskFunc answers a single query, returning the command and explanation.
+type AskFunc func(ctx context.Context, query string) (command, explanation string, err error)
+
+// ReadQueries reads one query per line, skipping blank lines and # comments.
+func ReadQueries(r io.Reader) ([]string, error) {
+	var queries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading queries: %w", err)
+	}
+	return queries, nil
+}
+
+// Run answers every query using ask, honouring the concurrency and rate
+// limits in opts. Results are returned in input order.
+func Run(ctx context.Context, queries []string, opts Options, ask AskFunc) []Item {
+	items := make([]Item, len(queries))
+	Stream(ctx, queries, opts, ask, func(item Item) {
+		items[item.Index] = item
+	})
+	return items
+}
+
+// Stream is Run, passing each item to emit as soon as its query is
+// answered rather than at the end, so items arrive in completion order.
+// emit is called from one goroutine at a time, and Stream returns once
+// every item has been emitted.
+func Stream(ctx context.Context, queries []string, opts Options, ask AskFunc, emit func(Item)) {
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var ticker *time.Ticker
+	if opts.Rate > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+	}
+
+	results := make(chan Item)
+	emitted := make(chan struct{})
+	go func() {
+		for item := range results {
+			emit(item)
+		}
+		close(emitted)
+	}()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item := Item{Index: i, Query: queries[i]}
+				cmd, expl, err := ask(ctx, queries[i])
+				if err != nil {
+					item.Error = err.Error()
+				} else {
+					item.Command, item.Explanation = cmd, expl
+				}
+				results <- item
+			}
+		}()
+	}
+
+	for i := range queries {
+		if ticker != nil && i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			results <- Item{Index: i, Query: queries[i], Error: ctx.Err().Error()}
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+	<-emitted
+}
@@ -0,0 +1,108 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadQueries(t *testing.T) {
+	input := "list files\n\n# a comment\n  show disk usage  \n"
+	queries, err := ReadQueries(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadQueries error: %v", err)
+	}
+	if len(queries) != 2 || queries[0] != "list files" || queries[1] != "show disk usage" {
+		t.Errorf("unexpected queries: %q", queries)
+	}
+}
+
+func TestRunPreservesOrder(t *testing.T) {
+	queries := []string{"a", "b", "c", "d", "e"}
+	ask := func(_ context.Context, q string) (string, string, error) {
+		// Finish earlier queries last to exercise reordering
+		time.Sleep(time.Duration(5-len(q)) * time.Millisecond)
+		if q == "c" {
+			return "", "", errors.New("boom")
+		}
+		return "echo " + q, "prints " + q, nil
+	}
+
+	items := Run(context.Background(), queries, Options{Concurrency: 3}, ask)
+
+	if len(items) != len(queries) {
+		t.Fatalf("expected %d items, got %d", len(queries), len(items))
+	}
+	for i, item := range items {
+		if item.Query != queries[i] {
+			t.Errorf("item %d: query %q, want %q", i, item.Query, queries[i])
+		}
+	}
+	if items[2].Error != "boom" {
+		t.Errorf("expected error for query c, got %+v", items[2])
+	}
+	if items[0].Command != "echo a" {
+		t.Errorf("unexpected command: %q", items[0].Command)
+	}
+}
+
+func TestStreamEmitsAsQueriesFinish(t *testing.T) {
+	release := make(chan struct{})
+	ask := func(_ context.Context, q string) (string, string, error) {
+		if q == "slow" {
+			<-release
+		}
+		return "echo " + q, "", nil
+	}
+
+	var order []string
+	Stream(context.Background(), []string{"slow", "fast"}, Options{Concurrency: 2}, ask, func(item Item) {
+		order = append(order, item.Query)
+		if item.Query == "fast" {
+			if item.Index != 1 {
+				t.Errorf("expected fast to keep its input index, got %d", item.Index)
+			}
+			close(release)
+		}
+	})
+	if len(order) != 2 || order[0] != "fast" || order[1] != "slow" {
+		t.Errorf("expected the fast query to be emitted before the slow one finished, got %v", order)
+	}
+}
+
+func TestRunConcurrencyLimit(t *testing.T) {
+	var inFlight, peak int32
+	ask := func(_ context.Context, q string) (string, string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return q, "", nil
+	}
+
+	Run(context.Background(), make([]string, 10), Options{Concurrency: 2}, ask)
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent queries, saw %d", peak)
+	}
+}
+
+func TestRunRateLimit(t *testing.T) {
+	ask := func(_ context.Context, q string) (string, string, error) { return q, "", nil }
+
+	start := time.Now()
+	Run(context.Background(), make([]string, 3), Options{Concurrency: 3, Rate: 50}, ask)
+
+	// 3 queries at 50/s need at least two 20ms gaps
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("rate limit not applied: finished in %v", elapsed)
+	}
+}
@@ -0,0 +1,126 @@
+// Package bench holds the standard queries, answer checks and prices used
+// to compare providers and models.
+package bench
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/eval"
+)
+
+// Query is a benchmark question with the patterns a right answer matches.
+type Query struct {
+	Question string
+	accept   []*regexp.Regexp
+}
+
+func query(question string, accept ...string) Query {
+	q := Query{Question: question}
+	for _, a := range accept {
+		q.accept = append(q.accept, regexp.MustCompile(a))
+	}
+	return q
+}
+
+// Correct reports whether command is an acceptable answer to q.
+func (q Query) Correct(command string) bool {
+	command = eval.Normalize(command)
+	for _, re := range q.accept {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// Queries is the standard set: everyday tasks with a few well-known right
+// answers each. Answers are normalized with eval.Normalize before matching.
+var Queries = []Query{
+	query("show disk usage of mounted filesystems in human readable units",
+		`^df (-\w*h\w*|.*-h\b)`),
+	query("count the lines in main.go",
+		`^wc -l (< ?)?main\.go$`, `^cat main\.go \| wc -l$`),
+	query("show the last 50 lines of app.log",
+		`^tail (-n ?|-)50 app\.log$`, `^tail -n 50 app\.log$`),
+	query("find all .log files under the current directory",
+		`^find (\. )?.*-i?name ['"]?\*\.log['"]?`, `^fd .*log`),
+	query("list listening TCP ports",
+		`^(sudo )?(ss|netstat) -\w*l\w*`, `^(sudo )?lsof .*-i.*LISTEN`),
+	query("extract archive.tar.gz",
+		`^tar -?\w*x\w* .*archive\.tar\.gz`, `^tar -?\w*x\w*f archive\.tar\.gz`),
+	query("show the current git branch",
+		`^git (branch --show-current|rev-parse --abbrev-ref HEAD|symbolic-ref --short( -q)? HEAD)$`),
+	query("replace foo with bar in config.txt in place",
+		`^sed -i\S* (''? )?['"]?s/foo/bar/g?['"]? config\.txt$`, `^perl -p?i -p?e ['"]s/foo/bar/g?['"] config\.txt$`),
+	query("make deploy.sh executable",
+		`^chmod (a?\+x|u\+x|7[57]5) deploy\.sh$`),
+	query("search for TODO in all files under the current directory",
+		`^grep -\w*r\w* .*TODO`, `^rg .*TODO`, `^git grep .*TODO`),
+	query("show environment variables whose name contains PATH",
+		`^(env|printenv) \| grep .*(?i:path)`),
+	query("show the 10 largest files in the current directory",
+		`\bsort -\w*[hn]\w*r|\bsort -\w*r\w*[hn]`, `^ls -\w*S\w* .*head`),
+}
+
+// Price is the cost of a model in dollars per million tokens.
+type Price struct {
+	Input, Output float64
+}
+
+// prices are list prices by model name prefix. The longest matching
+// prefix wins.
+var prices = map[string]Price{
+	"claude-opus-4-6":   {5, 25},
+	"claude-opus-4-5":   {5, 25},
+	"claude-opus-4":     {15, 75},
+	"claude-sonnet-4":   {3, 15},
+	"claude-3-7-sonnet": {3, 15},
+	"claude-haiku-4-5":  {1, 5},
+	"claude-3-5-haiku":  {0.8, 4},
+	"gpt-5-nano":        {0.05, 0.4},
+	"gpt-5-mini":        {0.25, 2},
+	"gpt-5":             {1.25, 10},
+	"gpt-4.1-nano":      {0.1, 0.4},
+	"gpt-4.1-mini":      {0.4, 1.6},
+	"gpt-4.1":           {2, 8},
+	"gpt-4o-mini":       {0.15, 0.6},
+	"gpt-4o":            {2.5, 10},
+	"o4-mini":           {1.1, 4.4},
+	"o3-mini":           {1.1, 4.4},
+	"o3":                {2, 8},
+}
+
+// PriceFor returns the price of model on provider. Local models are free;
+// ok is false for unknown models.
+func PriceFor(provider, model string) (p Price, ok bool) {
+	if provider == "ollama" {
+		return Price{}, true
+	}
+	best := ""
+	for prefix, price := range prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, p = prefix, price
+		}
+	}
+	return p, best != ""
+}
+
+// Cost is the price of the given token counts in dollars.
+func (p Price) Cost(input, output int64) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1e6
+}
+
+// Percentile returns the p-th percentile (0 to 100) of latencies, using
+// the nearest rank.
+func Percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
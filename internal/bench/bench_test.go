@@ -0,0 +1,69 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQueriesAcceptKnownAnswers(t *testing.T) {
+	answers := map[string][]string{
+		"show disk usage of mounted filesystems in human readable units": {"df -h", "df -hT"},
+		"count the lines in main.go":                                     {"wc -l main.go", "wc -l < main.go", "cat main.go | wc -l"},
+		"show the last 50 lines of app.log":                              {"tail -n 50 app.log", "tail -50 app.log"},
+		"find all .log files under the current directory":                {`find . -type f -name "*.log"`, "find . -name '*.log'"},
+		"list listening TCP ports":                                       {"ss -tlnp", "sudo lsof -iTCP -sTCP:LISTEN -P -n", "netstat -tln"},
+		"extract archive.tar.gz":                                         {"tar -xzf archive.tar.gz", "tar xvf archive.tar.gz"},
+		"show the current git branch":                                    {"git branch --show-current", "git rev-parse --abbrev-ref HEAD"},
+		"replace foo with bar in config.txt in place":                    {"sed -i 's/foo/bar/g' config.txt", "sed -i '' 's/foo/bar/g' config.txt"},
+		"make deploy.sh executable":                                      {"chmod +x deploy.sh", "chmod 755 deploy.sh"},
+		"search for TODO in all files under the current directory":       {"grep -rn TODO .", "rg TODO"},
+		"show environment variables whose name contains PATH":            {"env | grep PATH", "printenv | grep -i path"},
+		"show the 10 largest files in the current directory":             {"ls -lS | head -n 11", "du -ah . | sort -rh | head -n 10"},
+	}
+	if len(answers) != len(Queries) {
+		t.Fatalf("expected answers for all %d queries, have %d", len(Queries), len(answers))
+	}
+	for _, q := range Queries {
+		for _, a := range answers[q.Question] {
+			if !q.Correct(a) {
+				t.Errorf("%q: expected %q to be accepted", q.Question, a)
+			}
+		}
+	}
+
+	if Queries[0].Correct("du -sh") || Queries[1].Correct("wc -c main.go") {
+		t.Error("expected wrong answers to be rejected")
+	}
+}
+
+func TestPriceFor(t *testing.T) {
+	if p, ok := PriceFor("anthropic", "claude-sonnet-4-6"); !ok || p != (Price{3, 15}) {
+		t.Errorf("unexpected sonnet price: %v %v", p, ok)
+	}
+	if p, ok := PriceFor("openai", "gpt-4o-mini-2024-07-18"); !ok || p != (Price{0.15, 0.6}) {
+		t.Errorf("expected the longest prefix to win, got %v %v", p, ok)
+	}
+	if p, ok := PriceFor("ollama", "llama3"); !ok || p != (Price{}) {
+		t.Errorf("expected local models to be free, got %v %v", p, ok)
+	}
+	if _, ok := PriceFor("openai", "mystery-model"); ok {
+		t.Error("expected no price for an unknown model")
+	}
+	if got := (Price{3, 15}).Cost(1_000_000, 100_000); math.Abs(got-4.5) > 1e-9 {
+		t.Errorf("unexpected cost: %v", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ds := []time.Duration{5, 1, 4, 2, 3}
+	if got := Percentile(ds, 50); got != 3 {
+		t.Errorf("unexpected median: %v", got)
+	}
+	if got := Percentile(ds, 95); got != 5 {
+		t.Errorf("unexpected p95: %v", got)
+	}
+	if Percentile(nil, 50) != 0 {
+		t.Error("expected zero for no latencies")
+	}
+}
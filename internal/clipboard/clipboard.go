@@ -0,0 +1,121 @@
+// Package clipboard reads and writes the system clipboard through the
+// platform's command-line tools, or the terminal over SSH.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrEmpty is returned when the clipboard holds nothing of the requested
+// kind.
+var ErrEmpty = errors.New("clipboard is empty")
+
+// reader is a command that prints the clipboard to stdout.
+type reader struct {
+	name string
+	args []string
+}
+
+// textReaders returns the candidate commands for goos, in preference order.
+func textReaders(goos string) []reader {
+	switch goos {
+	case "darwin":
+		return []reader{{"pbpaste", nil}}
+	case "windows":
+		return []reader{{"powershell.exe", []string{"-NoProfile", "-Command", "Get-Clipboard -Raw"}}}
+	}
+	var rs []reader
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		rs = append(rs, reader{"wl-paste", []string{"--no-newline"}})
+	}
+	return append(rs,
+		reader{"xclip", []string{"-selection", "clipboard", "-o"}},
+		reader{"xsel", []string{"--clipboard", "--output"}},
+	)
+}
+
+// imageReaders returns the candidate commands for reading a PNG image on
+// goos, in preference order.
+func imageReaders(goos string) []reader {
+	switch goos {
+	case "darwin":
+		return []reader{{"pngpaste", []string{"-"}}}
+	case "windows":
+		return []reader{{"powershell.exe", []string{"-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; $i = [Windows.Forms.Clipboard]::GetImage(); " +
+				"if ($i) { $m = New-Object IO.MemoryStream; $i.Save($m, [Drawing.Imaging.ImageFormat]::Png); " +
+				"$o = [Console]::OpenStandardOutput(); $o.Write($m.ToArray(), 0, $m.Length) }"}}}
+	}
+	var rs []reader
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		rs = append(rs, reader{"wl-paste", []string{"--type", "image/png"}})
+	}
+	return append(rs, reader{"xclip", []string{"-selection", "clipboard", "-t", "image/png", "-o"}})
+}
+
+// lookPath and output are replaced in tests.
+var (
+	lookPath = exec.LookPath
+	output   = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return out, err
+	}
+)
+
+// ReadText returns the text on the clipboard using the first available
+// clipboard tool.
+func ReadText(ctx context.Context) (string, error) {
+	out, err := read(ctx, textReaders(runtime.GOOS))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(string(out), "\r\n", "\n"))
+	if text == "" {
+		return "", ErrEmpty
+	}
+	return text, nil
+}
+
+// ReadImage returns the image on the clipboard as PNG data.
+func ReadImage(ctx context.Context) ([]byte, error) {
+	out, err := read(ctx, imageReaders(runtime.GOOS))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrEmpty
+	}
+	return out, nil
+}
+
+// read runs the first installed reader in rs.
+func read(ctx context.Context, rs []reader) ([]byte, error) {
+	for _, r := range rs {
+		if _, err := lookPath(r.name); err != nil {
+			continue
+		}
+		out, err := output(ctx, r.name, r.args...)
+		if err != nil {
+			return nil, fmt.Errorf("reading clipboard with %s: %w", r.name, err)
+		}
+		return out, nil
+	}
+	names := make([]string, len(rs))
+	for i, r := range rs {
+		names[i] = r.name
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install one of: %s)", strings.Join(names, ", "))
+}
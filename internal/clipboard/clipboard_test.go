@@ -0,0 +1,152 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var allTools = map[string]bool{"pbpaste": true, "pngpaste": true, "powershell.exe": true, "wl-paste": true, "xclip": true, "xsel": true}
+
+func stub(t *testing.T, installed map[string]bool, out string) {
+	t.Helper()
+	origLook, origOut := lookPath, output
+	t.Cleanup(func() { lookPath, output = origLook, origOut })
+	lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+	output = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(out), nil
+	}
+}
+
+func TestReadText(t *testing.T) {
+	stub(t, allTools, "  Error: EACCES\r\nline two\n")
+	got, err := ReadText(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Error: EACCES\nline two" {
+		t.Errorf("unexpected clipboard text: %q", got)
+	}
+}
+
+func TestReadTextEmpty(t *testing.T) {
+	stub(t, allTools, " \n")
+	if _, err := ReadText(context.Background()); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestReadTextNoTool(t *testing.T) {
+	stub(t, nil, "")
+	_, err := ReadText(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no clipboard tool") {
+		t.Errorf("expected missing tool error, got %v", err)
+	}
+}
+
+func TestTextReadersLinuxOrder(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	rs := textReaders("linux")
+	if rs[0].name != "wl-paste" || rs[1].name != "xclip" {
+		t.Errorf("expected wl-paste first under Wayland, got %v", rs)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if rs := textReaders("linux"); rs[0].name != "xclip" {
+		t.Errorf("expected xclip first under X11, got %v", rs)
+	}
+}
+
+func TestReadImage(t *testing.T) {
+	stub(t, allTools, "\x89PNG\r\n")
+	got, err := ReadImage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "\x89PNG\r\n" {
+		t.Errorf("image data should be returned unchanged, got %q", got)
+	}
+
+	stub(t, allTools, "")
+	if _, err := ReadImage(context.Background()); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty, got %v", err)
+	}
+}
+
+type ttyBuffer struct{ strings.Builder }
+
+func (*ttyBuffer) Close() error { return nil }
+
+func stubWrite(t *testing.T, installed map[string]bool) (*ttyBuffer, *string) {
+	t.Helper()
+	stub(t, installed, "")
+	origInput, origTTY := input, openTTY
+	t.Cleanup(func() { input, openTTY = origInput, origTTY })
+	tty := &ttyBuffer{}
+	var used string
+	input = func(ctx context.Context, stdin []byte, name string, args ...string) error {
+		used = name + " " + string(stdin)
+		return nil
+	}
+	openTTY = func() (io.WriteCloser, error) { return tty, nil }
+	return tty, &used
+}
+
+func TestWriteText(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("clipboard tools differ by platform")
+	}
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("SSH_TTY", "")
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	tty, used := stubWrite(t, allTools)
+	if method, err := WriteText(context.Background(), "df -h"); err != nil || method != "xclip" {
+		t.Fatalf("expected xclip, got %q %v", method, err)
+	}
+	if *used != "xclip df -h" || tty.Len() != 0 {
+		t.Errorf("expected the text on xclip's stdin only, got %q and tty %q", *used, tty.String())
+	}
+
+	// Over SSH the local terminal is asked instead
+	t.Setenv("SSH_CONNECTION", "10.0.0.1 50000 10.0.0.2 22")
+	tty, used = stubWrite(t, allTools)
+	if method, err := WriteText(context.Background(), "df -h"); err != nil || method != MethodOSC52 {
+		t.Fatalf("expected OSC 52 over SSH, got %q %v", method, err)
+	}
+	if *used != "" || tty.String() != "\x1b]52;c;ZGYgLWg=\a" {
+		t.Errorf("unexpected clipboard writes: tool %q, tty %q", *used, tty.String())
+	}
+
+	// As it is with no tool installed
+	t.Setenv("SSH_CONNECTION", "")
+	tty, _ = stubWrite(t, nil)
+	if method, err := WriteText(context.Background(), "df -h"); err != nil || method != MethodOSC52 || tty.Len() == 0 {
+		t.Errorf("expected an OSC 52 fallback, got %q %v", method, err)
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	if got := OSC52("ls", env(nil)); got != "\x1b]52;c;bHM=\a" {
+		t.Errorf("plain: got %q", got)
+	}
+	if got := OSC52("ls", env(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"})); got != "\x1bPtmux;\x1b\x1b]52;c;bHM=\a\x1b\\" {
+		t.Errorf("tmux: got %q", got)
+	}
+	if got := OSC52("ls", env(map[string]string{"TERM": "screen-256color"})); got != "\x1bP\x1b]52;c;bHM=\a\x1b\\" {
+		t.Errorf("screen: got %q", got)
+	}
+}
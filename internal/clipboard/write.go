@@ -0,0 +1,105 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// MethodOSC52 is what WriteText reports when the terminal was asked to set
+// the clipboard itself.
+const MethodOSC52 = "OSC 52"
+
+// textWriters returns the candidate commands that set the clipboard from
+// their stdin on goos, in preference order.
+func textWriters(goos string) []reader {
+	switch goos {
+	case "darwin":
+		return []reader{{"pbcopy", nil}}
+	case "windows":
+		return []reader{{"clip.exe", nil}}
+	}
+	var ws []reader
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		ws = append(ws, reader{"wl-copy", nil})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		ws = append(ws,
+			reader{"xclip", []string{"-selection", "clipboard", "-in"}},
+			reader{"xsel", []string{"--clipboard", "--input"}},
+		)
+	}
+	return ws
+}
+
+// input and openTTY are replaced in tests.
+var (
+	input = func(ctx context.Context, stdin []byte, name string, args ...string) error {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = bytes.NewReader(stdin)
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	openTTY = func() (io.WriteCloser, error) {
+		return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	}
+)
+
+// WriteText puts text on the clipboard and returns the tool it used. Over
+// SSH, where a clipboard tool would set the remote machine's clipboard, or
+// when no tool is installed, the terminal is asked to set the clipboard
+// with an OSC 52 escape sequence instead, which works through tmux and
+// screen too.
+func WriteText(ctx context.Context, text string) (string, error) {
+	if !remote() {
+		for _, w := range textWriters(runtime.GOOS) {
+			if _, err := lookPath(w.name); err != nil {
+				continue
+			}
+			if err := input(ctx, []byte(text), w.name, w.args...); err != nil {
+				return "", fmt.Errorf("writing clipboard with %s: %w", w.name, err)
+			}
+			return w.name, nil
+		}
+	}
+	tty, err := openTTY()
+	if err != nil {
+		return "", fmt.Errorf("no clipboard tool found and no terminal for OSC 52: %w", err)
+	}
+	defer tty.Close() //nolint:errcheck
+	if _, err := io.WriteString(tty, OSC52(text, os.Getenv)); err != nil {
+		return "", fmt.Errorf("writing OSC 52 sequence: %w", err)
+	}
+	return MethodOSC52, nil
+}
+
+// remote reports whether this is an SSH session, whose clipboard is the
+// one on the user's own machine.
+func remote() bool {
+	return os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != ""
+}
+
+// OSC52 returns the escape sequence asking the terminal to set its
+// clipboard to text. Inside tmux or screen, the sequence is wrapped so the
+// multiplexer passes it through to the outer terminal.
+func OSC52(text string, getenv func(string) string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
@@ -0,0 +1,134 @@
+// Package collect gathers facts about the user's machine (git state, OS
+// distribution, installed tools, Kubernetes context) to include in prompts.
+package collect
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Budget is the combined time allowed for all collectors. Collectors that
+// haven't finished by then are skipped.
+var Budget = 150 * time.Millisecond
+
+// Collector produces one fact about the environment. An empty string with a
+// nil error means the fact doesn't apply (e.g. not inside a git repo).
+type Collector struct {
+	Name    string
+	Collect func(ctx context.Context) (string, error)
+}
+
+// Fact is the output of a collector that finished in time.
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// Defaults lists the built-in collectors in prompt order.
+var Defaults = []Collector{
+	{Name: "git", Collect: gitInfo},
+	{Name: "distro", Collect: distro},
+	{Name: "tools", Collect: tools},
+	{Name: "k8s", Collect: kubeContext},
+}
+
+// Names returns the names of the built-in collectors.
+func Names() []string {
+	names := make([]string, len(Defaults))
+	for i, c := range Defaults {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Select returns the built-in collectors named in names, in default order.
+// Unknown names are ignored.
+func Select(names []string) []Collector {
+	want := map[string]bool{}
+	for _, n := range names {
+		want[n] = true
+	}
+	var out []Collector
+	for _, c := range Defaults {
+		if want[c.Name] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type dirKey struct{}
+
+// WithDir makes collectors inspect dir instead of the process's working
+// directory.
+func WithDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, dirKey{}, dir)
+}
+
+func dirFrom(ctx context.Context) string {
+	dir, _ := ctx.Value(dirKey{}).(string)
+	return dir
+}
+
+// Gather runs collectors concurrently and returns the facts produced within
+// Budget, in collector order. Slow or failing collectors are logged and
+// left out.
+func Gather(ctx context.Context, collectors []Collector) []Fact {
+	return GatherWithin(ctx, collectors, Budget)
+}
+
+// GatherWithin is Gather with a different time budget, for subcommands
+// that collect more detail than the default prompt.
+func GatherWithin(ctx context.Context, collectors []Collector, budget time.Duration) []Fact {
+	if len(collectors) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	type result struct {
+		i     int
+		value string
+		err   error
+	}
+	results := make(chan result, len(collectors))
+	start := time.Now()
+	for i, c := range collectors {
+		go func() {
+			value, err := c.Collect(ctx)
+			results <- result{i: i, value: value, err: err}
+		}()
+	}
+
+	values := make([]string, len(collectors))
+	done := make([]bool, len(collectors))
+	for range collectors {
+		select {
+		case r := <-results:
+			done[r.i] = true
+			if r.err != nil {
+				slog.Info("context collector failed", "collector", collectors[r.i].Name, "error", r.err)
+				continue
+			}
+			values[r.i] = r.value
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	var facts []Fact
+	for i, c := range collectors {
+		if !done[i] {
+			slog.Info("context collector skipped", "collector", c.Name, "budget_ms", budget.Milliseconds())
+			continue
+		}
+		if values[i] != "" {
+			facts = append(facts, Fact{Name: c.Name, Value: values[i]})
+		}
+	}
+	slog.Debug("collected context", "facts", len(facts), "elapsed_ms", time.Since(start).Milliseconds())
+	return facts
+}
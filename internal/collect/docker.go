@@ -0,0 +1,73 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerDetails are the collectors for `how docker`: the real container,
+// image and compose service names to use in commands.
+var DockerDetails = []Collector{
+	{Name: "Containers (name, image, status, ports)", Collect: dockerContainers},
+	{Name: "Images", Collect: dockerImages},
+	{Name: "Compose", Collect: composeProject},
+}
+
+// maxDockerLines bounds each section so hosts with many images don't blow
+// up the prompt.
+const maxDockerLines = 30
+
+// composeFiles are the names docker compose looks for, in its order.
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+func dockerContainers(ctx context.Context) (string, error) {
+	out, err := run(ctx, "docker", "ps", "-a", "--format", "{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.Ports}}")
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "No containers", nil
+	}
+	return headLines(out, maxDockerLines), nil
+}
+
+func dockerImages(ctx context.Context) (string, error) {
+	out, err := run(ctx, "docker", "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.Size}}")
+	if err != nil {
+		return "", err
+	}
+	return headLines(out, maxDockerLines), nil
+}
+
+// composeProject reports the compose file in the directory and its
+// services.
+func composeProject(ctx context.Context) (string, error) {
+	dir := dirFrom(ctx)
+	var file string
+	for _, name := range composeFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			file = name
+			break
+		}
+	}
+	if file == "" {
+		return "No compose file in the current directory", nil
+	}
+	info := "Compose file " + file
+	if services, err := run(ctx, "docker", "compose", "config", "--services"); err == nil && services != "" {
+		info += " with services: " + strings.Join(strings.Fields(services), ", ")
+	}
+	return info, nil
+}
+
+// headLines returns the first n lines of s, noting how many were left out.
+func headLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n(%d more)", len(lines)-n)
+}
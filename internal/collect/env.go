@@ -0,0 +1,102 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ciServices maps environment variables set by CI services to their names,
+// checked before the generic CI variable.
+var ciServices = []struct{ env, name string }{
+	{"GITHUB_ACTIONS", "GitHub Actions"},
+	{"GITLAB_CI", "GitLab CI"},
+	{"CIRCLECI", "CircleCI"},
+	{"BUILDKITE", "Buildkite"},
+	{"JENKINS_URL", "Jenkins"},
+	{"TF_BUILD", "Azure Pipelines"},
+}
+
+// CI returns the name of the CI service the process runs under, "CI" for
+// an unknown one that sets CI=true, or "" outside CI. CI=false wins over
+// any service variable.
+func CI() string {
+	generic := strings.ToLower(os.Getenv("CI"))
+	if generic == "false" || generic == "0" {
+		return ""
+	}
+	for _, s := range ciServices {
+		if v := strings.ToLower(os.Getenv(s.env)); v != "" && v != "false" {
+			return s.name
+		}
+	}
+	if generic == "true" || generic == "1" {
+		return "CI"
+	}
+	return ""
+}
+
+// Session describes what sets the user's session apart from a local
+// desktop, such as "over SSH" or "in a Docker container", joined with
+// "and", or returns "" when nothing does.
+func Session() string {
+	var parts []string
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		parts = append(parts, "over SSH")
+	}
+	if c := container("/"); c != "" {
+		parts = append(parts, "in "+c)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// container names the container the process runs in, from the marker
+// files runtimes leave under root and the variables they set, or "".
+func container(root string) string {
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(root, path))
+		return err == nil
+	}
+	switch {
+	case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+		return "a Kubernetes pod"
+	case exists(".dockerenv"):
+		return "a Docker container"
+	case exists("run/.containerenv"):
+		return "a Podman container"
+	case os.Getenv("container") != "":
+		// systemd-nspawn, LXC and others
+		return "a " + os.Getenv("container") + " container"
+	}
+	return ""
+}
+
+// localtimePath is replaced in tests.
+var localtimePath = "/etc/localtime"
+
+// TimeZone returns the IANA name of the local time zone, from $TZ or the
+// /etc/localtime link, or "" if neither names one.
+func TimeZone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !strings.HasPrefix(tz, "/") {
+		return tz
+	}
+	target, err := os.Readlink(localtimePath)
+	if err != nil {
+		return ""
+	}
+	if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+		return name
+	}
+	return ""
+}
+
+// Locale returns the locale used for dates, from $LC_ALL, $LC_TIME or
+// $LANG, or "" if none is set.
+func Locale() string {
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package collect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitDetails are the collectors for `how git`: enough of the repository's
+// state to answer rebase, reflog and bisect questions accurately.
+var GitDetails = []Collector{
+	{Name: "Status", Collect: gitStatus},
+	{Name: "In progress", Collect: gitOperation},
+	{Name: "Branch graph (recent commits)", Collect: gitGraph},
+	{Name: "Stashes", Collect: gitStashes},
+	{Name: "Reflog (recent)", Collect: gitReflog},
+}
+
+// InGitRepo reports whether the directory in ctx is inside a git work tree.
+func InGitRepo(ctx context.Context) bool {
+	out, err := run(ctx, "git", "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+func gitStatus(ctx context.Context) (string, error) {
+	return run(ctx, "git", "status", "--short", "--branch")
+}
+
+func gitGraph(ctx context.Context) (string, error) {
+	return run(ctx, "git", "log", "--graph", "--oneline", "--decorate", "--all", "-n", "25")
+}
+
+func gitStashes(ctx context.Context) (string, error) {
+	return run(ctx, "git", "stash", "list", "-n", "10")
+}
+
+func gitReflog(ctx context.Context) (string, error) {
+	return run(ctx, "git", "reflog", "-n", "10")
+}
+
+// gitOperations maps state files in the git directory to the operation
+// they indicate.
+var gitOperations = []struct{ path, name string }{
+	{"rebase-merge", "interactive rebase"},
+	{"rebase-apply", "rebase or am"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// gitOperation reports a merge, rebase, cherry-pick, revert or bisect that
+// has been started but not finished.
+func gitOperation(ctx context.Context) (string, error) {
+	gitDir, err := run(ctx, "git", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	var ops []string
+	for _, op := range gitOperations {
+		if _, err := os.Stat(filepath.Join(gitDir, op.path)); err == nil {
+			ops = append(ops, op.name)
+		}
+	}
+	if len(ops) == 0 {
+		return "", nil
+	}
+	return "A " + strings.Join(ops, " and ") + " is in progress", nil
+}
@@ -0,0 +1,57 @@
+package collect
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// HookTimeout is how long context hooks may run. It's longer than Budget
+// since hooks are often scripts that ask a service, such as which tenant
+// or environment is active.
+var HookTimeout = 2 * time.Second
+
+// maxHookBytes caps how much of a hook's output goes into the prompt.
+const maxHookBytes = 1024
+
+// Hooks returns a collector for each command, which runs it with sh -c and
+// reports its trimmed output. Output over maxHookBytes is cut short.
+func Hooks(commands []string) []Collector {
+	var hooks []Collector
+	for _, command := range commands {
+		hooks = append(hooks, Collector{Name: command, Collect: func(ctx context.Context) (string, error) {
+			out, err := run(ctx, "sh", "-c", command)
+			if err != nil {
+				return "", err
+			}
+			return truncate(out, maxHookBytes), nil
+		}})
+	}
+	return hooks
+}
+
+// GatherWithHooks is Gather for collectors alongside hooks, which run at
+// the same time but within HookTimeout. Hook facts come last.
+func GatherWithHooks(ctx context.Context, collectors, hooks []Collector) []Fact {
+	if len(hooks) == 0 {
+		return Gather(ctx, collectors)
+	}
+	hookFacts := make(chan []Fact, 1)
+	go func() {
+		hookFacts <- GatherWithin(ctx, hooks, HookTimeout)
+	}()
+	facts := Gather(ctx, collectors)
+	return append(facts, <-hookFacts...)
+}
+
+// truncate cuts s to at most n bytes on a rune boundary, marking the cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "…"
+}
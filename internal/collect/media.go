@@ -0,0 +1,203 @@
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MediaDetails are the collectors for `how media`: the format, codecs and
+// dimensions of each input file, and which media tools are installed.
+func MediaDetails(paths []string) []Collector {
+	collectors := []Collector{{Name: "Installed media tools", Collect: mediaTools}}
+	for _, path := range paths {
+		collectors = append(collectors, Collector{
+			Name:    "Input " + path,
+			Collect: func(ctx context.Context) (string, error) { return probeMedia(ctx, path) },
+		})
+	}
+	return collectors
+}
+
+// mediaToolNames are the media tools worth telling the model about.
+var mediaToolNames = []string{"ffmpeg", "ffprobe", "magick", "convert", "identify", "sox"}
+
+func mediaTools(ctx context.Context) (string, error) {
+	var found []string
+	for _, name := range mediaToolNames {
+		if _, err := lookPath(name); err == nil {
+			found = append(found, name)
+		}
+	}
+	if len(found) == 0 {
+		return "None of " + strings.Join(mediaToolNames, ", "), nil
+	}
+	return strings.Join(found, ", "), nil
+}
+
+// probeMedia describes path with identify for images and ffprobe for
+// everything else, falling back to the file's size and type.
+func probeMedia(ctx context.Context, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	desc := fmt.Sprintf("%s, %s", filepath.Ext(path), formatBytes(info.Size()))
+
+	if isImage(path) {
+		if out, err := identify(ctx, path); err == nil && out != "" {
+			return desc + "\n" + out, nil
+		}
+	}
+	if _, err := lookPath("ffprobe"); err == nil {
+		out, err := run(ctx, "ffprobe", "-v", "error", "-show_format", "-show_streams", "-of", "json", path)
+		if err != nil {
+			return desc + "\nffprobe could not read the file", nil
+		}
+		if summary, err := summarizeProbe([]byte(out)); err == nil {
+			return desc + "\n" + summary, nil
+		}
+	}
+	return desc, nil
+}
+
+func isImage(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close() //nolint:errcheck
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return strings.HasPrefix(http.DetectContentType(buf[:n]), "image/")
+}
+
+// identify describes an image with ImageMagick 7's magick identify or
+// ImageMagick 6's identify.
+func identify(ctx context.Context, path string) (string, error) {
+	format := "%m %wx%h, %[colorspace], %[bit-depth]-bit, %[channels]\\n"
+	if _, err := lookPath("magick"); err == nil {
+		return run(ctx, "magick", "identify", "-format", format, path+"[0]")
+	}
+	if _, err := lookPath("identify"); err == nil {
+		return run(ctx, "identify", "-format", format, path+"[0]")
+	}
+	return "", fmt.Errorf("imagemagick not installed")
+}
+
+// summarizeProbe turns ffprobe's JSON into one line for the container and
+// one per stream.
+func summarizeProbe(data []byte) (string, error) {
+	var probe struct {
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			Index         int    `json:"index"`
+			CodecType     string `json:"codec_type"`
+			CodecName     string `json:"codec_name"`
+			Profile       string `json:"profile"`
+			Width         int    `json:"width"`
+			Height        int    `json:"height"`
+			PixFmt        string `json:"pix_fmt"`
+			AvgFrameRate  string `json:"avg_frame_rate"`
+			SampleRate    string `json:"sample_rate"`
+			Channels      int    `json:"channels"`
+			ChannelLayout string `json:"channel_layout"`
+			BitRate       string `json:"bit_rate"`
+			Tags          struct {
+				Language string `json:"language"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return "", fmt.Errorf("parsing ffprobe output: %w", err)
+	}
+
+	var lines []string
+	container := "Container " + probe.Format.FormatName
+	if d, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		container += fmt.Sprintf(", %.1fs", d)
+	}
+	if br := formatBitRate(probe.Format.BitRate); br != "" {
+		container += ", " + br
+	}
+	lines = append(lines, container)
+
+	for _, s := range probe.Streams {
+		parts := []string{s.CodecName}
+		if s.Profile != "" {
+			parts[0] += " (" + s.Profile + ")"
+		}
+		switch s.CodecType {
+		case "video":
+			if s.Width > 0 {
+				parts = append(parts, fmt.Sprintf("%dx%d", s.Width, s.Height))
+			}
+			if s.PixFmt != "" {
+				parts = append(parts, s.PixFmt)
+			}
+			if fps := formatFrameRate(s.AvgFrameRate); fps != "" {
+				parts = append(parts, fps)
+			}
+		case "audio":
+			if s.SampleRate != "" {
+				parts = append(parts, s.SampleRate+" Hz")
+			}
+			if s.ChannelLayout != "" {
+				parts = append(parts, s.ChannelLayout)
+			} else if s.Channels > 0 {
+				parts = append(parts, fmt.Sprintf("%d channels", s.Channels))
+			}
+		}
+		if br := formatBitRate(s.BitRate); br != "" {
+			parts = append(parts, br)
+		}
+		if s.Tags.Language != "" {
+			parts = append(parts, "language "+s.Tags.Language)
+		}
+		lines = append(lines, fmt.Sprintf("Stream %d %s: %s", s.Index, s.CodecType, strings.Join(parts, ", ")))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func formatBitRate(s string) string {
+	bps, err := strconv.Atoi(s)
+	if err != nil || bps <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d kb/s", bps/1000)
+}
+
+func formatFrameRate(s string) string {
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		return ""
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 || n == 0 {
+		return ""
+	}
+	fps := strings.TrimRight(strings.TrimRight(strconv.FormatFloat(n/d, 'f', 2, 64), "0"), ".")
+	return fps + " fps"
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
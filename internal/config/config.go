@@ -1,24 +1,229 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Provider     string          `yaml:"provider"`
-	SystemPrompt string          `yaml:"system_prompt,omitempty"`
-	Anthropic    AnthropicConfig `yaml:"anthropic"`
-	OpenAI       OpenAIConfig    `yaml:"openai"`
-	Ollama       OllamaConfig    `yaml:"ollama"`
-	Memory       MemoryConfig    `yaml:"memory"`
+	Provider        string             `yaml:"provider"`
+	SystemPrompt    string             `yaml:"system_prompt,omitempty"`
+	PromptAdditions string             `yaml:"prompt_additions,omitempty"`
+	DefaultProfile  string             `yaml:"default_profile,omitempty"`
+	Confirm         string             `yaml:"confirm,omitempty"`
+	Shell           string             `yaml:"shell,omitempty"`
+	ShellHistory    string             `yaml:"shell_history,omitempty"`
+	Theme           string             `yaml:"theme,omitempty"`
+	Icons           string             `yaml:"icons,omitempty"`
+	SpacePrefix     bool               `yaml:"space_prefix,omitempty"`
+	ExplainToStderr bool               `yaml:"explain_to_stderr,omitempty"`
+	Provenance      bool               `yaml:"provenance,omitempty"`
+	InstalledOnly   bool               `yaml:"installed_only,omitempty"`
+	Triage          bool               `yaml:"triage,omitempty"`
+	CostWarning     float64            `yaml:"cost_warning,omitempty"`
+	ClarifyRounds   int                `yaml:"clarify_rounds"`
+	Alternatives    int                `yaml:"alternatives"`
+	Budget          BudgetConfig       `yaml:"budget,omitempty"`
+	Prefer          map[string]string  `yaml:"prefer,omitempty"`
+	Anthropic       AnthropicConfig    `yaml:"anthropic"`
+	OpenAI          OpenAIConfig       `yaml:"openai"`
+	Ollama          OllamaConfig       `yaml:"ollama"`
+	Memory          MemoryConfig       `yaml:"memory"`
+	Server          ServerConfig       `yaml:"server,omitempty"`
+	Share           ShareConfig        `yaml:"share,omitempty"`
+	Favorites       FavoritesConfig    `yaml:"favorites,omitempty"`
+	Update          UpdateConfig       `yaml:"update"`
+	Context         ContextConfig      `yaml:"context"`
+	ContextHooks    []string           `yaml:"context_hooks,omitempty"`
+	Hooks           HooksConfig        `yaml:"hooks,omitempty"`
+	Profiles        map[string]Profile `yaml:"profiles,omitempty"`
+	Plugins         map[string]Plugin  `yaml:"plugins,omitempty"`
+	Policy          PolicyConfig       `yaml:"policy,omitempty"`
+	RiskRules       string             `yaml:"risk_rules,omitempty"`
+	ConfigURL       string             `yaml:"config_url,omitempty"`
+
+	// ActiveProfile is the name of the profile applied by LoadProfile, if any.
+	ActiveProfile string `yaml:"-"`
+}
+
+// HooksConfig lists shell commands run around every command how executes.
+type HooksConfig struct {
+	// Pre hooks run before the command; one that exits non-zero stops it.
+	Pre []string `yaml:"pre,omitempty"`
+	// Post hooks run after the command finishes, whatever its outcome.
+	Post []string `yaml:"post,omitempty"`
+}
+
+// Plugin configures the how-<name> plugin of the same name.
+type Plugin struct {
+	// PassCredentials sends the active provider's API key to the plugin.
+	// Plugins never get it otherwise.
+	PassCredentials bool `yaml:"pass_credentials,omitempty"`
+}
+
+// PolicyConfig restricts which suggested commands may be executed.
+type PolicyConfig struct {
+	// Deny lists regular expressions; matching commands are never run.
+	Deny []string `yaml:"deny,omitempty"`
+	// Rewrite lists rules applied to commands before they run.
+	Rewrite []RewriteRule `yaml:"rewrite,omitempty"`
+}
+
+// RewriteRule replaces matches of Match in a command with Replace, which
+// may refer to capture groups as ${1}. Commands matching Unless are left
+// alone, so a rule doesn't apply twice.
+type RewriteRule struct {
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+	Unless  string `yaml:"unless,omitempty"`
+	Note    string `yaml:"note,omitempty"`
+}
+
+// Profile overrides provider settings and prompt additions. Empty fields
+// leave the top-level value in place.
+type Profile struct {
+	Provider        string       `yaml:"provider,omitempty"`
+	APIKey          string       `yaml:"api_key,omitempty"`
+	Model           string       `yaml:"model,omitempty"`
+	URL             string       `yaml:"url,omitempty"`
+	SystemPrompt    string       `yaml:"system_prompt,omitempty"`
+	PromptAdditions string       `yaml:"prompt_additions,omitempty"`
+	Confirm         string       `yaml:"confirm,omitempty"`
+	Budget          BudgetConfig `yaml:"budget,omitempty"`
+}
+
+// BudgetConfig caps what a profile spends in a calendar month, in dollars
+// or tokens. Once either is reached, requests switch to the Fallback
+// profile, or carry on with a warning if there's none.
+type BudgetConfig struct {
+	Monthly  float64 `yaml:"monthly,omitempty"`
+	Tokens   int64   `yaml:"tokens,omitempty"`
+	Fallback string  `yaml:"fallback,omitempty"`
 }
 
+// Confirmation policies for the confirm setting.
+const (
+	ConfirmAlways          = "always"
+	ConfirmDestructiveOnly = "destructive-only"
+	ConfirmNever           = "never"
+)
+
+// When commands that how runs are appended to the shell's history file,
+// for the shell_history setting.
+const (
+	HistoryOnSuccess = "on-success"
+	HistoryOnAccept  = "on-accept"
+	HistoryNever     = "never"
+)
+
+// Colour themes for the theme setting. ThemeAuto picks Mocha or Latte
+// from the terminal's background; dark and light are aliases for them.
+const (
+	ThemeAuto           = "auto"
+	ThemeDark           = "dark"
+	ThemeLight          = "light"
+	ThemeMocha          = "mocha"
+	ThemeLatte          = "latte"
+	ThemeSolarizedDark  = "solarized-dark"
+	ThemeSolarizedLight = "solarized-light"
+	ThemeMono           = "mono"
+)
+
+// Glyph sets for the icons setting, used in badges and the spinner.
+const (
+	IconsNerdFont = "nerdfont"
+	IconsEmoji    = "emoji"
+	IconsASCII    = "ascii"
+	IconsNone     = "none"
+)
+
 type MemoryConfig struct {
 	Enabled bool `yaml:"enabled"`
+	// Feedback asks for a thumbs up or down after a command runs, and uses
+	// the answers as examples for similar questions.
+	Feedback bool `yaml:"feedback"`
+	// CaptureOutput keeps what commands run through how print, with
+	// credentials masked, for --from-last-run. It is off by default.
+	CaptureOutput bool `yaml:"capture_output"`
+}
+
+// ContextConfig selects which machine facts are added to suggestion prompts.
+type ContextConfig struct {
+	// Collectors names the collectors to run: git, distro, tools, k8s.
+	// An empty list disables context collection.
+	Collectors []string `yaml:"collectors"`
+	// Aliases makes the aliases defined in the user's interactive shell
+	// available to suggestions.
+	Aliases bool `yaml:"aliases,omitempty"`
+}
+
+// ShareConfig configures where `how share` uploads to.
+type ShareConfig struct {
+	// Service is gist or paste.
+	Service string `yaml:"service,omitempty"`
+	// URL is the paste service's upload endpoint, or the gists API of a
+	// GitHub Enterprise server.
+	URL string `yaml:"url,omitempty"`
+	// Token authenticates uploads; for gists it defaults to $GITHUB_TOKEN,
+	// $GH_TOKEN or the gh CLI's login.
+	Token string `yaml:"token,omitempty"`
+	// Public makes gists public instead of secret.
+	Public bool `yaml:"public,omitempty"`
+}
+
+// FavoritesConfig configures `how fav`.
+type FavoritesConfig struct {
+	// Remote is the team's shared library for fav pull and push: a git
+	// repository URL or an s3://bucket/key.
+	Remote string `yaml:"remote,omitempty"`
+}
+
+// UpdateConfig controls the weekly new-version notice.
+type UpdateConfig struct {
+	Check bool `yaml:"check"`
+}
+
+// ServerConfig configures `how serve`.
+type ServerConfig struct {
+	Listen string `yaml:"listen,omitempty"`
+	// GRPCListen, if set, also serves the gRPC API on this address.
+	GRPCListen string `yaml:"grpc_listen,omitempty"`
+	Token      string `yaml:"token,omitempty"`
+	// RateLimit caps the queries a minute answered for Token; 0 means no
+	// limit.
+	RateLimit int `yaml:"rate_limit,omitempty"`
+	// Tenants share the server, each with its own token, rate limit and
+	// profile, so teams can use their own provider keys.
+	Tenants map[string]Tenant `yaml:"tenants,omitempty"`
+	// MetricsToken is the bearer token /metrics requires. It must be set
+	// with Tenants, whose names label the metrics.
+	MetricsToken string      `yaml:"metrics_token,omitempty"`
+	Slack        SlackConfig `yaml:"slack,omitempty"`
+}
+
+// SlackConfig enables the /slack/command endpoint for a Slack app's slash
+// command.
+type SlackConfig struct {
+	SigningSecret string `yaml:"signing_secret,omitempty"`
+	// Tenant names the tenant whose provider and rate limit answer the
+	// command; empty means the one for server.token.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// Tenant is a client of a shared `how serve`.
+type Tenant struct {
+	Token string `yaml:"token"`
+	// Profile names the profile whose provider, key and model answer the
+	// tenant's queries; empty uses the top-level settings.
+	Profile   string `yaml:"profile,omitempty"`
+	RateLimit int    `yaml:"rate_limit,omitempty"`
 }
 
 type AnthropicConfig struct {
@@ -27,30 +232,52 @@ type AnthropicConfig struct {
 }
 
 type OpenAIConfig struct {
-	APIKey string `yaml:"api_key"`
-	Model  string `yaml:"model"`
+	APIKey         string `yaml:"api_key"`
+	Model          string `yaml:"model"`
+	EmbeddingModel string `yaml:"embedding_model"`
 }
 
 type OllamaConfig struct {
-	Model string `yaml:"model"`
-	URL   string `yaml:"url"`
+	Model          string `yaml:"model"`
+	URL            string `yaml:"url"`
+	EmbeddingModel string `yaml:"embedding_model"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Provider: "anthropic",
+		Provider:      "anthropic",
+		Confirm:       ConfirmAlways,
+		ShellHistory:  HistoryOnSuccess,
+		Theme:         ThemeAuto,
+		Icons:         IconsNone,
+		ClarifyRounds: 2,
 		Anthropic: AnthropicConfig{
 			Model: "claude-sonnet-4-6",
 		},
 		OpenAI: OpenAIConfig{
-			Model: "gpt-4o",
+			Model:          "gpt-4o",
+			EmbeddingModel: "text-embedding-3-small",
 		},
 		Ollama: OllamaConfig{
-			Model: "llama3",
-			URL:   "http://localhost:11434/v1",
+			Model:          "llama3",
+			URL:            "http://localhost:11434/v1",
+			EmbeddingModel: "nomic-embed-text",
 		},
 		Memory: MemoryConfig{
-			Enabled: true,
+			Enabled:  true,
+			Feedback: true,
+		},
+		Server: ServerConfig{
+			Listen: "127.0.0.1:8080",
+		},
+		Share: ShareConfig{
+			Service: "gist",
+		},
+		Update: UpdateConfig{
+			Check: true,
+		},
+		Context: ContextConfig{
+			Collectors: []string{"git", "distro", "tools", "k8s"},
 		},
 	}
 }
@@ -71,6 +298,21 @@ func ConfigDir() (string, error) {
 	return filepath.Join(home, ".config", "how"), nil
 }
 
+// Path returns the location of the config file.
+func Path() (string, error) {
+	return configPath()
+}
+
+// Exists reports whether a config file has been created.
+func Exists() bool {
+	path, err := configPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
 func configPath() (string, error) {
 	dir, err := ConfigDir()
 	if err != nil {
@@ -79,25 +321,56 @@ func configPath() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
+// Load reads the config file, falling back to defaults when it doesn't
+// exist, and applies environment variable overrides and the profile
+// selected by HOW_PROFILE or default_profile.
 func Load() (*Config, error) {
-	cfg := DefaultConfig()
+	return LoadProfile("")
+}
 
-	path, err := configPath()
+// LoadProfile is like Load but applies the named profile. An empty name
+// falls back to HOW_PROFILE, then default_profile, then no profile.
+func LoadProfile(name string) (*Config, error) {
+	local, err := readLocal()
 	if err != nil {
-		return cfg, nil
+		return nil, err
 	}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
+	// Org-wide defaults from config_url sit between the built-in defaults
+	// and the local file. Deny and rewrite rules and hooks are additive so
+	// a local file can't drop an org policy or audit hook.
+	cfg := DefaultConfig()
+	var probe struct {
+		ConfigURL string `yaml:"config_url"`
+	}
+	_ = yaml.Unmarshal(local, &probe)
+	var orgDeny []string
+	var orgRewrite []RewriteRule
+	var orgHooks HooksConfig
+	if probe.ConfigURL != "" {
+		remote, err := fetchRemote(probe.ConfigURL)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("reading config: %w", err)
+		if err := yaml.Unmarshal(remote, cfg); err != nil {
+			return nil, fmt.Errorf("parsing remote config from %s: %w", probe.ConfigURL, err)
+		}
+		orgDeny = cfg.Policy.Deny
+		orgRewrite = cfg.Policy.Rewrite
+		orgHooks = cfg.Hooks
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+	if err := yaml.Unmarshal(local, cfg); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
+	cfg.ConfigURL = probe.ConfigURL
+	cfg.Policy.Deny = mergeUnique(orgDeny, cfg.Policy.Deny)
+	cfg.Policy.Rewrite = mergeUnique(orgRewrite, cfg.Policy.Rewrite)
+	cfg.Hooks.Pre = mergeUnique(orgHooks.Pre, cfg.Hooks.Pre)
+	cfg.Hooks.Post = mergeUnique(orgHooks.Post, cfg.Hooks.Post)
+	if err := mergePacks(cfg); err != nil {
+		return nil, err
+	}
 
 	// Env vars take precedence over config file
 	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
@@ -106,10 +379,142 @@ func Load() (*Config, error) {
 	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
 		cfg.OpenAI.APIKey = key
 	}
+	if token := os.Getenv("HOW_SERVER_TOKEN"); token != "" {
+		cfg.Server.Token = token
+	}
+	if secret := os.Getenv("HOW_SLACK_SIGNING_SECRET"); secret != "" {
+		cfg.Server.Slack.SigningSecret = secret
+	}
 
+	if name == "" {
+		name = os.Getenv("HOW_PROFILE")
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name != "" {
+		if err := cfg.ApplyProfile(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// ApplyProfile overlays the named profile onto the top-level settings.
+// A profile's API key wins over environment variables, since selecting
+// the profile is the more specific choice.
+func (c *Config) ApplyProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	c.Overlay(p)
+	c.ActiveProfile = name
+	return nil
+}
+
+// Overlay applies the non-empty fields of p to the top-level settings.
+func (c *Config) Overlay(p Profile) {
+	if p.Provider != "" {
+		c.Provider = p.Provider
+	}
+	switch c.Provider {
+	case "anthropic":
+		overlay(&c.Anthropic.APIKey, p.APIKey)
+		overlay(&c.Anthropic.Model, p.Model)
+	case "openai":
+		overlay(&c.OpenAI.APIKey, p.APIKey)
+		overlay(&c.OpenAI.Model, p.Model)
+	case "ollama":
+		overlay(&c.Ollama.Model, p.Model)
+		overlay(&c.Ollama.URL, p.URL)
+	}
+	overlay(&c.SystemPrompt, p.SystemPrompt)
+	overlay(&c.Confirm, p.Confirm)
+	if p.Budget.Monthly != 0 {
+		c.Budget.Monthly = p.Budget.Monthly
+	}
+	if p.Budget.Tokens != 0 {
+		c.Budget.Tokens = p.Budget.Tokens
+	}
+	overlay(&c.Budget.Fallback, p.Budget.Fallback)
+	if p.PromptAdditions != "" {
+		if c.PromptAdditions != "" {
+			c.PromptAdditions += "\n"
+		}
+		c.PromptAdditions += p.PromptAdditions
+	}
+}
+
+// Model returns the model configured for the active provider.
+func (c *Config) Model() string {
+	switch c.Provider {
+	case "anthropic":
+		return c.Anthropic.Model
+	case "openai":
+		return c.OpenAI.Model
+	case "ollama":
+		return c.Ollama.Model
+	}
+	return ""
+}
+
+func overlay(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// LoadFile reads the config file without environment overrides or remote
+// defaults, so the result can be modified and saved back without
+// persisting env secrets or org settings.
+func LoadFile() (*Config, error) {
+	cfg := DefaultConfig()
+	data, err := readLocal()
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
 	return cfg, nil
 }
 
+// readLocal returns the contents of the config file, or nil if it doesn't exist.
+func readLocal() ([]byte, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return data, nil
+}
+
+func mergeUnique[T comparable](a, b []T) []T {
+	seen := make(map[T]bool, len(a)+len(b))
+	var out []T
+	for _, s := range append(append([]T{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func Save(cfg *Config) error {
 	path, err := configPath()
 	if err != nil {
@@ -148,3 +553,31 @@ func Show() (string, error) {
 
 	return fmt.Sprintf("Config file: %s\n\n%s", path, string(data)), nil
 }
+
+// Validate strictly parses the config file, reporting unknown keys and
+// invalid values that Load would silently ignore.
+func Validate() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	for key, validate := range validators {
+		value, _ := Get(cfg, key)
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
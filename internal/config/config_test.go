@@ -97,6 +97,53 @@ func TestEnvVarOverride(t *testing.T) {
 	}
 }
 
+func TestServerTokenEnvOverride(t *testing.T) {
+	setupTestDir(t)
+
+	cfg := DefaultConfig()
+	cfg.Server.Token = "file-token"
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	t.Setenv("HOW_SERVER_TOKEN", "env-token")
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded.Server.Token != "env-token" {
+		t.Errorf("server token: got %q, want %q", loaded.Server.Token, "env-token")
+	}
+}
+
+func TestEnvVarOverrideNoFile(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "env-anthropic-key")
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded.Anthropic.APIKey != "env-anthropic-key" {
+		t.Errorf("anthropic key: got %q, want %q", loaded.Anthropic.APIKey, "env-anthropic-key")
+	}
+}
+
+func TestExists(t *testing.T) {
+	setupTestDir(t)
+
+	if Exists() {
+		t.Error("expected no config file in a fresh directory")
+	}
+	if err := Save(DefaultConfig()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if !Exists() {
+		t.Error("expected config file after Save")
+	}
+}
+
 func TestShowNoFile(t *testing.T) {
 	setupTestDir(t)
 
@@ -129,6 +176,32 @@ func TestShowWithFile(t *testing.T) {
 	}
 }
 
+func TestValidate(t *testing.T) {
+	setupTestDir(t)
+
+	if err := Save(DefaultConfig()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("default config should validate, got: %v", err)
+	}
+
+	path, _ := Path()
+	if err := os.WriteFile(path, []byte("provider: anthropic\nanthropc:\n  model: x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(); err == nil || !contains(err.Error(), "anthropc") {
+		t.Errorf("expected unknown key error, got: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("provider: gemini\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(); err == nil || !contains(err.Error(), "provider") {
+		t.Errorf("expected invalid provider error, got: %v", err)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && searchString(s, substr)
 }
@@ -146,5 +219,98 @@ func TestMain(m *testing.M) {
 	// Ensure tests don't accidentally use real env vars
 	os.Unsetenv("ANTHROPIC_API_KEY")
 	os.Unsetenv("OPENAI_API_KEY")
+	os.Unsetenv("HOW_SERVER_TOKEN")
+	os.Unsetenv("HOW_SLACK_SIGNING_SECRET")
+	os.Unsetenv("HOW_PROFILE")
 	os.Exit(m.Run())
 }
+
+func TestProfiles(t *testing.T) {
+	setupTestDir(t)
+
+	cfg := DefaultConfig()
+	cfg.PromptAdditions = "Be concise."
+	cfg.DefaultProfile = "personal"
+	cfg.Budget = BudgetConfig{Monthly: 20, Fallback: "onprem"}
+	cfg.Profiles = map[string]Profile{
+		"work":     {Provider: "openai", APIKey: "work-key", Model: "gpt-4o-mini", PromptAdditions: "We use podman.", Confirm: ConfirmNever, Budget: BudgetConfig{Monthly: 50}},
+		"personal": {Model: "claude-haiku-4-5"},
+		"onprem":   {Provider: "ollama", URL: "http://ollama.internal:11434/v1"},
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	t.Run("default profile", func(t *testing.T) {
+		loaded, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if loaded.ActiveProfile != "personal" || loaded.Anthropic.Model != "claude-haiku-4-5" {
+			t.Errorf("expected personal profile applied, got profile %q model %q", loaded.ActiveProfile, loaded.Anthropic.Model)
+		}
+	})
+
+	t.Run("env selects profile", func(t *testing.T) {
+		t.Setenv("HOW_PROFILE", "onprem")
+		loaded, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if loaded.Provider != "ollama" || loaded.Ollama.URL != "http://ollama.internal:11434/v1" {
+			t.Errorf("expected onprem ollama settings, got %q %q", loaded.Provider, loaded.Ollama.URL)
+		}
+	})
+
+	t.Run("explicit profile wins over env", func(t *testing.T) {
+		t.Setenv("HOW_PROFILE", "onprem")
+		t.Setenv("OPENAI_API_KEY", "env-key")
+		loaded, err := LoadProfile("work")
+		if err != nil {
+			t.Fatalf("LoadProfile() error: %v", err)
+		}
+		if loaded.Provider != "openai" || loaded.OpenAI.APIKey != "work-key" || loaded.OpenAI.Model != "gpt-4o-mini" {
+			t.Errorf("unexpected work settings: %+v", loaded.OpenAI)
+		}
+		if loaded.Confirm != ConfirmNever {
+			t.Errorf("confirm: got %q, want %q", loaded.Confirm, ConfirmNever)
+		}
+		if loaded.PromptAdditions != "Be concise.\nWe use podman." {
+			t.Errorf("prompt additions: got %q", loaded.PromptAdditions)
+		}
+		if loaded.Budget != (BudgetConfig{Monthly: 50, Fallback: "onprem"}) {
+			t.Errorf("expected the profile's budget over the top-level fallback, got %+v", loaded.Budget)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := LoadProfile("nope")
+		if err == nil || !contains(err.Error(), "available: onprem, personal, work") {
+			t.Errorf("expected unknown profile error listing profiles, got: %v", err)
+		}
+	})
+}
+
+func TestLoadContextCollectors(t *testing.T) {
+	setupTestDir(t)
+	path, _ := Path()
+
+	cases := map[string]int{
+		"provider: anthropic\n":                          4,
+		"context:\n  collectors: [git]\n":                1,
+		"context:\n  collectors: []\n":                   0,
+		"context:\n  collectors: [git, tools, distro]\n": 3,
+	}
+	for data, want := range cases {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if len(cfg.Context.Collectors) != want {
+			t.Errorf("%q: expected %d collectors, got %v", data, want, cfg.Context.Collectors)
+		}
+	}
+}
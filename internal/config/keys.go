@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// validators restrict the values accepted by Set for specific keys.
+var validators = map[string]func(string) error{
+	"provider":      oneOf("anthropic", "openai", "ollama"),
+	"confirm":       oneOf(ConfirmAlways, ConfirmDestructiveOnly, ConfirmNever),
+	"shell_history": oneOf(HistoryOnSuccess, HistoryOnAccept, HistoryNever),
+	"theme": oneOf(ThemeAuto, ThemeDark, ThemeLight, ThemeMocha, ThemeLatte,
+		ThemeSolarizedDark, ThemeSolarizedLight, ThemeMono),
+	"icons":             oneOf(IconsNerdFont, IconsEmoji, IconsASCII, IconsNone),
+	"clarify_rounds":    atLeast(0),
+	"alternatives":      atLeast(0),
+	"server.rate_limit": atLeast(0),
+	"share.service":     oneOf("gist", "paste"),
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(v string) error {
+		for _, a := range allowed {
+			if v == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+	}
+}
+
+func atLeast(min int) func(string) error {
+	return func(v string) error {
+		if n, err := strconv.Atoi(v); err != nil || n < min {
+			return fmt.Errorf("must be a whole number, %d or more", min)
+		}
+		return nil
+	}
+}
+
+// Keys returns every settable key in dotted form (e.g. "anthropic.model"), sorted.
+func Keys() []string {
+	var keys []string
+	walkFields(reflect.ValueOf(DefaultConfig()).Elem(), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSecret reports whether key holds a credential that shouldn't be printed.
+func IsSecret(key string) bool {
+	return strings.HasSuffix(key, "api_key") || strings.HasSuffix(key, "token") || strings.HasSuffix(key, "secret")
+}
+
+// Get returns the value of a dotted key as a string.
+func Get(cfg *Config, key string) (string, error) {
+	field, err := lookup(cfg, key)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(field), nil
+}
+
+// Set parses value according to the key's type and stores it in cfg.
+func Set(cfg *Config, key, value string) error {
+	field, err := lookup(cfg, key)
+	if err != nil {
+		return err
+	}
+	if validate, ok := validators[key]; ok {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected true or false", key)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected an integer", key)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected a number", key)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s can't be set from the command line; use 'how config edit'", key)
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s can't be set from the command line; use 'how config edit'", key)
+	}
+	return nil
+}
+
+// lookup resolves a dotted key to the settable field it names.
+func lookup(cfg *Config, key string) (reflect.Value, error) {
+	var found reflect.Value
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(k string, v reflect.Value) {
+		if k == key {
+			found = v
+		}
+	})
+	if !found.IsValid() {
+		msg := fmt.Sprintf("unknown config key %q", key)
+		if s := closestKey(key); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		return reflect.Value{}, fmt.Errorf("%s; run 'how config list' to see all keys", msg)
+	}
+	return found, nil
+}
+
+// walkFields calls fn for every leaf field of a struct, keyed by its
+// dotted yaml path. Nested structs are descended into; maps such as
+// profiles are skipped since they have no fixed keys.
+func walkFields(v reflect.Value, prefix string, fn func(key string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			walkFields(field, key, fn)
+			continue
+		case reflect.Map:
+			continue
+		}
+		fn(key, field)
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// closestKey returns the known key nearest to key by edit distance,
+// or "" if none is reasonably close.
+func closestKey(key string) string {
+	best, bestDist := "", len(key)/2+2
+	for _, k := range Keys() {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
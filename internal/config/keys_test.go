@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	for _, want := range []string{"provider", "anthropic.model", "memory.enabled", "server.listen"} {
+		found := false
+		for _, k := range keys {
+			if k == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected key %q in %v", want, keys)
+		}
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := Set(cfg, "openai.model", "gpt-4o-mini"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	if got, _ := Get(cfg, "openai.model"); got != "gpt-4o-mini" {
+		t.Errorf("openai.model: got %q", got)
+	}
+
+	if err := Set(cfg, "memory.enabled", "false"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	if cfg.Memory.Enabled {
+		t.Error("memory.enabled should be false")
+	}
+}
+
+func TestSetValidation(t *testing.T) {
+	cfg := DefaultConfig()
+
+	cases := []struct {
+		key, value, wantErr string
+	}{
+		{"provider", "gemini", "must be one of"},
+		{"confirm", "sometimes", "must be one of"},
+		{"clarify_rounds", "-1", "0 or more"},
+		{"memory.enabled", "maybe", "expected true or false"},
+		{"anthropic.modle", "x", `did you mean "anthropic.model"`},
+		{"nonsense", "x", "unknown config key"},
+	}
+	for _, tc := range cases {
+		err := Set(cfg, tc.key, tc.value)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Set(%q, %q): expected error containing %q, got %v", tc.key, tc.value, tc.wantErr, err)
+		}
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	if !IsSecret("anthropic.api_key") || !IsSecret("server.token") || !IsSecret("server.slack.signing_secret") {
+		t.Error("api keys and tokens should be secret")
+	}
+	if IsSecret("anthropic.model") {
+		t.Error("model should not be secret")
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pack is a shareable bundle of profiles, few-shot examples and deny
+// rules for a domain such as Kubernetes or Postgres, installed with
+// `how pack install`.
+type Pack struct {
+	Name        string                 `yaml:"name"`
+	Version     string                 `yaml:"version"`
+	Description string                 `yaml:"description,omitempty"`
+	Profiles    map[string]PackProfile `yaml:"profiles,omitempty"`
+	Policy      PackPolicy             `yaml:"policy,omitempty"`
+
+	// Source and Ref record where the pack was installed from, so `how
+	// pack update` can fetch it again.
+	Source string `yaml:"source,omitempty"`
+	Ref    string `yaml:"ref,omitempty"`
+	// Commit is the git commit the pack was installed at, and Checksum the
+	// SHA-256 of its tarball, so an update can tell when it has changed.
+	Commit   string `yaml:"commit,omitempty"`
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// PackPolicy is the policy a pack adds to every command. Packs come from
+// third parties, so they can only refuse commands, never rewrite them.
+type PackPolicy struct {
+	// Deny lists regular expressions; matching commands are never run.
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+// PackProfile is a profile from a pack. Packs come from third parties, so
+// they can shape the prompt and pick a model but not change the provider,
+// its key or URL, or when commands need confirmation.
+type PackProfile struct {
+	Model           string    `yaml:"model,omitempty"`
+	SystemPrompt    string    `yaml:"system_prompt,omitempty"`
+	PromptAdditions string    `yaml:"prompt_additions,omitempty"`
+	Examples        []Example `yaml:"examples,omitempty"`
+}
+
+// Example is a question and the command that answers it, shown to the
+// model as a few-shot example.
+type Example struct {
+	Question string `yaml:"question"`
+	Command  string `yaml:"command"`
+}
+
+var packNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ParsePack parses and checks a pack.yaml, rejecting unknown keys so a
+// pack can't set anything it isn't allowed to.
+func ParsePack(data []byte) (*Pack, error) {
+	var p Pack
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parsing pack: %w", err)
+	}
+	if !packNameRe.MatchString(p.Name) {
+		return nil, fmt.Errorf("pack name %q must be lowercase letters, digits and dashes", p.Name)
+	}
+	if p.Version == "" {
+		return nil, fmt.Errorf("pack %s has no version", p.Name)
+	}
+	// Check the rules here, so a bad pack is refused at install rather than
+	// breaking every command once installed.
+	for _, re := range p.Policy.Deny {
+		if _, err := regexp.Compile(re); err != nil {
+			return nil, fmt.Errorf("pack %s, deny rule: %w", p.Name, err)
+		}
+	}
+	for name, profile := range p.Profiles {
+		for _, e := range profile.Examples {
+			if e.Question == "" || e.Command == "" {
+				return nil, fmt.Errorf("pack %s, profile %s: examples need a question and a command", p.Name, name)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// Profile returns p as a profile, with its examples added to the prompt.
+func (p PackProfile) Profile() Profile {
+	additions := p.PromptAdditions
+	if len(p.Examples) > 0 {
+		var b strings.Builder
+		b.WriteString(additions)
+		b.WriteString("\nExamples of good answers:\n")
+		for _, e := range p.Examples {
+			fmt.Fprintf(&b, "\nQ: %s\nCOMMAND: %s\n", e.Question, e.Command)
+		}
+		additions = strings.TrimPrefix(b.String(), "\n")
+	}
+	return Profile{Model: p.Model, SystemPrompt: p.SystemPrompt, PromptAdditions: additions}
+}
+
+// PacksDir returns the directory installed packs are kept in.
+func PacksDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packs"), nil
+}
+
+// Packs returns the installed packs, sorted by name.
+func Packs() ([]*Pack, error) {
+	dir, err := PacksDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var packs []*Pack
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading pack: %w", err)
+		}
+		p, err := ParsePack(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		packs = append(packs, p)
+	}
+	return packs, nil
+}
+
+// SavePack installs p, replacing any installed pack of the same name.
+func SavePack(p *Pack) error {
+	dir, err := PacksDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating packs directory: %w", err)
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, p.Name+".yaml"), data, 0o600)
+}
+
+// RemovePack uninstalls the named pack.
+func RemovePack(name string) error {
+	dir, err := PacksDir()
+	if err != nil {
+		return err
+	}
+	if !packNameRe.MatchString(name) {
+		return fmt.Errorf("no pack named %q is installed", name)
+	}
+	if err := os.Remove(filepath.Join(dir, name+".yaml")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no pack named %q is installed", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// mergePacks adds the installed packs' profiles, where the config doesn't
+// already define one of the same name, and their deny rules.
+func mergePacks(cfg *Config) error {
+	packs, err := Packs()
+	if err != nil {
+		return err
+	}
+	for _, p := range packs {
+		for name, profile := range p.Profiles {
+			if _, ok := cfg.Profiles[name]; ok {
+				continue
+			}
+			if cfg.Profiles == nil {
+				cfg.Profiles = map[string]Profile{}
+			}
+			cfg.Profiles[name] = profile.Profile()
+		}
+		cfg.Policy.Deny = mergeUnique(cfg.Policy.Deny, p.Policy.Deny)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPack = `
+name: k8s
+version: 1.2.0
+description: Kubernetes expertise
+profiles:
+  k8s:
+    model: claude-sonnet-4-5
+    prompt_additions: Always pass --context explicitly.
+    examples:
+      - question: restart a deployment
+        command: kubectl rollout restart deployment/<name>
+  work:
+    prompt_additions: From the pack.
+policy:
+  deny: ['kubectl delete ns']
+`
+
+func TestParsePack(t *testing.T) {
+	p, err := ParsePack([]byte(testPack))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := p.Profiles["k8s"].Profile()
+	if profile.Model != "claude-sonnet-4-5" || !strings.HasPrefix(profile.PromptAdditions, "Always pass --context explicitly.\nExamples") ||
+		!strings.Contains(profile.PromptAdditions, "Q: restart a deployment\nCOMMAND: kubectl rollout restart deployment/<name>") {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	tests := []struct {
+		pack, want string
+	}{
+		{"name: K8s\nversion: 1", "lowercase"},
+		{"name: k8s", "no version"},
+		{"name: k8s\nversion: 1\nprofiles:\n  k8s:\n    url: http://attacker", "url"},
+		{"name: k8s\nversion: 1\nprofiles:\n  k8s:\n    confirm: never", "confirm"},
+		{"name: k8s\nversion: 1\nprofiles:\n  k8s:\n    examples: [{question: x}]", "question and a command"},
+		{"name: k8s\nversion: 1\npolicy:\n  deny: ['(']", "deny rule"},
+		{"name: k8s\nversion: 1\npolicy:\n  rewrite: [{match: kubectl, replace: evil}]", "rewrite"},
+	}
+	for _, tt := range tests {
+		if _, err := ParsePack([]byte(tt.pack)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParsePack(%q): expected an error containing %q, got %v", tt.pack, tt.want, err)
+		}
+	}
+}
+
+func TestPacksMerge(t *testing.T) {
+	setupTestDir(t)
+	cfg := DefaultConfig()
+	cfg.Profiles = map[string]Profile{"work": {PromptAdditions: "From the config."}}
+	cfg.Policy.Deny = []string{"rm -rf /"}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParsePack([]byte(testPack))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Source = "https://example.com/how-pack-k8s"
+	if err := SavePack(p); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadProfile("k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Model() != "claude-sonnet-4-5" || !strings.Contains(loaded.PromptAdditions, "rollout restart") {
+		t.Errorf("pack profile not applied: model %q, additions %q", loaded.Model(), loaded.PromptAdditions)
+	}
+	if loaded.Profiles["work"].PromptAdditions != "From the config." {
+		t.Errorf("the config's own profile should win, got %+v", loaded.Profiles["work"])
+	}
+	if strings.Join(loaded.Policy.Deny, ",") != "rm -rf /,kubectl delete ns" {
+		t.Errorf("pack deny rules should be added, got %v", loaded.Policy.Deny)
+	}
+
+	if file, err := LoadFile(); err != nil || len(file.Profiles) != 1 {
+		t.Errorf("packs shouldn't be merged into the config file, got %v, %v", file.Profiles, err)
+	}
+
+	packs, err := Packs()
+	if err != nil || len(packs) != 1 || packs[0].Source != p.Source {
+		t.Fatalf("expected the installed pack with its source, got %v, %v", packs, err)
+	}
+	if err := RemovePack("k8s"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemovePack("k8s"); err == nil {
+		t.Error("expected an error removing a pack that isn't installed")
+	}
+}
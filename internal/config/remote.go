@@ -0,0 +1,98 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RemoteTTL is how long a fetched config_url document is reused before
+// fetching it again.
+var RemoteTTL = time.Hour
+
+const (
+	remoteTimeout  = 3 * time.Second
+	remoteMaxBytes = 1 << 20
+)
+
+// fetchRemote returns the org config document at source, using a cached copy
+// when it is fresher than RemoteTTL or when the fetch fails.
+//
+// s3://bucket/key URLs are fetched over HTTPS from the bucket's virtual
+// host, so the object must be readable anonymously or via a bucket policy
+// that allows the corporate network. Use a presigned https:// URL otherwise.
+func fetchRemote(source string) ([]byte, error) {
+	cachePath := ""
+	if dir, err := ConfigDir(); err == nil {
+		sum := sha256.Sum256([]byte(source))
+		cachePath = filepath.Join(dir, "remote-config-"+hex.EncodeToString(sum[:6])+".yaml")
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < RemoteTTL {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				return data, nil
+			}
+		}
+	}
+
+	data, fetchErr := download(source)
+	if fetchErr != nil {
+		// Stale cache beats no org policy at all
+		if cachePath != "" {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				return data, nil
+			}
+		}
+		return nil, fmt.Errorf("fetching remote config: %w", fetchErr)
+	}
+
+	if cachePath != "" {
+		_ = os.MkdirAll(filepath.Dir(cachePath), 0o755)
+		_ = os.WriteFile(cachePath, data, 0o600)
+	}
+	return data, nil
+}
+
+func download(source string) ([]byte, error) {
+	if bucketKey, ok := strings.CutPrefix(source, "s3://"); ok {
+		bucket, key, _ := strings.Cut(bucketKey, "/")
+		source = fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
+	}
+	if !secureURL(source) {
+		return nil, fmt.Errorf("config_url must use https:// or s3://, got %q", source)
+	}
+
+	client := &http.Client{Timeout: remoteTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, remoteMaxBytes))
+}
+
+// secureURL reports whether source is https, or plain http to this
+// machine's loopback address, where there's no network to snoop on.
+func secureURL(source string) bool {
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const orgConfig = `provider: openai
+openai:
+  model: gpt-4o-mini
+system_prompt: Company prompt
+prefer:
+  docker: podman
+  grep: rg
+policy:
+  deny:
+    - 'rm -rf /'
+  rewrite:
+    - match: '\bkubectl apply\b'
+      replace: 'kubectl apply --dry-run=client'
+      unless: '--dry-run'
+hooks:
+  pre:
+    - audit-log
+`
+
+func TestRemoteConfigMerge(t *testing.T) {
+	setupTestDir(t)
+
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte(orgConfig))
+	}))
+	defer srv.Close()
+
+	path, _ := Path()
+	local := "config_url: " + srv.URL + "\nopenai:\n  model: gpt-4o\nprefer:\n  grep: ag\npolicy:\n  deny:\n    - 'mkfs'\nhooks:\n  pre: [ticket-check]\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if cfg.Provider != "openai" {
+		t.Errorf("provider should come from org config, got %q", cfg.Provider)
+	}
+	if cfg.OpenAI.Model != "gpt-4o" {
+		t.Errorf("local model should override org model, got %q", cfg.OpenAI.Model)
+	}
+	if cfg.SystemPrompt != "Company prompt" {
+		t.Errorf("system prompt should come from org config, got %q", cfg.SystemPrompt)
+	}
+	if len(cfg.Policy.Deny) != 2 {
+		t.Errorf("deny rules should be merged, got %v", cfg.Policy.Deny)
+	}
+	if cfg.Prefer["docker"] != "podman" || cfg.Prefer["grep"] != "ag" {
+		t.Errorf("tool preferences should merge, with local ones winning, got %v", cfg.Prefer)
+	}
+	if len(cfg.Policy.Rewrite) != 1 || cfg.Policy.Rewrite[0].Unless != "--dry-run" {
+		t.Errorf("rewrite rules should come from org config, got %+v", cfg.Policy.Rewrite)
+	}
+	if len(cfg.Hooks.Pre) != 2 || cfg.Hooks.Pre[0] != "audit-log" || cfg.Hooks.Pre[1] != "ticket-check" {
+		t.Errorf("hooks should be merged, org ones first, got %v", cfg.Hooks.Pre)
+	}
+
+	// Second load within the TTL uses the cache
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected 1 fetch with caching, got %d", hits)
+	}
+
+	// LoadFile must not include the org settings
+	fileOnly, err := LoadFile()
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if fileOnly.SystemPrompt != "" {
+		t.Errorf("LoadFile should not merge remote config, got system prompt %q", fileOnly.SystemPrompt)
+	}
+}
+
+func TestRemoteConfigFallsBackToStaleCache(t *testing.T) {
+	setupTestDir(t)
+
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(orgConfig))
+	}))
+	defer srv.Close()
+
+	// Populate the cache, then age it past the TTL
+	if _, err := fetchRemote(srv.URL); err != nil {
+		t.Fatalf("fetchRemote error: %v", err)
+	}
+	dir, _ := ConfigDir()
+	matches, _ := filepath.Glob(filepath.Join(dir, "remote-config-*.yaml"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one cache file, got %v", matches)
+	}
+	stale := time.Now().Add(-2 * RemoteTTL)
+	if err := os.Chtimes(matches[0], stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(true)
+
+	path, _ := Path()
+	if err := os.WriteFile(path, []byte("config_url: "+srv.URL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.SystemPrompt != "Company prompt" {
+		t.Errorf("expected stale cached org config, got system prompt %q", cfg.SystemPrompt)
+	}
+}
+
+func TestRemoteConfigRejectsPlainHTTP(t *testing.T) {
+	if _, err := download("http://example.com/config.yaml"); err == nil {
+		t.Error("expected plain http config_url to be rejected")
+	}
+}
+
+func TestSecureURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com/how.yaml":           true,
+		"http://localhost:8080/how.yaml":         true,
+		"http://127.0.0.1/how.yaml":              true,
+		"http://[::1]:9000/how.yaml":             true,
+		"http://localhost.attacker.com/how.yaml": false,
+		"http://127.0.0.1.attacker.com/how.yaml": false,
+		"http://localhost@attacker.com/how.yaml": false,
+		"ftp://localhost/how.yaml":               false,
+		"https:///how.yaml":                      false,
+	}
+	for source, want := range cases {
+		if got := secureURL(source); got != want {
+			t.Errorf("secureURL(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
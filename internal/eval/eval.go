@@ -0,0 +1,92 @@
+// Package eval scores suggested commands against an eval set of questions
+// with known right answers.
+package eval
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Case is a question and the command expected for it. Wrong, if set, is a
+// known bad answer.
+type Case struct {
+	Question string `json:"question"`
+	Expected string `json:"expected"`
+	Wrong    string `json:"wrong,omitempty"`
+}
+
+// Result is how one case fared.
+type Result struct {
+	Case
+	Got   string `json:"got,omitempty"`
+	Error string `json:"error,omitempty"`
+	Pass  bool   `json:"pass"`
+	// Failures lists the suite assertions that didn't hold.
+	Failures []string `json:"failures,omitempty"`
+	// Repeated is set when the answer was the known wrong one again.
+	Repeated bool `json:"repeated,omitempty"`
+}
+
+// Score compares the command got for c to the expected one.
+func Score(c Case, got string) Result {
+	r := Result{Case: c, Got: got, Pass: Match(got, c.Expected)}
+	r.Repeated = !r.Pass && c.Wrong != "" && Match(got, c.Wrong)
+	return r
+}
+
+// Summary is the pass rate over a run.
+type Summary struct {
+	Passed   int `json:"passed"`
+	Failed   int `json:"failed"`
+	Errors   int `json:"errors"`
+	Repeated int `json:"repeated"`
+	Total    int `json:"total"`
+}
+
+// Summarize counts the results.
+func Summarize(results []Result) Summary {
+	s := Summary{Total: len(results)}
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			s.Errors++
+		case r.Pass:
+			s.Passed++
+		default:
+			s.Failed++
+		}
+		if r.Repeated {
+			s.Repeated++
+		}
+	}
+	return s
+}
+
+// Rate is the share of cases that passed, from 0 to 1.
+func (s Summary) Rate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Total)
+}
+
+// Match reports whether two commands are the same once spacing, line
+// continuations and trailing separators are normalized.
+func Match(a, b string) bool {
+	return Normalize(a) == Normalize(b)
+}
+
+// Normalize reprints command in a canonical shell form, or collapses its
+// whitespace when it doesn't parse.
+func Normalize(command string) string {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return strings.Join(strings.Fields(command), " ")
+	}
+	var b strings.Builder
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&b, file); err != nil {
+		return strings.Join(strings.Fields(command), " ")
+	}
+	return strings.TrimSpace(b.String())
+}
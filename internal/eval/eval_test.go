@@ -0,0 +1,46 @@
+package eval
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"ls -la", "ls   -la", true},
+		{"du -sh * | sort -h", "du -sh *|sort -h", true},
+		{"cd /tmp && ls;", "cd /tmp &&\n  ls", true},
+		{"ls -la", "ls -al", false},
+		{"echo 'a b'", "echo a b", false},
+		{"echo ((", "echo  ((", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.a, tt.b); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v (%q vs %q)", tt.a, tt.b, got, tt.want, Normalize(tt.a), Normalize(tt.b))
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	c := Case{Question: "list listening ports", Expected: "ss -tlnp", Wrong: "netstat -a"}
+	results := []Result{
+		Score(c, "ss  -tlnp"),
+		Score(c, "netstat -a"),
+		Score(c, "lsof -i"),
+		{Case: c, Error: "LLM request failed"},
+	}
+	if !results[0].Pass || results[1].Pass || !results[1].Repeated || results[2].Repeated {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	s := Summarize(results)
+	if s != (Summary{Passed: 1, Failed: 2, Errors: 1, Repeated: 1, Total: 4}) {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if s.Rate() != 0.25 {
+		t.Errorf("unexpected rate: %v", s.Rate())
+	}
+	if (Summary{}).Rate() != 0 {
+		t.Error("expected a zero rate for no cases")
+	}
+}
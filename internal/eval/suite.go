@@ -0,0 +1,136 @@
+package eval
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/swibrow/how/internal/shell"
+	"github.com/swibrow/how/risk"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of questions with assertions about the commands suggested
+// for them, for checking a system prompt or model before relying on it.
+type Suite struct {
+	Cases []SuiteCase `yaml:"cases"`
+}
+
+// SuiteCase is a question and what the command suggested for it must do.
+type SuiteCase struct {
+	Query  string `yaml:"query"`
+	Expect Expect `yaml:"expect"`
+
+	matches, notMatches *regexp.Regexp
+}
+
+// Expect holds the assertions for a suite case. Every one that is set must
+// hold for the case to pass.
+type Expect struct {
+	// Equals is the exact command, compared like the eval set's answers.
+	Equals string `yaml:"equals,omitempty"`
+	// Matches and NotMatches are regular expressions the command must and
+	// mustn't match.
+	Matches    string `yaml:"matches,omitempty"`
+	NotMatches string `yaml:"not_matches,omitempty"`
+	// Uses and Avoids are tools the command must and mustn't run,
+	// including through sudo, xargs and the like.
+	Uses   []string `yaml:"uses,omitempty"`
+	Avoids []string `yaml:"avoids,omitempty"`
+	// MaxRisk is the highest risk level allowed: safe, caution or
+	// dangerous.
+	MaxRisk string `yaml:"max_risk,omitempty"`
+}
+
+var riskLevels = map[string]risk.Level{"safe": risk.Safe, "caution": risk.Caution, "dangerous": risk.Dangerous}
+
+// LoadSuite reads a suite from a YAML file, rejecting unknown keys, cases
+// without assertions and invalid regular expressions.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading suite: %w", err)
+	}
+	var suite Suite
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&suite); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing suite %s: %w", path, err)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("suite %s has no cases", path)
+	}
+	for i := range suite.Cases {
+		if err := suite.Cases[i].compile(); err != nil {
+			return nil, fmt.Errorf("suite %s, case %d: %w", path, i+1, err)
+		}
+	}
+	return &suite, nil
+}
+
+func (c *SuiteCase) compile() error {
+	if c.Query == "" {
+		return errors.New("query is required")
+	}
+	e := c.Expect
+	if e.Equals == "" && e.Matches == "" && e.NotMatches == "" && len(e.Uses) == 0 && len(e.Avoids) == 0 && e.MaxRisk == "" {
+		return fmt.Errorf("%q has no assertions under expect", c.Query)
+	}
+	if _, ok := riskLevels[e.MaxRisk]; e.MaxRisk != "" && !ok {
+		return fmt.Errorf("max_risk %q must be safe, caution or dangerous", e.MaxRisk)
+	}
+	var err error
+	if e.Matches != "" {
+		if c.matches, err = regexp.Compile(e.Matches); err != nil {
+			return fmt.Errorf("matches: %w", err)
+		}
+	}
+	if e.NotMatches != "" {
+		if c.notMatches, err = regexp.Compile(e.NotMatches); err != nil {
+			return fmt.Errorf("not_matches: %w", err)
+		}
+	}
+	return nil
+}
+
+// Check tests the command got for c against its assertions, listing each
+// one that fails.
+func (c SuiteCase) Check(got string) Result {
+	var failures []string
+	e := c.Expect
+	if e.Equals != "" && !Match(got, e.Equals) {
+		failures = append(failures, "want: "+e.Equals)
+	}
+	if c.matches != nil && !c.matches.MatchString(got) {
+		failures = append(failures, fmt.Sprintf("doesn't match /%s/", e.Matches))
+	}
+	if c.notMatches != nil && c.notMatches.MatchString(got) {
+		failures = append(failures, fmt.Sprintf("matches /%s/", e.NotMatches))
+	}
+	if len(e.Uses) > 0 || len(e.Avoids) > 0 {
+		tools, err := shell.Tools(got)
+		if err != nil {
+			failures = append(failures, "doesn't parse, so its tools are unknown")
+		}
+		for _, tool := range e.Uses {
+			if err == nil && !slices.Contains(tools, tool) {
+				failures = append(failures, "doesn't use "+tool)
+			}
+		}
+		for _, tool := range e.Avoids {
+			if slices.Contains(tools, tool) {
+				failures = append(failures, "uses "+tool)
+			}
+		}
+	}
+	if max, ok := riskLevels[e.MaxRisk]; ok {
+		if level := risk.Classify(got).Level; level > max {
+			failures = append(failures, fmt.Sprintf("is %s, above %s", level, e.MaxRisk))
+		}
+	}
+	return Result{Case: Case{Question: c.Query}, Got: got, Pass: len(failures) == 0, Failures: failures}
+}
@@ -0,0 +1,73 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSuite(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "suite.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSuiteCheck(t *testing.T) {
+	suite, err := LoadSuite(writeSuite(t, `
+cases:
+  - query: tar a directory but exclude node_modules
+    expect:
+      matches: ^tar\s
+      uses: [tar]
+      avoids: [sudo]
+      max_risk: caution
+  - query: list listening ports
+    expect:
+      equals: ss -tlnp
+      not_matches: netstat
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		c    int
+		got  string
+		want []string
+	}{
+		{0, "tar --exclude=node_modules -czf dir.tgz dir", nil},
+		{0, "sudo tar -czf dir.tgz dir", []string{"doesn't match /^tar\\s/", "uses sudo"}},
+		{0, "zip -r dir.zip dir", []string{"doesn't match /^tar\\s/", "doesn't use tar"}},
+		{0, "tar -czf dir.tgz dir && rm -rf dir", []string{"is dangerous, above caution"}},
+		{1, "ss  -tlnp", nil},
+		{1, "netstat -tlnp", []string{"want: ss -tlnp", "matches /netstat/"}},
+	}
+	for _, tt := range tests {
+		r := suite.Cases[tt.c].Check(tt.got)
+		if r.Pass != (len(tt.want) == 0) || strings.Join(r.Failures, "; ") != strings.Join(tt.want, "; ") {
+			t.Errorf("Check(%q): got pass=%v %q, want %q", tt.got, r.Pass, r.Failures, tt.want)
+		}
+	}
+}
+
+func TestLoadSuiteErrors(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"cases: []", "no cases"},
+		{"cases:\n  - expect: {uses: [tar]}", "query is required"},
+		{"cases:\n  - query: x", "no assertions"},
+		{"cases:\n  - query: x\n    expect: {matches: '('}", "matches"},
+		{"cases:\n  - query: x\n    expect: {max_risk: low}", "max_risk"},
+		{"cases:\n  - query: x\n    expect: {usess: [tar]}", "usess"},
+	}
+	for _, tt := range tests {
+		if _, err := LoadSuite(writeSuite(t, tt.content)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadSuite(%q): expected an error containing %q, got %v", tt.content, tt.want, err)
+		}
+	}
+}
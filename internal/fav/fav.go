@@ -0,0 +1,150 @@
+// Package fav keeps a library of favorite commands in a YAML file and syncs
+// it with a git repository or S3 bucket shared by a team.
+package fav
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the name of the library, in the config directory and at the
+// root of a shared repository.
+const File = "favorites.yaml"
+
+// Favorite is a saved command.
+type Favorite struct {
+	Name        string `yaml:"name"`
+	Command     string `yaml:"command"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Library is a set of favorites with unique names.
+type Library struct {
+	Favorites []Favorite `yaml:"favorites"`
+}
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidName reports whether name can name a favorite: letters, digits, and
+// . _ - after the first character.
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// Parse reads a library, rejecting unknown keys and invalid or repeated
+// names.
+func Parse(data []byte) (*Library, error) {
+	lib := &Library{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(lib); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parsing favorites: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, f := range lib.Favorites {
+		switch {
+		case !ValidName(f.Name):
+			return nil, fmt.Errorf("parsing favorites: invalid name %q", f.Name)
+		case seen[f.Name]:
+			return nil, fmt.Errorf("parsing favorites: %s appears twice", f.Name)
+		case strings.TrimSpace(f.Command) == "":
+			return nil, fmt.Errorf("parsing favorites: %s has no command", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return lib, nil
+}
+
+// Marshal returns the library as YAML, sorted by name so shared copies
+// diff cleanly.
+func (l *Library) Marshal() ([]byte, error) {
+	sort.Slice(l.Favorites, func(i, j int) bool { return l.Favorites[i].Name < l.Favorites[j].Name })
+	return yaml.Marshal(l)
+}
+
+// Load reads the library at path; a missing file is an empty library.
+func Load(path string) (*Library, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Library{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Save writes the library to path.
+func (l *Library) Save(path string) error {
+	data, err := l.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Get returns the favorite called name.
+func (l *Library) Get(name string) (Favorite, bool) {
+	for _, f := range l.Favorites {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Favorite{}, false
+}
+
+// Add adds f, replacing any favorite with the same name, and reports
+// whether one was replaced.
+func (l *Library) Add(f Favorite) bool {
+	for i := range l.Favorites {
+		if l.Favorites[i].Name == f.Name {
+			l.Favorites[i] = f
+			return true
+		}
+	}
+	l.Favorites = append(l.Favorites, f)
+	return false
+}
+
+// Remove deletes the favorite called name and reports whether there was
+// one.
+func (l *Library) Remove(name string) bool {
+	for i, f := range l.Favorites {
+		if f.Name == name {
+			l.Favorites = append(l.Favorites[:i], l.Favorites[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Merge copies other's favorites into l, replacing those with the same
+// name, and returns the names it added and the names whose command or
+// description it changed.
+func (l *Library) Merge(other *Library) (added, changed []string) {
+	for _, f := range other.Favorites {
+		old, ok := l.Get(f.Name)
+		switch {
+		case !ok:
+			added = append(added, f.Name)
+		case old != f:
+			changed = append(changed, f.Name)
+		default:
+			continue
+		}
+		l.Add(f)
+	}
+	return added, changed
+}
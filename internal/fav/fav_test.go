@@ -0,0 +1,225 @@
+package fav
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	lib, err := Parse([]byte("favorites:\n  - name: pods\n    command: kubectl get pods -A\n    description: Every pod\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := lib.Get("pods"); !ok || f.Command != "kubectl get pods -A" || f.Description != "Every pod" {
+		t.Errorf("Get(pods) = %+v, %v", f, ok)
+	}
+
+	for _, bad := range []string{
+		"favorites:\n  - name: ../x\n    command: ls\n",
+		"favorites:\n  - name: a\n    command: ls\n  - name: a\n    command: pwd\n",
+		"favorites:\n  - name: a\n",
+		"favourites: []\n",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "how", File)
+	lib, err := Load(path)
+	if err != nil || len(lib.Favorites) != 0 {
+		t.Fatalf("Load of a missing file = %+v, %v", lib, err)
+	}
+	if lib.Add(Favorite{Name: "ports", Command: "ss -tlnp"}) {
+		t.Error("Add reported replacing a new favorite")
+	}
+	lib.Add(Favorite{Name: "disk", Command: "df -h"})
+	if !lib.Add(Favorite{Name: "ports", Command: "lsof -i -P"}) {
+		t.Error("Add didn't report replacing ports")
+	}
+	if err := lib.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Favorites) != 2 || loaded.Favorites[0].Name != "disk" || loaded.Favorites[1].Command != "lsof -i -P" {
+		t.Errorf("loaded %+v", loaded.Favorites)
+	}
+	if !loaded.Remove("disk") || loaded.Remove("disk") {
+		t.Error("Remove should succeed once")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	local := &Library{Favorites: []Favorite{
+		{Name: "a", Command: "ls"},
+		{Name: "b", Command: "pwd"},
+	}}
+	shared := &Library{Favorites: []Favorite{
+		{Name: "a", Command: "ls"},
+		{Name: "b", Command: "pwd -P"},
+		{Name: "c", Command: "id"},
+	}}
+	added, changed := local.Merge(shared)
+	if !slices.Equal(added, []string{"c"}) || !slices.Equal(changed, []string{"b"}) {
+		t.Errorf("Merge added %v, changed %v", added, changed)
+	}
+	if f, _ := local.Get("b"); f.Command != "pwd -P" {
+		t.Errorf("b = %q, want the shared command", f.Command)
+	}
+}
+
+func TestGitRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, kv := range [][2]string{{"GIT_AUTHOR_NAME", "t"}, {"GIT_AUTHOR_EMAIL", "t@example.com"}, {"GIT_COMMITTER_NAME", "t"}, {"GIT_COMMITTER_EMAIL", "t@example.com"}} {
+		t.Setenv(kv[0], kv[1])
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	ctx := context.Background()
+	remote := NewRemote("file://" + repo)
+	lib, err := remote.Pull(ctx)
+	if err != nil || len(lib.Favorites) != 0 {
+		t.Fatalf("Pull from an empty repository = %+v, %v", lib, err)
+	}
+
+	lib.Add(Favorite{Name: "pods", Command: "kubectl get pods -A"})
+	if err := remote.Push(ctx, lib, "Add pods"); err != nil {
+		t.Fatal(err)
+	}
+	// Pushing an unchanged library is a no-op rather than an empty commit
+	if err := remote.Push(ctx, lib, "Again"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("git", "-C", repo, "log", "--format=%s").Output()
+	if err != nil || string(out) != "Add pods\n" {
+		t.Errorf("log = %q, %v", out, err)
+	}
+
+	pulled, err := remote.Pull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := pulled.Get("pods"); !ok || f.Command != "kubectl get pods -A" {
+		t.Errorf("pulled %+v", pulled.Favorites)
+	}
+}
+
+// fakeAWS puts an aws CLI on PATH that serves s3api get-object and
+// put-object from dir, honouring --if-match and --if-none-match. When
+// dir/race exists, the first put-object finds it already written by
+// someone else.
+func fakeAWS(t *testing.T, dir string) {
+	t.Helper()
+	script := `#!/bin/sh
+state=` + dir + `
+cmd=$2; shift 2
+body= match= none= out=
+while [ $# -gt 0 ]; do
+  case $1 in
+    --body) body=$2; shift ;;
+    --if-match) match=$2; shift ;;
+    --if-none-match) none=$2; shift ;;
+    --bucket|--key|--content-type|--output) shift ;;
+    *) out=$1 ;;
+  esac
+  shift
+done
+etag() { printf '"%s"' "$(cat "$state/version")"; }
+case $cmd in
+get-object)
+  if [ ! -f "$state/object" ]; then
+    echo "An error occurred (NoSuchKey) when calling the GetObject operation" >&2; exit 254
+  fi
+  cp "$state/object" "$out"
+  printf '{"ETag": "%s"}\n' "$(etag | sed 's/"/\\"/g')" ;;
+put-object)
+  if [ -f "$state/race" ]; then
+    mv "$state/race" "$state/object"; echo $(( $(cat "$state/version" 2>/dev/null || echo 0) + 1 )) > "$state/version"
+  fi
+  if { [ -n "$none" ] && [ -f "$state/object" ]; } || { [ -n "$match" ] && [ "$match" != "$(etag)" ]; }; then
+    echo "An error occurred (PreconditionFailed) when calling the PutObject operation" >&2; exit 254
+  fi
+  cp "$body" "$state/object"; echo $(( $(cat "$state/version" 2>/dev/null || echo 0) + 1 )) > "$state/version"
+  echo '{}' ;;
+esac
+`
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "aws"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestS3RemoteMergesConcurrentPushes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake aws CLI is a shell script")
+	}
+	dir := t.TempDir()
+	fakeAWS(t, dir)
+	ctx := context.Background()
+	remote := NewRemote("s3://team-bucket/how")
+
+	lib, err := remote.Pull(ctx)
+	if err != nil || len(lib.Favorites) != 0 {
+		t.Fatalf("Pull before anything is pushed = %+v, %v", lib, err)
+	}
+	lib.Add(Favorite{Name: "pods", Command: "kubectl get pods -A"})
+	if err := remote.Push(ctx, lib, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone else pushes between our read and our write
+	other := &Library{Favorites: []Favorite{{Name: "pods", Command: "kubectl get pods -A"}, {Name: "disk", Command: "df -h"}}}
+	data, err := other.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "race"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mine := &Library{Favorites: []Favorite{{Name: "ports", Command: "ss -tlnp"}}}
+	if err := remote.Push(ctx, mine, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	shared, err := remote.Pull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pods", "disk", "ports"} {
+		if _, ok := shared.Get(name); !ok {
+			t.Errorf("shared library lost %s: %+v", name, shared.Favorites)
+		}
+	}
+}
+
+func TestNewRemote(t *testing.T) {
+	if r, ok := NewRemote("s3://team-bucket/how").(s3Remote); !ok || r.url != "s3://team-bucket/how/favorites.yaml" {
+		t.Errorf("s3 prefix: %#v", r)
+	}
+	if r, ok := NewRemote("s3://team-bucket/shared.yaml").(s3Remote); !ok || r.url != "s3://team-bucket/shared.yaml" {
+		t.Errorf("s3 key: %#v", r)
+	}
+	if _, ok := NewRemote("git@github.com:example/snippets.git").(gitRemote); !ok {
+		t.Error("expected a git remote")
+	}
+}
@@ -0,0 +1,230 @@
+package fav
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/swibrow/how/internal/gitrepo"
+)
+
+// Remote is a shared copy of a library.
+type Remote interface {
+	// Pull returns the shared library, empty if there isn't one yet.
+	Pull(ctx context.Context) (*Library, error)
+	// Push merges lib into the shared library, replacing favorites with
+	// the same name. If someone else writes the shared library meanwhile,
+	// it is read and merged again rather than overwritten.
+	Push(ctx context.Context, lib *Library, message string) error
+}
+
+// maxPushAttempts bounds how often Push starts again when the shared
+// library changes under it.
+const maxPushAttempts = 5
+
+// errConflict is returned by an attempt to write the shared library after
+// someone else changed it.
+var errConflict = errors.New("the shared library changed while it was being written")
+
+// NewRemote returns the remote at url: an s3://bucket/key URL, where a key
+// not ending in .yaml is a prefix for favorites.yaml, or anything git can
+// clone, with the library at the repository's root.
+func NewRemote(url string) Remote {
+	if rest, ok := strings.CutPrefix(url, "s3://"); ok {
+		if !strings.HasSuffix(rest, ".yaml") && !strings.HasSuffix(rest, ".yml") {
+			rest = strings.TrimSuffix(rest, "/") + "/" + File
+		}
+		return s3Remote{url: "s3://" + rest}
+	}
+	return gitRemote{url: url}
+}
+
+// push runs attempt until it succeeds or fails for a reason other than a
+// conflict, up to maxPushAttempts times.
+func push(url string, attempt func() error) error {
+	for range maxPushAttempts {
+		if err := attempt(); !errors.Is(err, errConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s kept changing while pushing; try again", url)
+}
+
+// gitRemote keeps the library in a git repository, read and written with
+// the git CLI and whatever credentials it's set up with.
+type gitRemote struct {
+	url string
+}
+
+func (r gitRemote) Pull(ctx context.Context) (*Library, error) {
+	dir, err := gitrepo.Clone(ctx, r.url, "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	return Load(filepath.Join(dir, File))
+}
+
+// Push commits the merged library to a fresh clone. A push rejected
+// because the branch moved on is a conflict.
+func (r gitRemote) Push(ctx context.Context, lib *Library, message string) error {
+	return push(r.url, func() error {
+		dir, err := gitrepo.Clone(ctx, r.url, "")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir) //nolint:errcheck
+
+		path := filepath.Join(dir, File)
+		shared, err := Load(path)
+		if err != nil {
+			return err
+		}
+		// Nothing to merge means the repository already has this library
+		if added, changed := shared.Merge(lib); len(added)+len(changed) == 0 {
+			return nil
+		}
+		if err := shared.Save(path); err != nil {
+			return err
+		}
+		if _, err := gitrepo.Run(ctx, dir, "add", File); err != nil {
+			return err
+		}
+		if _, err := gitrepo.Run(ctx, dir, "commit", "--quiet", "-m", message); err != nil {
+			return err
+		}
+		if _, err := gitrepo.Run(ctx, dir, "push", "--quiet", "origin", "HEAD"); err != nil {
+			if strings.Contains(err.Error(), "[rejected]") || strings.Contains(err.Error(), "fetch first") {
+				return errConflict
+			}
+			return fmt.Errorf("pushing to %s: %w", r.url, err)
+		}
+		return nil
+	})
+}
+
+// s3Remote keeps the library in an S3 object, read and written with the
+// aws CLI and its configured credentials. Writes are conditional on the
+// object's ETag, so concurrent pushes don't lose each other's favorites.
+type s3Remote struct {
+	url string
+}
+
+func (r s3Remote) Pull(ctx context.Context) (*Library, error) {
+	lib, _, err := r.get(ctx)
+	return lib, err
+}
+
+func (r s3Remote) Push(ctx context.Context, lib *Library, _ string) error {
+	return push(r.url, func() error {
+		shared, etag, err := r.get(ctx)
+		if err != nil {
+			return err
+		}
+		if added, changed := shared.Merge(lib); len(added)+len(changed) == 0 {
+			return nil
+		}
+		return r.put(ctx, shared, etag)
+	})
+}
+
+// location returns the bucket and key of the object.
+func (r s3Remote) location() (bucket, key string) {
+	bucket, key, _ = strings.Cut(strings.TrimPrefix(r.url, "s3://"), "/")
+	return bucket, key
+}
+
+// get returns the shared library and its ETag, or an empty library and no
+// ETag if the object doesn't exist yet.
+func (r s3Remote) get(ctx context.Context) (*Library, string, error) {
+	tmp, err := os.CreateTemp("", "how-fav-*.yaml")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if err := tmp.Close(); err != nil {
+		return nil, "", err
+	}
+
+	bucket, key := r.location()
+	out, stderr, err := awsCLI(ctx, "s3api", "get-object", "--bucket", bucket, "--key", key, "--output", "json", tmp.Name())
+	if err != nil {
+		if strings.Contains(stderr, "(404)") || strings.Contains(stderr, "NoSuchKey") {
+			return &Library{}, "", nil
+		}
+		return nil, "", awsError(r.url, err, stderr)
+	}
+	var meta struct {
+		ETag string `json:"ETag"`
+	}
+	if err := json.Unmarshal(out, &meta); err != nil || meta.ETag == "" {
+		return nil, "", fmt.Errorf("%s: the aws CLI didn't report the object's ETag", r.url)
+	}
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, "", err
+	}
+	lib, err := Parse(data)
+	return lib, meta.ETag, err
+}
+
+// put writes lib if the object still has etag, or still doesn't exist
+// when etag is "", and returns errConflict if it has changed since.
+func (r s3Remote) put(ctx context.Context, lib *Library, etag string) error {
+	data, err := lib.Marshal()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "how-fav-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	bucket, key := r.location()
+	args := []string{"s3api", "put-object", "--bucket", bucket, "--key", key, "--body", tmp.Name(), "--content-type", "application/yaml", "--output", "json"}
+	if etag == "" {
+		args = append(args, "--if-none-match", "*")
+	} else {
+		args = append(args, "--if-match", etag)
+	}
+	if _, stderr, err := awsCLI(ctx, args...); err != nil {
+		if strings.Contains(stderr, "PreconditionFailed") || strings.Contains(stderr, "(412)") || strings.Contains(stderr, "ConditionalRequestConflict") {
+			return errConflict
+		}
+		return awsError(r.url, err, stderr)
+	}
+	return nil
+}
+
+// awsCLI runs the aws CLI with args, returning its stdout and stderr.
+func awsCLI(ctx context.Context, args ...string) ([]byte, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.String(), err
+}
+
+func awsError(url string, err error, stderr string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("syncing with %s needs the aws CLI", url)
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%s: %s", url, msg)
+	}
+	return fmt.Errorf("%s: %w", url, err)
+}
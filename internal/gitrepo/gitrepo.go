@@ -0,0 +1,58 @@
+// Package gitrepo runs the git CLI for features that keep their data in a
+// git repository, such as prompt packs and shared favorites.
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Run runs git with args in dir, or the current directory when dir is "",
+// and returns its output. Errors include what git printed.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// Never stop to ask for credentials; a private repository needs a
+	// credential helper or SSH key set up already.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Clone makes a shallow clone of url at ref, its default branch when ref
+// is "", in a new temporary directory, which the caller must remove.
+func Clone(ctx context.Context, url, ref string) (string, error) {
+	dir, err := os.MkdirTemp("", "how-git-")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if _, err := Run(ctx, "", append(args, "--", url, dir)...); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("cloning %s: %w", url, err)
+	}
+	return dir, nil
+}
+
+// Head returns the commit checked out in dir.
+func Head(ctx context.Context, dir string) (string, error) {
+	out, err := Run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,52 @@
+package gitrepo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := Run(ctx, repo, append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	git("init", "--quiet")
+	for _, v := range []string{"1", "2"} {
+		if err := os.WriteFile(filepath.Join(repo, "v"), []byte(v), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "v")
+		git("commit", "--quiet", "-m", v)
+		git("tag", "v"+v)
+	}
+
+	dir, err := Clone(ctx, "file://"+repo, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if data, _ := os.ReadFile(filepath.Join(dir, "v")); string(data) != "1" {
+		t.Errorf("clone at v1 has v = %q", data)
+	}
+	head, err := Head(ctx, dir)
+	if want := strings.TrimSpace(git("rev-parse", "v1")); err != nil || head != want {
+		t.Errorf("Head() = %q, %v, want %q", head, err, want)
+	}
+
+	if _, err := Clone(ctx, "file://"+filepath.Join(repo, "missing"), ""); err == nil || !strings.Contains(err.Error(), "cloning") {
+		t.Errorf("expected a clone error with git's message, got %v", err)
+	}
+}
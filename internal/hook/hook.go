@@ -0,0 +1,91 @@
+// Package hook runs the commands configured to run before and after each
+// command how executes, such as audit logging or metrics.
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// PostTimeout bounds each post hook, so a slow metrics endpoint doesn't
+// hold up the shell. Pre hooks aren't bounded since they may prompt, say
+// for a ticket ID.
+var PostTimeout = 10 * time.Second
+
+// Event describes the command a hook runs around. It's passed to hooks as
+// HOW_* environment variables.
+type Event struct {
+	Command string
+	// Host is where the command runs, empty for this machine.
+	Host    string
+	Profile string
+	// ExitCode and Duration are set for post hooks.
+	ExitCode int
+	Duration time.Duration
+}
+
+func (e Event) env(post bool) []string {
+	env := []string{"HOW_COMMAND=" + e.Command, "HOW_HOST=" + e.Host, "HOW_PROFILE=" + e.Profile}
+	if post {
+		env = append(env, "HOW_EXIT_CODE="+strconv.Itoa(e.ExitCode), "HOW_DURATION_MS="+strconv.FormatInt(e.Duration.Milliseconds(), 10))
+	}
+	return env
+}
+
+// BlockedError reports a command stopped by a pre hook that exited
+// non-zero.
+type BlockedError struct {
+	Hook     string
+	ExitCode int
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("command blocked by pre hook %q (exit %d)", e.Hook, e.ExitCode)
+}
+
+// Pre runs hooks in order before ev's command. The first that fails stops
+// the rest and returns a *BlockedError, or the error starting it. Hooks
+// read the terminal and write to out, which should be stderr so how's
+// output stays clean for pipes.
+func Pre(ctx context.Context, hooks []string, ev Event, out io.Writer) error {
+	for _, h := range hooks {
+		err := run(ctx, h, ev.env(false), out)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &BlockedError{Hook: h, ExitCode: exitErr.ExitCode()}
+		}
+		if err != nil {
+			return fmt.Errorf("running pre hook %q: %w", h, err)
+		}
+	}
+	return nil
+}
+
+// Post runs hooks after ev's command, each within PostTimeout. They can't
+// change the outcome, so failures are returned together for logging.
+func Post(ctx context.Context, hooks []string, ev Event, out io.Writer) error {
+	var errs []error
+	for _, h := range hooks {
+		ctx, cancel := context.WithTimeout(ctx, PostTimeout)
+		if err := run(ctx, h, ev.env(true), out); err != nil {
+			errs = append(errs, fmt.Errorf("post hook %q: %w", h, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+func run(ctx context.Context, hook string, env []string, out io.Writer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
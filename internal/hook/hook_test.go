@@ -0,0 +1,51 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPre(t *testing.T) {
+	ev := Event{Command: "rm -rf build", Profile: "work"}
+	var out bytes.Buffer
+	if err := Pre(context.Background(), []string{`echo "audit: $HOW_COMMAND ($HOW_PROFILE)"`, "true"}, ev, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "audit: rm -rf build (work)\n" {
+		t.Errorf("expected the hook to see the command, got %q", got)
+	}
+
+	out.Reset()
+	err := Pre(context.Background(), []string{"echo first", `echo "no ticket" >&2; exit 3`, "echo never"}, ev, &out)
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.ExitCode != 3 || !strings.Contains(blocked.Hook, "no ticket") {
+		t.Fatalf("expected the failing hook to block the command, got %v", err)
+	}
+	if got := out.String(); got != "first\nno ticket\n" {
+		t.Errorf("expected hooks after the failure to be skipped, got %q", got)
+	}
+}
+
+func TestPost(t *testing.T) {
+	orig := PostTimeout
+	PostTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { PostTimeout = orig })
+
+	ev := Event{Command: "make", ExitCode: 2, Duration: 1500 * time.Millisecond}
+	var out bytes.Buffer
+	start := time.Now()
+	err := Post(context.Background(), []string{"exec sleep 5", `echo "$HOW_COMMAND $HOW_EXIT_CODE $HOW_DURATION_MS"`, "exit 1"}, ev, &out)
+	if time.Since(start) > 2*time.Second {
+		t.Error("expected slow post hooks to time out")
+	}
+	if err == nil || !strings.Contains(err.Error(), "exec sleep 5") || !strings.Contains(err.Error(), "exit 1") {
+		t.Errorf("expected both failures to be reported, got %v", err)
+	}
+	if got := out.String(); got != "make 2 1500\n" {
+		t.Errorf("expected every hook to run with the outcome, got %q", got)
+	}
+}
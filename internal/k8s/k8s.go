@@ -0,0 +1,271 @@
+// Package k8s gathers Kubernetes context for troubleshooting prompts and
+// keeps suggested kubectl commands scoped to the intended cluster.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/shell"
+)
+
+// maxEvents bounds how many events are included in the prompt.
+const maxEvents = 20
+
+// Target is the cluster, context and namespace commands should act on.
+type Target struct {
+	Context   string
+	Cluster   string
+	Server    string
+	Namespace string
+}
+
+// kubectl runs kubectl and returns its stdout; replaced in tests.
+var kubectl = func(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("kubectl %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("kubectl %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// Current resolves the target from kubeconfig. Non-empty kubeContext and
+// namespace override the current context and its namespace.
+func Current(ctx context.Context, kubeContext, namespace string) (Target, error) {
+	args := []string{"config", "view", "--minify", "-o", "json"}
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	out, err := kubectl(ctx, args...)
+	if err != nil {
+		return Target{}, err
+	}
+	t, err := parseConfig(out)
+	if err != nil {
+		return Target{}, err
+	}
+	if namespace != "" {
+		t.Namespace = namespace
+	}
+	return t, nil
+}
+
+func parseConfig(data []byte) (Target, error) {
+	var kc struct {
+		CurrentContext string `json:"current-context"`
+		Contexts       []struct {
+			Name    string `json:"name"`
+			Context struct {
+				Cluster   string `json:"cluster"`
+				Namespace string `json:"namespace"`
+			} `json:"context"`
+		} `json:"contexts"`
+		Clusters []struct {
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+	}
+	if err := json.Unmarshal(data, &kc); err != nil {
+		return Target{}, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	if kc.CurrentContext == "" || len(kc.Contexts) == 0 {
+		return Target{}, fmt.Errorf("no current Kubernetes context (set one with kubectl config use-context or pass --context)")
+	}
+
+	t := Target{Context: kc.CurrentContext, Namespace: "default"}
+	c := kc.Contexts[0].Context
+	t.Cluster = c.Cluster
+	if c.Namespace != "" {
+		t.Namespace = c.Namespace
+	}
+	if len(kc.Clusters) > 0 {
+		t.Server = kc.Clusters[0].Cluster.Server
+	}
+	return t, nil
+}
+
+// Event is a Kubernetes event about an object.
+type Event struct {
+	Type    string
+	Reason  string
+	Message string
+	Object  string // kind/name
+	Count   int
+	Last    time.Time
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("%s %s %s: %s", e.Type, e.Reason, e.Object, e.Message)
+	if e.Count > 1 {
+		s += fmt.Sprintf(" (x%d)", e.Count)
+	}
+	return s
+}
+
+// RecentEvents returns the most recent events in the target namespace.
+// Events about objects named in query are preferred, so a question about
+// "the api deployment" sees the api pods' events; without a match, recent
+// warnings are returned.
+func RecentEvents(ctx context.Context, t Target, query string) ([]Event, error) {
+	out, err := kubectl(ctx, "get", "events", "--context", t.Context, "-n", t.Namespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	events, err := parseEvents(out)
+	if err != nil {
+		return nil, err
+	}
+	return relevant(events, query), nil
+}
+
+func parseEvents(data []byte) ([]Event, error) {
+	var list struct {
+		Items []struct {
+			Type           string    `json:"type"`
+			Reason         string    `json:"reason"`
+			Message        string    `json:"message"`
+			Count          int       `json:"count"`
+			LastTimestamp  time.Time `json:"lastTimestamp"`
+			EventTime      time.Time `json:"eventTime"`
+			InvolvedObject struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"involvedObject"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing events: %w", err)
+	}
+	events := make([]Event, 0, len(list.Items))
+	for _, it := range list.Items {
+		last := it.LastTimestamp
+		if last.IsZero() {
+			last = it.EventTime
+		}
+		events = append(events, Event{
+			Type:    it.Type,
+			Reason:  it.Reason,
+			Message: strings.TrimSpace(it.Message),
+			Object:  strings.ToLower(it.InvolvedObject.Kind) + "/" + it.InvolvedObject.Name,
+			Count:   it.Count,
+			Last:    last,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Last.Before(events[j].Last) })
+	return events, nil
+}
+
+var wordRe = regexp.MustCompile(`[a-z0-9][a-z0-9.-]{2,}`)
+
+// relevant picks up to maxEvents of the newest events, preferring those
+// whose object is named in query, then warnings.
+func relevant(events []Event, query string) []Event {
+	words := wordRe.FindAllString(strings.ToLower(query), -1)
+	var named, warnings []Event
+	for _, e := range events {
+		name := e.Object[strings.Index(e.Object, "/")+1:]
+		if slices.ContainsFunc(words, func(w string) bool { return strings.HasPrefix(name, w) }) {
+			named = append(named, e)
+		}
+		if e.Type != "Normal" {
+			warnings = append(warnings, e)
+		}
+	}
+	picked := warnings
+	if len(named) > 0 {
+		picked = named
+	}
+	if len(picked) > maxEvents {
+		picked = picked[len(picked)-maxEvents:]
+	}
+	return picked
+}
+
+// kubectlRe matches kubectl where it starts a command.
+var kubectlRe = regexp.MustCompile(`(?:^|[;&|(]\s*|\$\(\s*|\bsudo\s+|\bxargs\s+(?:-\S+\s+)*|\bwatch\s+(?:-\S+\s+)*)kubectl\b`)
+
+// separatorRe ends a kubectl invocation.
+var separatorRe = regexp.MustCompile(`[;&|)]`)
+
+var (
+	contextFlagRe   = regexp.MustCompile(`(?:^|\s)--context[=\s]`)
+	namespaceFlagRe = regexp.MustCompile(`(?:^|\s)(?:-n[=\s]?\S|--namespace[=\s]|-A\b|--all-namespaces\b)`)
+)
+
+// Scope adds --context and -n for t to every kubectl invocation in command
+// that doesn't already choose them.
+func Scope(command string, t Target) string {
+	matches := kubectlRe.FindAllStringIndex(command, -1)
+	var b strings.Builder
+	prev := 0
+	for _, m := range matches {
+		end := m[1]
+		rest := command[end:]
+		if i := separatorRe.FindStringIndex(rest); i != nil {
+			rest = rest[:i[0]]
+		}
+		var flags []string
+		if !contextFlagRe.MatchString(rest) {
+			flags = append(flags, "--context", quoteArg(t.Context))
+		}
+		if !namespaceFlagRe.MatchString(rest) {
+			flags = append(flags, "-n", quoteArg(t.Namespace))
+		}
+		b.WriteString(command[prev:end])
+		if len(flags) > 0 {
+			b.WriteString(" " + strings.Join(flags, " "))
+		}
+		prev = end
+	}
+	b.WriteString(command[prev:])
+	return b.String()
+}
+
+var safeArgRe = regexp.MustCompile(`^[A-Za-z0-9@%_+=:,./-]+$`)
+
+func quoteArg(s string) string {
+	if safeArgRe.MatchString(s) {
+		return s
+	}
+	return shell.Quote(s)
+}
+
+// guardRules are kubectl operations that need typed confirmation.
+var guardRules = []struct {
+	re     *regexp.Regexp
+	reason string
+}{
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sdelete\b`), "deletes resources"},
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sdelete\b[^;&|]*(?:\s--all\b|\s-A\b|\s--all-namespaces\b)`), "deletes every matching resource"},
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sdelete\s+(?:ns|namespaces?)\b`), "deletes a namespace and everything in it"},
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sscale\b`), "changes the number of replicas"},
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sscale\b[^;&|]*--replicas[=\s]+0\b`), "scales to zero, stopping every pod"},
+	{regexp.MustCompile(`\bkubectl\b[^;&|]*\sdrain\b`), "evicts every pod from a node"},
+}
+
+// Guard returns why command needs explicit confirmation, or nil if it needs
+// none.
+func Guard(command string) []string {
+	var reasons []string
+	for _, r := range guardRules {
+		if r.re.MatchString(command) {
+			reasons = append(reasons, r.reason)
+		}
+	}
+	return reasons
+}
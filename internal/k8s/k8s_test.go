@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var prod = Target{Context: "prod-eu", Namespace: "payments"}
+
+func TestScope(t *testing.T) {
+	cases := []struct {
+		name, command, want string
+	}{
+		{"unscoped", "kubectl get pods", "kubectl --context prod-eu -n payments get pods"},
+		{"namespace given", "kubectl logs -n kube-system coredns", "kubectl --context prod-eu logs -n kube-system coredns"},
+		{"all namespaces", "kubectl get pods -A", "kubectl --context prod-eu get pods -A"},
+		{"fully scoped", "kubectl --context dev --namespace x get svc", "kubectl --context dev --namespace x get svc"},
+		{"pipeline", "kubectl get pods -o name | xargs kubectl delete", "kubectl --context prod-eu -n payments get pods -o name | xargs kubectl --context prod-eu -n payments delete"},
+		{"subshell", "echo $(kubectl get ns -n x)", "echo $(kubectl --context prod-eu get ns -n x)"},
+		{"not kubectl", "echo kubectl", "echo kubectl"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Scope(tc.command, prod); got != tc.want {
+				t.Errorf("Scope(%q) = %q, want %q", tc.command, got, tc.want)
+			}
+		})
+	}
+
+	if got := Scope("kubectl get pods", Target{Context: "arn:aws:eks:eu/my cluster", Namespace: "default"}); got != "kubectl --context 'arn:aws:eks:eu/my cluster' -n default get pods" {
+		t.Errorf("context should be quoted, got %q", got)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	if r := Guard("kubectl --context prod -n payments get pods"); r != nil {
+		t.Errorf("read-only command should not be guarded, got %v", r)
+	}
+	r := Guard("kubectl -n payments scale deploy/api --replicas=0")
+	if len(r) != 2 || !strings.Contains(r[1], "zero") {
+		t.Errorf("expected scale and scale-to-zero reasons, got %v", r)
+	}
+	if r := Guard("kubectl delete ns payments"); len(r) != 2 {
+		t.Errorf("expected delete and namespace reasons, got %v", r)
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	orig := kubectl
+	t.Cleanup(func() { kubectl = orig })
+	var gotArgs []string
+	kubectl = func(ctx context.Context, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(`{"current-context":"prod-eu","contexts":[{"name":"prod-eu","context":{"cluster":"eks-eu","namespace":"payments"}}],"clusters":[{"name":"eks-eu","cluster":{"server":"https://k8s.example.com"}}]}`), nil
+	}
+
+	got, err := Current(context.Background(), "prod-eu", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Target{Context: "prod-eu", Cluster: "eks-eu", Server: "https://k8s.example.com", Namespace: "payments"}
+	if got != want {
+		t.Errorf("Current() = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(strings.Join(gotArgs, " "), "--context prod-eu") {
+		t.Errorf("expected the context override to be passed, got %v", gotArgs)
+	}
+
+	if got, _ := Current(context.Background(), "", "scratch"); got.Namespace != "scratch" {
+		t.Errorf("expected namespace override, got %q", got.Namespace)
+	}
+}
+
+func TestRecentEvents(t *testing.T) {
+	orig := kubectl
+	t.Cleanup(func() { kubectl = orig })
+	kubectl = func(ctx context.Context, args ...string) ([]byte, error) {
+		return []byte(`{"items":[
+			{"type":"Warning","reason":"BackOff","message":"Back-off restarting failed container","count":12,"lastTimestamp":"2026-01-01T10:05:00Z","involvedObject":{"kind":"Pod","name":"api-7d9f-x2"}},
+			{"type":"Normal","reason":"Pulled","message":"Pulled image","count":1,"lastTimestamp":"2026-01-01T10:00:00Z","involvedObject":{"kind":"Pod","name":"api-7d9f-x2"}},
+			{"type":"Warning","reason":"FailedMount","message":"volume not found","count":1,"lastTimestamp":"2026-01-01T10:03:00Z","involvedObject":{"kind":"Pod","name":"worker-1"}}
+		]}`), nil
+	}
+
+	events, err := RecentEvents(context.Background(), prod, "why is the api crashing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Reason != "Pulled" || events[1].String() != "Warning BackOff pod/api-7d9f-x2: Back-off restarting failed container (x12)" {
+		t.Errorf("expected the api pod's events oldest first, got %v", events)
+	}
+
+	events, _ = RecentEvents(context.Background(), prod, "what is broken")
+	if len(events) != 2 || events[0].Reason != "FailedMount" || events[1].Reason != "BackOff" {
+		t.Errorf("expected warnings without a named object, got %v", events)
+	}
+}
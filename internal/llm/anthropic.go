@@ -20,7 +20,7 @@ func NewAnthropic(cfg config.AnthropicConfig) (*Anthropic, error) {
 		return nil, fmt.Errorf("anthropic API key not set (set ANTHROPIC_API_KEY or configure in ~/.config/how/config.yaml)")
 	}
 
-	client := anthropic.NewClient(option.WithAPIKey(cfg.APIKey))
+	client := anthropic.NewClient(option.WithAPIKey(cfg.APIKey), option.WithHTTPClient(httpClient))
 
 	return &Anthropic{
 		client: &client,
@@ -29,6 +29,15 @@ func NewAnthropic(cfg config.AnthropicConfig) (*Anthropic, error) {
 }
 
 func (a *Anthropic) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return a.CompleteWithImages(ctx, systemPrompt, userQuery, nil)
+}
+
+func (a *Anthropic) CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error) {
+	blocks := []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(userQuery)}
+	for _, img := range images {
+		blocks = append(blocks, anthropic.NewImageBlockBase64(img.MediaType, img.base64()))
+	}
+
 	resp, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
 		Model:     anthropic.Model(a.model),
 		MaxTokens: 1024,
@@ -36,12 +45,13 @@ func (a *Anthropic) Complete(ctx context.Context, systemPrompt, userQuery string
 			{Text: systemPrompt},
 		},
 		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(userQuery)),
+			anthropic.NewUserMessage(blocks...),
 		},
 	})
 	if err != nil {
 		return "", fmt.Errorf("anthropic API error: %w", err)
 	}
+	addUsage(ctx, resp.Usage.InputTokens, resp.Usage.OutputTokens)
 
 	var parts []string
 	for _, block := range resp.Content {
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/openai/openai-go"
+)
+
+// ErrNoEmbeddings is returned by Embed for providers without an embeddings
+// API, such as Anthropic.
+var ErrNoEmbeddings = errors.New("provider has no embeddings API")
+
+// Embedder is implemented by providers that can embed text for similarity
+// search.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// Embed returns an embedding of text from p, or ErrNoEmbeddings if p can't
+// make one.
+func Embed(ctx context.Context, p Provider, text string) ([]float32, error) {
+	e, ok := p.(Embedder)
+	if !ok {
+		return nil, ErrNoEmbeddings
+	}
+	return e.Embed(ctx, text)
+}
+
+func (o *OpenAI) Embed(ctx context.Context, text string) ([]float32, error) {
+	return embed(ctx, o.client, o.embeddingModel, text, "openai")
+}
+
+func (o *Ollama) Embed(ctx context.Context, text string) ([]float32, error) {
+	return embed(ctx, o.client, o.embeddingModel, text, "ollama")
+}
+
+// embed calls an OpenAI-compatible embeddings endpoint.
+func embed(ctx context.Context, client *openai.Client, model, text, name string) ([]float32, error) {
+	if model == "" {
+		return nil, ErrNoEmbeddings
+	}
+	resp, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: openai.EmbeddingModel(model),
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String(text)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s embeddings API error: %w", name, err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("%s returned no embeddings", name)
+	}
+	v := make([]float32, len(resp.Data[0].Embedding))
+	for i, f := range resp.Data[0].Embedding {
+		v[i] = float32(f)
+	}
+	return v, nil
+}
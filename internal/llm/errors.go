@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+// Retryable reports whether err, from a provider request, may succeed if
+// tried again later: timeouts, rate limits and server errors.
+func Retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	status := StatusCode(err)
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// StatusCode returns the HTTP status of a provider's error response in
+// err, or 0 if the request got no response.
+func StatusCode(err error) int {
+	var (
+		anthropicErr *anthropic.Error
+		openaiErr    *openai.Error
+	)
+	switch {
+	case errors.As(err, &anthropicErr):
+		return anthropicErr.StatusCode
+	case errors.As(err, &openaiErr):
+		return openaiErr.StatusCode
+	}
+	return 0
+}
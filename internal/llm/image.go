@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/openai/openai-go"
+)
+
+// MaxImageBytes is the largest image accepted, matching the lowest limit
+// among the supported providers.
+const MaxImageBytes = 5 << 20
+
+// ErrNoVision is returned when images are sent to a provider that can't
+// accept them.
+var ErrNoVision = errors.New("provider does not support image input")
+
+// Image is a picture attached to a query, such as a screenshot of an error.
+type Image struct {
+	MediaType string
+	Data      []byte
+}
+
+// VisionProvider is implemented by providers that accept images alongside
+// the query. Whether a request succeeds still depends on the model.
+type VisionProvider interface {
+	CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error)
+}
+
+// supportedImageTypes are the formats every vision provider accepts.
+var supportedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// NewImage checks that data is a supported image and detects its type.
+func NewImage(data []byte) (Image, error) {
+	if len(data) == 0 {
+		return Image{}, errors.New("image is empty")
+	}
+	if len(data) > MaxImageBytes {
+		return Image{}, fmt.Errorf("image is %d bytes, larger than the %d byte limit", len(data), MaxImageBytes)
+	}
+	mediaType := http.DetectContentType(data)
+	if !supportedImageTypes[mediaType] {
+		return Image{}, fmt.Errorf("unsupported image type %s (expected PNG, JPEG, GIF or WebP)", mediaType)
+	}
+	return Image{MediaType: mediaType, Data: data}, nil
+}
+
+// LoadImage reads an image file for attaching to a query.
+func LoadImage(path string) (Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Image{}, fmt.Errorf("reading image: %w", err)
+	}
+	img, err := NewImage(data)
+	if err != nil {
+		return Image{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+func (i Image) base64() string {
+	return base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// imagesProvider attaches the same images to every query.
+type imagesProvider struct {
+	next   Provider
+	images []Image
+}
+
+// WithImages wraps p so every query sent through it carries images. This
+// includes follow-up requests such as re-prompts and repairs.
+func WithImages(p Provider, images []Image) Provider {
+	return &imagesProvider{next: p, images: images}
+}
+
+func (p *imagesProvider) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	v, ok := p.next.(VisionProvider)
+	if !ok {
+		return "", ErrNoVision
+	}
+	return v.CompleteWithImages(ctx, systemPrompt, userQuery, p.images)
+}
+
+// openAIUserMessage builds a user message for the OpenAI-compatible APIs,
+// with images passed as data URLs.
+func openAIUserMessage(query string, images []Image) openai.ChatCompletionMessageParamUnion {
+	if len(images) == 0 {
+		return openai.UserMessage(query)
+	}
+	parts := []openai.ChatCompletionContentPartUnionParam{openai.TextContentPart(query)}
+	for _, img := range images {
+		parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+			URL: "data:" + img.MediaType + ";base64," + img.base64(),
+		}))
+	}
+	return openai.UserMessage(parts)
+}
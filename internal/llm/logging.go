@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/logging"
+)
+
+// loggingProvider records each request's latency and outcome.
+type loggingProvider struct {
+	next Provider
+	name string
+}
+
+// WithLogging wraps p so every request is logged with its request ID,
+// provider name and latency.
+func WithLogging(p Provider, name string) Provider {
+	return &loggingProvider{next: p, name: name}
+}
+
+func (l *loggingProvider) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return l.log(ctx, systemPrompt, userQuery, nil, l.next.Complete)
+}
+
+func (l *loggingProvider) CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error) {
+	v, ok := l.next.(VisionProvider)
+	if !ok {
+		return "", ErrNoVision
+	}
+	return l.log(ctx, systemPrompt, userQuery, images, func(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+		return v.CompleteWithImages(ctx, systemPrompt, userQuery, images)
+	})
+}
+
+func (l *loggingProvider) Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error {
+	_, err := l.log(ctx, systemPrompt, userQuery, nil, func(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+		var resp strings.Builder
+		err := Stream(ctx, l.next, systemPrompt, userQuery, func(text string) {
+			resp.WriteString(text)
+			onText(text)
+		})
+		return resp.String(), err
+	})
+	return err
+}
+
+func (l *loggingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	start := time.Now()
+	v, err := Embed(ctx, l.next, text)
+	if err != nil && !errors.Is(err, ErrNoEmbeddings) {
+		slog.Warn("embedding request failed", "provider", l.name, "latency_ms", time.Since(start).Milliseconds(), "error", err)
+	} else if err == nil {
+		slog.Debug("embedding response", "provider", l.name, "latency_ms", time.Since(start).Milliseconds(), "dimensions", len(v))
+	}
+	return v, err
+}
+
+func (l *loggingProvider) log(ctx context.Context, systemPrompt, userQuery string, images []Image, complete func(context.Context, string, string) (string, error)) (string, error) {
+	ctx = logging.WithRequestID(ctx)
+	log := slog.With("request_id", logging.RequestID(ctx), "provider", l.name)
+
+	log.Debug("provider request", "system_prompt_bytes", len(systemPrompt), "images", len(images), "query", userQuery)
+	start := time.Now()
+	resp, err := complete(ctx, systemPrompt, userQuery)
+	latency := time.Since(start)
+
+	if err != nil {
+		log.Warn("provider request failed", "latency_ms", latency.Milliseconds(), "error", err)
+		return "", err
+	}
+	log.Info("provider response", "latency_ms", latency.Milliseconds(), "response_bytes", len(resp))
+	log.Debug("provider response body", "response", resp)
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// meteredProvider reports the tokens each request uses.
+type meteredProvider struct {
+	next   Provider
+	record func(input, output int64)
+}
+
+// WithMeter wraps p so the input and output tokens of every request are
+// passed to record. Requests whose provider doesn't report usage, such as
+// streamed answers, are estimated from their text.
+func WithMeter(p Provider, record func(input, output int64)) Provider {
+	return &meteredProvider{next: p, record: record}
+}
+
+func (m *meteredProvider) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return m.meter(ctx, systemPrompt, userQuery, m.next.Complete)
+}
+
+func (m *meteredProvider) CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error) {
+	v, ok := m.next.(VisionProvider)
+	if !ok {
+		return "", ErrNoVision
+	}
+	return m.meter(ctx, systemPrompt, userQuery, func(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+		return v.CompleteWithImages(ctx, systemPrompt, userQuery, images)
+	})
+}
+
+func (m *meteredProvider) Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error {
+	_, err := m.meter(ctx, systemPrompt, userQuery, func(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+		var resp strings.Builder
+		err := Stream(ctx, m.next, systemPrompt, userQuery, func(text string) {
+			resp.WriteString(text)
+			onText(text)
+		})
+		return resp.String(), err
+	})
+	return err
+}
+
+func (m *meteredProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return Embed(ctx, m.next, text)
+}
+
+func (m *meteredProvider) meter(ctx context.Context, systemPrompt, userQuery string, complete func(context.Context, string, string) (string, error)) (string, error) {
+	reqCtx, usage := WithUsage(ctx)
+	resp, err := complete(reqCtx, systemPrompt, userQuery)
+	input, output := usage.Tokens()
+	switch {
+	case usage.Requests() > 0:
+		addUsage(ctx, input, output)
+	case err != nil:
+		return "", err
+	default:
+		input = EstimateTokens(systemPrompt) + EstimateTokens(userQuery)
+		output = EstimateTokens(resp)
+	}
+	m.record(input, output)
+	return resp, err
+}
@@ -10,33 +10,41 @@ import (
 )
 
 type Ollama struct {
-	client *openai.Client
-	model  string
+	client         *openai.Client
+	model          string
+	embeddingModel string
 }
 
 func NewOllama(cfg config.OllamaConfig) (*Ollama, error) {
 	client := openai.NewClient(
 		option.WithBaseURL(cfg.URL),
 		option.WithAPIKey("ollama"), // Ollama doesn't need a real key
+		option.WithHTTPClient(httpClient),
 	)
 
 	return &Ollama{
-		client: &client,
-		model:  cfg.Model,
+		client:         &client,
+		model:          cfg.Model,
+		embeddingModel: cfg.EmbeddingModel,
 	}, nil
 }
 
 func (o *Ollama) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return o.CompleteWithImages(ctx, systemPrompt, userQuery, nil)
+}
+
+func (o *Ollama) CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error) {
 	resp, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Model: o.model,
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userQuery),
+			openAIUserMessage(userQuery, images),
 		},
 	})
 	if err != nil {
 		return "", fmt.Errorf("ollama API error: %w", err)
 	}
+	addUsage(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("ollama returned no choices")
@@ -10,8 +10,9 @@ import (
 )
 
 type OpenAI struct {
-	client *openai.Client
-	model  string
+	client         *openai.Client
+	model          string
+	embeddingModel string
 }
 
 func NewOpenAI(cfg config.OpenAIConfig) (*OpenAI, error) {
@@ -19,25 +20,31 @@ func NewOpenAI(cfg config.OpenAIConfig) (*OpenAI, error) {
 		return nil, fmt.Errorf("openai API key not set (set OPENAI_API_KEY or configure in ~/.config/how/config.yaml)")
 	}
 
-	client := openai.NewClient(option.WithAPIKey(cfg.APIKey))
+	client := openai.NewClient(option.WithAPIKey(cfg.APIKey), option.WithHTTPClient(httpClient))
 
 	return &OpenAI{
-		client: &client,
-		model:  cfg.Model,
+		client:         &client,
+		model:          cfg.Model,
+		embeddingModel: cfg.EmbeddingModel,
 	}, nil
 }
 
 func (o *OpenAI) Complete(ctx context.Context, systemPrompt, userQuery string) (string, error) {
+	return o.CompleteWithImages(ctx, systemPrompt, userQuery, nil)
+}
+
+func (o *OpenAI) CompleteWithImages(ctx context.Context, systemPrompt, userQuery string, images []Image) (string, error) {
 	resp, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Model: o.model,
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userQuery),
+			openAIUserMessage(userQuery, images),
 		},
 	})
 	if err != nil {
 		return "", fmt.Errorf("openai API error: %w", err)
 	}
+	addUsage(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("openai returned no choices")
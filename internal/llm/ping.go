@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	anthropicoption "github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/openai/openai-go/option"
+	"github.com/swibrow/how/internal/config"
+)
+
+// Ping looks up cfg's model with its provider, which checks that the
+// provider is reachable, accepts the API key and serves the model without
+// spending any tokens. The request isn't retried, so its latency is that
+// of a single round trip.
+func Ping(ctx context.Context, cfg *config.Config) error {
+	switch cfg.Provider {
+	case "anthropic":
+		a, err := NewAnthropic(cfg.Anthropic)
+		if err != nil {
+			return err
+		}
+		if _, err := a.client.Models.Get(ctx, a.model, anthropic.ModelGetParams{}, anthropicoption.WithMaxRetries(0)); err != nil {
+			return fmt.Errorf("anthropic API error: %w", err)
+		}
+	case "openai":
+		o, err := NewOpenAI(cfg.OpenAI)
+		if err != nil {
+			return err
+		}
+		if _, err := o.client.Models.Get(ctx, o.model, option.WithMaxRetries(0)); err != nil {
+			return fmt.Errorf("openai API error: %w", err)
+		}
+	case "ollama":
+		o, err := NewOllama(cfg.Ollama)
+		if err != nil {
+			return err
+		}
+		if _, err := o.client.Models.Get(ctx, o.model, option.WithMaxRetries(0)); err != nil {
+			return fmt.Errorf("ollama API error: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown provider: %s", cfg.Provider)
+	}
+	return nil
+}
@@ -12,16 +12,25 @@ type Provider interface {
 	Complete(ctx context.Context, systemPrompt, userQuery string) (string, error)
 }
 
-// NewProvider creates a provider based on the config.
+// NewProvider creates a provider based on the config. Requests made
+// through it are logged via slog.
 func NewProvider(cfg *config.Config) (Provider, error) {
+	var (
+		p   Provider
+		err error
+	)
 	switch cfg.Provider {
 	case "anthropic":
-		return NewAnthropic(cfg.Anthropic)
+		p, err = NewAnthropic(cfg.Anthropic)
 	case "openai":
-		return NewOpenAI(cfg.OpenAI)
+		p, err = NewOpenAI(cfg.OpenAI)
 	case "ollama":
-		return NewOllama(cfg.Ollama)
+		p, err = NewOllama(cfg.Ollama)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", cfg.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return WithLogging(p, cfg.Provider), nil
 }
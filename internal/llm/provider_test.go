@@ -1,10 +1,21 @@
 package llm
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
 	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/logging"
 )
 
 func TestNewProviderUnknown(t *testing.T) {
@@ -58,3 +69,317 @@ func TestNewProviderOllama(t *testing.T) {
 		t.Fatal("expected non-nil provider for ollama")
 	}
 }
+
+type stubProvider struct {
+	ctx context.Context
+}
+
+func (s *stubProvider) Complete(ctx context.Context, _, _ string) (string, error) {
+	s.ctx = ctx
+	return "COMMAND: ls", nil
+}
+
+func TestWithLoggingAddsRequestID(t *testing.T) {
+	stub := &stubProvider{}
+	p := WithLogging(stub, "stub")
+
+	resp, err := p.Complete(context.Background(), "system", "list files")
+	if err != nil {
+		t.Fatalf("Complete error: %v", err)
+	}
+	if resp != "COMMAND: ls" {
+		t.Errorf("response should pass through unchanged, got %q", resp)
+	}
+	if logging.RequestID(stub.ctx) == "" {
+		t.Error("expected a request ID in the provider context")
+	}
+}
+
+type visionStub struct {
+	stubProvider
+	images []Image
+}
+
+func (s *visionStub) CompleteWithImages(ctx context.Context, _, _ string, images []Image) (string, error) {
+	s.images = images
+	return "COMMAND: ls", nil
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestWithImages(t *testing.T) {
+	img, err := NewImage(pngHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.MediaType != "image/png" {
+		t.Errorf("expected image/png, got %s", img.MediaType)
+	}
+
+	stub := &visionStub{}
+	p := WithImages(WithLogging(stub, "stub"), []Image{img})
+	if _, err := p.Complete(context.Background(), "system", "fix this"); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.images) != 1 {
+		t.Errorf("expected the image to reach the provider, got %d images", len(stub.images))
+	}
+
+	p = WithImages(WithLogging(&stubProvider{}, "stub"), []Image{img})
+	if _, err := p.Complete(context.Background(), "system", "fix this"); !errors.Is(err, ErrNoVision) {
+		t.Errorf("expected ErrNoVision for a text-only provider, got %v", err)
+	}
+}
+
+func TestNewImageRejectsNonImages(t *testing.T) {
+	if _, err := NewImage([]byte("plain text")); err == nil {
+		t.Error("expected an error for non-image data")
+	}
+	if _, err := NewImage(nil); err == nil {
+		t.Error("expected an error for empty data")
+	}
+}
+
+func TestAnthropicSendsImages(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":"COMMAND: ls"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+
+	a, err := NewAnthropic(config.AnthropicConfig{APIKey: "test", Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := NewImage(pngHeader)
+	if _, err := a.CompleteWithImages(context.Background(), "system", "fix this", []Image{img}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"type":"image"`) || !strings.Contains(string(body), `"media_type":"image/png"`) {
+		t.Errorf("expected an image block in the request, got %s", body)
+	}
+}
+
+func TestStreamFallsBackToComplete(t *testing.T) {
+	var got strings.Builder
+	err := Stream(context.Background(), WithLogging(&stubProvider{}, "stub"), "system", "what is a tty", func(s string) { got.WriteString(s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "COMMAND: ls" {
+		t.Errorf("expected the full response, got %q", got.String())
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{"A tty ", "is a terminal."} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"created\":0,\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", chunk)
+		}
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	p, err := NewOllama(config.OllamaConfig{URL: srv.URL, Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []string
+	if err := p.Stream(context.Background(), "system", "what is a tty", func(s string) { chunks = append(chunks, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(chunks, "|") != "A tty |is a terminal." {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+}
+
+func TestOllamaEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5,-0.25]}],"model":"e"}`)
+	}))
+	defer srv.Close()
+
+	p, err := NewOllama(config.OllamaConfig{URL: srv.URL, Model: "m", EmbeddingModel: "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := Embed(context.Background(), WithLogging(p, "ollama"), "list files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 || v[0] != 0.5 || v[1] != -0.25 {
+		t.Errorf("unexpected embedding: %v", v)
+	}
+}
+
+func TestEmbedUnsupported(t *testing.T) {
+	p := WithLogging(&stubProvider{}, "stub")
+	if _, err := Embed(context.Background(), p, "x"); !errors.Is(err, ErrNoEmbeddings) {
+		t.Errorf("expected ErrNoEmbeddings, got %v", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"m","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"COMMAND: ls"}}],"usage":{"prompt_tokens":120,"completion_tokens":8,"total_tokens":128}}`)
+	}))
+	defer srv.Close()
+
+	p, err := NewOllama(config.OllamaConfig{URL: srv.URL, Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, usage := WithUsage(context.Background())
+	for range 2 {
+		if _, err := WithLogging(p, "ollama").Complete(ctx, "sys", "list files"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if in, out := usage.Tokens(); in != 240 || out != 16 || usage.Requests() != 2 {
+		t.Errorf("unexpected usage: %d in, %d out, %d requests", in, out, usage.Requests())
+	}
+
+	// Contexts without a Usage are unaffected
+	if _, err := p.Complete(context.Background(), "sys", "list files"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithMeter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"m","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"COMMAND: ls"}}],"usage":{"prompt_tokens":120,"completion_tokens":8,"total_tokens":128}}`)
+	}))
+	defer srv.Close()
+
+	p, err := NewOllama(config.OllamaConfig{URL: srv.URL, Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in, out int64
+	record := func(input, output int64) { in, out = in+input, out+output }
+
+	ctx, usage := WithUsage(context.Background())
+	if _, err := WithMeter(p, record).Complete(ctx, "sys", "list files"); err != nil {
+		t.Fatal(err)
+	}
+	if in != 120 || out != 8 {
+		t.Errorf("expected the reported usage to be recorded, got %d in, %d out", in, out)
+	}
+	if i, o := usage.Tokens(); i != 120 || o != 8 || usage.Requests() != 1 {
+		t.Errorf("expected the caller's usage to still be counted, got %d in, %d out, %d requests", i, o, usage.Requests())
+	}
+
+	// Providers that don't report usage are estimated from the text
+	in, out = 0, 0
+	if _, err := WithMeter(&stubProvider{}, record).Complete(context.Background(), "system prompt", "list files"); err != nil {
+		t.Fatal(err)
+	}
+	if in != 7 || out != 3 {
+		t.Errorf("expected estimated usage, got %d in, %d out", in, out)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for text, want := range map[string]int64{
+		"":               0,
+		"ls":             1,
+		"list files":     3,
+		"héllo wörld":    3,
+		"find . -name x": 4,
+	} {
+		if got := EstimateTokens(text); got != want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("LLM request failed: %w", &anthropic.Error{StatusCode: 429}), true},
+		{&anthropic.Error{StatusCode: 529}, true},
+		{&openai.Error{StatusCode: 503}, true},
+		{&openai.Error{StatusCode: 401}, false},
+		{fmt.Errorf("waiting: %w", context.DeadlineExceeded), true},
+		{errors.New("unknown provider: nope"), false},
+	}
+	for _, c := range cases {
+		if got := Retryable(c.err); got != c.want {
+			t.Errorf("Retryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/models/llama3":
+			_, _ = io.WriteString(w, `{"id":"llama3","object":"model","created":0,"owned_by":"library"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"message":"model not found"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Provider = "ollama"
+	cfg.Ollama.URL = srv.URL
+	if err := Ping(context.Background(), cfg); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+
+	requests = 0
+	cfg.Ollama.Model = "missing"
+	err := Ping(context.Background(), cfg)
+	if StatusCode(err) != http.StatusNotFound {
+		t.Errorf("expected a 404 for a missing model, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request without retries, got %d", requests)
+	}
+}
+
+func TestProvidersReuseConnections(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","created":0,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"COMMAND: ls"},"finish_reason":"stop"}]}`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	// Separate providers share the client and its idle connections
+	for range 3 {
+		p, err := NewOllama(config.OllamaConfig{URL: srv.URL, Model: "m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Complete(context.Background(), "system", "list files"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("expected requests to share one connection, opened %d", n)
+	}
+}
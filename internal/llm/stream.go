@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+// streamMaxTokens allows free-form answers to run longer than commands.
+const streamMaxTokens = 4096
+
+// Streamer is implemented by providers that can deliver a response as it
+// is generated.
+type Streamer interface {
+	Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error
+}
+
+// Stream sends a request through p, calling onText with each piece of the
+// response as it arrives. Providers that can't stream deliver the whole
+// response in one call.
+func Stream(ctx context.Context, p Provider, systemPrompt, userQuery string, onText func(string)) error {
+	if s, ok := p.(Streamer); ok {
+		return s.Stream(ctx, systemPrompt, userQuery, onText)
+	}
+	resp, err := p.Complete(ctx, systemPrompt, userQuery)
+	if err != nil {
+		return err
+	}
+	onText(resp)
+	return nil
+}
+
+func (a *Anthropic) Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error {
+	stream := a.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.model),
+		MaxTokens: streamMaxTokens,
+		System: []anthropic.TextBlockParam{
+			{Text: systemPrompt},
+		},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(userQuery)),
+		},
+	})
+	defer stream.Close() //nolint:errcheck
+
+	for stream.Next() {
+		if event, ok := stream.Current().AsAny().(anthropic.ContentBlockDeltaEvent); ok {
+			if delta, ok := event.Delta.AsAny().(anthropic.TextDelta); ok {
+				onText(delta.Text)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("anthropic API error: %w", err)
+	}
+	return nil
+}
+
+func (o *OpenAI) Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error {
+	return streamChat(ctx, o.client, o.model, systemPrompt, userQuery, onText, "openai")
+}
+
+func (o *Ollama) Stream(ctx context.Context, systemPrompt, userQuery string, onText func(string)) error {
+	return streamChat(ctx, o.client, o.model, systemPrompt, userQuery, onText, "ollama")
+}
+
+// streamChat streams a chat completion from an OpenAI-compatible API.
+func streamChat(ctx context.Context, client *openai.Client, model, systemPrompt, userQuery string, onText func(string), name string) error {
+	stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userQuery),
+		},
+	})
+	defer stream.Close() //nolint:errcheck
+
+	for stream.Next() {
+		for _, choice := range stream.Current().Choices {
+			if choice.Delta.Content != "" {
+				onText(choice.Delta.Content)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("%s API error: %w", name, err)
+	}
+	return nil
+}
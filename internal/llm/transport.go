@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// httpClient is shared by every provider, so a long-running process such
+// as how serve, how daemon or how batch keeps its connections to each API
+// open between requests instead of paying for a new TLS handshake each
+// time.
+var httpClient = &http.Client{Transport: newTransport()}
+
+// newTransport tunes http.DefaultTransport for reuse: more idle
+// connections per host, for concurrent batch requests over HTTP/1.1 (as
+// Ollama and some proxies speak); a longer idle timeout, for a daemon that
+// sits between questions; TLS session resumption, for when a connection
+// does have to be replaced; and HTTP/2 health checks, so a connection
+// that died while the machine slept is noticed before a request hangs on
+// it.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 100
+	t.MaxIdleConnsPerHost = 16
+	t.IdleConnTimeout = 5 * time.Minute
+	t.TLSClientConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(64)}
+	t.HTTP2 = &http.HTTP2Config{SendPingTimeout: 30 * time.Second, PingTimeout: 10 * time.Second}
+	return t
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"unicode/utf8"
+)
+
+// Usage totals the tokens used by requests made with a context from
+// WithUsage. It's safe for concurrent use.
+type Usage struct {
+	mu           sync.Mutex
+	inputTokens  int64
+	outputTokens int64
+	requests     int
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context whose requests add their token counts to the
+// returned Usage.
+func WithUsage(ctx context.Context) (context.Context, *Usage) {
+	u := &Usage{}
+	return context.WithValue(ctx, usageKey{}, u), u
+}
+
+// Tokens returns the input and output tokens used so far.
+func (u *Usage) Tokens() (input, output int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.inputTokens, u.outputTokens
+}
+
+// Requests returns how many requests reported usage.
+func (u *Usage) Requests() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.requests
+}
+
+// addUsage records a response's token counts on ctx's Usage, if any.
+func addUsage(ctx context.Context, input, output int64) {
+	u, ok := ctx.Value(usageKey{}).(*Usage)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	u.inputTokens += input
+	u.outputTokens += output
+	u.requests++
+	u.mu.Unlock()
+}
+
+// charsPerToken approximates how many characters of English text or shell
+// code make up one token across the supported providers' tokenizers.
+const charsPerToken = 4
+
+// EstimateTokens roughly counts the tokens text will use as input, without
+// a provider round trip. It's meant for previews, not billing.
+func EstimateTokens(text string) int64 {
+	n := int64(utf8.RuneCountInString(text))
+	return (n + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup installs the default slog logger. Logs go to stderr as text, or to
+// file as JSON when file is non-empty. The returned function closes the
+// log file, if any.
+func Setup(level, file string) (func() error, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		w       io.Writer = os.Stderr
+		closeFn           = func() error { return nil }
+		handler slog.Handler
+	)
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		w, closeFn = f, f.Close
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return closeFn, nil
+}
+
+// ParseLevel converts debug, info, warn or error into a slog level.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "", "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", level)
+	}
+}
+
+type requestIDKey struct{}
+
+// NewRequestID returns a short random identifier for correlating log lines.
+func NewRequestID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a context carrying a new request ID, or ctx
+// unchanged if it already has one.
+func WithRequestID(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, NewRequestID())
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
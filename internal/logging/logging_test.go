@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"":      slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	for in, want := range cases {
+		got, err := ParseLevel(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestSetupLogFile(t *testing.T) {
+	old := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	path := filepath.Join(t.TempDir(), "how.log")
+	closeFn, err := Setup("debug", path)
+	if err != nil {
+		t.Fatalf("Setup error: %v", err)
+	}
+	slog.Debug("hello", "request_id", "abc")
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"msg":"hello"`) || !strings.Contains(string(data), `"request_id":"abc"`) {
+		t.Errorf("expected JSON log line, got: %s", data)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	if RequestID(ctx) != "" {
+		t.Error("expected no request ID in a fresh context")
+	}
+
+	ctx = WithRequestID(ctx)
+	id := RequestID(ctx)
+	if len(id) != 12 {
+		t.Errorf("expected 12-char request ID, got %q", id)
+	}
+	if RequestID(WithRequestID(ctx)) != id {
+		t.Error("WithRequestID should keep an existing ID")
+	}
+}
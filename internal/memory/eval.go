@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const evalSchema = `
+CREATE TABLE IF NOT EXISTS eval_cases (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    question    TEXT    NOT NULL,
+    expected    TEXT    NOT NULL,
+    wrong       TEXT    NOT NULL DEFAULT '',
+    created_at  TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+`
+
+// EvalCase is a question with the command the user says is right, and
+// optionally the wrong answer they were given.
+type EvalCase struct {
+	ID        int64     `json:"id"`
+	Question  string    `json:"question"`
+	Expected  string    `json:"expected"`
+	Wrong     string    `json:"wrong,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddEvalCase adds a question and its right command to the eval set,
+// returning the new case's ID.
+func (s *Store) AddEvalCase(ctx context.Context, question, expected, wrong string) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO eval_cases (question, expected, wrong) VALUES (?, ?, ?)`,
+		question, expected, wrong,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("adding eval case: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// EvalCases returns the whole eval set, oldest first.
+func (s *Store) EvalCases(ctx context.Context) ([]EvalCase, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, question, expected, wrong, created_at FROM eval_cases ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing eval cases: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var cases []EvalCase
+	for rows.Next() {
+		var c EvalCase
+		var createdAt string
+		if err := rows.Scan(&c.ID, &c.Question, &c.Expected, &c.Wrong, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning eval case: %w", err)
+		}
+		c.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		cases = append(cases, c)
+	}
+	return cases, rows.Err()
+}
+
+// DeleteEvalCase removes a case from the eval set. It reports false if no
+// case has that ID.
+func (s *Store) DeleteEvalCase(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM eval_cases WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("deleting eval case: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("checking rows affected: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvalCases(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	id, err := store.AddEvalCase(ctx, "list listening ports", "ss -tlnp", "netstat -a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddEvalCase(ctx, "disk usage", "df -h", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := store.EvalCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 || cases[0].ID != id || cases[0].Expected != "ss -tlnp" || cases[0].Wrong != "netstat -a" {
+		t.Fatalf("expected both cases to survive Clear, got %+v", cases)
+	}
+
+	if ok, err := store.DeleteEvalCase(ctx, id); err != nil || !ok {
+		t.Fatalf("expected the case to be deleted, got %v %v", ok, err)
+	}
+	if ok, _ := store.DeleteEvalCase(ctx, id); ok {
+		t.Error("expected deleting a missing case to report false")
+	}
+	if cases, _ := store.EvalCases(ctx); len(cases) != 1 || cases[0].Question != "disk usage" {
+		t.Errorf("unexpected cases after delete: %+v", cases)
+	}
+}
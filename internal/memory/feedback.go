@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const feedbackSchema = `
+CREATE TABLE IF NOT EXISTS feedback (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    question    TEXT    NOT NULL,
+    command     TEXT    NOT NULL,
+    accepted    INTEGER NOT NULL,
+    tags        TEXT    NOT NULL DEFAULT '',
+    embedding   BLOB,
+    created_at  TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+`
+
+const (
+	// feedbackScanLimit bounds how many recent answers Examples compares
+	// against.
+	feedbackScanLimit = 2000
+
+	// minEmbeddingSimilarity and minKeywordSimilarity are the cosine and
+	// keyword overlap below which past answers aren't relevant.
+	minEmbeddingSimilarity = 0.5
+	minKeywordSimilarity   = 0.3
+)
+
+// Feedback is a thumbs up or down the user gave a command.
+type Feedback struct {
+	ID        int64
+	Question  string
+	Command   string
+	Accepted  bool
+	CreatedAt time.Time
+	// Similarity is how close Question is to the one passed to Examples.
+	Similarity float64
+}
+
+// RecordFeedback stores whether command was a good answer to question.
+// embedding may be nil when the provider can't embed text, in which case
+// the answer is matched by keywords.
+func (s *Store) RecordFeedback(ctx context.Context, question, command string, accepted bool, embedding []float32) error {
+	tags := strings.Join(extractKeywords(question), " ")
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO feedback (question, command, accepted, tags, embedding) VALUES (?, ?, ?, ?, ?)`,
+		question, command, accepted, tags, encodeEmbedding(embedding),
+	)
+	if err != nil {
+		return fmt.Errorf("recording feedback: %w", err)
+	}
+	return nil
+}
+
+// HasFeedback reports whether any feedback has been recorded, so callers
+// can skip embedding a question when there is nothing to compare it to.
+func (s *Store) HasFeedback(ctx context.Context) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM feedback)`).Scan(&n); err != nil {
+		return false, fmt.Errorf("checking feedback: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Examples returns up to limit past answers to questions like question,
+// most similar first. Answers are compared by embedding where both have
+// one of the same size, and by keywords otherwise. Only the latest
+// feedback for each command counts.
+func (s *Store) Examples(ctx context.Context, question string, embedding []float32, limit int) ([]Feedback, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, question, command, accepted, tags, embedding, created_at
+		 FROM feedback
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		feedbackScanLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing feedback: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	keywords := extractKeywords(question)
+	seen := map[string]bool{}
+	var out []Feedback
+	for rows.Next() {
+		var (
+			f         Feedback
+			tags      string
+			blob      []byte
+			createdAt string
+		)
+		if err := rows.Scan(&f.ID, &f.Question, &f.Command, &f.Accepted, &tags, &blob, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning feedback: %w", err)
+		}
+		if seen[f.Command] {
+			continue
+		}
+		seen[f.Command] = true
+		f.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+		if stored := decodeEmbedding(blob); len(embedding) > 0 && len(stored) == len(embedding) {
+			f.Similarity = cosine(embedding, stored)
+			if f.Similarity < minEmbeddingSimilarity {
+				continue
+			}
+		} else {
+			f.Similarity = keywordOverlap(keywords, strings.Fields(tags))
+			if f.Similarity < minKeywordSimilarity {
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func encodeEmbedding(v []float32) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeEmbedding(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// keywordOverlap is the share of keywords two questions have in common.
+func keywordOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := map[string]bool{}
+	for _, k := range b {
+		set[k] = true
+	}
+	shared := 0
+	for _, k := range a {
+		if set[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(max(len(a), len(b)))
+}
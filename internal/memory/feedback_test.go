@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExamplesByEmbedding(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	if has, err := store.HasFeedback(ctx); err != nil || has {
+		t.Fatalf("expected no feedback, got %v %v", has, err)
+	}
+	for _, f := range []struct {
+		question, command string
+		accepted          bool
+		embedding         []float32
+	}{
+		{"list listening ports", "ss -tlnp", true, []float32{1, 0, 0}},
+		{"show open ports", "netstat -an", false, []float32{0.9, 0.1, 0}},
+		{"compress a folder", "tar czf out.tgz dir", true, []float32{0, 0, 1}},
+	} {
+		if err := store.RecordFeedback(ctx, f.question, f.command, f.accepted, f.embedding); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.Examples(ctx, "which ports are listening", []float32{1, 0.05, 0}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Command != "ss -tlnp" || !got[0].Accepted || got[1].Command != "netstat -an" || got[1].Accepted {
+		t.Errorf("unexpected examples: %+v", got)
+	}
+}
+
+func TestExamplesByKeywords(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	if err := store.RecordFeedback(ctx, "find large files", "du -ah . | sort -rh | head", true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordFeedback(ctx, "find large files quickly", "du -ah . | sort -rh | head", false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Examples(ctx, "find large files", nil, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The latest feedback for a command wins
+	if len(got) != 1 || got[0].Accepted {
+		t.Errorf("expected the later rejection, got %+v", got)
+	}
+	if got, _ := store.Examples(ctx, "restart nginx", nil, 5); len(got) != 0 {
+		t.Errorf("expected no examples for an unrelated question, got %+v", got)
+	}
+
+	if err := store.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := store.HasFeedback(ctx); has {
+		t.Error("Clear should remove feedback")
+	}
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS history (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    question    TEXT    NOT NULL,
+    command     TEXT    NOT NULL,
+    exit_code   INTEGER NOT NULL DEFAULT 0,
+    output      TEXT    NOT NULL DEFAULT '',
+    session     TEXT    NOT NULL DEFAULT '',
+    executed_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_history_executed_at ON history(executed_at);
+`
+
+// HistoryEntry is a single command executed through how, successful or not.
+type HistoryEntry struct {
+	ID       int64
+	Question string
+	Command  string
+	ExitCode int
+	// Output is what the command printed to stdout, possibly truncated,
+	// or empty when it wasn't captured.
+	Output string
+	// Session is the shell session it ran in; see Session.
+	Session    string
+	ExecutedAt time.Time
+}
+
+// Session identifies the shell session how is running in: $HOW_SESSION
+// when it's set, such as to an incident's name, and otherwise the process
+// how was started from, normally the user's shell.
+func Session() string {
+	if s := os.Getenv("HOW_SESSION"); s != "" {
+		return s
+	}
+	return "pid:" + strconv.Itoa(os.Getppid())
+}
+
+// Record appends an executed command and its captured output, which may
+// be empty, to the history, in the current Session.
+func (s *Store) Record(ctx context.Context, question, command string, exitCode int, output string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO history (question, command, exit_code, output, session) VALUES (?, ?, ?, ?, ?)`,
+		question, command, exitCode, output, Session(),
+	)
+	if err != nil {
+		return fmt.Errorf("recording history: %w", err)
+	}
+	return nil
+}
+
+// Last returns the most recently executed command, or nil if the history is empty.
+func (s *Store) Last(ctx context.Context) (*HistoryEntry, error) {
+	entries, err := s.History(ctx, 1)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// History returns the most recently executed commands, newest first.
+func (s *Store) History(ctx context.Context, limit int) ([]HistoryEntry, error) {
+	return s.queryHistory(ctx,
+		`SELECT id, question, command, exit_code, output, session, executed_at
+		 FROM history
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		limit,
+	)
+}
+
+// SessionHistory returns the commands executed in session since the given
+// time, oldest first. An empty session matches every session.
+func (s *Store) SessionHistory(ctx context.Context, session string, since time.Time) ([]HistoryEntry, error) {
+	return s.queryHistory(ctx,
+		`SELECT id, question, command, exit_code, output, session, executed_at
+		 FROM history
+		 WHERE (? = '' OR session = ?) AND executed_at >= ?
+		 ORDER BY id`,
+		session, session, since.UTC().Format("2006-01-02T15:04:05Z"),
+	)
+}
+
+func (s *Store) queryHistory(ctx context.Context, query string, args ...any) ([]HistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing history: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var executedAt string
+		if err := rows.Scan(&e.ID, &e.Question, &e.Command, &e.ExitCode, &e.Output, &e.Session, &executedAt); err != nil {
+			return nil, fmt.Errorf("scanning history: %w", err)
+		}
+		e.ExecutedAt, _ = time.Parse(time.RFC3339, executedAt)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLast(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	last, err := store.Last(ctx)
+	if err != nil {
+		t.Fatalf("Last error: %v", err)
+	}
+	if last != nil {
+		t.Fatalf("expected nil for empty history, got %+v", last)
+	}
+
+	_ = store.Record(ctx, "make a dir", "mkdir foo", 0, "")
+	_ = store.Record(ctx, "move it", "mv foo bar", 1, "")
+
+	last, err = store.Last(ctx)
+	if err != nil {
+		t.Fatalf("Last error: %v", err)
+	}
+	if last == nil || last.Command != "mv foo bar" {
+		t.Fatalf("expected last command 'mv foo bar', got %+v", last)
+	}
+	if last.ExitCode != 1 {
+		t.Errorf("exit_code: got %d, want 1", last.ExitCode)
+	}
+}
+
+func TestHistoryKeepsRepeats(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	_ = store.Record(ctx, "list", "ls", 0, "")
+	_ = store.Record(ctx, "list", "ls", 0, "")
+
+	entries, err := store.History(ctx, 10)
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+}
+
+func TestClearRemovesHistory(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	_ = store.Record(ctx, "list", "ls", 0, "")
+	if err := store.Clear(ctx); err != nil {
+		t.Fatalf("Clear error: %v", err)
+	}
+
+	last, err := store.Last(ctx)
+	if err != nil {
+		t.Fatalf("Last error: %v", err)
+	}
+	if last != nil {
+		t.Errorf("expected empty history after clear, got %+v", last)
+	}
+}
+
+func TestRecordKeepsOutput(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	_ = store.Record(ctx, "run the tests", "go test ./...", 1, "--- FAIL: TestX\nok  pkg\n")
+	last, err := store.Last(ctx)
+	if err != nil {
+		t.Fatalf("Last error: %v", err)
+	}
+	if last == nil || last.Output != "--- FAIL: TestX\nok  pkg\n" {
+		t.Errorf("expected output to round-trip, got %+v", last)
+	}
+}
+
+func TestMigrateAddsOutputColumn(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "memory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question TEXT NOT NULL,
+		command TEXT NOT NULL,
+		exit_code INTEGER NOT NULL DEFAULT 0,
+		executed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+	);
+	INSERT INTO history (question, command) VALUES ('list', 'ls');
+	PRAGMA user_version = 4;`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if err := store.Record(ctx, "count", "wc -l", 0, "3\n"); err != nil {
+		t.Fatalf("Record error: %v", err)
+	}
+	entries, err := store.History(ctx, 2)
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Output != "3\n" || entries[1].Output != "" {
+		t.Errorf("unexpected history after migration: %+v", entries)
+	}
+}
+
+func TestSessionHistory(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	t.Setenv("HOW_SESSION", "incident-42")
+	_ = store.Record(ctx, "which pods restarted", "kubectl get pods -A", 0, "")
+	_ = store.Record(ctx, "show the logs", "kubectl logs web-1", 1, "")
+	t.Setenv("HOW_SESSION", "other")
+	_ = store.Record(ctx, "list", "ls", 0, "")
+
+	entries, err := store.SessionHistory(ctx, "incident-42", time.Time{})
+	if err != nil {
+		t.Fatalf("SessionHistory error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "kubectl get pods -A" || entries[1].Session != "incident-42" {
+		t.Errorf("expected the session's two commands, oldest first, got %+v", entries)
+	}
+
+	all, err := store.SessionHistory(ctx, "", time.Time{})
+	if err != nil || len(all) != 3 {
+		t.Errorf("expected every session's commands, got %d, %v", len(all), err)
+	}
+	if later, _ := store.SessionHistory(ctx, "", time.Now().Add(time.Hour)); len(later) != 0 {
+		t.Errorf("expected nothing after since, got %+v", later)
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Setenv("HOW_SESSION", "")
+	if got, want := Session(), "pid:"+strconv.Itoa(os.Getppid()); got != want {
+		t.Errorf("Session() = %q, want %q", got, want)
+	}
+	t.Setenv("HOW_SESSION", "incident-42")
+	if got := Session(); got != "incident-42" {
+		t.Errorf("Session() = %q with HOW_SESSION set", got)
+	}
+}
@@ -42,13 +42,13 @@ END;
 `
 
 type Interaction struct {
-	ID          int64
-	Question    string
-	Command     string
-	Explanation string
-	Tags        string
-	CreatedAt   time.Time
-	UseCount    int
+	ID          int64     `json:"id"`
+	Question    string    `json:"question"`
+	Command     string    `json:"command"`
+	Explanation string    `json:"explanation,omitempty"`
+	Tags        string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UseCount    int       `json:"use_count"`
 }
 
 type Store struct {
@@ -67,21 +67,79 @@ func Open(dir string) (*Store, error) {
 		return nil, fmt.Errorf("enabling WAL mode: %w", err)
 	}
 
-	if _, err := db.Exec(schema); err != nil {
+	if err := migrate(db); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("creating schema: %w", err)
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// schemaVersion is stored in PRAGMA user_version. Bump it when the schema
+// changes so existing databases are migrated on the next Open; otherwise
+// Open skips straight to use and stays cheap as memory grows.
+const schemaVersion = 6
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if version >= schemaVersion {
+		return nil
+	}
+
+	if _, err := db.Exec(schema + historySchema + feedbackSchema + evalSchema + spendSchema); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
 	}
 
 	// Rebuild FTS index to handle existing data from before the FTS migration
 	if _, err := db.Exec("INSERT INTO interactions_fts(interactions_fts) VALUES('rebuild')"); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("rebuilding FTS index: %w", err)
+		return fmt.Errorf("rebuilding FTS index: %w", err)
 	}
 
 	// Drop legacy index if it exists (FTS5 replaces it)
 	_, _ = db.Exec("DROP INDEX IF EXISTS idx_interactions_tags")
 
-	return &Store{db: db}, nil
+	// CREATE TABLE IF NOT EXISTS leaves history tables from before
+	// version 5 without the output column, and from before 6 without the
+	// session column
+	if err := addColumn(db, "history", "output", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := addColumn(db, "history", "session", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return fmt.Errorf("recording schema version: %w", err)
+	}
+	return nil
+}
+
+// addColumn adds column to table unless it already has it.
+func addColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return fmt.Errorf("reading %s columns: %w", table, err)
+	}
+	defer rows.Close() //nolint:errcheck
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("reading %s columns: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading %s columns: %w", table, err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("adding %s.%s: %w", table, column, err)
+	}
+	return nil
 }
 
 func (s *Store) Close() error {
@@ -162,11 +220,19 @@ func (s *Store) List(ctx context.Context, limit int) ([]Interaction, error) {
 	return scanInteractions(rows)
 }
 
+// Clear forgets remembered commands, history and feedback. The eval set is
+// kept, since it's curated by hand.
 func (s *Store) Clear(ctx context.Context) error {
 	_, err := s.db.ExecContext(ctx, "DELETE FROM interactions")
 	if err != nil {
 		return fmt.Errorf("clearing interactions: %w", err)
 	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM history"); err != nil {
+		return fmt.Errorf("clearing history: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM feedback"); err != nil {
+		return fmt.Errorf("clearing feedback: %w", err)
+	}
 	return nil
 }
 
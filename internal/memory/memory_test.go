@@ -59,6 +59,40 @@ func TestOpenCreatesDB(t *testing.T) {
 	}
 }
 
+func TestOpenSkipsMigrationWhenCurrent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Save(ctx, "list files", "ls -la", "list"); err != nil {
+		t.Fatal(err)
+	}
+
+	var version int
+	if err := store.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != schemaVersion {
+		t.Errorf("expected user_version %d, got %d", schemaVersion, version)
+	}
+	store.Close()
+
+	store, err = Open(dir)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer store.Close()
+	results, err := store.Search(ctx, "list files", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected saved interaction after reopen, got %d", len(results))
+	}
+}
+
 func TestSaveAndSearch(t *testing.T) {
 	store := openTestStore(t)
 	ctx := context.Background()
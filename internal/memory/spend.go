@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const spendSchema = `
+CREATE TABLE IF NOT EXISTS spend (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    profile       TEXT    NOT NULL,
+    provider      TEXT    NOT NULL,
+    model         TEXT    NOT NULL,
+    input_tokens  INTEGER NOT NULL,
+    output_tokens INTEGER NOT NULL,
+    cost          REAL    NOT NULL,
+    created_at    TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_spend_profile_created_at ON spend(profile, created_at);
+`
+
+// Spend totals the tokens and dollars used over a period.
+type Spend struct {
+	InputTokens  int64
+	OutputTokens int64
+	Cost         float64
+}
+
+// Tokens returns the input and output tokens together.
+func (s Spend) Tokens() int64 {
+	return s.InputTokens + s.OutputTokens
+}
+
+// RecordSpend stores the tokens and cost of one request made under
+// profile.
+func (s *Store) RecordSpend(ctx context.Context, profile, provider, model string, input, output int64, cost float64) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO spend (profile, provider, model, input_tokens, output_tokens, cost) VALUES (?, ?, ?, ?, ?, ?)`,
+		profile, provider, model, input, output, cost,
+	)
+	if err != nil {
+		return fmt.Errorf("recording spend: %w", err)
+	}
+	return nil
+}
+
+// MonthlySpend totals what profile has used since the start of now's
+// calendar month, in UTC.
+func (s *Store) MonthlySpend(ctx context.Context, profile string, now time.Time) (Spend, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var sp Spend
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(cost), 0)
+		 FROM spend WHERE profile = ? AND created_at >= ?`,
+		profile, start.Format(time.RFC3339),
+	).Scan(&sp.InputTokens, &sp.OutputTokens, &sp.Cost)
+	if err != nil {
+		return Spend{}, fmt.Errorf("totalling spend: %w", err)
+	}
+	return sp, nil
+}
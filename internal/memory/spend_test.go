@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMonthlySpend(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+
+	if err := store.RecordSpend(ctx, "work", "anthropic", "claude-sonnet-4-6", 1000, 100, 0.0045); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordSpend(ctx, "work", "anthropic", "claude-sonnet-4-6", 2000, 200, 0.009); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordSpend(ctx, "local", "ollama", "llama3", 500, 50, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	sp, err := store.MonthlySpend(ctx, "work", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.InputTokens != 3000 || sp.OutputTokens != 300 || sp.Tokens() != 3300 || sp.Cost < 0.0134 || sp.Cost > 0.0136 {
+		t.Errorf("expected the work profile's spend to survive Clear, got %+v", sp)
+	}
+	if sp, _ := store.MonthlySpend(ctx, "local", now); sp.Tokens() != 550 || sp.Cost != 0 {
+		t.Errorf("expected spend to be kept per profile, got %+v", sp)
+	}
+	if sp, _ := store.MonthlySpend(ctx, "work", now.AddDate(0, 1, 0)); sp != (Spend{}) {
+		t.Errorf("expected nothing spent next month, got %+v", sp)
+	}
+}
@@ -0,0 +1,65 @@
+// Package notify shows desktop notifications through the platform's
+// command-line tools, falling back to the terminal bell.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notifier is a command that shows a notification.
+type notifier struct {
+	name string
+	args []string
+}
+
+// notifiers returns the candidate commands for goos, in preference order.
+func notifiers(goos, title, body string) []notifier {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return []notifier{
+			{"terminal-notifier", []string{"-title", title, "-message", body}},
+			{"osascript", []string{"-e", script}},
+		}
+	case "windows":
+		return nil
+	}
+	return []notifier{{"notify-send", []string{"--app-name=how", title, body}}}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// lookPath, run and bell are replaced in tests.
+var (
+	lookPath = exec.LookPath
+	run      = func(ctx context.Context, name string, args ...string) error {
+		return exec.CommandContext(ctx, name, args...).Run()
+	}
+	bell io.Writer = os.Stderr
+)
+
+// Send shows a desktop notification with the first available tool. When
+// none is installed, or it fails (say, with no desktop session), the
+// terminal bell rings instead.
+func Send(ctx context.Context, title, body string) error {
+	for _, n := range notifiers(runtime.GOOS, title, body) {
+		if _, err := lookPath(n.name); err != nil {
+			continue
+		}
+		if err := run(ctx, n.name, n.args...); err == nil {
+			return nil
+		}
+		break
+	}
+	_, err := io.WriteString(bell, "\a")
+	return err
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func stub(t *testing.T, installed map[string]bool, runErr error) (*[]string, *bytes.Buffer) {
+	t.Helper()
+	origLook, origRun, origBell := lookPath, run, bell
+	t.Cleanup(func() { lookPath, run, bell = origLook, origRun, origBell })
+
+	var ran []string
+	var rang bytes.Buffer
+	lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+	run = func(ctx context.Context, name string, args ...string) error {
+		ran = append(ran, name)
+		return runErr
+	}
+	bell = &rang
+	return &ran, &rang
+}
+
+func TestSend(t *testing.T) {
+	ran, rang := stub(t, map[string]bool{"notify-send": true, "osascript": true}, nil)
+	if err := Send(context.Background(), "done", "make build"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 1 || rang.Len() != 0 {
+		t.Errorf("expected one notifier and no bell, got %v and %q", *ran, rang.String())
+	}
+}
+
+func TestSendFallsBackToBell(t *testing.T) {
+	_, rang := stub(t, nil, nil)
+	if err := Send(context.Background(), "done", "make build"); err != nil {
+		t.Fatal(err)
+	}
+	if rang.String() != "\a" {
+		t.Errorf("expected the bell without a notifier, got %q", rang.String())
+	}
+
+	_, rang = stub(t, map[string]bool{"notify-send": true, "osascript": true, "terminal-notifier": true}, errors.New("no display"))
+	if err := Send(context.Background(), "done", "make build"); err != nil {
+		t.Fatal(err)
+	}
+	if rang.String() != "\a" {
+		t.Errorf("expected the bell when the notifier fails, got %q", rang.String())
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	ns := notifiers("darwin", "Finished", `say "hi" \ bye`)
+	if ns[0].name != "terminal-notifier" || ns[1].args[1] != `display notification "say \"hi\" \\ bye" with title "Finished"` {
+		t.Errorf("unexpected macOS notifiers: %v", ns)
+	}
+	if ns := notifiers("linux", "Finished", "ok"); len(ns) != 1 || ns[0].name != "notify-send" || ns[0].args[2] != "ok" {
+		t.Errorf("unexpected Linux notifiers: %v", ns)
+	}
+	if ns := notifiers("windows", "Finished", "ok"); len(ns) != 0 {
+		t.Errorf("expected the bell on Windows, got %v", ns)
+	}
+}
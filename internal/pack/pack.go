@@ -0,0 +1,168 @@
+// Package pack fetches prompt packs from git repositories and tarballs.
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/gitrepo"
+)
+
+// File is the name of the pack definition at the root of a pack.
+const File = "pack.yaml"
+
+// maxPackBytes bounds the size of a pack.yaml, and maxTarballBytes that of
+// a downloaded tarball.
+const (
+	maxPackBytes    = 1 << 20
+	maxTarballBytes = 32 << 20
+)
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+// IsTarball reports whether source names a gzipped tarball rather than a
+// git repository.
+func IsTarball(source string) bool {
+	return strings.HasSuffix(source, ".tar.gz") || strings.HasSuffix(source, ".tgz")
+}
+
+// Fetch reads the pack at source: a gzipped tarball URL or path, or else a
+// git repository cloned at ref (its default branch when ref is empty).
+// The pack records source and ref so it can be updated later, and the
+// commit or tarball checksum it was read from.
+func Fetch(ctx context.Context, source, ref string) (*config.Pack, error) {
+	var data []byte
+	var commit, checksum string
+	var err error
+	if IsTarball(source) {
+		if ref != "" {
+			return nil, errors.New("a ref only applies to git repositories")
+		}
+		data, checksum, err = fetchTarball(ctx, source)
+	} else {
+		data, commit, err = fetchGit(ctx, source, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := config.ParsePack(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	p.Source, p.Ref = source, ref
+	p.Commit, p.Checksum = commit, checksum
+	return p, nil
+}
+
+// fetchGit returns the pack.yaml in the git repository source at ref, and
+// the commit it was read from.
+func fetchGit(ctx context.Context, source, ref string) ([]byte, string, error) {
+	dir, err := gitrepo.Clone(ctx, source, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	commit, err := gitrepo.Head(ctx, dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading the commit of %s: %w", source, err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, File))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("no %s at the root of %s", File, source)
+		}
+		return nil, "", err
+	}
+	defer f.Close() //nolint:errcheck
+	data, err := readPackFile(f)
+	return data, commit, err
+}
+
+// fetchTarball returns the pack.yaml in the tarball at source, and the
+// tarball's SHA-256 checksum.
+func fetchTarball(ctx context.Context, source string) ([]byte, string, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, "", fmt.Errorf("downloading %s: %w", source, err)
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("downloading %s: %s", source, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, "", err
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+	}
+	// The whole tarball is read to checksum it, not just up to pack.yaml
+	tarball, err := io.ReadAll(io.LimitReader(r, maxTarballBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", source, err)
+	}
+	if len(tarball) > maxTarballBytes {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", source, maxTarballBytes)
+	}
+	sum := sha256.Sum256(tarball)
+	data, err := packFromTarball(bytes.NewReader(tarball))
+	return data, hex.EncodeToString(sum[:]), err
+}
+
+// packFromTarball returns the pack.yaml at the root of a gzipped tarball,
+// or inside its single top-level directory, as in GitHub's archives.
+func packFromTarball(r io.Reader) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading tarball: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no %s at the root of the tarball", File)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tarball: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if hdr.Typeflag != tar.TypeReg || path.Base(name) != File || strings.Count(name, "/") > 1 {
+			continue
+		}
+		return readPackFile(tr)
+	}
+}
+
+func readPackFile(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPackBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPackBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", File, maxPackBytes)
+	}
+	return data, nil
+}
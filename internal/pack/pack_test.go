@@ -0,0 +1,124 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPack = "name: ffmpeg\nversion: 0.3.0\nprofiles:\n  ffmpeg:\n    prompt_additions: Prefer -c copy when possible.\n"
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetchTarball(t *testing.T) {
+	// GitHub archives put everything in one top-level directory
+	archive := tarball(t, map[string]string{"how-pack-ffmpeg-0.3.0/README.md": "hi", "how-pack-ffmpeg-0.3.0/pack.yaml": testPack})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ffmpeg.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	p, err := Fetch(context.Background(), srv.URL+"/ffmpeg.tar.gz", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "ffmpeg" || p.Version != "0.3.0" || p.Source != srv.URL+"/ffmpeg.tar.gz" {
+		t.Errorf("unexpected pack: %+v", p)
+	}
+	if sum := sha256.Sum256(archive); p.Checksum != hex.EncodeToString(sum[:]) || p.Commit != "" {
+		t.Errorf("pack should record the tarball's checksum, got checksum %q, commit %q", p.Checksum, p.Commit)
+	}
+
+	if _, err := Fetch(context.Background(), srv.URL+"/missing.tar.gz", ""); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a download error, got %v", err)
+	}
+	nested := filepath.Join(t.TempDir(), "nested.tgz")
+	if err := os.WriteFile(nested, tarball(t, map[string]string{"a/b/pack.yaml": testPack}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fetch(context.Background(), nested, ""); err == nil || !strings.Contains(err.Error(), "no pack.yaml") {
+		t.Errorf("expected pack.yaml deeper than one directory to be ignored, got %v", err)
+	}
+	if _, err := Fetch(context.Background(), nested, "v1"); err == nil {
+		t.Error("expected an error for a ref with a tarball")
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(repo, "pack.yaml"), []byte(testPack), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "pack.yaml")
+	git("commit", "--quiet", "-m", "0.3.0")
+	git("tag", "v0.3.0")
+	if err := os.WriteFile(filepath.Join(repo, "pack.yaml"), []byte(strings.Replace(testPack, "0.3.0", "0.4.0", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "--quiet", "-am", "0.4.0")
+
+	source := "file://" + repo
+	latest, err := Fetch(context.Background(), source, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := Fetch(context.Background(), source, "v0.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != "0.4.0" || pinned.Version != "0.3.0" || pinned.Ref != "v0.3.0" {
+		t.Errorf("unexpected versions: latest %s, pinned %s at %q", latest.Version, pinned.Version, pinned.Ref)
+	}
+	head, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Commit != strings.TrimSpace(string(head)) || pinned.Commit == "" || pinned.Commit == latest.Commit {
+		t.Errorf("packs should record their commits, got latest %q, pinned %q", latest.Commit, pinned.Commit)
+	}
+}
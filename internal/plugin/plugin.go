@@ -0,0 +1,95 @@
+// Package plugin runs how-<name> executables found on PATH as how
+// subcommands, git-style, so new modes don't need changes to how itself.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix starts the name of every plugin executable.
+const Prefix = "how-"
+
+// Request is written to a plugin's stdin as a single JSON object. It
+// carries what a plugin needs to ask the configured model itself.
+type Request struct {
+	Version  string   `json:"version"`
+	Args     []string `json:"args"`
+	Profile  string   `json:"profile,omitempty"`
+	Provider string   `json:"provider"`
+	Model    string   `json:"model"`
+	APIKey   string   `json:"api_key,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Shell    string   `json:"shell,omitempty"`
+	Dir      string   `json:"dir"`
+	// Context is the machine context how sends with its own prompts.
+	Context string `json:"context,omitempty"`
+}
+
+// Find returns the path of the plugin for name, how-<name> on PATH.
+func Find(name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsRune(name, filepath.Separator) {
+		return "", false
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// List returns the plugins on PATH by name, with the path Find resolves
+// each to.
+func List() map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), Prefix)
+			if !ok || name == "" || e.IsDir() {
+				continue
+			}
+			name = strings.TrimSuffix(name, ".exe")
+			if _, seen := plugins[name]; seen {
+				continue
+			}
+			if path, ok := Find(name); ok {
+				plugins[name] = path
+			}
+		}
+	}
+	return plugins
+}
+
+// Names returns the keys of plugins, sorted.
+func Names(plugins map[string]string) []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Command prepares the plugin at path to run with args, reading req from
+// stdin. HOW_PLUGIN is set so a plugin can tell it was started by how.
+func Command(ctx context.Context, path string, args []string, req Request) (*exec.Cmd, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding plugin request: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Env = append(os.Environ(), "HOW_PLUGIN=1")
+	return cmd, nil
+}
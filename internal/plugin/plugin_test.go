@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, Prefix+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindAndList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	hello := writePlugin(t, first, "hello", "echo hi\n")
+	writePlugin(t, second, "hello", "echo shadowed\n")
+	aws := writePlugin(t, second, "aws", "echo aws\n")
+	if err := os.WriteFile(filepath.Join(second, Prefix+"notes"), []byte("not executable"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	if path, ok := Find("hello"); !ok || path != hello {
+		t.Errorf("expected the first hello on PATH, got %q %v", path, ok)
+	}
+	for _, name := range []string{"", "missing", "notes", "-h", "../hello"} {
+		if path, ok := Find(name); ok {
+			t.Errorf("Find(%q) = %q, expected no plugin", name, path)
+		}
+	}
+
+	plugins := List()
+	if want := map[string]string{"hello": hello, "aws": aws}; !reflect.DeepEqual(plugins, want) {
+		t.Errorf("List() = %v, want %v", plugins, want)
+	}
+	if names := Names(plugins); !reflect.DeepEqual(names, []string{"aws", "hello"}) {
+		t.Errorf("Names() = %v", names)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	path := writePlugin(t, t.TempDir(), "echo", `echo "$HOW_PLUGIN $*"; cat`+"\n")
+
+	req := Request{Version: "1.2.3", Args: []string{"a", "b"}, Provider: "ollama", Model: "llama3", Dir: "/tmp"}
+	cmd, err := Command(context.Background(), path, req.Args, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, body, _ := strings.Cut(string(out), "\n")
+	if first != "1 a b" {
+		t.Errorf("expected HOW_PLUGIN and the args, got %q", first)
+	}
+	var got Request
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("expected the request on stdin, got %q: %v", body, err)
+	}
+	if !reflect.DeepEqual(got, req) {
+		t.Errorf("got request %+v, want %+v", got, req)
+	}
+}
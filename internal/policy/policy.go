@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/swibrow/how/internal/config"
+)
+
+// Policy decides whether a suggested command may be executed, and how it
+// is rewritten first.
+type Policy struct {
+	deny    []*regexp.Regexp
+	rewrite []rewrite
+}
+
+type rewrite struct {
+	match   *regexp.Regexp
+	unless  *regexp.Regexp
+	replace string
+	note    string
+}
+
+// BlockedError reports a command rejected by a deny rule.
+type BlockedError struct {
+	Command string
+	Rule    string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("command blocked by policy rule %q", e.Rule)
+}
+
+// New compiles the rules in cfg.
+func New(cfg config.PolicyConfig) (*Policy, error) {
+	p := &Policy{}
+	for _, rule := range cfg.Deny {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy deny rule %q: %w", rule, err)
+		}
+		p.deny = append(p.deny, re)
+	}
+	for _, rule := range cfg.Rewrite {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("policy rewrite rule for %q has no match", rule.Replace)
+		}
+		r := rewrite{replace: rule.Replace, note: rule.Note}
+		var err error
+		if r.match, err = regexp.Compile(rule.Match); err != nil {
+			return nil, fmt.Errorf("invalid policy rewrite match %q: %w", rule.Match, err)
+		}
+		if rule.Unless != "" {
+			if r.unless, err = regexp.Compile(rule.Unless); err != nil {
+				return nil, fmt.Errorf("invalid policy rewrite unless %q: %w", rule.Unless, err)
+			}
+		}
+		if r.note == "" {
+			r.note = fmt.Sprintf("rewritten by policy rule %q", rule.Match)
+		}
+		p.rewrite = append(p.rewrite, r)
+	}
+	return p, nil
+}
+
+// Rewrite applies the rewrite rules in order and returns the resulting
+// command with a note for each rule that changed it.
+func (p *Policy) Rewrite(command string) (string, []string) {
+	var notes []string
+	for _, r := range p.rewrite {
+		if r.unless != nil && r.unless.MatchString(command) {
+			continue
+		}
+		rewritten := r.match.ReplaceAllString(command, r.replace)
+		if rewritten != command {
+			command = rewritten
+			notes = append(notes, r.note)
+		}
+	}
+	return command, notes
+}
+
+// Check returns a *BlockedError if command matches a deny rule.
+func (p *Policy) Check(command string) error {
+	for _, re := range p.deny {
+		if re.MatchString(command) {
+			return &BlockedError{Command: command, Rule: re.String()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/swibrow/how/internal/config"
+)
+
+func TestCheck(t *testing.T) {
+	p, err := New(config.PolicyConfig{Deny: []string{`rm\s+-rf\s+/\s*$`, `^mkfs`}})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	if err := p.Check("ls -la"); err != nil {
+		t.Errorf("ls should be allowed, got: %v", err)
+	}
+
+	err = p.Check("rm -rf /")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected BlockedError, got: %v", err)
+	}
+	if !strings.Contains(blocked.Rule, "rm") {
+		t.Errorf("unexpected rule: %q", blocked.Rule)
+	}
+}
+
+func TestNewInvalidRule(t *testing.T) {
+	if _, err := New(config.PolicyConfig{Deny: []string{"("}}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	p, err := New(config.PolicyConfig{Rewrite: []config.RewriteRule{
+		{Match: `(^|[;&|]\s*)rm `, Replace: "${1}rm -i ", Unless: `\brm -i\b`},
+		{Match: `\bkubectl apply\b`, Replace: "kubectl apply --dry-run=client", Unless: `--dry-run`, Note: "kubectl apply is a dry run"},
+	}})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	got, notes := p.Rewrite("rm a.txt && kubectl apply -f app.yaml")
+	if got != "rm -i a.txt && kubectl apply --dry-run=client -f app.yaml" {
+		t.Errorf("unexpected rewrite: %q", got)
+	}
+	if len(notes) != 2 || notes[1] != "kubectl apply is a dry run" || !strings.Contains(notes[0], "rm") {
+		t.Errorf("unexpected notes: %v", notes)
+	}
+
+	if got, notes := p.Rewrite("rm -i a.txt"); got != "rm -i a.txt" || notes != nil {
+		t.Errorf("unless should skip the rule, got %q %v", got, notes)
+	}
+	if got, notes := p.Rewrite("ls"); got != "ls" || notes != nil {
+		t.Errorf("unmatched command should be unchanged, got %q %v", got, notes)
+	}
+}
+
+func TestNewInvalidRewrite(t *testing.T) {
+	if _, err := New(config.PolicyConfig{Rewrite: []config.RewriteRule{{Match: ""}}}); err == nil {
+		t.Error("expected error for empty match")
+	}
+	if _, err := New(config.PolicyConfig{Rewrite: []config.RewriteRule{{Match: "a", Unless: "("}}}); err == nil {
+		t.Error("expected error for invalid unless")
+	}
+}
@@ -0,0 +1,601 @@
+// Package preview shows what a command would change by running it
+// against copies of the files it touches first.
+package preview
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/risk"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Timeout bounds how long the edit may run against the copies.
+const Timeout = 5 * time.Second
+
+// maxFileBytes is the largest file that is copied for a preview.
+const maxFileBytes = 1 << 20
+
+// Change is one file the edit would change, as a unified diff.
+type Change struct {
+	Path string
+	Diff string
+}
+
+// InPlace runs command, a single sed, perl or gawk call that edits files
+// in place, against copies of the files it would modify in dir, and
+// returns a diff for each file that changed. ok is false when command
+// isn't such an edit or can't be previewed safely: its script could run
+// other commands or write elsewhere, or its files are outside dir.
+func InPlace(ctx context.Context, command, dir string) (changes []Change, ok bool, err error) {
+	args, ok := simpleCall(command)
+	if !ok || !safeScript(filepath.Base(args[0]), args[1:]) {
+		return nil, false, nil
+	}
+	paths := risk.AffectedPaths(command, dir)
+	if len(paths.Modified) == 0 || len(paths.Deleted) > 0 {
+		return nil, false, nil
+	}
+	for _, p := range paths.Modified {
+		if strings.HasSuffix(p, "/") {
+			return nil, false, nil
+		}
+	}
+	for _, p := range append(paths.Modified, paths.Read...) {
+		if !filepath.IsLocal(strings.TrimSuffix(p, "/")) {
+			return nil, false, nil
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "how-preview-")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.RemoveAll(tmp) //nolint:errcheck
+
+	for _, p := range append(paths.Modified, paths.Read...) {
+		if strings.HasSuffix(p, "/") {
+			continue
+		}
+		copied, err := copyFile(filepath.Join(dir, p), filepath.Join(tmp, p), maxFileBytes)
+		if err != nil {
+			return nil, false, err
+		}
+		if !copied {
+			return nil, false, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, true, fmt.Errorf("running the edit on a copy: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	for _, p := range paths.Modified {
+		before, err := os.ReadFile(filepath.Join(dir, p))
+		if err != nil {
+			return nil, true, err
+		}
+		after, err := os.ReadFile(filepath.Join(tmp, p))
+		if err != nil {
+			return nil, true, err
+		}
+		if diff := Unified(p, string(before), string(after)); diff != "" {
+			changes = append(changes, Change{Path: p, Diff: diff})
+		}
+	}
+	return changes, true, nil
+}
+
+// simpleCall returns the words of command when it's one plain call with
+// no redirections, expansions or wrappers.
+func simpleCall(command string) ([]string, bool) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return nil, false
+	}
+	stmt := file.Stmts[0]
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(stmt.Redirs) > 0 || stmt.Background || stmt.Negated || len(call.Assigns) > 0 || len(call.Args) == 0 {
+		return nil, false
+	}
+	args := make([]string, len(call.Args))
+	for i, w := range call.Args {
+		if args[i], ok = literal(w); !ok {
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// literal returns the text of a word made only of literal and quoted
+// parts.
+func literal(w *syntax.Word) (string, bool) {
+	var b strings.Builder
+	for _, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				b.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// safeScript reports whether the script given to name can only edit the
+// files it's given. It works from allowlists: sed scripts without e, r or
+// w, perl made only of s/// and tr///, and awk that only prints and
+// assigns. Scripts read from files and unknown options aren't previewed.
+func safeScript(name string, args []string) bool {
+	scripts, operands, ok := splitArgs(name, args)
+	if !ok {
+		return false
+	}
+	if len(scripts) == 0 && len(operands) > 0 && name != "perl" {
+		scripts = operands[:1]
+	}
+	safe := map[string]func(string) bool{"sed": sedSafe, "perl": perlSafe, "awk": awkSafe, "gawk": awkSafe}[name]
+	if safe == nil || len(scripts) == 0 {
+		return false
+	}
+	for _, s := range scripts {
+		if !safe(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// plainFlags are the single-letter options of each tool that take no
+// value and can't load code or write other files.
+var plainFlags = map[string]string{
+	"sed":  "Enrsuz",
+	"perl": "anpw",
+	"awk":  "",
+	"gawk": "",
+}
+
+// sedLongFlags are the long sed options that take no value and are
+// harmless.
+var sedLongFlags = []string{"--regexp-extended", "--quiet", "--silent", "--null-data", "--separate", "--posix", "--unbuffered"}
+
+// splitArgs separates name's options from its operands and returns the
+// scripts given with -e and the like. ok is false for any option it
+// doesn't know to be harmless, such as ones that read scripts from files,
+// load modules or write backups to another directory.
+func splitArgs(name string, args []string) (scripts, operands []string, ok bool) {
+	awk := name == "awk" || name == "gawk"
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		// value returns an option's value, attached or in the next argument
+		value := func(attached string) (string, bool) {
+			if attached != "" {
+				return attached, true
+			}
+			if i+1 == len(args) {
+				return "", false
+			}
+			i++
+			return args[i], true
+		}
+
+		switch {
+		case a == "--":
+			return scripts, append(operands, args[i+1:]...), true
+		case a == "-" || !strings.HasPrefix(a, "-"):
+			operands = append(operands, a)
+		case strings.HasPrefix(a, "--"):
+			key, v, _ := strings.Cut(a, "=")
+			switch {
+			case (name == "sed" && key == "--expression") || (awk && key == "--source"):
+				if v, ok = value(v); !ok {
+					return nil, nil, false
+				}
+				scripts = append(scripts, v)
+			case name == "sed" && key == "--in-place":
+				if strings.Contains(v, "/") {
+					return nil, nil, false
+				}
+			case name == "sed" && slices.Contains(sedLongFlags, a):
+			case awk && key == "--include":
+				if v, ok = value(v); !ok || v != "inplace" {
+					return nil, nil, false
+				}
+			case awk && key == "--assign":
+				if v, ok = value(v); !ok || !awkAssignSafe(v) {
+					return nil, nil, false
+				}
+			case awk && key == "--field-separator":
+				if _, ok = value(v); !ok {
+					return nil, nil, false
+				}
+			default:
+				return nil, nil, false
+			}
+		default:
+			// Short options, possibly combined as in -pi.bak or -nE
+			flags := a[1:]
+			for j := 0; j < len(flags); j++ {
+				c, rest := flags[j], flags[j+1:]
+				switch {
+				case c == 'i' && !awk:
+					// The rest is a backup suffix, which could name another
+					// directory
+					if strings.Contains(rest, "/") {
+						return nil, nil, false
+					}
+					j = len(flags)
+				case c == 'e' || (c == 'E' && name == "perl"):
+					v, ok := value(rest)
+					if !ok {
+						return nil, nil, false
+					}
+					scripts = append(scripts, v)
+					j = len(flags)
+				case awk && c == 'i':
+					if v, ok := value(rest); !ok || v != "inplace" {
+						return nil, nil, false
+					}
+					j = len(flags)
+				case awk && c == 'v':
+					if v, ok := value(rest); !ok || !awkAssignSafe(v) {
+						return nil, nil, false
+					}
+					j = len(flags)
+				case awk && c == 'F':
+					if _, ok := value(rest); !ok {
+						return nil, nil, false
+					}
+					j = len(flags)
+				case name == "perl" && (c == 'l' || c == '0'):
+					// An optional octal separator follows
+					for j+1 < len(flags) && flags[j+1] >= '0' && flags[j+1] <= '7' {
+						j++
+					}
+				case strings.IndexByte(plainFlags[name], c) >= 0:
+				default:
+					return nil, nil, false
+				}
+			}
+		}
+	}
+	return scripts, operands, true
+}
+
+// sedSafe reports whether a sed script only edits its input: it has no
+// e, r, R, w or W commands and no s///e or s///w flags. Anything it
+// doesn't recognise counts as unsafe.
+func sedSafe(script string) bool {
+	i := 0
+	// delimited skips past text ending in an unescaped delim
+	delimited := func(delim byte) bool {
+		for ; i < len(script); i++ {
+			switch script[i] {
+			case '\\':
+				i++
+			case delim:
+				i++
+				return true
+			}
+		}
+		return false
+	}
+	// toEnd skips to the end of the command
+	toEnd := func(ends string) {
+		for i < len(script) && !strings.ContainsRune(ends, rune(script[i])) {
+			i++
+		}
+	}
+
+	for i < len(script) {
+		c := script[i]
+		i++
+		switch {
+		case strings.ContainsRune(" \t\n;{}!,$~+0123456789", rune(c)):
+		case c == '/':
+			if !delimited('/') {
+				return false
+			}
+		case c == '\\':
+			if i == len(script) {
+				return false
+			}
+			i++
+			if !delimited(script[i-1]) {
+				return false
+			}
+		case c == 'I' || c == 'M':
+			// address flags
+		case c == 's':
+			if i == len(script) {
+				return false
+			}
+			delim := script[i]
+			i++
+			if !delimited(delim) || !delimited(delim) {
+				return false
+			}
+			for i < len(script) && strings.ContainsRune("gpiImM0123456789", rune(script[i])) {
+				i++
+			}
+			if i < len(script) && (script[i] == 'e' || script[i] == 'w' || script[i] == 'W') {
+				return false
+			}
+		case c == 'y':
+			if i == len(script) {
+				return false
+			}
+			delim := script[i]
+			i++
+			if !delimited(delim) || !delimited(delim) {
+				return false
+			}
+		case c == 'a' || c == 'i' || c == 'c' || c == '#':
+			toEnd("\n")
+		case c == ':' || c == 'b' || c == 't' || c == 'T':
+			toEnd(";\n}")
+		case strings.ContainsRune("dDgGhHnNpPxz=FlLqQ", rune(c)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// perlSafe reports whether a perl script is only s///, tr/// and y///
+// operations. Substitutions can't have the e flag, patterns can't embed
+// code with (?{...}), and the only variables interpolated are capture
+// groups, since a subscript or @{[...]} in an interpolated string runs
+// code.
+func perlSafe(script string) bool {
+	i := 0
+	// part skips past text ending in an unescaped delim
+	part := func(delim byte, interpolates bool) bool {
+		for ; i < len(script); i++ {
+			c := script[i]
+			switch {
+			case c == '\\':
+				i++
+			case c == delim:
+				i++
+				return true
+			case !interpolates:
+			case c == '@':
+				return false
+			case c == '(' && (strings.HasPrefix(script[i:], "(?{") || strings.HasPrefix(script[i:], "(??{") || strings.HasPrefix(script[i:], "(*{")):
+				return false
+			case c == '$':
+				j := i + 1
+				for j < len(script) && script[j] >= '0' && script[j] <= '9' {
+					j++
+				}
+				if j == i+1 && j < len(script) && script[j] == '&' {
+					j++
+				}
+				if j == i+1 {
+					// Only an end-of-line anchor
+					if j < len(script) && script[j] != delim && script[j] != ')' && script[j] != '|' {
+						return false
+					}
+				} else if rest := script[j:]; strings.HasPrefix(rest, "[") || strings.HasPrefix(rest, "{") || strings.HasPrefix(rest, "->") {
+					return false
+				}
+				i = j - 1
+			}
+		}
+		return false
+	}
+	// delimiter reads an operation's delimiter, refusing bracketing pairs
+	delimiter := func() (byte, bool) {
+		if i == len(script) {
+			return 0, false
+		}
+		d := script[i]
+		i++
+		return d, strings.IndexByte("/|!#,:%+=~^", d) >= 0
+	}
+
+	ops := 0
+	for i < len(script) {
+		var flags string
+		interpolates := true
+		switch {
+		case strings.IndexByte(" \t\n;", script[i]) >= 0:
+			i++
+			continue
+		case strings.HasPrefix(script[i:], "tr"):
+			i += 2
+			flags, interpolates = "cdsr", false
+		case script[i] == 'y':
+			i++
+			flags, interpolates = "cdsr", false
+		case script[i] == 's':
+			i++
+			flags = "msixpodualngcr"
+		default:
+			return false
+		}
+		delim, ok := delimiter()
+		if !ok || !part(delim, interpolates) || !part(delim, interpolates) {
+			return false
+		}
+		for i < len(script) && strings.IndexByte(flags, script[i]) >= 0 {
+			i++
+		}
+		if i < len(script) && strings.IndexByte(" \t\n;", script[i]) < 0 {
+			return false
+		}
+		ops++
+	}
+	return ops > 0
+}
+
+// awkFuncs are the awk built-ins a previewed script may call.
+var awkFuncs = map[string]bool{
+	"sub": true, "gsub": true, "gensub": true, "length": true, "substr": true,
+	"index": true, "split": true, "sprintf": true, "tolower": true,
+	"toupper": true, "match": true, "int": true, "sqrt": true, "exp": true,
+	"log": true, "sin": true, "cos": true, "atan2": true,
+}
+
+// awkKeywords are the awk statements a previewed script may use.
+var awkKeywords = map[string]bool{
+	"BEGIN": true, "END": true, "print": true, "printf": true, "if": true,
+	"else": true, "while": true, "for": true, "do": true, "in": true,
+	"next": true, "delete": true, "break": true, "continue": true,
+}
+
+// awkReserved are variables a script may not touch: assigning ARGV or ARGC
+// makes an in-place edit open other files, and INPLACE_SUFFIX names where
+// backups go.
+var awkReserved = map[string]bool{"ARGV": true, "ARGC": true, "INPLACE_SUFFIX": true}
+
+// awkSafe reports whether an awk script only prints, assigns and calls
+// string and maths built-ins: it has no getline, system, user-defined or
+// indirect functions, gawk @ directives, pipes or output redirections.
+func awkSafe(script string) bool {
+	parens, braces := 0, 0
+	// operand is whether the last token ends an expression, so a / after
+	// it divides rather than starting a regex
+	operand := false
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == ';':
+			i++
+			if c != ' ' && c != '\t' {
+				operand = false
+			}
+			continue
+		case c == '#':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '"' || (c == '/' && !operand):
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(script) {
+				return false
+			}
+			i++
+			operand = true
+			continue
+		case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			j := i
+			for j < len(script) && (script[j] == '_' || (script[j]|0x20 >= 'a' && script[j]|0x20 <= 'z') || (script[j] >= '0' && script[j] <= '9')) {
+				j++
+			}
+			word := script[i:j]
+			if awkReserved[word] || strings.HasPrefix(script[j:], "::") {
+				return false
+			}
+			next := strings.TrimLeft(script[j:], " \t")
+			if strings.HasPrefix(next, "(") && !awkFuncs[word] && !awkKeywords[word] {
+				return false
+			}
+			if !awkFuncs[word] && !awkKeywords[word] && !awkVariable(word) {
+				return false
+			}
+			i, operand = j, !awkKeywords[word]
+			continue
+		case c >= '0' && c <= '9' || c == '.':
+			operand = true
+		case c == '(' || c == '[':
+			parens++
+			operand = false
+		case c == ')' || c == ']':
+			parens--
+			operand = true
+		case c == '{':
+			braces++
+			operand = false
+		case c == '}':
+			braces--
+			operand = false
+		case c == '$':
+			operand = false
+		case c == '|':
+			if !strings.HasPrefix(script[i:], "||") {
+				return false
+			}
+			i++
+			operand = false
+		case c == '>':
+			// In an action, > outside parentheses redirects print's output
+			if braces > 0 && parens == 0 {
+				return false
+			}
+			operand = false
+		case strings.IndexByte("=+-*/%^!~?:&<,", c) >= 0:
+			operand = false
+		default:
+			return false
+		}
+		i++
+	}
+	return parens == 0 && braces == 0
+}
+
+// awkVariable reports whether word can be used as a plain variable
+// rather than being one of the statements awkSafe refuses.
+func awkVariable(word string) bool {
+	switch word {
+	case "system", "getline", "close", "fflush", "function", "func":
+		return false
+	}
+	return true
+}
+
+// awkAssignSafe reports whether a -v var=value assignment leaves the
+// variables awkSafe protects alone.
+func awkAssignSafe(assign string) bool {
+	name, _, ok := strings.Cut(assign, "=")
+	return ok && !awkReserved[name] && !strings.Contains(name, "::")
+}
+
+// copyFile copies a regular file of at most limit bytes from src to dst,
+// keeping its mode. It reports false for anything else.
+func copyFile(src, dst string, limit int64) (bool, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() || info.Size() > limit {
+		return false, nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
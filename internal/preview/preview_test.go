@@ -0,0 +1,249 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	want := `--- a/f.txt
++++ b/f.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := Unified("f.txt", before, after); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Unified("f.txt", before, before); got != "" {
+		t.Errorf("Unified() of identical text = %q, want empty", got)
+	}
+}
+
+func TestSedSafe(t *testing.T) {
+	cases := map[string]bool{
+		"s/foo/bar/g":         true,
+		`s|/usr/local|/opt|`:  true,
+		"/^#/d; s/a/b/2":      true,
+		`1,/end/{s/\/x/y/;p}`: true,
+		"$a\\last line":       true,
+		"y/abc/xyz/":          true,
+		"s/foo/date/e":        false,
+		"s/foo/bar/w out.txt": false,
+		"1e rm -rf /":         false,
+		"r /etc/passwd":       false,
+		"/x/w /tmp/leak":      false,
+		"s/unterminated":      false,
+	}
+	for script, want := range cases {
+		if got := sedSafe(script); got != want {
+			t.Errorf("sedSafe(%q) = %v, want %v", script, got, want)
+		}
+	}
+}
+
+func TestPerlSafe(t *testing.T) {
+	cases := map[string]bool{
+		"s/foo/bar/g":             true,
+		`s|^\s+||; tr/a-z/A-Z/`:   true,
+		`s/(\d+)px$/$1rem/`:       true,
+		"y/abc/xyz/":              true,
+		"s/a/b/e":                 false,
+		"s/a/system('id')/ee":     false,
+		"readpipe('id')":          false,
+		"CORE::readpipe('id')":    false,
+		`&{"sys"."tem"}("id")`:    false,
+		"s/a/@{[ `id` ]}/":        false,
+		"s/a/$x[system('id')]/":   false,
+		"s/(a)/$1->[0]/":          false,
+		"s/(?{ system('id') })//": false,
+		"s{a}{b}":                 false,
+		"print":                   false,
+		"s/a/b/; unlink 'f.txt'":  false,
+		"s/unterminated":          false,
+	}
+	for script, want := range cases {
+		if got := perlSafe(script); got != want {
+			t.Errorf("perlSafe(%q) = %v, want %v", script, got, want)
+		}
+	}
+}
+
+func TestAwkSafe(t *testing.T) {
+	cases := map[string]bool{
+		`{ sub(/a/, "b") } 1`:                  true,
+		`NR > 1 { print $1 }`:                  true,
+		`(NR > 1) { n = $2 * 2; print $1, n }`: true,
+		`{ $3 = $2 / 100 } 1`:                  true,
+		`/x|y/ { gsub(/a/, "b") } { print }`:   true,
+		`{ print | "sh" }`:                     false,
+		`{ print > "/tmp/leak" }`:              false,
+		`{ system("id") }`:                     false,
+		`{ "id" | getline x }`:                 false,
+		`@load "readfile"`:                     false,
+		`@include "evil.awk"`:                  false,
+		`BEGIN { ARGV[1] = "/etc/passwd" }`:    false,
+		`function f() { } { f() }`:             false,
+		`{ f = "system"; @f("id") }`:           false,
+	}
+	for script, want := range cases {
+		if got := awkSafe(script); got != want {
+			t.Errorf("awkSafe(%q) = %v, want %v", script, got, want)
+		}
+	}
+}
+
+func TestSafeScript(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"sed", "-i", "-E", "s/a+/b/", "f.txt"}, true},
+		{[]string{"sed", "-i.bak", "-e", "s/a/b/", "f.txt"}, true},
+		{[]string{"sed", "-i", "-f", "edit.sed", "f.txt"}, false},
+		{[]string{"sed", "-i../backup/*", "s/a/b/", "f.txt"}, false},
+		{[]string{"perl", "-pi", "-e", "s/a/b/", "f.txt"}, true},
+		{[]string{"perl", "-0777", "-pi.bak", "-e", "s/a/b/g", "f.txt"}, true},
+		{[]string{"perl", "-pi", "-e", "system('rm f.txt')", "f.txt"}, false},
+		{[]string{"perl", "-pi", "-MFile::Path", "-e", "s/a/b/", "f.txt"}, false},
+		{[]string{"perl", "-pi", "-d:Trace", "-e", "s/a/b/", "f.txt"}, false},
+		{[]string{"perl", "-pi", "f.txt"}, false},
+		{[]string{"gawk", "-i", "inplace", "{ sub(/a/, \"b\") } 1", "f.txt"}, true},
+		{[]string{"gawk", "-i", "inplace", "-v", "n=1", "{ print }", "f.txt"}, true},
+		{[]string{"gawk", "-i", "inplace", "{ print | \"sh\" }", "f.txt"}, false},
+		{[]string{"gawk", "-i", "/tmp/evil.awk", "{ print }", "f.txt"}, false},
+		{[]string{"gawk", "-f", "prog.awk", "f.txt"}, false},
+		{[]string{"gawk", "-E", "prog.awk", "f.txt"}, false},
+		{[]string{"gawk", "-l", "readfile", "{ print }", "f.txt"}, false},
+		{[]string{"gawk", "-i", "inplace", "-v", "INPLACE_SUFFIX=/tmp/x", "{ print }", "f.txt"}, false},
+		{[]string{"rm", "f.txt"}, false},
+	}
+	for _, tc := range cases {
+		if got := safeScript(tc.args[0], tc.args[1:]); got != tc.want {
+			t.Errorf("safeScript(%q) = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	if _, err := exec.LookPath("perl"); err != nil {
+		t.Skip("perl not installed")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(path, []byte("port = 80\nhost = localhost\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, ok, err := InPlace(context.Background(), "perl -pi -e 's/80/8080/' app.conf", dir)
+	if err != nil || !ok {
+		t.Fatalf("InPlace() = %v, %v, want a preview", ok, err)
+	}
+	want := "--- a/app.conf\n+++ b/app.conf\n@@ -1,2 +1,2 @@\n-port = 80\n+port = 8080\n host = localhost\n"
+	if len(changes) != 1 || changes[0].Path != "app.conf" || changes[0].Diff != want {
+		t.Errorf("InPlace() changes = %+v, want one diff of app.conf:\n%s", changes, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "port = 80\nhost = localhost\n" {
+		t.Errorf("the original was changed: %q", data)
+	}
+
+	for _, command := range []string{
+		"cat app.conf",
+		"perl -pi -e 'unlink q(app.conf)' app.conf",
+		"perl -pi -e 's/80/8080/' /etc/hosts",
+		"perl -pi -e 's/80/8080/' app.conf && rm app.conf",
+	} {
+		if _, ok, _ := InPlace(context.Background(), command, dir); ok {
+			t.Errorf("InPlace(%q) previewed, want it skipped", command)
+		}
+	}
+}
+
+// unsandboxed runs Rehearse's commands with plain sh for the rest of t,
+// so the tests don't depend on bwrap being installed.
+func unsandboxed(t *testing.T) {
+	restore := sandbox
+	sandbox = func(ctx context.Context, dir, command string) (*exec.Cmd, error) {
+		return exec.CommandContext(ctx, "sh", "-c", command), nil
+	}
+	t.Cleanup(func() { sandbox = restore })
+}
+
+func TestRehearse(t *testing.T) {
+	unsandboxed(t)
+	dir := t.TempDir()
+	for name, content := range map[string]string{"notes.txt": "one\n", "a.txt": "a\n", "other.txt": "untouched\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Rehearse(context.Background(), "echo two >> notes.txt && mv a.txt c.txt && echo done", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ExitCode != 0 || r.Output != "done\n" {
+		t.Errorf("Rehearse() exit %d, output %q, want 0 and %q", r.ExitCode, r.Output, "done\n")
+	}
+	if len(r.Changes) != 1 || r.Changes[0].Path != "notes.txt" {
+		t.Errorf("Rehearse() changes = %+v, want notes.txt", r.Changes)
+	}
+	if !reflect.DeepEqual(r.Created, []string{"c.txt"}) || !reflect.DeepEqual(r.Removed, []string{"a.txt"}) {
+		t.Errorf("Rehearse() created %v, removed %v, want [c.txt] and [a.txt]", r.Created, r.Removed)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.txt")); string(data) != "one\n" {
+		t.Errorf("the original was changed: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+		t.Errorf("the original was moved: %v", err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "keep.txt")
+	if err := os.WriteFile(outside, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rehearse(context.Background(), "rm "+outside, dir); err == nil {
+		t.Error("Rehearse() of a command on a path outside dir succeeded, want an error")
+	}
+}
+
+func TestRehearseNeedsSandbox(t *testing.T) {
+	restore := sandbox
+	sandbox = func(context.Context, string, string) (*exec.Cmd, error) { return nil, ErrNoSandbox }
+	t.Cleanup(func() { sandbox = restore })
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran")
+	if _, err := Rehearse(context.Background(), "touch "+marker, dir); !errors.Is(err, ErrNoSandbox) {
+		t.Errorf("Rehearse() error = %v, want ErrNoSandbox", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Rehearse() ran the command without a sandbox")
+	}
+}
+
+func TestBwrapArgs(t *testing.T) {
+	args := strings.Join(bwrapArgs("/tmp/how-rehearse-1", "rm -rf x"), " ")
+	for _, want := range []string{"--unshare-all", "--ro-bind / /", "--bind /tmp/how-rehearse-1 /tmp/how-rehearse-1", "-- sh -c rm -rf x"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("bwrapArgs() = %q, missing %q", args, want)
+		}
+	}
+}
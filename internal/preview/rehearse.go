@@ -0,0 +1,208 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/swibrow/how/risk"
+)
+
+// Limits on what Rehearse copies.
+const (
+	maxRehearseFiles = 10000
+	maxRehearseBytes = 100 << 20
+)
+
+// Rehearsal is what a command did when run against copies of the files
+// and directories it touches.
+type Rehearsal struct {
+	// Changes are the files it modified.
+	Changes []Change
+	// Created and Removed are files it added or deleted.
+	Created []string
+	Removed []string
+	// Output is what it printed, and ExitCode how it exited.
+	Output   string
+	ExitCode int
+}
+
+// ErrNoSandbox is returned by Rehearse when there's no sandbox to contain
+// the command in.
+var ErrNoSandbox = errors.New("rehearsing needs bubblewrap (bwrap) on Linux or sandbox-exec on macOS")
+
+// sandbox returns a command that runs command with sh in dir, without
+// network access and unable to write anywhere but dir. It's a variable so
+// tests can run where no sandbox is installed.
+var sandbox = func(ctx context.Context, dir, command string) (*exec.Cmd, error) {
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		return exec.CommandContext(ctx, bwrap, bwrapArgs(dir, command)...), nil
+	}
+	if runtime.GOOS == "darwin" {
+		if sb, err := exec.LookPath("sandbox-exec"); err == nil {
+			return exec.CommandContext(ctx, sb, "-p", seatbeltProfile(dir), "sh", "-c", command), nil
+		}
+	}
+	return nil, ErrNoSandbox
+}
+
+// bwrapArgs runs command on a read-only view of the system with dir
+// writable, in new namespaces so it has no network.
+func bwrapArgs(dir, command string) []string {
+	return []string{
+		"--unshare-all", "--die-with-parent", "--new-session",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--bind", dir, dir,
+		"--chdir", dir,
+		"--", "sh", "-c", command,
+	}
+}
+
+// seatbeltProfile is the sandbox-exec equivalent of bwrapArgs.
+func seatbeltProfile(dir string) string {
+	return fmt.Sprintf(`(version 1)
+(allow default)
+(deny network*)
+(deny file-write*)
+(allow file-write* (subpath %q) (literal "/dev/null"))
+`, dir)
+}
+
+// Rehearse copies the existing files and directories command refers to
+// from dir into a temporary directory, runs command there in a sandbox
+// with no network that can only write to the copies, and reports what it
+// changed. Commands that name paths outside dir are refused, and so is
+// everything when no sandbox is available, since running it would touch
+// the originals.
+func Rehearse(ctx context.Context, command, dir string) (*Rehearsal, error) {
+	paths := risk.AffectedPaths(command, dir)
+	all := append(append(append([]string{}, paths.Read...), paths.Modified...), paths.Deleted...)
+	for _, p := range all {
+		if !filepath.IsLocal(strings.TrimSuffix(p, "/")) {
+			return nil, fmt.Errorf("%s is outside the current directory", p)
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "how-rehearse-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp) //nolint:errcheck
+	// sandbox-exec matches resolved paths, and macOS's temp dir is a symlink
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		return nil, err
+	}
+	cmd, err := sandbox(ctx, tmp, command)
+	if err != nil {
+		return nil, err
+	}
+
+	var files, size int64
+	copied := map[string]bool{}
+	for _, p := range all {
+		root := strings.TrimSuffix(p, "/")
+		err := filepath.WalkDir(filepath.Join(dir, root), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return os.MkdirAll(filepath.Join(tmp, rel), 0o700)
+			}
+			if copied[rel] || !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files++
+			size += info.Size()
+			if files > maxRehearseFiles || size > maxRehearseBytes {
+				return fmt.Errorf("%s is too large to copy (over %d files or %d MiB)", p, maxRehearseFiles, maxRehearseBytes>>20)
+			}
+			if _, err := copyFile(path, filepath.Join(tmp, rel), info.Size()); err != nil {
+				return err
+			}
+			copied[rel] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	cmd.Dir = tmp
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	r := &Rehearsal{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("running in %s: %w", tmp, err)
+		}
+		r.ExitCode = exitErr.ExitCode()
+	}
+	r.Output = out.String()
+
+	seen := map[string]bool{}
+	err = filepath.WalkDir(tmp, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(tmp, path)
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		if !copied[rel] {
+			r.Created = append(r.Created, rel)
+			return nil
+		}
+		before, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		after, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(before, after) {
+			r.Changes = append(r.Changes, Change{Path: rel, Diff: fileDiff(rel, before, after)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for rel := range copied {
+		if !seen[rel] {
+			r.Removed = append(r.Removed, rel)
+		}
+	}
+	sort.Strings(r.Removed)
+	return r, nil
+}
+
+// fileDiff is Unified for text, and a note for binary files.
+func fileDiff(path string, before, after []byte) string {
+	if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+		return fmt.Sprintf("Binary file %s changed\n", path)
+	}
+	return Unified(path, string(before), string(after))
+}
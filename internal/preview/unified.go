@@ -0,0 +1,145 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is how many unchanged lines surround each hunk.
+const contextLines = 3
+
+// maxDiffCells bounds the table used to diff the changed middle of two
+// files; larger changes are shown as the whole middle replaced.
+const maxDiffCells = 4_000_000
+
+type line struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// Unified returns a unified diff of before and after labelled with path,
+// or "" when they're the same.
+func Unified(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	lines := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	// aAt and bAt are the 1-based line numbers each entry of lines starts at
+	aAt, bAt := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	aAt[0], bAt[0] = 1, 1
+	for i, l := range lines {
+		aAt[i+1], bAt[i+1] = aAt[i], bAt[i]
+		if l.op != '+' {
+			aAt[i+1]++
+		}
+		if l.op != '-' {
+			bAt[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := max(0, i-contextLines)
+		end := i
+		// extend the hunk while the next change is close enough to share context
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j
+			} else if j-end > 2*contextLines {
+				break
+			}
+		}
+		end = min(len(lines), end+contextLines+1)
+
+		aLen, bLen := aAt[end]-aAt[start], bAt[end]-bAt[start]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(aAt[start], aLen), hunkRange(bAt[start], bLen))
+		for _, l := range lines[start:end] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk's start and length; an empty range starts at
+// the line before it, as diff does.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a line diff from the longest common subsequence of
+// a and b, after setting aside their common prefix and suffix.
+func diffLines(a, b []string) []line {
+	var prefix, suffix []line
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, line{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]line{{' ', a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	out := prefix
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, s := range a {
+			out = append(out, line{'-', s})
+		}
+		for _, s := range b {
+			out = append(out, line{'+', s})
+		}
+		return append(out, suffix...)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, line{'-', a[i]})
+			i++
+		default:
+			out = append(out, line{'+', b[j]})
+			j++
+		}
+	}
+	return append(out, suffix...)
+}
@@ -3,8 +3,13 @@ package prompt
 import (
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/k8s"
 	"github.com/swibrow/how/internal/memory"
 )
 
@@ -33,6 +38,48 @@ Examples of interactive selection:
 - Opening a file: find . -type f | fzf | xargs open
 - Checking out a PR: gh pr list | fzf | awk '{print $1}' | xargs gh pr checkout`
 
+// StrictFormatReminder is appended to the system prompt when retrying a
+// response that contained no command.
+const StrictFormatReminder = `Your previous reply did not contain a usable command. Reply with exactly one COMMAND line and one EXPLANATION line and nothing else. If the request cannot be done safely with a shell command, give the closest safe command (for example one that only inspects or lists) and say why in the EXPLANATION.`
+
+// ClarifyInstruction is appended to the system prompt when the user can
+// answer a clarifying question, letting the model ask one instead of
+// guessing.
+const ClarifyInstruction = `If the request is ambiguous in a way that changes which command is right (which of several things it refers to, or the scope of something destructive), you may instead reply with exactly one line and nothing else:
+
+QUESTION: <one short clarifying question>
+
+Only ask when a wrong guess would be costly; otherwise pick the most common interpretation and answer in the usual format.`
+
+// Clarifying reports whether sysPrompt lets the model ask a clarifying
+// question.
+func Clarifying(sysPrompt string) bool {
+	return strings.Contains(sysPrompt, ClarifyInstruction)
+}
+
+// Clarification is one clarifying question the model asked and the
+// user's answer to it.
+type Clarification struct {
+	Question string
+	Answer   string
+}
+
+// ClarifiedQuery adds the model's clarifying questions and the user's
+// answers, in order, to query. An empty answer leaves the choice to the
+// model.
+func ClarifiedQuery(query string, rounds []Clarification) string {
+	var b strings.Builder
+	b.WriteString(query)
+	for _, c := range rounds {
+		answer := c.Answer
+		if answer == "" {
+			answer = "(no answer; assume the most common interpretation)"
+		}
+		fmt.Fprintf(&b, "\n\nYou asked: %s\nThe user answered: %s", c.Question, answer)
+	}
+	return b.String()
+}
+
 // SystemPrompt returns the system prompt with OS-specific context appended.
 // If customPrompt is non-empty, it replaces the default base prompt.
 func SystemPrompt(customPrompt string) string {
@@ -40,11 +87,159 @@ func SystemPrompt(customPrompt string) string {
 	if customPrompt != "" {
 		base = customPrompt
 	}
-	osHint := osContext()
-	if osHint == "" {
-		return base
+	return withContextRules(base)
+}
+
+// RemoteSystemPrompt is SystemPrompt for commands that will run on target,
+// a remote host described by host, instead of the user's machine.
+func RemoteSystemPrompt(customPrompt, target, host string) string {
+	base := baseSystemPrompt
+	if customPrompt != "" {
+		base = customPrompt
 	}
-	return base + "\n- " + osHint
+	return base + fmt.Sprintf("\n- The command will run over SSH on the remote host %s: %s. Tailor it to that host, not the user's machine.", target, host) + "\n- " + dataRule
+}
+
+// withContextRules appends the OS-specific rule and the rule for delimited
+// data to a prompt's rule list.
+func withContextRules(base string) string {
+	if osHint := osContext(); osHint != "" {
+		base += "\n- " + osHint
+	}
+	return base + "\n- " + dataRule
+}
+
+// FormatMachineContext formats collected facts about the user's machine as
+// context for the LLM prompt.
+func FormatMachineContext(facts []collect.Fact) string {
+	if len(facts) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, f := range facts {
+		lines = append(lines, "- "+f.Value)
+	}
+	return "\nAbout the user's environment:\n" + Untrusted(strings.Join(lines, "\n")) +
+		"\nPrefer tools that are installed and tailor commands to this environment.\n"
+}
+
+// FormatDetails formats facts collected for a subcommand as titled
+// sections under heading.
+func FormatDetails(heading string, facts []collect.Fact) string {
+	if len(facts) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s:\n", heading)
+	for _, f := range facts {
+		fmt.Fprintf(&b, "\n%s:\n%s\n", f.Name, Untrusted(truncate(f.Value, maxSampleBytes)))
+	}
+	return b.String()
+}
+
+// ShellRule is the prompt rule for users whose shell, as named by
+// shell.Names, needs commands in its own syntax, or "" for shells that
+// run POSIX commands.
+func ShellRule(name string) string {
+	switch name {
+	case "nushell":
+		return "\n- The user's shell is Nushell, and the command will run with nu -c. Write it in Nushell syntax, not POSIX sh: structured pipelines (ls | where size > 10mb | sort-by modified), $env.NAME for environment variables, (cmd) for command substitution, `and`/`or` or separate statements instead of && and ||, and ^name to run an external command that shadows a built-in."
+	case "powershell":
+		return "\n- The user's shell is PowerShell 7 (pwsh), and the command will run with pwsh -Command. Write it in PowerShell syntax, not POSIX sh: cmdlets and pipelines of objects (Get-ChildItem -Recurse | Where-Object Length -gt 10MB), $env:NAME for environment variables, $(...) for subexpressions and ; or -and between statements. Native tools such as git, grep and curl are still available, and aliases like ls and rm are PowerShell cmdlets, not the GNU tools."
+	}
+	return ""
+}
+
+// CIRule is the prompt rule for commands that will run unattended in the
+// named CI service, or "" when system is empty.
+func CIRule(system string) string {
+	if system == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n- The command will run unattended in %s, with no terminal and nobody to answer prompts. Use non-interactive flags (-y, --yes, --no-progress, --non-interactive, DEBIAN_FRONTEND=noninteractive), never fzf, pagers or editors, and fail rather than wait for input.", system)
+}
+
+// EscalationRule is the prompt rule for running commands as root with
+// escalator, as chosen by shell.ChooseEscalator: "" and ok for a root
+// user, and false ok when there's no way to become root. It's "" for sudo,
+// which the model uses anyway.
+func EscalationRule(escalator string, ok bool) string {
+	switch {
+	case !ok:
+		return "\n- The user isn't root and neither sudo nor doas is installed, so they can't run commands as root. Prefer commands that work without root, such as user-level installs into ~/.local, and say in the explanation when something needs root."
+	case escalator == "":
+		return "\n- The user is already root, so don't prefix commands with sudo."
+	case escalator != "sudo":
+		return fmt.Sprintf("\n- sudo isn't installed; use %s instead to run commands as root.", escalator)
+	}
+	return ""
+}
+
+// SessionRule is the prompt rule for a user working in session, as
+// described by collect.Session, or "" when session is empty.
+func SessionRule(session string) string {
+	if session == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n- The user is working %s, so there is no desktop or clipboard to use. Don't open GUI programs or browsers (open, xdg-open, start) or use clipboard commands (pbcopy, xclip, xsel, wl-copy); print the result or write it to a file instead.", session)
+}
+
+// FormatClock tells the model the user's local time, time zone and
+// locale, so relative times like "since yesterday 9am" resolve to the
+// right absolute values. zone and locale may be empty.
+func FormatClock(now time.Time, zone, locale string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nThe user's local time is %s (UTC%s", now.Format("Monday 2006-01-02 15:04 MST"), now.Format("-07:00"))
+	if zone != "" {
+		b.WriteString(", " + zone)
+	}
+	b.WriteString(")")
+	if locale != "" {
+		b.WriteString(" and their locale is " + locale)
+	}
+	b.WriteString(". Work out dates and times from it, in local time unless asked otherwise, rather than assuming UTC.\n")
+	return b.String()
+}
+
+// FormatPreferences tells the model which tools the user prefers over
+// others, from the prefer setting.
+func FormatPreferences(prefer map[string]string) string {
+	if len(prefer) == 0 {
+		return ""
+	}
+	tools := make([]string, 0, len(prefer))
+	for tool := range prefer {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	var b strings.Builder
+	b.WriteString("\nThe user prefers these tools; use them, with their own flags and syntax, instead of the alternatives:\n")
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "- %s instead of %s\n", prefer[tool], tool)
+	}
+	return b.String()
+}
+
+// FormatAliases lists the user's shell aliases so the model can use them
+// in place of the commands they stand for.
+func FormatAliases(aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s=%s", name, aliases[name])
+	}
+	return "\nThe user's shell defines these aliases, which the command may use where they fit:\n" + Untrusted(strings.Join(lines, "\n")) + "\n"
 }
 
 // FormatMemoryContext formats past interactions as context for the LLM prompt.
@@ -66,6 +261,30 @@ func FormatMemoryContext(interactions []memory.Interaction) string {
 	return b.String()
 }
 
+// FormatFeedback formats answers the user rated for similar questions:
+// accepted ones as examples to follow, rejected ones as answers to avoid.
+func FormatFeedback(feedback []memory.Feedback) string {
+	var good, bad strings.Builder
+	for _, f := range feedback {
+		if f.Accepted {
+			fmt.Fprintf(&good, "\nQ: %s\nCOMMAND: %s\n", f.Question, f.Command)
+		} else {
+			fmt.Fprintf(&bad, "- Q: %s → $ %s\n", f.Question, f.Command)
+		}
+	}
+
+	var b strings.Builder
+	if good.Len() > 0 {
+		b.WriteString("\nExamples of answers the user marked as good for similar questions:\n")
+		b.WriteString(good.String())
+	}
+	if bad.Len() > 0 {
+		b.WriteString("\nThe user marked these answers as wrong; don't suggest them again:\n")
+		b.WriteString(bad.String())
+	}
+	return b.String()
+}
+
 func osContext() string {
 	switch runtime.GOOS {
 	case "darwin":
@@ -78,3 +297,488 @@ func osContext() string {
 		return ""
 	}
 }
+
+const regexSystemPrompt = `You are a regular expression expert. The user will describe text they want to match. Respond with a single regular expression in the %s dialect and a brief explanation.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the regular expression>
+EXPLANATION: <brief one-line explanation>
+
+Rules:
+- Output the bare pattern: no surrounding slashes, quotes, flags, or backticks
+- Prefer the simplest pattern that correctly matches the description
+- Do not include any text outside the COMMAND/EXPLANATION format`
+
+var regexDialectNames = map[string]string{
+	"pcre":  "PCRE (Perl-compatible)",
+	"posix": "POSIX extended (ERE)",
+	"go":    "Go RE2 (regexp package)",
+}
+
+// RegexPrompt returns the system prompt for generating a regular expression
+// in the given dialect ("pcre", "posix" or "go").
+func RegexPrompt(dialect string) string {
+	name, ok := regexDialectNames[dialect]
+	if !ok {
+		name = dialect
+	}
+	return fmt.Sprintf(regexSystemPrompt, name)
+}
+
+const querySystemPrompt = `You are a %[1]s expert. The user will describe what they want to extract or transform from the %[2]s document below. Respond with a single %[1]s expression and a brief explanation.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the %[1]s expression>
+EXPLANATION: <brief one-line explanation>
+
+Rules:
+- Output only the filter expression, not the full shell command, and without surrounding quotes or backticks
+- The expression must work against the structure of the sample, which may be truncated
+- Do not include any text outside the COMMAND/EXPLANATION format
+- %[4]s
+
+Sample input:
+%[3]s`
+
+// maxSampleBytes bounds how much piped input is included in the prompt.
+const maxSampleBytes = 4096
+
+// QueryPrompt returns the system prompt for building a jq or yq expression
+// against a sample of the user's data. format is "JSON" or "YAML".
+func QueryPrompt(tool, format, sample string) string {
+	return fmt.Sprintf(querySystemPrompt, tool, format, Untrusted(truncate(sample, maxSampleBytes)), dataRule)
+}
+
+// truncate shortens s to at most n bytes, marking it when cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n... (truncated)"
+}
+
+const undoSystemPrompt = `You are a terminal command expert. The user will give you a shell command they just ran. Respond with the command that reverses its effects, if one exists.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the command that undoes it, or NONE>
+EXPLANATION: <brief one-line explanation>
+WARNING: <what cannot be restored, or omit this line if the undo is complete>
+
+Rules:
+- Use the tool's own undo mechanism where one exists (e.g. git reset/reflog, kubectl rollout undo, mv back to the original path)
+- If the action is irreversible (e.g. rm without a trash, dropped databases, overwritten files), respond with COMMAND: NONE and explain in WARNING what was lost
+- If the undo is only partial, give the best command and describe the gap in WARNING
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// UndoPrompt returns the system prompt for reversing a previously executed command.
+func UndoPrompt() string {
+	return withContextRules(undoSystemPrompt)
+}
+
+const optimizeSystemPrompt = `You are a terminal command expert. The user will give you a shell command. Respond with a faster, safer, or more idiomatic version of it that does the same thing.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the improved command>
+EXPLANATION: <brief one-line explanation of what changed and why>
+
+Rules:
+- Preserve the command's behaviour and output unless it is clearly a bug
+- Prefer purpose-built tools where they are commonly installed (e.g. rg over find | xargs grep, find -delete over find | xargs rm)
+- Quote variables and paths that could contain spaces, and handle filenames with newlines where relevant
+- If the command is already optimal, return it unchanged and say so in the explanation
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION format`
+
+// OptimizePrompt returns the system prompt for improving an existing command.
+func OptimizePrompt() string {
+	return withContextRules(optimizeSystemPrompt)
+}
+
+const translateSystemPrompt = `You are an expert in shell scripting across platforms. The user will give you a %[1]s command. Translate it into an equivalent %[2]s command.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the %[2]s command>
+EXPLANATION: <brief one-line explanation>
+WARNING: <behaviour that doesn't translate exactly, or omit this line>
+
+Rules:
+- Use idiomatic %[2]s syntax and built-ins rather than emulating %[1]s
+- Preserve quoting, pipelines and exit-status behaviour where the target shell allows
+- If an external tool has no equivalent on the target platform, say so in WARNING
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// TranslatePrompt returns the system prompt for converting a command from
+// one shell dialect to another.
+func TranslatePrompt(from, to string) string {
+	return fmt.Sprintf(translateSystemPrompt, from, to)
+}
+
+const teachSystemPrompt = `You are a patient terminal instructor. The user wants to learn how to accomplish a task on the command line. Break it into a short sequence of 2-6 commands that build on each other, each with an explanation aimed at a beginner.
+
+You MUST respond in exactly this format, repeating the pair for each step:
+
+STEP: <the command>
+EXPLANATION: <one or two sentences on what this step does and why>
+
+Rules:
+- Start with commands that inspect state before commands that change it
+- Each step must be a single runnable command
+- Explain flags the first time they appear
+- Do not wrap commands in backticks or code blocks
+- Do not include any text outside the STEP/EXPLANATION format`
+
+// TeachPrompt returns the system prompt for step-by-step tutorial mode.
+func TeachPrompt() string {
+	return withContextRules(teachSystemPrompt)
+}
+
+const explainSystemPrompt = `You are a terminal command expert. The user will give you a shell command. Explain what it does.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the command, unchanged>
+EXPLANATION: <what the command does, one sentence per pipeline stage or notable flag, on a single line>
+WARNING: <side effects the user should know about (deletes files, needs root, reaches the network), or omit this line>
+
+Rules:
+- Do not suggest a different command
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// ExplainPrompt returns the system prompt for explaining an existing command.
+func ExplainPrompt() string {
+	return withContextRules(explainSystemPrompt)
+}
+
+const fixSystemPrompt = `You are a terminal command expert. The user will give you a shell command that failed, along with its error output when available. Respond with a corrected command that achieves what they were trying to do.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the corrected command>
+EXPLANATION: <brief one-line explanation of what was wrong>
+
+Rules:
+- Keep the user's intent and as much of the original command as possible
+- If the failure is environmental (missing tool, permissions, no network), give the command that resolves it and say so
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION format`
+
+// FixPrompt returns the system prompt for correcting a failed command.
+func FixPrompt() string {
+	return withContextRules(fixSystemPrompt)
+}
+
+// FixQuery formats a failed command and its error output as a user query.
+func FixQuery(command, errOutput string) string {
+	if strings.TrimSpace(errOutput) == "" {
+		return "Command: " + command
+	}
+	return fmt.Sprintf("Command: %s\nError output:\n%s", command, Untrusted(truncate(errOutput, maxSampleBytes)))
+}
+
+const k8sSystemPrompt = `You are a Kubernetes troubleshooting expert. The user will describe a problem or task in their cluster, along with recent events from the namespace. Respond with the kubectl command that best diagnoses or resolves it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the kubectl command>
+EXPLANATION: <what the command does and what to look for in its output, on a single line>
+WARNING: <optional, only if the command changes or disrupts the cluster>
+
+Rules:
+- Every kubectl invocation must pass --context %[1]s and -n %[2]s (or -A when the question spans namespaces)
+- Prefer read-only commands (get, describe, logs, events, top) to find the cause before changing anything
+- Only suggest delete, scale, drain or rollout restart when the user asks for a change, and name exactly which resources are affected in the WARNING
+- Never delete namespaces, persistent volumes or use --all unless the user explicitly asks
+- Use the events to point at the failing resource
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format
+- %[3]s
+
+The cluster:
+- Context: %[1]s
+- Namespace: %[2]s`
+
+// K8sPrompt returns the system prompt for troubleshooting in target.
+func K8sPrompt(target k8s.Target) string {
+	p := fmt.Sprintf(k8sSystemPrompt, target.Context, target.Namespace, dataRule)
+	if target.Cluster != "" {
+		p += "\n- Cluster: " + target.Cluster
+	}
+	if target.Server != "" {
+		p += "\n- API server: " + target.Server
+	}
+	return p
+}
+
+// K8sQuery formats a question with recent namespace events.
+func K8sQuery(question string, events []k8s.Event) string {
+	if len(events) == 0 {
+		return question + "\n\nNo recent events in the namespace."
+	}
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&b, "- %s\n", e)
+	}
+	return question + "\n\nRecent events (oldest first):\n" + Untrusted(truncate(strings.TrimSuffix(b.String(), "\n"), 2*maxSampleBytes))
+}
+
+const gitSystemPrompt = `You are a git expert. The user will describe what they want to do in their repository, whose current state is given below. Respond with the git command or short command sequence that does it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the command, joining several steps with &&>
+EXPLANATION: <brief one-line explanation, referring to the real branches and commits>
+WARNING: <optional, only if the command rewrites history, discards work or needs a force push>
+
+Rules:
+- Use the real branch names, commit hashes, stash entries and reflog entries from the repository state
+- If a rebase, merge, cherry-pick or bisect is in progress, account for it (e.g. continue or abort it first)
+- Prefer recoverable operations, and mention the reflog entry that undoes a history rewrite
+- Never use --force where --force-with-lease works
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// GitPrompt returns the system prompt for `how git`, with the repository
+// state from collect.GitDetails.
+func GitPrompt(facts []collect.Fact) string {
+	return withContextRules(gitSystemPrompt) + "\n" + FormatDetails("The repository", facts)
+}
+
+const dockerSystemPrompt = `You are a Docker and Docker Compose expert. The user will describe what they want to do with their containers. The containers, images and compose project on their machine are given below. Respond with the command that does it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the docker or docker compose command>
+EXPLANATION: <brief one-line explanation>
+WARNING: <optional, only if the command removes containers, images, volumes or data>
+
+Rules:
+- Use the real container, image and service names listed below; match the user's description to the closest one
+- Use docker compose (not docker-compose) for services in the compose project, and plain docker for other containers
+- Never remove volumes (-v, volume rm, system prune --volumes) unless the user asks
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// DockerPrompt returns the system prompt for `how docker`, with the state
+// from collect.DockerDetails.
+func DockerPrompt(facts []collect.Fact) string {
+	return withContextRules(dockerSystemPrompt) + "\n" + FormatDetails("Docker on this machine", facts)
+}
+
+const systemdSystemPrompt = `You are a systemd expert. The user will describe a service or scheduled job. Write the unit files for it: a .service, plus a .timer when it should run on a schedule.
+
+You MUST respond in exactly this format:
+
+UNIT: <file name, e.g. backup.service>
+<the complete unit file>
+UNIT: <file name of the next unit, if any>
+<the complete unit file>
+ENABLE: <the unit to enable and start: the .timer if there is one, otherwise the .service>
+EXPLANATION: <brief one-line explanation of what the units do>
+
+Rules:
+- These are %s
+- Use absolute paths in ExecStart
+- Make scheduled services Type=oneshot without an [Install] section, and give the timer WantedBy=timers.target
+- Give long-running services Restart=on-failure and an [Install] section with WantedBy=%s
+- Do not wrap the files in backticks or code blocks
+- Do not include any text outside this format`
+
+// SystemdPrompt returns the system prompt for generating unit files, for
+// the user's service manager when user is true.
+func SystemdPrompt(user bool) string {
+	if user {
+		return fmt.Sprintf(systemdSystemPrompt, "user units, run by systemctl --user without root; don't set User=", "default.target")
+	}
+	return fmt.Sprintf(systemdSystemPrompt, "system units installed in /etc/systemd/system; set User= when the job shouldn't run as root", "multi-user.target")
+}
+
+const scriptSystemPrompt = `You are a shell scripting expert. The user will describe a task. Write a complete %[1]s script that does it.
+
+You MUST respond in exactly this format:
+
+FILE: <a short file name for the script, e.g. rotate-logs.sh>
+<the complete script, starting with #!%[2]s>
+EXPLANATION: <brief one-line explanation of what the script does and how to run it>
+
+Rules:
+- %[3]s
+- Take paths and other values that vary as arguments or environment variables with sensible defaults, and check them
+- Quote variables, and print errors to stderr
+- Do not wrap the script in backticks or code blocks
+- Do not include any text outside this format`
+
+// ScriptPrompt returns the system prompt for how script, for a bash
+// script or, when posix is true, a POSIX sh one.
+func ScriptPrompt(posix bool) string {
+	if posix {
+		return fmt.Sprintf(scriptSystemPrompt, "POSIX sh", "/bin/sh", "Use only POSIX sh features and utilities, and start with set -eu")
+	}
+	return fmt.Sprintf(scriptSystemPrompt, "bash", "/usr/bin/env bash", "Start with set -euo pipefail")
+}
+
+const mediaSystemPrompt = `You are an ffmpeg and ImageMagick expert. The user will describe a conversion or edit of the input files, whose probed details are given below. Respond with the command that does it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the ffmpeg, magick or other media command>
+EXPLANATION: <brief one-line explanation, including why the codec, size or quality settings were chosen>
+WARNING: <optional, only if the command loses quality, drops streams or overwrites a file>
+
+Rules:
+- Use the input file paths exactly as given, and never write over an input file
+- Base codec, resolution, frame rate and pixel format choices on the probed details, and copy streams (-c copy) when no re-encode is needed
+- Keep audio, subtitle and other streams unless the user asks to drop them
+- Only use the installed media tools; prefer magick over convert when both exist
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// MediaPrompt returns the system prompt for `how media`, with the details
+// from collect.MediaDetails.
+func MediaPrompt(facts []collect.Fact) string {
+	return withContextRules(mediaSystemPrompt) + "\n" + FormatDetails("The media", facts)
+}
+
+const sqlSystemPrompt = `You are a %[1]s SQL expert. The user will ask a question about their database, whose tables and columns are listed below. Respond with a single query that answers it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <the SQL query, on a single line>
+EXPLANATION: <brief one-line explanation of how the query answers the question>
+WARNING: <optional, only if the query could be slow on large tables>
+
+Rules:
+- Write %[1]s SQL using only the tables and columns listed below
+- Answer with a read-only SELECT (or WITH ... SELECT) query; never modify data or schema
+- Add a LIMIT when the question doesn't ask for every row
+- Do not wrap the query in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format
+- %[3]s
+
+Tables:
+%[2]s`
+
+// sqlDialectNames are the display names of sqldb dialects.
+var sqlDialectNames = map[string]string{
+	"postgres": "PostgreSQL",
+	"mysql":    "MySQL",
+	"sqlite":   "SQLite",
+}
+
+// SQLPrompt returns the system prompt for writing a query in dialect
+// against schema, as formatted by sqldb.FormatSchema.
+func SQLPrompt(dialect, schema string) string {
+	name := sqlDialectNames[dialect]
+	if name == "" {
+		name = dialect
+	}
+	return fmt.Sprintf(sqlSystemPrompt, name, Untrusted(truncate(schema, 4*maxSampleBytes)), dataRule)
+}
+
+const rawSystemPrompt = `You are a terminal and command-line expert. Answer the user's question directly, in plain text suitable for a terminal.
+
+Rules:
+- Be concise: lead with the answer, then only the detail needed
+- For comparisons, give the key differences and when to use each
+- Put commands on their own lines, indented by four spaces, without markdown fences`
+
+// RawPrompt returns the system prompt for free-form answers (--raw), which
+// aren't parsed as COMMAND/EXPLANATION.
+func RawPrompt() string {
+	return withContextRules(rawSystemPrompt)
+}
+
+const whySystemPrompt = `You are a terminal troubleshooting expert. The user will give you the shell command they just ran, its exit status and, when available, its error output. Explain what went wrong and what to do about it.
+
+You MUST respond in exactly this format:
+
+COMMAND: <a command that fixes the problem or retries correctly, or NONE if the fix is not a command>
+EXPLANATION: <what went wrong and why, then what to do, on a single line>
+WARNING: <anything risky about the fix, or omit this line>
+
+Rules:
+- Base the diagnosis on the error output; without it, reason from the command and its exit status (e.g. 1 general failure, 2 misuse, 126 not executable, 127 command not found, 130 interrupted, 137 killed)
+- Keep the user's intent and as much of the original command as possible
+- If the problem is environmental (missing tool, permissions, no network, wrong directory), give the command that resolves it
+- Do not wrap the command in backticks or code blocks
+- Do not include any text outside the COMMAND/EXPLANATION/WARNING format`
+
+// WhyPrompt returns the system prompt for diagnosing a failed command.
+func WhyPrompt() string {
+	return withContextRules(whySystemPrompt)
+}
+
+// WhyQuery formats a failed command, its exit status and any captured error
+// output as a user query.
+func WhyQuery(command string, exitCode int, errOutput string) string {
+	q := fmt.Sprintf("Command: %s\nExit status: %d", command, exitCode)
+	if strings.TrimSpace(errOutput) != "" {
+		q += "\nError output:\n" + Untrusted(truncate(errOutput, maxSampleBytes))
+	}
+	return q
+}
+
+// StageFixQuery asks WhyPrompt for a replacement for stage n (from 1) of
+// stages, a command chained with &&, that failed after the earlier stages
+// succeeded. The remaining stages run after the replacement.
+func StageFixQuery(stages []string, n, exitCode int, errOutput string) string {
+	q := fmt.Sprintf("Command: %s\nStages 1 to %d succeeded; stage %d failed: %s\nExit status: %d", strings.Join(stages, " && "), n-1, n, stages[n-1], exitCode)
+	if strings.TrimSpace(errOutput) != "" {
+		q += "\nError output:\n" + Untrusted(truncate(errOutput, maxSampleBytes))
+	}
+	return q + "\nGive a command to run in place of the failed stage, including any set-up it needs, after which the remaining stages will run. Don't repeat the stages that succeeded."
+}
+
+// ClipboardQuery combines question with text pasted from the clipboard,
+// such as an error message from a CI log. With no question, the model is
+// asked to diagnose the text.
+func ClipboardQuery(question, text string) string {
+	text = Untrusted(truncate(text, maxSampleBytes))
+	if strings.TrimSpace(question) == "" {
+		return "Suggest a command to diagnose or fix the problem in this text, copied from an error message or log:\n" + text
+	}
+	return question + "\n\nContext (copied from the clipboard):\n" + text
+}
+
+// LastRunQuery combines question with the previous command run through
+// how and a sample of what it printed, so the answer can add a stage to
+// its pipeline, such as a filter for the lines that matter.
+func LastRunQuery(question, command string, exitCode int, output string) string {
+	q := fmt.Sprintf("%s\n\nThe previous command was: %s\nExit status: %d", question, command, exitCode)
+	if strings.TrimSpace(output) == "" {
+		q += "\nIts output wasn't captured."
+	} else {
+		q += "\nIts output began:\n" + Untrusted(truncate(output, maxSampleBytes))
+	}
+	return q + "\nThe output isn't saved anywhere, so answer with the previous command piped into the new stage, matching the format of the output shown. If running the previous command again would change something, say so in the WARNING."
+}
+
+// ImageQuery frames question for a query with attached images. With no
+// question, the model is asked to fix the error the image shows.
+func ImageQuery(question string) string {
+	if strings.TrimSpace(question) == "" {
+		return "Suggest a command that fixes the error shown in the attached image."
+	}
+	return question + "\n\n(See the attached image.)"
+}
+
+// AlternativesQuery asks for n commands other than command that answer
+// query, each in the usual COMMAND/EXPLANATION/WARNING format.
+func AlternativesQuery(query, command string, n int) string {
+	return fmt.Sprintf("%s\n\nYou already suggested:\nCOMMAND: %s\nGive %d different commands that do the same job another way, such as with other tools, flags or approaches, best first. Reply with a COMMAND line for each, followed by its EXPLANATION and, if needed, WARNING line.", query, command, n)
+}
+
+// RepairQuery asks for a corrected answer to query after the suggested
+// command failed validation.
+func RepairQuery(query, command, problem string) string {
+	return fmt.Sprintf("%s\n\nYour previous answer was:\nCOMMAND: %s\nIt cannot be run here: %s\nReply with a corrected command in the same format, using only tools that are installed.", query, command, problem)
+}
@@ -3,7 +3,10 @@ package prompt
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/k8s"
 	"github.com/swibrow/how/internal/memory"
 )
 
@@ -91,3 +94,352 @@ func TestFormatMemoryContextWithInteractions(t *testing.T) {
 		t.Error("expected result to contain instruction text")
 	}
 }
+
+func TestRegexPrompt(t *testing.T) {
+	p := RegexPrompt("posix")
+	if !strings.Contains(p, "POSIX extended") {
+		t.Error("regex prompt should name the requested dialect")
+	}
+	if !strings.Contains(p, "COMMAND") {
+		t.Error("regex prompt should use the COMMAND format")
+	}
+}
+
+func TestQueryPromptTruncatesSample(t *testing.T) {
+	sample := `{"items": [` + strings.Repeat(`{"name": "x"},`, 1000) + `]}`
+	p := QueryPrompt("jq", "JSON", sample)
+
+	if !strings.Contains(p, "jq expression") {
+		t.Error("query prompt should name the tool")
+	}
+	if !strings.Contains(p, "(truncated)") {
+		t.Error("long samples should be truncated")
+	}
+	if len(p) > maxSampleBytes+2048 {
+		t.Errorf("prompt too long: %d bytes", len(p))
+	}
+}
+
+func TestUndoPrompt(t *testing.T) {
+	p := UndoPrompt()
+	if !strings.Contains(p, "WARNING") {
+		t.Error("undo prompt should ask for a WARNING field")
+	}
+	if !strings.Contains(p, "COMMAND: NONE") {
+		t.Error("undo prompt should describe how to flag irreversible actions")
+	}
+}
+
+func TestOptimizePrompt(t *testing.T) {
+	p := OptimizePrompt()
+	if !strings.Contains(p, "improved command") {
+		t.Error("optimize prompt should ask for an improved command")
+	}
+	if !strings.Contains(p, "user is on") {
+		t.Error("optimize prompt should include OS context")
+	}
+}
+
+func TestTranslatePrompt(t *testing.T) {
+	p := TranslatePrompt("bash", "powershell")
+	if !strings.Contains(p, "bash command") || !strings.Contains(p, "equivalent powershell command") {
+		t.Errorf("translate prompt should name both shells, got: %s", p)
+	}
+}
+
+func TestTeachPrompt(t *testing.T) {
+	p := TeachPrompt()
+	if !strings.Contains(p, "STEP:") {
+		t.Error("teach prompt should use the STEP format")
+	}
+}
+
+func TestFixQuery(t *testing.T) {
+	q := FixQuery("gti status", "gti: command not found")
+	if !strings.Contains(q, "gti status") || !strings.Contains(q, "command not found") {
+		t.Errorf("fix query should include command and error, got: %q", q)
+	}
+
+	if q := FixQuery("ls /nope", "  "); strings.Contains(q, "Error output") {
+		t.Errorf("fix query should omit empty error output, got: %q", q)
+	}
+}
+
+func TestClipboardQuery(t *testing.T) {
+	q := ClipboardQuery("", "npm ERR! code EACCES")
+	if !strings.Contains(q, "diagnose") || !strings.Contains(q, "EACCES") {
+		t.Errorf("clipboard query without a question should ask for a diagnosis, got: %q", q)
+	}
+
+	q = ClipboardQuery("how do I fix this", "npm ERR! code EACCES")
+	if !strings.HasPrefix(q, "how do I fix this") || !strings.Contains(q, "EACCES") {
+		t.Errorf("clipboard query should keep the question and add the text, got: %q", q)
+	}
+}
+
+func TestLastRunQuery(t *testing.T) {
+	q := LastRunQuery("only the failures", "go test ./...", 1, "--- FAIL: TestX\nok  pkg")
+	for _, want := range []string{"only the failures", "go test ./...", "Exit status: 1", "<data>\n--- FAIL: TestX", "piped"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("last run query should contain %q, got: %q", want, q)
+		}
+	}
+	if q := LastRunQuery("count them", "ls", 0, ""); !strings.Contains(q, "wasn't captured") || strings.Contains(q, "<data>") {
+		t.Errorf("last run query without output should say so, got: %q", q)
+	}
+}
+
+func TestAlternativesQuery(t *testing.T) {
+	q := AlternativesQuery("find big files", "du -ah . | sort -h", 2)
+	if !strings.HasPrefix(q, "find big files") || !strings.Contains(q, "COMMAND: du -ah . | sort -h") || !strings.Contains(q, "Give 2 different commands") {
+		t.Errorf("alternatives query should carry the question, the first command and the count, got: %q", q)
+	}
+}
+
+func TestImageQuery(t *testing.T) {
+	if q := ImageQuery(" "); !strings.Contains(q, "attached image") {
+		t.Errorf("image query without a question should refer to the image, got: %q", q)
+	}
+	if q := ImageQuery("what does this mean"); !strings.HasPrefix(q, "what does this mean") {
+		t.Errorf("image query should keep the question, got: %q", q)
+	}
+}
+
+func TestRemoteSystemPrompt(t *testing.T) {
+	p := RemoteSystemPrompt("", "deploy@web1", "Ubuntu 22.04 LTS (Linux x86_64)")
+	if !strings.Contains(p, "deploy@web1") || !strings.Contains(p, "Ubuntu 22.04") {
+		t.Errorf("remote prompt should describe the host, got: %q", p)
+	}
+	if strings.Contains(p, osContext()) {
+		t.Error("remote prompt should not include the local OS")
+	}
+}
+
+func TestK8sPrompt(t *testing.T) {
+	p := K8sPrompt(k8s.Target{Context: "prod-eu", Namespace: "payments", Server: "https://k8s.example.com"})
+	if !strings.Contains(p, "--context prod-eu and -n payments") || !strings.Contains(p, "https://k8s.example.com") {
+		t.Errorf("k8s prompt should scope commands to the target, got: %q", p)
+	}
+}
+
+func TestK8sQuery(t *testing.T) {
+	q := K8sQuery("why is api crashing", []k8s.Event{{Type: "Warning", Reason: "BackOff", Object: "pod/api-1", Message: "Back-off"}})
+	if !strings.Contains(q, "Warning BackOff pod/api-1") {
+		t.Errorf("k8s query should list events, got: %q", q)
+	}
+	if q := K8sQuery("why", nil); !strings.Contains(q, "No recent events") {
+		t.Errorf("k8s query should note missing events, got: %q", q)
+	}
+}
+
+func TestGitPrompt(t *testing.T) {
+	p := GitPrompt([]collect.Fact{{Name: "Status", Value: "## main...origin/main [ahead 2]"}})
+	if !strings.Contains(p, "Status:\n<data>\n## main...origin/main [ahead 2]") {
+		t.Errorf("git prompt should include the repository state, got: %q", p)
+	}
+	if !strings.Contains(p, "--force-with-lease") {
+		t.Error("git prompt should prefer --force-with-lease")
+	}
+}
+
+func TestDockerPrompt(t *testing.T) {
+	p := DockerPrompt([]collect.Fact{{Name: "Compose", Value: "Compose file compose.yaml with services: api, db"}})
+	if !strings.Contains(p, "services: api, db") {
+		t.Errorf("docker prompt should include the compose services, got: %q", p)
+	}
+}
+
+func TestSystemdPrompt(t *testing.T) {
+	if p := SystemdPrompt(true); !strings.Contains(p, "systemctl --user") || !strings.Contains(p, "WantedBy=default.target") {
+		t.Errorf("user systemd prompt should target the user manager, got: %q", p)
+	}
+	if p := SystemdPrompt(false); !strings.Contains(p, "WantedBy=multi-user.target") {
+		t.Errorf("system systemd prompt should target multi-user.target, got: %q", p)
+	}
+}
+
+func TestScriptPrompt(t *testing.T) {
+	if p := ScriptPrompt(false); !strings.Contains(p, "#!/usr/bin/env bash") || !strings.Contains(p, "pipefail") {
+		t.Error("bash script prompt should ask for a bash shebang and pipefail")
+	}
+	if p := ScriptPrompt(true); !strings.Contains(p, "#!/bin/sh") || strings.Contains(p, "pipefail") {
+		t.Error("POSIX script prompt should ask for /bin/sh without pipefail")
+	}
+}
+
+func TestMediaPrompt(t *testing.T) {
+	p := MediaPrompt([]collect.Fact{{Name: "Input clip.mp4", Value: "Stream 0 video: h264, 1920x1080"}})
+	if !strings.Contains(p, "Input clip.mp4:\n<data>\nStream 0 video: h264, 1920x1080") {
+		t.Errorf("media prompt should include the probed input, got: %q", p)
+	}
+}
+
+func TestSQLPrompt(t *testing.T) {
+	p := SQLPrompt("postgres", "users(id, email)\n")
+	if !strings.Contains(p, "PostgreSQL SQL") || !strings.Contains(p, "users(id, email)") {
+		t.Errorf("sql prompt should name the dialect and list the schema, got: %q", p)
+	}
+}
+
+func TestRawPrompt(t *testing.T) {
+	if strings.Contains(RawPrompt(), "COMMAND:") {
+		t.Error("raw prompt should not ask for the COMMAND format")
+	}
+}
+
+func TestExplainPrompt(t *testing.T) {
+	if !strings.Contains(ExplainPrompt(), "Do not suggest a different command") {
+		t.Error("explain prompt should not rewrite the command")
+	}
+}
+
+func TestClarifiedQuery(t *testing.T) {
+	q := ClarifiedQuery("delete the old logs", []Clarification{
+		{Question: "Older than how many days?", Answer: "30"},
+		{Question: "Only in /var/log?", Answer: ""},
+	})
+	for _, want := range []string{"delete the old logs", "Older than how many days?", "answered: 30", "Only in /var/log?", "most common interpretation"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("clarified query should contain %q, got %q", want, q)
+		}
+	}
+	if strings.Index(q, "Older than") > strings.Index(q, "Only in") {
+		t.Errorf("clarifications should stay in order, got %q", q)
+	}
+	if Clarifying(SystemPrompt("")) || !Clarifying(SystemPrompt("")+"\n\n"+ClarifyInstruction) {
+		t.Error("Clarifying should report whether the instruction was added")
+	}
+}
+
+func TestRepairQuery(t *testing.T) {
+	q := RepairQuery("find large files", "fd -S +100m", "command not found: fd")
+	for _, want := range []string{"find large files", "COMMAND: fd -S +100m", "command not found: fd"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("repair query should contain %q, got: %q", want, q)
+		}
+	}
+}
+
+func TestFormatMachineContext(t *testing.T) {
+	if FormatMachineContext(nil) != "" {
+		t.Error("expected empty context for no facts")
+	}
+	got := FormatMachineContext([]collect.Fact{
+		{Name: "git", Value: "Inside a git repository on branch main"},
+		{Name: "tools", Value: "Installed tools: rg, fzf"},
+	})
+	if !strings.Contains(got, "- Inside a git repository on branch main\n") || !strings.Contains(got, "- Installed tools: rg, fzf\n") {
+		t.Errorf("unexpected machine context: %q", got)
+	}
+}
+
+func TestWhyQuery(t *testing.T) {
+	q := WhyQuery("make build", 2, "make: *** No rule to make target 'build'.")
+	for _, want := range []string{"Command: make build", "Exit status: 2", "No rule to make target"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("why query should contain %q, got: %q", want, q)
+		}
+	}
+	if q := WhyQuery("false", 1, ""); strings.Contains(q, "Error output") {
+		t.Errorf("why query should omit empty error output, got: %q", q)
+	}
+}
+
+func TestFormatFeedback(t *testing.T) {
+	if FormatFeedback(nil) != "" {
+		t.Error("expected nothing without feedback")
+	}
+	got := FormatFeedback([]memory.Feedback{
+		{Question: "list ports", Command: "ss -tlnp", Accepted: true},
+		{Question: "open ports", Command: "netstat -an"},
+	})
+	if !strings.Contains(got, "Q: list ports\nCOMMAND: ss -tlnp") || !strings.Contains(got, "don't suggest them again:\n- Q: open ports → $ netstat -an") {
+		t.Errorf("unexpected feedback context: %q", got)
+	}
+}
+
+func TestFormatPreferences(t *testing.T) {
+	if FormatPreferences(nil) != "" {
+		t.Error("expected nothing without preferences")
+	}
+	got := FormatPreferences(map[string]string{"grep": "rg", "docker": "podman"})
+	if !strings.Contains(got, "- podman instead of docker\n- rg instead of grep\n") {
+		t.Errorf("unexpected preferences: %q", got)
+	}
+}
+
+func TestFormatAliases(t *testing.T) {
+	if FormatAliases(nil) != "" {
+		t.Error("expected nothing without aliases")
+	}
+	got := FormatAliases(map[string]string{"k": "kubectl", "gst": "git status"})
+	if !strings.Contains(got, "gst=git status\nk=kubectl") {
+		t.Errorf("unexpected aliases: %q", got)
+	}
+}
+
+func TestSessionRule(t *testing.T) {
+	if SessionRule("") != "" {
+		t.Error("expected no rule for a local session")
+	}
+	if got := SessionRule("over SSH"); !strings.Contains(got, "working over SSH") || !strings.Contains(got, "xdg-open") {
+		t.Errorf("unexpected session rule: %q", got)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	now := time.Date(2026, 10, 15, 18, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	got := FormatClock(now, "Europe/Zurich", "de_CH.UTF-8")
+	want := "The user's local time is Thursday 2026-10-15 18:30 CEST (UTC+02:00, Europe/Zurich) and their locale is de_CH.UTF-8."
+	if !strings.Contains(got, want) {
+		t.Errorf("FormatClock = %q, want it to contain %q", got, want)
+	}
+	if got := FormatClock(now, "", ""); !strings.Contains(got, "(UTC+02:00). ") {
+		t.Errorf("unexpected clock without zone or locale: %q", got)
+	}
+}
+
+func TestCIRule(t *testing.T) {
+	if CIRule("") != "" {
+		t.Error("expected no rule outside CI")
+	}
+	if got := CIRule("GitHub Actions"); !strings.Contains(got, "unattended in GitHub Actions") || !strings.Contains(got, "never fzf") {
+		t.Errorf("unexpected CI rule: %q", got)
+	}
+}
+
+func TestStageFixQuery(t *testing.T) {
+	got := StageFixQuery([]string{"cd app", "npm ci", "npm test"}, 2, 1, "npm ERR! missing lockfile")
+	for _, want := range []string{"Command: cd app && npm ci && npm test", "stage 2 failed: npm ci", "Exit status: 1", "missing lockfile", "in place of the failed stage"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("StageFixQuery missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEscalationRule(t *testing.T) {
+	if EscalationRule("sudo", true) != "" {
+		t.Error("expected no rule when sudo is installed")
+	}
+	if got := EscalationRule("doas", true); !strings.Contains(got, "use doas instead") {
+		t.Errorf("unexpected doas rule: %q", got)
+	}
+	if got := EscalationRule("", true); !strings.Contains(got, "already root") {
+		t.Errorf("unexpected root rule: %q", got)
+	}
+	if got := EscalationRule("", false); !strings.Contains(got, "neither sudo nor doas") {
+		t.Errorf("unexpected rule without escalation: %q", got)
+	}
+}
+
+func TestShellRule(t *testing.T) {
+	if ShellRule("bash") != "" {
+		t.Error("expected no rule for POSIX shells")
+	}
+	if got := ShellRule("nushell"); !strings.Contains(got, "Nushell syntax") {
+		t.Errorf("unexpected Nushell rule: %q", got)
+	}
+	if got := ShellRule("powershell"); !strings.Contains(got, "pwsh -Command") {
+		t.Errorf("unexpected PowerShell rule: %q", got)
+	}
+}
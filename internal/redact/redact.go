@@ -0,0 +1,78 @@
+// Package redact masks credentials in text before it leaves the machine.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mask replaces each secret found.
+const Mask = "[REDACTED]"
+
+// patterns match well-known credential formats. Where a pattern has a
+// group named keep, that part of the match is kept and the rest masked.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`(?i)(?P<keep>authorization:\s*(?:bearer|basic|token)\s+)[^\s"']+`),
+	regexp.MustCompile(`(?P<keep>\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:)[^@\s/]+@`),
+}
+
+// assignment matches a value given to a name that ends in a secret-sounding
+// word, as in PASSWORD=x, api_key: x or --token x.
+var assignment = regexp.MustCompile(`(?i)(?P<keep>(?:^|[^A-Za-z0-9])-{0,2}[A-Za-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)["']?(?:\s*[=:]\s*|\s+))(?:"(?P<dq>[^"\n]*)"|'(?P<sq>[^'\n]*)'|(?P<bare>[^\s"',;]+))`)
+
+// Redact masks credentials in text: well-known token formats, passwords
+// in URLs, values assigned to secret-sounding names, and each of secrets,
+// such as configured API keys, wherever they appear. It returns the
+// masked text and how many secrets were masked.
+func Redact(text string, secrets ...string) (string, int) {
+	n := 0
+	for _, s := range secrets {
+		if len(s) < 8 {
+			// Too short to mask without mangling ordinary text
+			continue
+		}
+		n += strings.Count(text, s)
+		text = strings.ReplaceAll(text, s, Mask)
+	}
+	for _, re := range patterns {
+		keep := re.SubexpIndex("keep")
+		text = re.ReplaceAllStringFunc(text, func(m string) string {
+			n++
+			if keep < 0 {
+				return Mask
+			}
+			sub := re.FindStringSubmatch(m)
+			suffix := ""
+			if strings.HasSuffix(m, "@") {
+				suffix = "@"
+			}
+			return sub[keep] + Mask + suffix
+		})
+	}
+	text = assignment.ReplaceAllStringFunc(text, func(m string) string {
+		sub := assignment.FindStringSubmatch(m)
+		keep := sub[assignment.SubexpIndex("keep")]
+		value, quote := sub[assignment.SubexpIndex("bare")], ""
+		switch {
+		case strings.HasPrefix(m[len(keep):], `"`):
+			value, quote = sub[assignment.SubexpIndex("dq")], `"`
+		case strings.HasPrefix(m[len(keep):], "'"):
+			value, quote = sub[assignment.SubexpIndex("sq")], "'"
+		}
+		// Variables, placeholders and earlier masks aren't secrets
+		if value == "" || strings.HasPrefix(value, "$") || strings.HasPrefix(value, "<") || value == Mask {
+			return m
+		}
+		n++
+		return keep + quote + Mask + quote
+	})
+	return text, n
+}
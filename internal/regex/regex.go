@@ -0,0 +1,71 @@
+package regex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dialects lists the regex flavours the model can be asked to produce.
+var Dialects = []string{"pcre", "posix", "go"}
+
+// ValidDialect reports whether dialect is one of the supported flavours.
+func ValidDialect(dialect string) bool {
+	for _, d := range Dialects {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile compiles pattern using the engine closest to the given dialect.
+// POSIX patterns use leftmost-longest semantics; PCRE patterns are compiled
+// with RE2, so lookarounds and backreferences cannot be tested locally.
+func Compile(pattern, dialect string) (*regexp.Regexp, error) {
+	var (
+		re  *regexp.Regexp
+		err error
+	)
+	switch dialect {
+	case "posix":
+		re, err = regexp.CompilePOSIX(pattern)
+	case "go", "pcre":
+		re, err = regexp.Compile(pattern)
+	default:
+		return nil, fmt.Errorf("unknown dialect: %s", dialect)
+	}
+	if err != nil {
+		if dialect == "pcre" {
+			return nil, fmt.Errorf("pattern uses features that cannot be tested locally: %w", err)
+		}
+		return nil, fmt.Errorf("invalid %s pattern: %w", dialect, err)
+	}
+	return re, nil
+}
+
+// Match is a single line of sample input containing at least one match.
+type Match struct {
+	Line    int      // 1-based line number
+	Text    string   // the full line
+	Matches [][]int  // byte offsets of each match within Text
+	Groups  []string // capture groups of the first match, if any
+}
+
+// FindMatches runs re against each line of input and returns the lines
+// that contain a match.
+func FindMatches(re *regexp.Regexp, input string) []Match {
+	var matches []Match
+	for i, line := range strings.Split(strings.TrimRight(input, "\n"), "\n") {
+		locs := re.FindAllStringIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		m := Match{Line: i + 1, Text: line, Matches: locs}
+		if sub := re.FindStringSubmatch(line); len(sub) > 1 {
+			m.Groups = sub[1:]
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
@@ -0,0 +1,70 @@
+package regex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidDialect(t *testing.T) {
+	for _, d := range []string{"pcre", "posix", "go"} {
+		if !ValidDialect(d) {
+			t.Errorf("expected %q to be valid", d)
+		}
+	}
+	if ValidDialect("perl6") {
+		t.Error("expected perl6 to be invalid")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		dialect string
+		wantErr string
+	}{
+		{name: "go", pattern: `\d+`, dialect: "go"},
+		{name: "posix", pattern: `[0-9]+`, dialect: "posix"},
+		{name: "pcre lookahead", pattern: `foo(?=bar)`, dialect: "pcre", wantErr: "cannot be tested locally"},
+		{name: "invalid go", pattern: `(`, dialect: "go", wantErr: "invalid go pattern"},
+		{name: "unknown dialect", pattern: `a`, dialect: "ecma", wantErr: "unknown dialect"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile(tc.pattern, tc.dialect)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFindMatches(t *testing.T) {
+	re, err := Compile(`(\w+)@example\.com`, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "alice@example.com\nno match here\nbob@example.com, carol@example.com\n"
+	matches := FindMatches(re, input)
+
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matching lines, got %d", len(matches))
+	}
+	if matches[0].Line != 1 || matches[1].Line != 3 {
+		t.Errorf("unexpected line numbers: %d, %d", matches[0].Line, matches[1].Line)
+	}
+	if len(matches[1].Matches) != 2 {
+		t.Errorf("expected 2 matches on line 3, got %d", len(matches[1].Matches))
+	}
+	if len(matches[0].Groups) != 1 || matches[0].Groups[0] != "alice" {
+		t.Errorf("expected capture group 'alice', got %v", matches[0].Groups)
+	}
+}
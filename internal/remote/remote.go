@@ -0,0 +1,243 @@
+// Package remote runs suggested commands on another machine over SSH.
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/swibrow/how/internal/collect"
+	"github.com/swibrow/how/internal/shell"
+)
+
+// sshPath is the ssh binary, replaced in tests.
+var sshPath = "ssh"
+
+// packageManagers are probed on the host, in preference order.
+var packageManagers = []string{"apt-get", "dnf", "yum", "pacman", "apk", "zypper", "brew"}
+
+// Host is an SSH target such as user@server.
+type Host struct {
+	Target string
+	// ControlDir holds the SSH control socket shared by every connection
+	// to the host, so only the first one authenticates.
+	ControlDir string
+
+	// Set by Detect.
+	OS             string
+	Arch           string
+	Distro         string
+	PackageManager string
+	// Root is set when commands run as root on the host, and Escalator
+	// names what runs them as root otherwise, as chosen by
+	// shell.ChooseEscalator; it's "" when there's nothing to use.
+	Root      bool
+	Escalator string
+	// escalation is set once Root and Escalator have been detected.
+	escalation bool
+}
+
+// New returns a host for target whose connections share a control socket
+// in controlDir.
+func New(target, controlDir string) *Host {
+	return &Host{Target: target, ControlDir: controlDir}
+}
+
+// detectScript prints uname output, the distribution, the installed
+// package managers, and the user ID and installed escalators such as sudo,
+// separated by --- lines.
+var detectScript = `uname -s; uname -m; echo ---
+if [ -r /etc/os-release ]; then cat /etc/os-release
+elif command -v sw_vers >/dev/null 2>&1; then echo "NAME=\"$(sw_vers -productName)\""; echo "VERSION_ID=$(sw_vers -productVersion)"
+fi; echo ---
+for p in ` + strings.Join(packageManagers, " ") + `; do command -v "$p" >/dev/null 2>&1 && echo "$p"; done; echo ---
+id -u; for e in ` + strings.Join(shell.Escalators, " ") + `; do command -v "$e" >/dev/null 2>&1 && echo "$e"; done; true`
+
+// Detect connects to the host and records its OS, distribution, package
+// manager and how to run commands as root.
+func (h *Host) Detect(ctx context.Context) error {
+	out, err := h.output(ctx, detectScript)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", h.Target, err)
+	}
+	h.parseDetect(out)
+	if h.OS == "" {
+		return fmt.Errorf("connecting to %s: could not detect the remote OS", h.Target)
+	}
+	return nil
+}
+
+func (h *Host) parseDetect(out []byte) {
+	sections := strings.SplitN(string(out), "---\n", 4)
+	if len(sections) == 4 {
+		h.parseEscalation(strings.Fields(sections[3]))
+	}
+	for len(sections) < 3 {
+		sections = append(sections, "")
+	}
+	uname := strings.Fields(sections[0])
+	if len(uname) > 0 {
+		h.OS = uname[0]
+	}
+	if len(uname) > 1 {
+		h.Arch = uname[1]
+	}
+	h.Distro = collect.ParseOSRelease([]byte(sections[1]))
+	if pm := strings.Fields(sections[2]); len(pm) > 0 {
+		h.PackageManager = pm[0]
+	}
+}
+
+// parseEscalation reads the user ID and installed escalators, as printed
+// by detectScript.
+func (h *Host) parseEscalation(fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	installed := fields[1:]
+	h.Root = fields[0] == "0"
+	h.Escalator, _ = shell.ChooseEscalator(h.Root, func(name string) bool {
+		return slices.Contains(installed, name)
+	})
+	h.escalation = true
+}
+
+// Describe summarizes the host for the prompt, e.g. "Ubuntu 22.04 LTS
+// (Linux x86_64)".
+func (h *Host) Describe() string {
+	desc := strings.TrimSpace(h.OS + " " + h.Arch)
+	if h.Distro != "" {
+		desc = h.Distro + " (" + desc + ")"
+	}
+	if h.PackageManager != "" {
+		desc += ", package manager " + h.PackageManager
+	}
+	switch {
+	case !h.escalation || h.Escalator == "sudo":
+	case h.Root:
+		desc += ", logged in as root"
+	case h.Escalator == "":
+		desc += ", no sudo or doas"
+	default:
+		desc += ", " + h.Escalator + " instead of sudo"
+	}
+	return desc
+}
+
+// Check is shell.Check against the host's $PATH instead of the local one.
+func (h *Host) Check(ctx context.Context, command string) error {
+	names, err := shell.ExternalCommands(command)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	missing, err := h.Missing(ctx, names)
+	if err != nil {
+		// Don't fail a suggestion because the check couldn't run
+		return nil
+	}
+	if len(missing) > 0 {
+		return &shell.MissingError{Names: missing, Host: h.Target}
+	}
+	return nil
+}
+
+// Missing returns the names that aren't commands on the host.
+func (h *Host) Missing(ctx context.Context, names []string) ([]string, error) {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = shell.Quote(n)
+	}
+	script := `for c in ` + strings.Join(quoted, " ") + `; do command -v "$c" >/dev/null 2>&1 || echo "$c"; done; true`
+	out, err := h.output(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// InstallHint suggests how to install a missing command on the host.
+func (h *Host) InstallHint(name string) string {
+	sudo := "sudo "
+	if h.escalation && h.PackageManager != "brew" {
+		if !h.Root && h.Escalator == "" {
+			return fmt.Sprintf("Install %s on %s without root, e.g. into ~/.local/bin", name, h.Target)
+		}
+		sudo = h.Escalator
+		if sudo != "" {
+			sudo += " "
+		}
+	}
+	switch h.PackageManager {
+	case "apt-get":
+		return fmt.Sprintf("Install on %s with: %sapt install %s", h.Target, sudo, name)
+	case "dnf", "yum":
+		return fmt.Sprintf("Install on %s with: %s%s install %s", h.Target, sudo, h.PackageManager, name)
+	case "pacman":
+		return fmt.Sprintf("Install on %s with: %spacman -S %s", h.Target, sudo, name)
+	case "apk":
+		return fmt.Sprintf("Install on %s with: %sapk add %s", h.Target, sudo, name)
+	case "zypper":
+		return fmt.Sprintf("Install on %s with: %szypper install %s", h.Target, sudo, name)
+	case "brew":
+		return fmt.Sprintf("Install on %s with: brew install %s", h.Target, name)
+	}
+	return fmt.Sprintf("Install %s on %s using its package manager", name, h.Target)
+}
+
+// Command returns an ssh invocation that runs command on the host with
+// sh, allocating a terminal when tty is true so interactive programs work.
+func (h *Host) Command(command string, tty bool) *exec.Cmd {
+	args := h.sshArgs()
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, "--", h.Target, "sh -c "+shell.Quote(command))
+	return exec.Command(sshPath, args...)
+}
+
+func (h *Host) sshArgs() []string {
+	if h.ControlDir == "" {
+		return nil
+	}
+	return []string{
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPersist=60",
+		"-o", "ControlPath=" + filepath.Join(h.ControlDir, "ssh-%C"),
+	}
+}
+
+// output runs script on the host and returns its stdout. ssh may still
+// prompt for a password on the terminal.
+func (h *Host) output(ctx context.Context, script string) ([]byte, error) {
+	if h.ControlDir != "" {
+		if err := os.MkdirAll(h.ControlDir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	args := append(h.sshArgs(), "-o", "ConnectTimeout=10", "--", h.Target, "sh -c "+shell.Quote(script))
+	cmd := exec.CommandContext(ctx, sshPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSSH installs an ssh stand-in that runs the remote command locally.
+func fakeSSH(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ssh")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift 2\nexec sh -c \"$*\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orig := sshPath
+	sshPath = path
+	t.Cleanup(func() { sshPath = orig })
+}
+
+func TestDetect(t *testing.T) {
+	fakeSSH(t)
+	h := New("user@server", t.TempDir())
+	if err := h.Detect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if h.OS == "" || h.Arch == "" {
+		t.Errorf("expected OS and architecture, got %+v", h)
+	}
+}
+
+func TestParseDetect(t *testing.T) {
+	h := New("user@server", "")
+	h.parseDetect([]byte("Linux\nx86_64\n---\nPRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n---\napt-get\n"))
+	if got := h.Describe(); got != "Ubuntu 22.04.4 LTS (Linux x86_64), package manager apt-get" {
+		t.Errorf("unexpected description: %q", got)
+	}
+	if got := h.InstallHint("jq"); got != "Install on user@server with: sudo apt install jq" {
+		t.Errorf("unexpected install hint: %q", got)
+	}
+
+	h = New("alpine", "")
+	h.parseDetect([]byte("Linux\naarch64\n---\nPRETTY_NAME=\"Alpine Linux v3.20\"\n---\napk\n---\n1000\ndoas\n"))
+	if got := h.Describe(); got != "Alpine Linux v3.20 (Linux aarch64), package manager apk, doas instead of sudo" {
+		t.Errorf("unexpected description with doas: %q", got)
+	}
+	if got := h.InstallHint("jq"); got != "Install on alpine with: doas apk add jq" {
+		t.Errorf("unexpected install hint with doas: %q", got)
+	}
+
+	h = New("root@box", "")
+	h.parseDetect([]byte("Linux\nx86_64\n---\n---\ndnf\n---\n0\n"))
+	if got := h.InstallHint("jq"); got != "Install on root@box with: dnf install jq" {
+		t.Errorf("unexpected install hint as root: %q", got)
+	}
+
+	h = New("ci", "")
+	h.parseDetect([]byte("Linux\nx86_64\n---\n---\napt-get\n---\n1001\n"))
+	if got := h.InstallHint("jq"); !strings.Contains(got, "without root") {
+		t.Errorf("unexpected install hint without sudo: %q", got)
+	}
+
+	h = New("mac", "")
+	h.parseDetect([]byte("Darwin\narm64\n---\n---\n"))
+	if got := h.Describe(); got != "Darwin arm64" {
+		t.Errorf("unexpected description without distro: %q", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fakeSSH(t)
+	h := New("user@server", t.TempDir())
+	if err := h.Check(context.Background(), "ls | definitely-not-installed-xyz"); err == nil || !strings.Contains(err.Error(), "on user@server: definitely-not-installed-xyz") {
+		t.Errorf("expected the missing command on the host, got %v", err)
+	}
+	if err := h.Check(context.Background(), "ls -la"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandQuotes(t *testing.T) {
+	fakeSSH(t)
+	out, err := New("user@server", "").Command(`printf '%s\n' "it's" | tr a-z A-Z`, false).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "IT'S\n" {
+		t.Errorf("command should survive quoting, got %q", out)
+	}
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Call sends a single request on conn and decodes its result into result.
+// A JSON-RPC error response is returned as *Error. Call is meant for
+// one request per connection, as the daemon client uses it.
+func Call(conn io.ReadWriter, method string, params, result any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encoding params: %w", err)
+	}
+	req := request{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: method, Params: raw}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("reading response: %w", err)
+		}
+		return fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF)
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// ServeListener accepts connections on ln and serves handlers on each with
+// Serve until ctx is cancelled, then closes ln and waits for open
+// connections to finish.
+func ServeListener(ctx context.Context, ln net.Listener, handlers map[string]HandlerFunc) error {
+	var wg sync.WaitGroup
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			wg.Wait()
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close() //nolint:errcheck
+			// Unblock the read in Serve on shutdown
+			stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+			defer stop()
+			_ = Serve(ctx, conn, conn, handlers)
+		}()
+	}
+}
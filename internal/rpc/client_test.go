@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCallOverListener(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+
+	handlers := map[string]HandlerFunc{
+		"echo": func(_ context.Context, params json.RawMessage) (any, error) {
+			var p struct {
+				Text string `json:"text"`
+			}
+			if err := DecodeParams(params, &p); err != nil {
+				return nil, err
+			}
+			return map[string]string{"text": p.Text}, nil
+		},
+		"fail": func(context.Context, json.RawMessage) (any, error) {
+			return nil, &Error{Code: -32001, Message: "boom"}
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ServeListener(ctx, ln, handlers) }()
+
+	call := func(method string, result any) error {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return Call(conn, method, map[string]string{"text": "hi"}, result)
+	}
+
+	var got struct {
+		Text string `json:"text"`
+	}
+	if err := call("echo", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "hi" {
+		t.Errorf("expected echo of hi, got %q", got.Text)
+	}
+
+	var rpcErr *Error
+	if err := call("fail", nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32001 {
+		t.Errorf("expected rpc error -32001, got %v", err)
+	}
+
+	// An idle connection must not block shutdown
+	idle, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ServeListener returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeListener did not stop after cancel")
+	}
+}
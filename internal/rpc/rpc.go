@@ -0,0 +1,142 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// maxMessageBytes bounds a single newline-delimited message.
+const maxMessageBytes = 1 << 20
+
+// HandlerFunc answers a single method call.
+type HandlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+// Error is a JSON-RPC error object. Handlers may return one to control the code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Serve reads newline-delimited JSON-RPC 2.0 requests from r and writes
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently, so responses may arrive out of order; clients
+// match them by id. Notifications (requests without an id) get no reply.
+func Serve(ctx context.Context, r io.Reader, w io.Writer, handlers map[string]HandlerFunc) error {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	enc := json.NewEncoder(w)
+	write := func(resp response) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(resp)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			break
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: "parse error"}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			write(response{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+
+		wg.Add(1)
+		go func(req request) {
+			defer wg.Done()
+			resp := call(ctx, handlers, req)
+			if len(req.ID) > 0 {
+				write(resp)
+			}
+		}(req)
+	}
+
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading requests: %w", err)
+	}
+	return nil
+}
+
+func call(ctx context.Context, handlers map[string]HandlerFunc, req request) response {
+	resp := response{JSONRPC: "2.0", ID: idOrNull(req.ID)}
+
+	handler, ok := handlers[req.Method]
+	if !ok {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+		return resp
+	}
+
+	result, err := handler(ctx, req.Params)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// DecodeParams unmarshals params into v, returning an invalid-params error on failure.
+func DecodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "params are required"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, input string) []map[string]any {
+	t.Helper()
+
+	handlers := map[string]HandlerFunc{
+		"echo": func(_ context.Context, params json.RawMessage) (any, error) {
+			var p struct {
+				Text string `json:"text"`
+			}
+			if err := DecodeParams(params, &p); err != nil {
+				return nil, err
+			}
+			return map[string]string{"text": p.Text}, nil
+		},
+		"fail": func(context.Context, json.RawMessage) (any, error) {
+			return nil, errors.New("boom")
+		},
+	}
+
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader(input), &out, handlers); err != nil {
+		t.Fatalf("Serve error: %v", err)
+	}
+
+	var responses []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeEcho(t *testing.T) {
+	resps := serve(t, `{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`+"\n")
+	if len(resps) != 1 {
+		t.Fatalf("expected 1 response, got %d", len(resps))
+	}
+	result, _ := resps[0]["result"].(map[string]any)
+	if result["text"] != "hi" {
+		t.Errorf("unexpected response: %v", resps[0])
+	}
+	if resps[0]["id"] != float64(1) {
+		t.Errorf("id not echoed: %v", resps[0]["id"])
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		code  float64
+	}{
+		{name: "parse error", input: `{nope`, code: CodeParseError},
+		{name: "invalid request", input: `{"id":1,"method":"echo"}`, code: CodeInvalidRequest},
+		{name: "unknown method", input: `{"jsonrpc":"2.0","id":1,"method":"nope"}`, code: CodeMethodNotFound},
+		{name: "missing params", input: `{"jsonrpc":"2.0","id":1,"method":"echo"}`, code: CodeInvalidParams},
+		{name: "handler error", input: `{"jsonrpc":"2.0","id":1,"method":"fail"}`, code: CodeInternalError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resps := serve(t, tc.input+"\n")
+			if len(resps) != 1 {
+				t.Fatalf("expected 1 response, got %d", len(resps))
+			}
+			rpcErr, _ := resps[0]["error"].(map[string]any)
+			if rpcErr["code"] != tc.code {
+				t.Errorf("error code: got %v, want %v", rpcErr["code"], tc.code)
+			}
+		})
+	}
+}
+
+func TestServeNotificationHasNoResponse(t *testing.T) {
+	resps := serve(t, `{"jsonrpc":"2.0","method":"echo","params":{"text":"hi"}}`+"\n")
+	if len(resps) != 0 {
+		t.Errorf("expected no response for notification, got %v", resps)
+	}
+}
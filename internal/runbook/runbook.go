@@ -0,0 +1,82 @@
+// Package runbook renders the commands run in a session as a Markdown
+// runbook, for postmortems and handovers.
+package runbook
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/memory"
+)
+
+// Markdown renders entries, oldest first, as a Markdown document: each
+// question as a heading, followed by the command, when it ran, its exit
+// code and any captured output. Times are shown in loc.
+func Markdown(title string, entries []memory.HistoryEntry, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", oneLine(title))
+	if len(entries) == 0 {
+		b.WriteString("No commands were run.\n")
+		return b.String()
+	}
+
+	first, last := entries[0].ExecutedAt.In(loc), entries[len(entries)-1].ExecutedAt.In(loc)
+	if len(entries) == 1 {
+		fmt.Fprintf(&b, "1 command run with how at %s", first.Format("2006-01-02 15:04 MST"))
+	} else {
+		fmt.Fprintf(&b, "%d commands run with how between %s and %s", len(entries), first.Format("2006-01-02 15:04"), last.Format(timeLayout(first, last)))
+	}
+	failed := 0
+	for _, e := range entries {
+		if e.ExitCode != 0 {
+			failed++
+		}
+	}
+	switch {
+	case failed > 0 && len(entries) == 1:
+		b.WriteString(", and it failed")
+	case failed > 0:
+		fmt.Fprintf(&b, ", %d of them failed", failed)
+	}
+	b.WriteString(".\n")
+
+	for i, e := range entries {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, oneLine(e.Question))
+		status := "exit 0"
+		if e.ExitCode != 0 {
+			status = fmt.Sprintf("**exit %d**", e.ExitCode)
+		}
+		fmt.Fprintf(&b, "%s · %s\n\n", e.ExecutedAt.In(loc).Format("15:04:05"), status)
+		writeBlock(&b, "sh", e.Command)
+		if out := strings.TrimRight(e.Output, "\n"); out != "" {
+			b.WriteString("\nOutput:\n\n")
+			writeBlock(&b, "text", out)
+		}
+	}
+	return b.String()
+}
+
+// timeLayout shows only the time of the session's end when it's on the
+// same day as its start.
+func timeLayout(first, last time.Time) string {
+	if first.Format("2006-01-02") == last.Format("2006-01-02") {
+		return "15:04 MST"
+	}
+	return "2006-01-02 15:04 MST"
+}
+
+// writeBlock writes text as a fenced code block, with a fence longer than
+// any run of backticks in it.
+func writeBlock(b *strings.Builder, lang, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
+
+// oneLine collapses a question that spans lines into a heading.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,50 @@
+package runbook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swibrow/how/internal/memory"
+)
+
+func TestMarkdown(t *testing.T) {
+	at := time.Date(2026, 10, 15, 14, 3, 0, 0, time.UTC)
+	entries := []memory.HistoryEntry{
+		{Question: "which pods\nrestarted", Command: "kubectl get pods -A", Output: "web-1   CrashLoopBackOff\n", ExecutedAt: at},
+		{Question: "show the logs", Command: "kubectl logs web-1", ExitCode: 1, Output: "```\nsnippet\n```\n", ExecutedAt: at.Add(5 * time.Minute)},
+	}
+	want := "# Incident 42\n" +
+		"\n" +
+		"2 commands run with how between 2026-10-15 14:03 and 14:08 UTC, 1 of them failed.\n" +
+		"\n" +
+		"## 1. which pods restarted\n" +
+		"\n" +
+		"14:03:00 · exit 0\n" +
+		"\n" +
+		"```sh\nkubectl get pods -A\n```\n" +
+		"\n" +
+		"Output:\n" +
+		"\n" +
+		"```text\nweb-1   CrashLoopBackOff\n```\n" +
+		"\n" +
+		"## 2. show the logs\n" +
+		"\n" +
+		"14:08:00 · **exit 1**\n" +
+		"\n" +
+		"```sh\nkubectl logs web-1\n```\n" +
+		"\n" +
+		"Output:\n" +
+		"\n" +
+		"````text\n```\nsnippet\n```\n````\n"
+	if got := Markdown("Incident 42", entries, time.UTC); got != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Markdown("One", entries[1:], time.UTC); !strings.Contains(got, "1 command run with how at 2026-10-15 14:08 UTC, and it failed.") {
+		t.Errorf("single command summary:\n%s", got)
+	}
+	if got := Markdown("Empty", nil, time.UTC); got != "# Empty\n\nNo commands were run.\n" {
+		t.Errorf("empty session = %q", got)
+	}
+}
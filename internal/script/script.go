@@ -0,0 +1,229 @@
+// Package script parses generated shell scripts and tidies them up before
+// they're saved: formatting as shfmt does, linting with shellcheck and
+// adding a header recording where the script came from.
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Script is a generated script.
+type Script struct {
+	Name        string
+	Content     string
+	Explanation string
+}
+
+// nameRe matches file names it's reasonable to suggest saving a script as.
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Parse reads a script in the format requested by prompt.ScriptPrompt:
+// FILE: <name> followed by the script's lines, then an EXPLANATION: line.
+func Parse(response string) (Script, error) {
+	var (
+		s       Script
+		body    strings.Builder
+		inFile  bool
+		sawFile bool
+	)
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inFile && strings.HasPrefix(trimmed, "FILE:"):
+			s.Name = strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "FILE:")), "`")
+			inFile, sawFile = true, true
+		case strings.HasPrefix(trimmed, "EXPLANATION:"):
+			s.Explanation = strings.TrimSpace(strings.TrimPrefix(trimmed, "EXPLANATION:"))
+			inFile = false
+		case strings.HasPrefix(trimmed, "```"):
+			// Models sometimes fence the script despite instructions
+		case inFile:
+			body.WriteString(line + "\n")
+		}
+	}
+	if !sawFile {
+		return Script{}, errors.New("no script in the response")
+	}
+	s.Content = strings.TrimSpace(body.String()) + "\n"
+	if strings.TrimSpace(s.Content) == "" {
+		return Script{}, errors.New("the script is empty")
+	}
+	s.Name = path.Base(s.Name)
+	if !nameRe.MatchString(s.Name) {
+		s.Name = "script.sh"
+	}
+	return s, nil
+}
+
+// Provenance describes where a script came from, for its header.
+type Provenance struct {
+	Query   string
+	Version string
+	Model   string
+	Time    time.Time
+}
+
+// Header returns the comment block placed after the script's #! line.
+func (p Provenance) Header() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by how %s", p.Version)
+	if p.Model != "" {
+		fmt.Fprintf(&b, " with %s", p.Model)
+	}
+	fmt.Fprintf(&b, " on %s.\n", p.Time.Format("2006-01-02"))
+	b.WriteString("# Review it before running it.\n#\n")
+	for i, line := range strings.Split(strings.TrimSpace(p.Query), "\n") {
+		if i == 0 {
+			fmt.Fprintf(&b, "# Query: %s\n", line)
+		} else {
+			fmt.Fprintf(&b, "#        %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// Sanitize formats content as shfmt would, adds a #! line if it has none
+// and puts p's header after it. A script that doesn't parse as the shell
+// its #! line names is returned unformatted with the parse error.
+func Sanitize(content string, p Provenance) (string, error) {
+	shebang, body := splitShebang(content)
+	if shebang == "" {
+		shebang = "#!/usr/bin/env bash"
+	}
+	body = strings.TrimLeft(dropHeader(body), "\n")
+
+	var parseErr error
+	if lang, ok := language(shebang); ok {
+		formatted, err := Format(body, lang)
+		if err != nil {
+			parseErr = err
+		} else {
+			body = formatted
+		}
+	}
+	return shebang + "\n" + p.Header() + "\n" + body, parseErr
+}
+
+// Format reprints a script in the given shell language the way shfmt does
+// by default: tab indents, with comments kept.
+func Format(content string, lang syntax.LangVariant) (string, error) {
+	file, err := syntax.NewParser(syntax.KeepComments(true), syntax.Variant(lang)).Parse(strings.NewReader(content), "")
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter().Print(&buf, file); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// splitShebang separates a script's #! line from the rest.
+func splitShebang(content string) (string, string) {
+	if !strings.HasPrefix(content, "#!") {
+		return "", content
+	}
+	shebang, body, _ := strings.Cut(content, "\n")
+	return strings.TrimSpace(shebang), body
+}
+
+// dropHeader removes a header added by an earlier Sanitize, so sanitizing
+// twice doesn't stack them.
+func dropHeader(body string) string {
+	if !strings.HasPrefix(body, "# Generated by how ") {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], "#") {
+		i++
+	}
+	return strings.Join(lines[i:], "\n")
+}
+
+// language returns the parser variant for the interpreter named by a #!
+// line, and false for interpreters that aren't shells it can format.
+func language(shebang string) (syntax.LangVariant, bool) {
+	fields := strings.Fields(strings.TrimPrefix(shebang, "#!"))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" && len(fields) > 1 {
+		interp = fields[1]
+	}
+	switch interp {
+	case "bash":
+		return syntax.LangBash, true
+	case "sh", "dash", "ash":
+		return syntax.LangPOSIX, true
+	case "mksh", "ksh":
+		return syntax.LangMirBSDKorn, true
+	}
+	return 0, false
+}
+
+// Finding is one problem shellcheck reported.
+type Finding struct {
+	Line    int
+	Level   string
+	Message string
+	Code    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s: %s [%s]", f.Line, f.Level, f.Message, f.Code)
+}
+
+// ErrNoShellcheck is returned by Lint when shellcheck isn't installed.
+var ErrNoShellcheck = errors.New("shellcheck isn't installed")
+
+// Lint runs shellcheck over content and returns what it reports.
+func Lint(content string) ([]Finding, error) {
+	if _, err := exec.LookPath("shellcheck"); err != nil {
+		return nil, ErrNoShellcheck
+	}
+	cmd := exec.Command("shellcheck", "--format=gcc", "-")
+	cmd.Stdin = strings.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	findings := parseFindings(out)
+	// shellcheck exits 1 when it finds problems
+	if err != nil && len(findings) == 0 {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("shellcheck: %s", msg)
+		}
+		return nil, fmt.Errorf("shellcheck: %w", err)
+	}
+	return findings, nil
+}
+
+// findingRe matches shellcheck's gcc format:
+// -:3:8: warning: Double quote to prevent globbing. [SC2086]
+var findingRe = regexp.MustCompile(`^[^:]*:(\d+):\d+: (\w+): (.*) \[(SC\d+)\]$`)
+
+func parseFindings(out []byte) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		m := findingRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[1])
+		findings = append(findings, Finding{Line: line, Level: m[2], Message: m[3], Code: m[4]})
+	}
+	return findings
+}
@@ -0,0 +1,106 @@
+package script
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse("FILE: `rotate-logs.sh`\n```bash\n#!/usr/bin/env bash\nset -euo pipefail\necho hi\n```\nEXPLANATION: Rotates the logs.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "rotate-logs.sh" {
+		t.Errorf("Name = %q", s.Name)
+	}
+	if s.Content != "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n" {
+		t.Errorf("Content = %q", s.Content)
+	}
+	if s.Explanation != "Rotates the logs." {
+		t.Errorf("Explanation = %q", s.Explanation)
+	}
+
+	if s, err := Parse("FILE: ../../etc/cron.daily/x y\necho hi\n"); err != nil || s.Name != "script.sh" {
+		t.Errorf("unsafe name: got %q, %v", s.Name, err)
+	}
+	if _, err := Parse("COMMAND: echo hi"); err == nil {
+		t.Error("expected an error without FILE:")
+	}
+	if _, err := Parse("FILE: x.sh\nEXPLANATION: nothing\n"); err == nil {
+		t.Error("expected an error for an empty script")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	p := Provenance{Query: "say hi\ntwice", Version: "1.2.3", Model: "anthropic claude", Time: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)}
+	got, err := Sanitize("#!/usr/bin/env bash\nif true; then\necho   hi\nfi\n", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/usr/bin/env bash
+# Generated by how 1.2.3 with anthropic claude on 2026-10-15.
+# Review it before running it.
+#
+# Query: say hi
+#        twice
+
+if true; then
+	echo hi
+fi
+`
+	if got != want {
+		t.Errorf("Sanitize =\n%s\nwant\n%s", got, want)
+	}
+
+	again, err := Sanitize(got, p)
+	if err != nil || again != want {
+		t.Errorf("sanitizing twice changed the script:\n%s", again)
+	}
+
+	// No #! line gets bash; an unparsable script is kept as it was
+	got, err = Sanitize("echo $((\n", p)
+	if err == nil {
+		t.Error("expected a parse error")
+	}
+	if !strings.HasPrefix(got, "#!/usr/bin/env bash\n# Generated by how") || !strings.HasSuffix(got, "echo $((\n") {
+		t.Errorf("unparsable script = %q", got)
+	}
+
+	// Other interpreters aren't formatted
+	py := "#!/usr/bin/env python3\nprint(  'hi')\n"
+	if got, err := Sanitize(py, p); err != nil || !strings.HasSuffix(got, "print(  'hi')\n") {
+		t.Errorf("python script = %q, %v", got, err)
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	out := "-:3:6: warning: Double quote to prevent globbing and word splitting. [SC2086]\n-:5:1: error: Couldn't parse this. [SC1073]\nnoise\n"
+	got := parseFindings([]byte(out))
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2", len(got))
+	}
+	if got[0].Line != 3 || got[0].Level != "warning" || got[0].Code != "SC2086" {
+		t.Errorf("first finding = %+v", got[0])
+	}
+	if s := got[1].String(); s != "line 5: error: Couldn't parse this. [SC1073]" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestLint(t *testing.T) {
+	if _, err := exec.LookPath("shellcheck"); err != nil {
+		if _, err := Lint("echo hi\n"); err != ErrNoShellcheck {
+			t.Errorf("Lint without shellcheck = %v, want ErrNoShellcheck", err)
+		}
+		t.Skip("shellcheck isn't installed")
+	}
+	findings, err := Lint("#!/bin/bash\nrm $1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) == 0 {
+		t.Error("expected shellcheck to flag the unquoted $1")
+	}
+}
@@ -0,0 +1,132 @@
+//go:build grpc
+
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/ui"
+	howv1 "github.com/swibrow/how/proto/how/v1"
+	"github.com/swibrow/how/risk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPC returns a gRPC server for the HowService in proto/how/v1. It shares
+// the HTTP API's tenants, rate limits and suggestion metrics; tokens are
+// sent as "authorization" metadata.
+func (s *Server) GRPC() *grpc.Server {
+	g := grpc.NewServer(grpc.UnaryInterceptor(s.grpcAuthenticate), grpc.MaxRecvMsgSize(maxRequestBytes))
+	howv1.RegisterHowServiceServer(g, &grpcService{s: s})
+	return g
+}
+
+// grpcAuthenticate finds the call's tenant and applies its rate limit.
+func (s *Server) grpcAuthenticate(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	t := s.tenantFor(authorization)
+	if t == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+	if ok, wait := t.limiter.allow(1, time.Now()); !ok {
+		s.metrics.observeRateLimited(t.Name)
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit of %d queries a minute exceeded, retry in %ds", t.RateLimit, int(math.Ceil(wait.Seconds())))
+	}
+	return handler(withTenant(ctx, t), req)
+}
+
+type grpcService struct {
+	howv1.UnimplementedHowServiceServer
+	s *Server
+}
+
+func (g *grpcService) Suggest(ctx context.Context, req *howv1.SuggestRequest) (*howv1.SuggestResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	answer, err := g.answer(ctx, func(ctx context.Context) (ui.Result, error) {
+		return tenantFrom(ctx).Suggest(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &howv1.SuggestResponse{Answer: answer}, nil
+}
+
+func (g *grpcService) Explain(ctx context.Context, req *howv1.ExplainRequest) (*howv1.ExplainResponse, error) {
+	command := strings.TrimSpace(req.GetCommand())
+	if command == "" {
+		return nil, status.Error(codes.InvalidArgument, "command is required")
+	}
+	explain := tenantFrom(ctx).Explain
+	if explain == nil {
+		return nil, status.Error(codes.Unimplemented, "explain is not available")
+	}
+	answer, err := g.answer(ctx, func(ctx context.Context) (ui.Result, error) {
+		return explain(ctx, command)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &howv1.ExplainResponse{Answer: answer}, nil
+}
+
+func (g *grpcService) Fix(ctx context.Context, req *howv1.FixRequest) (*howv1.FixResponse, error) {
+	command := strings.TrimSpace(req.GetCommand())
+	if command == "" {
+		return nil, status.Error(codes.InvalidArgument, "command is required")
+	}
+	fix := tenantFrom(ctx).Fix
+	if fix == nil {
+		return nil, status.Error(codes.Unimplemented, "fix is not available")
+	}
+	answer, err := g.answer(ctx, func(ctx context.Context) (ui.Result, error) {
+		return fix(ctx, command, req.GetError())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &howv1.FixResponse{Answer: answer}, nil
+}
+
+// answer calls fn through the server, turning provider errors into
+// Unavailable and cancellations into their own codes.
+func (g *grpcService) answer(ctx context.Context, fn func(context.Context) (ui.Result, error)) (*howv1.Answer, error) {
+	result, err := g.s.answer(ctx, fn)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &howv1.Answer{
+		Command:     result.Command,
+		Explanation: result.Explanation,
+		Warning:     result.Warning,
+		Danger:      danger(risk.Classify(result.Command).Level),
+	}, nil
+}
+
+func danger(level risk.Level) howv1.Danger {
+	switch level {
+	case risk.Safe:
+		return howv1.Danger_DANGER_SAFE
+	case risk.Caution:
+		return howv1.Danger_DANGER_CAUTION
+	case risk.Dangerous:
+		return howv1.Danger_DANGER_DANGEROUS
+	}
+	return howv1.Danger_DANGER_UNSPECIFIED
+}
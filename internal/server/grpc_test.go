@@ -0,0 +1,115 @@
+//go:build grpc
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/swibrow/how/internal/ui"
+	howv1 "github.com/swibrow/how/proto/how/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func grpcClient(t *testing.T, srv *Server) howv1.HowServiceClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	g := srv.GRPC()
+	go g.Serve(ln)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return howv1.NewHowServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPC(t *testing.T) {
+	srv := NewMultiTenant([]Tenant{{
+		Name:    "platform",
+		Token:   "secret",
+		Suggest: fakeSuggest,
+		Explain: func(_ context.Context, command string) (ui.Result, error) {
+			return ui.Result{Command: command, Explanation: "Deletes everything"}, nil
+		},
+		Fix: func(_ context.Context, command, errOutput string) (ui.Result, error) {
+			return ui.Result{Command: "git status", Explanation: errOutput}, nil
+		},
+	}})
+	client := grpcClient(t, srv)
+	ctx := withToken("secret")
+
+	suggested, err := client.Suggest(ctx, &howv1.SuggestRequest{Query: "list files"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := suggested.GetAnswer(); a.GetCommand() != "ls -la" || a.GetDanger() != howv1.Danger_DANGER_SAFE {
+		t.Errorf("unexpected suggestion: %v", a)
+	}
+	explained, err := client.Explain(ctx, &howv1.ExplainRequest{Command: "rm -rf /"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := explained.GetAnswer(); a.GetExplanation() != "Deletes everything" || a.GetDanger() != howv1.Danger_DANGER_DANGEROUS {
+		t.Errorf("unexpected explanation: %v", a)
+	}
+	fixed, err := client.Fix(ctx, &howv1.FixRequest{Command: "gti status", Error: "gti: command not found"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fixed.GetAnswer(); a.GetCommand() != "git status" || a.GetExplanation() != "gti: command not found" {
+		t.Errorf("unexpected fix: %v", a)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	client := grpcClient(t, NewMultiTenant([]Tenant{{Name: "limited", Token: "secret", RateLimit: 3, Suggest: fakeSuggest}}))
+
+	cases := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"no token", func() error {
+			_, err := client.Suggest(context.Background(), &howv1.SuggestRequest{Query: "x"})
+			return err
+		}, codes.Unauthenticated},
+		{"empty query", func() error {
+			_, err := client.Suggest(withToken("secret"), &howv1.SuggestRequest{Query: " "})
+			return err
+		}, codes.InvalidArgument},
+		{"provider error", func() error {
+			_, err := client.Suggest(withToken("secret"), &howv1.SuggestRequest{Query: "fail"})
+			return err
+		}, codes.Unavailable},
+		{"no explain", func() error {
+			_, err := client.Explain(withToken("secret"), &howv1.ExplainRequest{Command: "ls"})
+			return err
+		}, codes.Unimplemented},
+		{"rate limit", func() error {
+			_, err := client.Suggest(withToken("secret"), &howv1.SuggestRequest{Query: "x"})
+			return err
+		}, codes.ResourceExhausted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := status.Code(tc.call()); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram, spanning a cached health check to a slow batch.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics counts what the server has done since it started, for /metrics
+// in the Prometheus text format. It's safe for concurrent use.
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]int64 // endpoint, status code
+	latencies map[string]*histogram
+	tenants   map[string]*tenantCounts
+}
+
+// tenantCounts are one tenant's queries. suggestions and providerErrors
+// count queries answered and failed, including each query in a batch.
+type tenantCounts struct {
+	suggestions    int64
+	providerErrors int64
+	rateLimited    int64
+	inputTokens    int64
+	outputTokens   int64
+}
+
+type histogram struct {
+	counts []int64 // per bucket, not cumulative
+	sum    float64
+	count  int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{requests: map[[2]string]int64{}, latencies: map[string]*histogram{}, tenants: map[string]*tenantCounts{}}
+}
+
+func (m *metrics) observeRequest(endpoint string, code int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{endpoint, strconv.Itoa(code)}]++
+	h, ok := m.latencies[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(latencyBuckets))}
+		m.latencies[endpoint] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// observeSuggestion records one query answered for tenant and the tokens
+// it used.
+func (m *metrics) observeSuggestion(tenant string, err error, input, output int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.tenant(tenant)
+	c.suggestions++
+	if err != nil {
+		c.providerErrors++
+	}
+	c.inputTokens += input
+	c.outputTokens += output
+}
+
+// observeRateLimited records a request refused by tenant's rate limit.
+func (m *metrics) observeRateLimited(tenant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tenant(tenant).rateLimited++
+}
+
+// tenant returns tenant's counts, creating them. m.mu must be held.
+func (m *metrics) tenant(name string) *tenantCounts {
+	c, ok := m.tenants[name]
+	if !ok {
+		c = &tenantCounts{}
+		m.tenants[name] = c
+	}
+	return c
+}
+
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP how_http_requests_total HTTP requests handled, by endpoint and status code.")
+	fmt.Fprintln(w, "# TYPE how_http_requests_total counter")
+	keys := make([][2]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "how_http_requests_total{endpoint=%q,code=%q} %d\n", k[0], k[1], m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP how_http_request_duration_seconds Time to handle an HTTP request, by endpoint.")
+	fmt.Fprintln(w, "# TYPE how_http_request_duration_seconds histogram")
+	endpoints := make([]string, 0, len(m.latencies))
+	for e := range m.latencies {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		h := m.latencies[e]
+		var cumulative int64
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "how_http_request_duration_seconds_bucket{endpoint=%q,le=%q} %d\n", e, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "how_http_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", e, h.count)
+		fmt.Fprintf(w, "how_http_request_duration_seconds_sum{endpoint=%q} %s\n", e, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "how_http_request_duration_seconds_count{endpoint=%q} %d\n", e, h.count)
+	}
+
+	tenants := make([]string, 0, len(m.tenants))
+	for t := range m.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	fmt.Fprintln(w, "# HELP how_suggestions_total Queries sent to the provider, including each query in a batch, by tenant.")
+	fmt.Fprintln(w, "# TYPE how_suggestions_total counter")
+	for _, t := range tenants {
+		fmt.Fprintf(w, "how_suggestions_total{tenant=%q} %d\n", t, m.tenants[t].suggestions)
+	}
+	fmt.Fprintln(w, "# HELP how_provider_errors_total Queries the provider failed to answer, by tenant.")
+	fmt.Fprintln(w, "# TYPE how_provider_errors_total counter")
+	for _, t := range tenants {
+		fmt.Fprintf(w, "how_provider_errors_total{tenant=%q} %d\n", t, m.tenants[t].providerErrors)
+	}
+	fmt.Fprintln(w, "# HELP how_rate_limited_total Requests refused by a tenant's rate limit.")
+	fmt.Fprintln(w, "# TYPE how_rate_limited_total counter")
+	for _, t := range tenants {
+		fmt.Fprintf(w, "how_rate_limited_total{tenant=%q} %d\n", t, m.tenants[t].rateLimited)
+	}
+	fmt.Fprintln(w, "# HELP how_tokens_total Tokens used by the provider, by tenant and direction.")
+	fmt.Fprintln(w, "# TYPE how_tokens_total counter")
+	for _, t := range tenants {
+		fmt.Fprintf(w, "how_tokens_total{tenant=%q,direction=\"input\"} %d\n", t, m.tenants[t].inputTokens)
+		fmt.Fprintf(w, "how_tokens_total{tenant=%q,direction=\"output\"} %d\n", t, m.tenants[t].outputTokens)
+	}
+}
+
+func (m *metrics) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+// instrument records each request's endpoint, status and latency. The
+// endpoint is the mux pattern the request matches, so unknown paths share
+// one label.
+func (m *metrics) instrument(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		endpoint := "other"
+		if _, pattern := mux.Handler(r); pattern != "" {
+			if _, path, ok := strings.Cut(pattern, " "); ok {
+				endpoint = strings.TrimSuffix(path, "{$}")
+			}
+		}
+		m.observeRequest(endpoint, sw.status, time.Since(start))
+	})
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush passes through, so batch answers still stream.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
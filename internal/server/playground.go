@@ -0,0 +1,33 @@
+package server
+
+import (
+	"embed"
+	"net/http"
+	"strings"
+)
+
+// playground is a small web page for asking the server questions from a
+// browser, for people who don't use the CLI.
+//
+//go:embed playground
+var playground embed.FS
+
+// playgroundCSP only lets the page load its own assets and call this
+// server's API.
+const playgroundCSP = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+func (s *Server) handlePlayground(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy", playgroundCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if r.URL.Path == "/" {
+		http.ServeFileFS(w, r, playground, "playground/index.html")
+		return
+	}
+	http.FileServerFS(playground).ServeHTTP(w, r)
+}
+
+// isPlayground reports whether path is part of the playground, which
+// holds no queries and so needs no token.
+func isPlayground(path string) bool {
+	return path == "/" || (strings.HasPrefix(path, "/playground/") && path != "/playground/")
+}
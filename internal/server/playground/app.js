@@ -0,0 +1,72 @@
+// The playground asks /v1/suggest and lists the answers, newest first.
+// Everything is rendered with textContent, since answers come from a model.
+
+const form = document.getElementById("ask");
+const query = document.getElementById("query");
+const token = document.getElementById("token");
+const error = document.getElementById("error");
+const results = document.getElementById("results");
+const template = document.getElementById("result");
+
+token.value = localStorage.getItem("how-token") || "";
+token.addEventListener("change", () => localStorage.setItem("how-token", token.value.trim()));
+
+async function suggest(q) {
+  const headers = { "Content-Type": "application/json" };
+  if (token.value.trim()) {
+    headers.Authorization = "Bearer " + token.value.trim();
+  }
+  const resp = await fetch("/v1/suggest", { method: "POST", headers, body: JSON.stringify({ query: q }) });
+  const body = await resp.json().catch(() => ({}));
+  if (resp.status === 401) {
+    document.getElementById("settings").open = true;
+    token.focus();
+  }
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function render(q, answer) {
+  const item = template.content.firstElementChild.cloneNode(true);
+  item.querySelector(".query").textContent = q;
+  item.querySelector("code").textContent = answer.command;
+  const badge = item.querySelector(".badge");
+  badge.textContent = answer.danger;
+  badge.classList.add(answer.danger);
+  item.querySelector(".explanation").textContent = answer.explanation || "";
+  if (answer.warning) {
+    const warning = item.querySelector(".warning");
+    warning.textContent = answer.warning;
+    warning.hidden = false;
+  }
+  const copy = item.querySelector(".copy");
+  copy.addEventListener("click", async () => {
+    await navigator.clipboard.writeText(answer.command);
+    copy.textContent = "Copied";
+    setTimeout(() => (copy.textContent = "Copy"), 1500);
+  });
+  results.prepend(item);
+}
+
+form.addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const q = query.value.trim();
+  if (!q) {
+    return;
+  }
+  const button = form.querySelector("button");
+  button.disabled = true;
+  error.hidden = true;
+  try {
+    render(q, await suggest(q));
+    query.value = "";
+  } catch (err) {
+    error.textContent = err.message;
+    error.hidden = false;
+  } finally {
+    button.disabled = false;
+    query.focus();
+  }
+});
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/batch"
+	"github.com/swibrow/how/internal/llm"
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+const (
+	// maxRequestBytes bounds the size of a request body.
+	maxRequestBytes = 64 << 10
+
+	// maxBatchQueries bounds the queries in one /v1/batch request, and
+	// batchConcurrency is how many of them are answered at once.
+	maxBatchQueries  = 100
+	batchConcurrency = 4
+)
+
+// SuggestFunc answers a natural-language query with a command.
+type SuggestFunc func(ctx context.Context, query string) (ui.Result, error)
+
+// Server exposes suggestions over HTTP.
+type Server struct {
+	tenants []*tenant
+	// open answers requests without a token when no token is required.
+	open    *tenant
+	slack   *slack
+	metrics *metrics
+	// metricsToken, if set, must be sent as a bearer token for /metrics.
+	metricsToken string
+}
+
+// New creates a server. If token is non-empty, every request must carry it
+// as a bearer token.
+func New(suggest SuggestFunc, token string) *Server {
+	return NewMultiTenant([]Tenant{{Name: "default", Token: token, Suggest: suggest}})
+}
+
+// NewMultiTenant creates a server shared by tenants. Each request must
+// carry one tenant's token and is answered and rate limited as that
+// tenant, except that a tenant without a token answers requests that
+// carry none.
+func NewMultiTenant(tenants []Tenant) *Server {
+	s := &Server{metrics: newMetrics()}
+	for _, t := range tenants {
+		if t.Token == "" {
+			s.open = newTenant(t)
+			continue
+		}
+		s.tenants = append(s.tenants, newTenant(t))
+	}
+	return s
+}
+
+// SuggestRequest is the body of a /v1/suggest request.
+type SuggestRequest struct {
+	Query string `json:"query"`
+}
+
+// SuggestResponse is the body of a successful /v1/suggest response.
+type SuggestResponse struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+	// Danger is the command's risk level: safe, caution or dangerous.
+	Danger string `json:"danger"`
+}
+
+// BatchRequest is the body of a /v1/batch request.
+type BatchRequest struct {
+	Queries []string `json:"queries"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the HTTP handler serving the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/suggest", s.handleSuggest)
+	mux.HandleFunc("POST /v1/batch", s.handleBatch)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /metrics", s.metrics.handle)
+	mux.HandleFunc("GET /{$}", s.handlePlayground)
+	mux.HandleFunc("GET /playground/", s.handlePlayground)
+	if s.slack != nil {
+		mux.HandleFunc("POST /slack/command", s.handleSlack)
+	}
+	return s.metrics.instrument(mux, s.authenticate(mux))
+}
+
+// RequireMetricsToken makes /metrics require token as a bearer token,
+// since its labels name the tenants.
+func (s *Server) RequireMetricsToken(token string) {
+	s.metricsToken = token
+}
+
+// authenticate finds the tenant whose bearer token the request carries,
+// rejecting requests without one unless the server is open. Health checks
+// and the playground page, which hold no queries, are always allowed,
+// metrics are unless RequireMetricsToken was called, and Slack commands
+// carry a signature instead.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" && s.metricsToken != "" {
+			got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.metricsToken)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid metrics token"})
+				return
+			}
+		}
+		if r.URL.Path == "/healthz" || r.URL.Path == "/metrics" || isPlayground(r.URL.Path) || (s.slack != nil && r.URL.Path == "/slack/command") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t := s.tenantFor(r.Header.Get("Authorization"))
+		if t == nil {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withTenant(r.Context(), t)))
+	})
+}
+
+// tenantFor returns the tenant whose token an Authorization header
+// carries, the open tenant if it carries none, or nil.
+func (s *Server) tenantFor(authorization string) *tenant {
+	t := s.open
+	if got, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		for _, candidate := range s.tenants {
+			if subtle.ConstantTimeCompare([]byte(got), []byte(candidate.Token)) == 1 {
+				t = candidate
+			}
+		}
+	}
+	return t
+}
+
+// limit reports whether the request's tenant may ask n more queries now,
+// replying 429 with a Retry-After header if not.
+func (s *Server) limit(w http.ResponseWriter, r *http.Request, n int) bool {
+	t := tenantFrom(r.Context())
+	ok, wait := t.limiter.allow(n, time.Now())
+	if ok {
+		return true
+	}
+	s.metrics.observeRateLimited(t.Name)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: fmt.Sprintf("rate limit of %d queries a minute exceeded", t.RateLimit)})
+	return false
+}
+
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	var req SuggestRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		return
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "query is required"})
+		return
+	}
+
+	if !s.limit(w, r, 1) {
+		return
+	}
+
+	result, err := s.ask(r.Context(), req.Query)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuggestResponse{
+		Command:     result.Command,
+		Explanation: result.Explanation,
+		Warning:     result.Warning,
+		Danger:      risk.Classify(result.Command).Level.String(),
+	})
+}
+
+// handleBatch answers several queries, writing each answer as a line of
+// JSON as soon as it's ready. Lines arrive in completion order; index
+// gives each one's position in the request.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		return
+	}
+	var queries []string
+	for _, q := range req.Queries {
+		if q = strings.TrimSpace(q); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	switch {
+	case len(queries) == 0:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "queries are required"})
+		return
+	case len(queries) > maxBatchQueries:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("at most %d queries are allowed", maxBatchQueries)})
+		return
+	}
+	if !s.limit(w, r, len(queries)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	ask := func(ctx context.Context, q string) (string, string, error) {
+		result, err := s.ask(ctx, q)
+		return result.Command, result.Explanation, err
+	}
+	batch.Stream(r.Context(), queries, batch.Options{Concurrency: batchConcurrency}, ask, func(item batch.Item) {
+		_ = enc.Encode(item)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+}
+
+// ask answers query with the request's tenant's SuggestFunc.
+func (s *Server) ask(ctx context.Context, query string) (ui.Result, error) {
+	return s.answer(ctx, func(ctx context.Context) (ui.Result, error) {
+		return tenantFrom(ctx).Suggest(ctx, query)
+	})
+}
+
+// answer calls fn for the request's tenant, counting the answer and the
+// tokens it used in the server's metrics.
+func (s *Server) answer(ctx context.Context, fn func(context.Context) (ui.Result, error)) (ui.Result, error) {
+	ctx, usage := llm.WithUsage(ctx)
+	result, err := fn(ctx)
+	input, output := usage.Tokens()
+	s.metrics.observeSuggestion(tenantFrom(ctx).Name, err, input, output)
+	return result, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swibrow/how/internal/ui"
+)
+
+func fakeSuggest(_ context.Context, query string) (ui.Result, error) {
+	if query == "fail" {
+		return ui.Result{}, errors.New("provider down")
+	}
+	return ui.Result{Command: "ls -la", Explanation: "List files"}, nil
+}
+
+func do(t *testing.T, h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSuggest(t *testing.T) {
+	h := New(fakeSuggest, "secret").Handler()
+
+	rec := do(t, h, "POST", "/v1/suggest", "secret", `{"query": "list files"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+
+	var resp SuggestResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Command != "ls -la" || resp.Explanation != "List files" || resp.Danger != "safe" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestSuggestAuth(t *testing.T) {
+	h := New(fakeSuggest, "secret").Handler()
+
+	if rec := do(t, h, "POST", "/v1/suggest", "", `{"query": "x"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: got %d, want 401", rec.Code)
+	}
+	if rec := do(t, h, "POST", "/v1/suggest", "wrong", `{"query": "x"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: got %d, want 401", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/healthz", "", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("healthz: got %d, want 204", rec.Code)
+	}
+}
+
+func TestSuggestErrors(t *testing.T) {
+	h := New(fakeSuggest, "").Handler()
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "invalid json", body: `{`, want: http.StatusBadRequest},
+		{name: "empty query", body: `{"query": "  "}`, want: http.StatusBadRequest},
+		{name: "provider error", body: `{"query": "fail"}`, want: http.StatusBadGateway},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := do(t, h, "POST", "/v1/suggest", "", tc.body); rec.Code != tc.want {
+				t.Errorf("status: got %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	h := New(fakeSuggest, "").Handler()
+
+	rec := do(t, h, "POST", "/v1/batch", "", `{"queries": ["list files", " ", "fail"]}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("got %d %q, want 200 ndjson (body %s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per non-blank query, got %q", lines)
+	}
+	byIndex := map[int]map[string]any{}
+	for _, line := range lines {
+		var item map[string]any
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		byIndex[int(item["index"].(float64))] = item
+	}
+	if byIndex[0]["command"] != "ls -la" || byIndex[1]["error"] != "provider down" {
+		t.Errorf("unexpected items: %v", byIndex)
+	}
+
+	if rec := do(t, h, "POST", "/v1/batch", "", `{"queries": []}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: got %d, want 400", rec.Code)
+	}
+	tooMany := `{"queries": [` + strings.Repeat(`"q",`, maxBatchQueries) + `"q"]}`
+	if rec := do(t, h, "POST", "/v1/batch", "", tooMany); rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch: got %d, want 400", rec.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := New(fakeSuggest, "secret").Handler()
+	do(t, h, "POST", "/v1/suggest", "secret", `{"query": "list files"}`)
+	do(t, h, "POST", "/v1/suggest", "secret", `{"query": "fail"}`)
+	do(t, h, "POST", "/v1/batch", "secret", `{"queries": ["a", "b"]}`)
+
+	rec := do(t, h, "GET", "/metrics", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics should be served without a token, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`how_http_requests_total{endpoint="/v1/suggest",code="200"} 1`,
+		`how_http_requests_total{endpoint="/v1/suggest",code="502"} 1`,
+		`how_http_requests_total{endpoint="/v1/batch",code="200"} 1`,
+		`how_http_request_duration_seconds_count{endpoint="/v1/suggest"} 2`,
+		`how_http_request_duration_seconds_bucket{endpoint="/v1/suggest",le="+Inf"} 2`,
+		`how_suggestions_total{tenant="default"} 4`,
+		`how_provider_errors_total{tenant="default"} 1`,
+		`how_tokens_total{tenant="default",direction="input"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	srv := NewMultiTenant([]Tenant{{Name: "platform", Token: "p-token", Suggest: fakeSuggest}})
+	srv.RequireMetricsToken("m-token")
+	h := srv.Handler()
+
+	for _, token := range []string{"", "p-token", "other"} {
+		if rec := do(t, h, "GET", "/metrics", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("metrics with token %q: got %d, want 401", token, rec.Code)
+		}
+	}
+	rec := do(t, h, "GET", "/metrics", "m-token", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "how_http_requests_total") {
+		t.Errorf("metrics with the metrics token: got %d\n%s", rec.Code, rec.Body)
+	}
+	if rec := do(t, h, "GET", "/healthz", "", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("healthz should still need no token, got %d", rec.Code)
+	}
+}
+
+func TestMultiTenant(t *testing.T) {
+	answer := func(command string) SuggestFunc {
+		return func(context.Context, string) (ui.Result, error) {
+			return ui.Result{Command: command}, nil
+		}
+	}
+	h := NewMultiTenant([]Tenant{
+		{Name: "platform", Token: "p-token", Suggest: answer("kubectl get pods")},
+		{Name: "data", Token: "d-token", Suggest: answer("psql -l")},
+	}).Handler()
+
+	for token, want := range map[string]string{"p-token": "kubectl get pods", "d-token": "psql -l"} {
+		rec := do(t, h, "POST", "/v1/suggest", token, `{"query": "x"}`)
+		var resp SuggestResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Command != want {
+			t.Errorf("token %s: got %q, want %q", token, resp.Command, want)
+		}
+	}
+	if rec := do(t, h, "POST", "/v1/suggest", "", `{"query": "x"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: got %d, want 401", rec.Code)
+	}
+	if rec := do(t, h, "POST", "/v1/suggest", "other", `{"query": "x"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: got %d, want 401", rec.Code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := NewMultiTenant([]Tenant{
+		{Name: "limited", Token: "l-token", RateLimit: 2, Suggest: fakeSuggest},
+		{Name: "open", Token: "o-token", Suggest: fakeSuggest},
+	}).Handler()
+
+	if rec := do(t, h, "POST", "/v1/batch", "l-token", `{"queries": ["a", "b", "c"]}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("batch over the limit: got %d, want 429", rec.Code)
+	}
+	for range 2 {
+		if rec := do(t, h, "POST", "/v1/suggest", "l-token", `{"query": "x"}`); rec.Code != http.StatusOK {
+			t.Fatalf("within the limit: got %d, want 200", rec.Code)
+		}
+	}
+	rec := do(t, h, "POST", "/v1/suggest", "l-token", `{"query": "x"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("over the limit: got %d with Retry-After %q, want 429 with a delay", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	for range 5 {
+		if rec := do(t, h, "POST", "/v1/suggest", "o-token", `{"query": "x"}`); rec.Code != http.StatusOK {
+			t.Fatalf("other tenants shouldn't be limited, got %d", rec.Code)
+		}
+	}
+
+	metrics := do(t, h, "GET", "/metrics", "", "").Body.String()
+	if !strings.Contains(metrics, `how_rate_limited_total{tenant="limited"} 2`) {
+		t.Errorf("rate limited requests not counted:\n%s", metrics)
+	}
+}
+
+func TestLimiterRefills(t *testing.T) {
+	l := newLimiter(60)
+	now := time.Now()
+	if ok, _ := l.allow(60, now); !ok {
+		t.Fatal("a full bucket should allow a minute's worth")
+	}
+	ok, wait := l.allow(1, now)
+	if ok || wait != time.Second {
+		t.Errorf("empty bucket: got %v, %v, want refused for 1s", ok, wait)
+	}
+	if ok, _ := l.allow(1, now.Add(time.Second)); !ok {
+		t.Error("bucket should refill one query a second")
+	}
+}
+
+func TestPlayground(t *testing.T) {
+	h := New(fakeSuggest, "secret").Handler()
+
+	rec := do(t, h, "GET", "/", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/playground/app.js") {
+		t.Fatalf("playground page: got %d %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Content-Security-Policy") == "" {
+		t.Error("playground page should set a Content-Security-Policy")
+	}
+	for _, asset := range []string{"/playground/app.js", "/playground/style.css"} {
+		if rec := do(t, h, "GET", asset, "", ""); rec.Code != http.StatusOK {
+			t.Errorf("%s: got %d, want 200", asset, rec.Code)
+		}
+	}
+	if rec := do(t, h, "GET", "/playground/", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("directory listing should need a token, got %d", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/nope", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown paths should still need a token, got %d", rec.Code)
+	}
+}
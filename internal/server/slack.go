@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/ui"
+	"github.com/swibrow/how/risk"
+)
+
+const (
+	// slackMaxAge bounds how old a signed Slack request may be, so a
+	// captured one can't be replayed later.
+	slackMaxAge = 5 * time.Minute
+
+	// slackAnswerTimeout bounds answering a slash command. Slack wants a
+	// reply within 3 seconds, so the answer follows via response_url.
+	slackAnswerTimeout = 2 * time.Minute
+)
+
+// slack answers slash commands from a Slack app as one tenant.
+type slack struct {
+	secret []byte
+	tenant *tenant
+	client *http.Client
+}
+
+// slackMessage is a slash command reply. Ephemeral replies are shown only
+// to the user who asked.
+type slackMessage struct {
+	ResponseType    string `json:"response_type"`
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original,omitempty"`
+}
+
+// EnableSlack serves Slack slash commands at /slack/command, answered as
+// the named tenant. Slack can't send a bearer token, so requests are
+// verified with the app's signing secret instead.
+func (s *Server) EnableSlack(signingSecret, tenantName string) error {
+	for _, t := range append([]*tenant{s.open}, s.tenants...) {
+		if t != nil && t.Name == tenantName {
+			s.slack = &slack{secret: []byte(signingSecret), tenant: t, client: &http.Client{Timeout: 10 * time.Second}}
+			return nil
+		}
+	}
+	return fmt.Errorf("no tenant named %s to answer Slack commands", tenantName)
+}
+
+// handleSlack acknowledges a slash command at once and posts the answer
+// to its response_url when it's ready.
+func (s *Server) handleSlack(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid body"})
+		return
+	}
+	if !s.slack.verify(r.Header, body, time.Now()) {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid Slack signature"})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid form body"})
+		return
+	}
+
+	query := strings.TrimSpace(form.Get("text"))
+	if query == "" {
+		writeJSON(w, http.StatusOK, ephemeral(fmt.Sprintf("Usage: `%s <what you want to do>`", form.Get("command"))))
+		return
+	}
+	t := s.slack.tenant
+	if ok, wait := t.limiter.allow(1, time.Now()); !ok {
+		s.metrics.observeRateLimited(t.Name)
+		writeJSON(w, http.StatusOK, ephemeral(fmt.Sprintf("Rate limit reached, try again in %ds.", int(math.Ceil(wait.Seconds())))))
+		return
+	}
+
+	responseURL := form.Get("response_url")
+	go func() {
+		ctx, cancel := context.WithTimeout(withTenant(context.Background(), t), slackAnswerTimeout)
+		defer cancel()
+		result, err := s.ask(ctx, query)
+		msg := slackMessage{ResponseType: "ephemeral", Text: slackAnswer(query, result, err), ReplaceOriginal: true}
+		if err := s.slack.post(ctx, responseURL, msg); err != nil {
+			slog.Warn("answering Slack command", "error", err)
+		}
+	}()
+	writeJSON(w, http.StatusOK, ephemeral(fmt.Sprintf("Thinking about _%s_…", slackEscape(query))))
+}
+
+// verify checks the request's signature, an HMAC-SHA256 of its timestamp
+// and body keyed with the signing secret.
+func (s *slack) verify(h http.Header, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(h.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil || now.Sub(time.Unix(ts, 0)).Abs() > slackMaxAge {
+		return false
+	}
+	got, ok := strings.CutPrefix(h.Get("X-Slack-Signature"), "v0=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func (s *slack) post(ctx context.Context, responseURL string, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	// Drained so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxRequestBytes))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("posting to the Slack response URL: %s", resp.Status)
+	}
+	return nil
+}
+
+func ephemeral(text string) slackMessage {
+	return slackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// slackAnswer formats a suggestion as Slack mrkdwn: the question, the
+// command in a code block, then its explanation and any warnings.
+func slackAnswer(query string, result ui.Result, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Couldn't answer _%s_: %s", slackEscape(query), slackEscape(err.Error()))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "_%s_\n```%s```", slackEscape(query), slackEscape(result.Command))
+	if result.Explanation != "" {
+		fmt.Fprintf(&b, "\n%s", slackEscape(result.Explanation))
+	}
+	if level := risk.Classify(result.Command).Level; level != risk.Safe {
+		fmt.Fprintf(&b, "\n:warning: Risk: *%s*", level)
+	}
+	if result.Warning != "" {
+		fmt.Fprintf(&b, "\n:warning: %s", slackEscape(result.Warning))
+	}
+	return b.String()
+}
+
+// slackEscape escapes the characters Slack treats as markup in text.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
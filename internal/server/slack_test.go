@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swibrow/how/internal/ui"
+)
+
+func slackRequest(t *testing.T, h http.Handler, secret string, ts time.Time, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	body := form.Encode()
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:%s", ts.Unix(), body)
+	req := httptest.NewRequest("POST", "/slack/command", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSlackCommand(t *testing.T) {
+	answers := make(chan slackMessage, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+		answers <- msg
+	}))
+	defer hook.Close()
+
+	srv := New(fakeSuggest, "secret")
+	if err := srv.EnableSlack("signing-secret", "default"); err != nil {
+		t.Fatal(err)
+	}
+	h := srv.Handler()
+
+	form := url.Values{"command": {"/how"}, "text": {"list files"}, "response_url": {hook.URL}}
+	rec := slackRequest(t, h, "signing-secret", time.Now(), form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	var ack slackMessage
+	if err := json.NewDecoder(rec.Body).Decode(&ack); err != nil {
+		t.Fatal(err)
+	}
+	if ack.ResponseType != "ephemeral" {
+		t.Errorf("acknowledgement should be ephemeral, got %+v", ack)
+	}
+
+	select {
+	case msg := <-answers:
+		if msg.ResponseType != "ephemeral" || !msg.ReplaceOriginal || !strings.Contains(msg.Text, "```ls -la```") || !strings.Contains(msg.Text, "List files") {
+			t.Errorf("unexpected answer: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no answer posted to response_url")
+	}
+
+	rec = slackRequest(t, h, "signing-secret", time.Now(), url.Values{"command": {"/how"}})
+	if !strings.Contains(rec.Body.String(), "Usage: `/how") {
+		t.Errorf("empty command should reply with usage, got %s", rec.Body)
+	}
+}
+
+func TestSlackSignature(t *testing.T) {
+	srv := New(fakeSuggest, "secret")
+	if err := srv.EnableSlack("signing-secret", "default"); err != nil {
+		t.Fatal(err)
+	}
+	h := srv.Handler()
+	form := url.Values{"text": {"list files"}}
+
+	if rec := slackRequest(t, h, "wrong-secret", time.Now(), form); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: got %d, want 401", rec.Code)
+	}
+	if rec := slackRequest(t, h, "signing-secret", time.Now().Add(-10*time.Minute), form); rec.Code != http.StatusUnauthorized {
+		t.Errorf("stale timestamp: got %d, want 401", rec.Code)
+	}
+	if err := srv.EnableSlack("signing-secret", "missing"); err == nil {
+		t.Error("expected an error for an unknown tenant")
+	}
+	if rec := do(t, New(fakeSuggest, "secret").Handler(), "POST", "/slack/command", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without Slack enabled the endpoint should need a token, got %d", rec.Code)
+	}
+}
+
+func TestSlackAnswer(t *testing.T) {
+	got := slackAnswer("wipe <disk>", ui.Result{Command: "rm -rf /tmp/x", Explanation: "Deletes x & more"}, nil)
+	for _, want := range []string{"_wipe &lt;disk&gt;_", "```rm -rf /tmp/x```", "Deletes x &amp; more", ":warning: Risk:"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("answer missing %q:\n%s", want, got)
+		}
+	}
+	if got := slackAnswer("q", ui.Result{}, errors.New("provider down")); !strings.Contains(got, "provider down") {
+		t.Errorf("error answer should include the error, got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/swibrow/how/internal/ui"
+)
+
+// Tenant is a client of a shared server, such as a team, identified by
+// its bearer token and answered by its own SuggestFunc, which can use its
+// own provider and API key.
+type Tenant struct {
+	Name  string
+	Token string
+	// RateLimit caps the queries answered per minute, counting each query
+	// in a batch; 0 means no limit.
+	RateLimit int
+	Suggest   SuggestFunc
+	// Explain describes a command and Fix corrects a failed one, for the
+	// gRPC API; nil leaves them unimplemented.
+	Explain SuggestFunc
+	Fix     FixFunc
+}
+
+// FixFunc corrects a command that failed with errOutput.
+type FixFunc func(ctx context.Context, command, errOutput string) (ui.Result, error)
+
+type tenantKey struct{}
+
+func withTenant(ctx context.Context, t *tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+func tenantFrom(ctx context.Context) *tenant {
+	t, _ := ctx.Value(tenantKey{}).(*tenant)
+	return t
+}
+
+// tenant is a Tenant with its rate limiter.
+type tenant struct {
+	Tenant
+	limiter *limiter
+}
+
+func newTenant(t Tenant) *tenant {
+	return &tenant{Tenant: t, limiter: newLimiter(t.RateLimit)}
+}
+
+// limiter is a token bucket holding up to a minute's worth of queries,
+// refilled continuously. A nil limiter allows everything.
+type limiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	tokens    float64
+	last      time.Time
+}
+
+func newLimiter(perMinute int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &limiter{perMinute: float64(perMinute), tokens: float64(perMinute)}
+}
+
+// allow takes n tokens if there are enough, or returns how long until
+// there will be. Requests for more than a minute's worth never succeed.
+func (l *limiter) allow(n int, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.perMinute, l.tokens+now.Sub(l.last).Minutes()*l.perMinute)
+	}
+	l.last = now
+	need := float64(n)
+	if need <= l.tokens {
+		l.tokens -= need
+		return true, 0
+	}
+	if need > l.perMinute {
+		return false, time.Minute
+	}
+	return false, time.Duration((need - l.tokens) / l.perMinute * float64(time.Minute))
+}
@@ -0,0 +1,123 @@
+// Package share uploads text to a GitHub gist or a paste service and
+// returns its URL.
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultGistAPI is GitHub's endpoint for creating gists.
+const DefaultGistAPI = "https://api.github.com/gists"
+
+// maxResponseBytes bounds what's read of a service's reply.
+const maxResponseBytes = 1 << 20
+
+// Client uploads to gists and paste services.
+type Client struct {
+	GistAPI string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for github.com's gists.
+func NewClient() *Client {
+	return &Client{GistAPI: DefaultGistAPI, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Gist creates a gist holding content as a file called name, secret
+// unless public is set, and returns its URL.
+func (c *Client) Gist(ctx context.Context, token, name, description, content string, public bool) (string, error) {
+	if token == "" {
+		return "", errors.New("a GitHub token is needed to create a gist")
+	}
+	body, err := json.Marshal(map[string]any{
+		"description": description,
+		"public":      public,
+		"files":       map[string]any{name: map[string]string{"content": content}},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.GistAPI, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	data, err := c.do(req, http.StatusCreated)
+	if err != nil {
+		return "", fmt.Errorf("creating gist: %w", err)
+	}
+	var gist struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &gist); err != nil || gist.HTMLURL == "" {
+		return "", errors.New("creating gist: GitHub's reply has no URL")
+	}
+	return gist.HTMLURL, nil
+}
+
+// Paste posts content as the body of a request to endpoint, with token as
+// a bearer token if it's set, and returns the paste's URL: the Location
+// header of the reply, or else its first line, as paste.rs and many
+// self-hosted services answer.
+func (c *Client) Paste(ctx context.Context, endpoint, token, content string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("uploading paste: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("uploading paste: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if loc, err := resp.Location(); err == nil {
+		return loc.String(), nil
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	if u, err := url.Parse(strings.TrimSpace(first)); err == nil && u.Host != "" && (u.Scheme == "https" || u.Scheme == "http") {
+		return u.String(), nil
+	}
+	return "", errors.New("uploading paste: the reply doesn't give the paste's URL")
+}
+
+func (c *Client) do(req *http.Request, want int) ([]byte, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+		}
+		return nil, errors.New(resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`)) //nolint:errcheck
+			return
+		}
+		var req struct {
+			Description string `json:"description"`
+			Public      bool   `json:"public"`
+			Files       map[string]struct {
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.Public || req.Description != "how" || req.Files["how.md"].Content != "# hi\n" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url":"https://gist.github.com/abc"}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	c := &Client{GistAPI: srv.URL, HTTP: srv.Client()}
+	url, err := c.Gist(context.Background(), "t0ken", "how.md", "how", "# hi\n", false)
+	if err != nil || url != "https://gist.github.com/abc" {
+		t.Fatalf("Gist = %q, %v", url, err)
+	}
+
+	_, err = c.Gist(context.Background(), "wrong", "how.md", "how", "# hi\n", false)
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("expected GitHub's message, got %v", err)
+	}
+	if _, err := c.Gist(context.Background(), "", "how.md", "how", "# hi\n", false); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
+
+func TestPaste(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("body = %q", body)
+		}
+		switch r.URL.Path {
+		case "/body":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("https://paste.example/x1\n")) //nolint:errcheck
+		case "/location":
+			w.Header().Set("Location", "/p/x2")
+			w.WriteHeader(http.StatusCreated)
+		case "/none":
+			w.Write([]byte("thanks")) //nolint:errcheck
+		default:
+			http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{HTTP: srv.Client()}
+	ctx := context.Background()
+	if url, err := c.Paste(ctx, srv.URL+"/body", "", "hello"); err != nil || url != "https://paste.example/x1" {
+		t.Errorf("URL in body: %q, %v", url, err)
+	}
+	if url, err := c.Paste(ctx, srv.URL+"/location", "", "hello"); err != nil || url != srv.URL+"/p/x2" {
+		t.Errorf("Location header: %q, %v", url, err)
+	}
+	if _, err := c.Paste(ctx, srv.URL+"/none", "", "hello"); err == nil {
+		t.Error("expected an error when the reply has no URL")
+	}
+	if _, err := c.Paste(ctx, srv.URL+"/big", "", "hello"); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected the service's error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DumpAliases starts shellPath as an interactive shell, so it reads the
+// user's rc files, and returns the aliases it defines.
+func DumpAliases(ctx context.Context, shellPath string) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, shellPath, "-i", "-c", "alias")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing aliases with %s: %w", filepath.Base(shellPath), err)
+	}
+	return ParseAliases(string(out)), nil
+}
+
+// ParseAliases parses the output of the alias builtin in bash
+// (alias k='kubectl'), zsh (k=kubectl) or fish (alias k kubectl).
+func ParseAliases(out string) map[string]string {
+	aliases := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		rest, fish := strings.CutPrefix(line, "alias ")
+		var name, value string
+		var ok bool
+		if fish && !strings.Contains(strings.SplitN(rest, " ", 2)[0], "=") {
+			name, value, ok = strings.Cut(rest, " ")
+		} else {
+			name, value, ok = strings.Cut(rest, "=")
+		}
+		if !ok || name == "" {
+			continue
+		}
+		aliases[name] = unquote(value)
+	}
+	return aliases
+}
+
+// unquote removes the quoting the alias builtin adds around a value.
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch {
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		s = s[1 : len(s)-1]
+		s = strings.ReplaceAll(s, `'\''`, "'")
+		return strings.ReplaceAll(s, `\'`, "'")
+	case s[0] == '"' && s[len(s)-1] == '"':
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// UsedAliases returns the names in aliases that command invokes, which
+// only resolve when it runs in the user's interactive shell.
+func UsedAliases(command string, aliases map[string]string) []string {
+	if len(aliases) == 0 {
+		return nil
+	}
+	names, err := ExternalCommands(command)
+	if err != nil {
+		return nil
+	}
+	var used []string
+	for _, name := range names {
+		if _, ok := aliases[name]; ok {
+			used = append(used, name)
+		}
+	}
+	return used
+}
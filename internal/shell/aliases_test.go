@@ -0,0 +1,33 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAliases(t *testing.T) {
+	cases := []struct {
+		name, out string
+		want      map[string]string
+	}{
+		{"bash", "alias k='kubectl'\nalias ll='ls -la'\n", map[string]string{"k": "kubectl", "ll": "ls -la"}},
+		{"zsh", "k=kubectl\ngst='git status'\n", map[string]string{"k": "kubectl", "gst": "git status"}},
+		{"fish", "alias k kubectl\nalias ll 'ls -la'\n", map[string]string{"k": "kubectl", "ll": "ls -la"}},
+		{"escaped quote", `alias say='echo '\''hi'\'''` + "\n", map[string]string{"say": "echo 'hi'"}},
+	}
+	for _, tc := range cases {
+		if got := ParseAliases(tc.out); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: ParseAliases = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestUsedAliases(t *testing.T) {
+	aliases := map[string]string{"k": "kubectl", "ll": "ls -la"}
+	if got := UsedAliases("k get po | grep Running && echo ll", aliases); !reflect.DeepEqual(got, []string{"k"}) {
+		t.Errorf("UsedAliases = %v, want [k]", got)
+	}
+	if got := UsedAliases("kubectl get po", aliases); got != nil {
+		t.Errorf("UsedAliases = %v, want none", got)
+	}
+}
@@ -0,0 +1,199 @@
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// lookPath is replaced in tests.
+var lookPath = exec.LookPath
+
+// builtins are shell builtins and keywords that have no binary on $PATH.
+var builtins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "alias": true, "bg": true, "builtin": true,
+	"cd": true, "command": true, "declare": true, "dirs": true, "disown": true, "echo": true,
+	"eval": true, "exec": true, "exit": true, "export": true, "false": true, "fg": true,
+	"getopts": true, "hash": true, "help": true, "history": true, "jobs": true, "kill": true,
+	"let": true, "local": true, "mapfile": true, "popd": true, "printf": true, "pushd": true,
+	"pwd": true, "read": true, "readarray": true, "readonly": true, "return": true, "set": true,
+	"shift": true, "shopt": true, "source": true, "test": true, "time": true, "times": true,
+	"trap": true, "true": true, "type": true, "typeset": true, "ulimit": true, "umask": true,
+	"unalias": true, "unset": true, "wait": true,
+}
+
+// placeholderRe matches <placeholder> values, which the prompt allows but
+// which would otherwise parse as redirections.
+var placeholderRe = regexp.MustCompile(`<[A-Za-z][A-Za-z0-9_.-]*>`)
+
+// Check parses command as bash and verifies that every command it invokes
+// by literal name is a builtin, a function it defines, or a binary on $PATH.
+// Unbalanced quotes are reported as syntax errors.
+func Check(command string) error {
+	names, err := ExternalCommands(command)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, name := range names {
+		if _, err := lookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingError{Names: missing}
+	}
+	return nil
+}
+
+// MissingError reports commands that aren't installed.
+type MissingError struct {
+	Names []string
+	// Host is set when the commands were looked up on another machine.
+	Host string
+}
+
+func (e *MissingError) Error() string {
+	if e.Host != "" {
+		return fmt.Sprintf("command not found on %s: %s", e.Host, strings.Join(e.Names, ", "))
+	}
+	return "command not found: " + strings.Join(e.Names, ", ")
+}
+
+// alternatives are commonly installed tools that can stand in for others.
+var alternatives = map[string][]string{
+	"rg":        {"grep", "git"},
+	"ag":        {"rg", "grep"},
+	"ack":       {"rg", "grep"},
+	"fd":        {"find"},
+	"fdfind":    {"find"},
+	"bat":       {"cat", "less"},
+	"eza":       {"ls"},
+	"exa":       {"ls"},
+	"lsd":       {"ls"},
+	"tree":      {"find", "ls"},
+	"dust":      {"du"},
+	"ncdu":      {"du"},
+	"duf":       {"df"},
+	"htop":      {"top", "ps"},
+	"btop":      {"top", "ps"},
+	"procs":     {"ps"},
+	"sd":        {"sed", "perl"},
+	"gsed":      {"sed"},
+	"gawk":      {"awk"},
+	"ggrep":     {"grep"},
+	"jq":        {"python3", "yq"},
+	"yq":        {"python3"},
+	"fzf":       {"grep"},
+	"wget":      {"curl"},
+	"curl":      {"wget"},
+	"http":      {"curl", "wget"},
+	"xh":        {"curl", "wget"},
+	"netstat":   {"ss", "lsof"},
+	"ss":        {"netstat", "lsof"},
+	"lsof":      {"ss", "netstat", "fuser"},
+	"ifconfig":  {"ip"},
+	"ip":        {"ifconfig"},
+	"dig":       {"nslookup", "host", "getent"},
+	"nslookup":  {"dig", "host", "getent"},
+	"nc":        {"ncat", "socat", "curl"},
+	"telnet":    {"nc", "curl"},
+	"pv":        {"dd", "rsync"},
+	"rsync":     {"cp", "scp"},
+	"7z":        {"unzip", "tar"},
+	"unzip":     {"python3", "7z", "bsdtar"},
+	"zip":       {"tar", "7z"},
+	"xclip":     {"wl-copy", "xsel"},
+	"pbcopy":    {"xclip", "wl-copy", "xsel"},
+	"gdate":     {"date"},
+	"shasum":    {"sha256sum", "openssl"},
+	"sha256sum": {"shasum", "openssl"},
+	"md5sum":    {"md5", "openssl"},
+	"md5":       {"md5sum", "openssl"},
+	"tac":       {"tail"},
+	"timeout":   {"gtimeout", "perl"},
+	"watch":     {"sh"},
+	"hexdump":   {"xxd", "od"},
+	"xxd":       {"hexdump", "od"},
+	"docker":    {"podman", "nerdctl"},
+	"podman":    {"docker"},
+	"python":    {"python3"},
+	"pip":       {"pip3", "python3"},
+	"vim":       {"vi", "nano"},
+	"nano":      {"vi", "vim"},
+}
+
+// Alternatives returns tools that can often do what name does, in order of
+// preference. Callers check which are installed.
+func Alternatives(name string) []string {
+	return alternatives[filepath.Base(name)]
+}
+
+// Installed returns the names that are binaries on $PATH.
+func Installed(names []string) []string {
+	var found []string
+	for _, n := range names {
+		if _, err := lookPath(n); err == nil {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// ExternalCommands parses command as bash and returns the names of the
+// commands it invokes by literal name that aren't builtins or functions it
+// defines, in order of first use.
+func ExternalCommands(command string) ([]string, error) {
+	return externalCommands(command, false)
+}
+
+// Tools is like ExternalCommands but also returns the commands that prefix
+// commands such as sudo and xargs run, so "sudo rm -r x" uses sudo and rm.
+func Tools(command string) ([]string, error) {
+	return externalCommands(command, true)
+}
+
+func externalCommands(command string, throughPrefixes bool) ([]string, error) {
+	src := placeholderRe.ReplaceAllString(command, "placeholder")
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return nil, fmt.Errorf("syntax error: %w", err)
+	}
+
+	defined := map[string]bool{}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if fn, ok := node.(*syntax.FuncDecl); ok {
+			defined[fn.Name.Value] = true
+		}
+		return true
+	})
+
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name == "" || seen[name] || builtins[name] || defined[name] || strings.Contains(name, "placeholder") {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		lits := literals(call.Args)
+		for i := 0; i < len(lits); i = wrappedCommand(lits, i) {
+			add(lits[i])
+			if !throughPrefixes || !prefixCommands[lits[i]] {
+				break
+			}
+		}
+		return true
+	})
+	return names, nil
+}
@@ -0,0 +1,119 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fakePath(t *testing.T, installed ...string) {
+	t.Helper()
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fakePath(t, "ls", "grep", "git", "fzf", "xargs", "cat")
+
+	cases := []struct {
+		name    string
+		command string
+		wantErr string
+	}{
+		{name: "simple", command: "ls -la"},
+		{name: "pipeline", command: "git branch --format='%(refname:short)' | fzf | xargs git checkout"},
+		{name: "builtins", command: `cd /tmp && echo "$PWD"`},
+		{name: "placeholder", command: "cat <filename> | grep foo"},
+		{name: "function", command: "f() { ls; }; f"},
+		{name: "variable command", command: `$EDITOR notes.txt`},
+		{name: "unbalanced quote", command: `echo "hello`, wantErr: "syntax error"},
+		{name: "missing binary", command: "ls | rg foo | fd bar", wantErr: "command not found: rg, fd"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Check(tc.command)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExternalCommands(t *testing.T) {
+	got, err := ExternalCommands(`f() { jq .; }; cd /tmp && curl -s <url> | f | grep x | grep y`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, " ") != "jq curl grep" {
+		t.Errorf("expected jq, curl and grep without the function or builtins, got %v", got)
+	}
+}
+
+func TestTools(t *testing.T) {
+	got, err := Tools(`sudo -E rm -r /tmp/x && find . -name '*.o' | xargs -0 rm; env FOO=1 make`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, " ") != "sudo rm find xargs env make" {
+		t.Errorf("expected the commands prefix commands run too, got %v", got)
+	}
+
+	cases := map[string]string{
+		"sudo -u postgres psql -c 'select 1'":    "sudo psql",
+		"sudo --user=postgres -i psql":           "sudo psql",
+		"ls | xargs -n 1 -P 4 gzip":              "ls xargs gzip",
+		"xargs -I{} cp {} /backup":               "xargs cp",
+		"timeout 5 curl example.com":             "timeout curl",
+		"timeout -s KILL 30s make":               "timeout make",
+		"nice -n 10 tar czf a.tgz .":             "nice tar",
+		"doas -u www -- php artisan migrate":     "doas php",
+		"env -u HOME FOO=1 -- python3 script.py": "env python3",
+	}
+	for command, want := range cases {
+		got, err := Tools(command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("Tools(%q) = %v, want %s", command, got, want)
+		}
+	}
+}
+
+func TestMissingAlternatives(t *testing.T) {
+	fakePath(t, "ls", "grep", "find")
+
+	var missing *MissingError
+	if err := Check("rg TODO | fd x"); !errors.As(err, &missing) || len(missing.Names) != 2 {
+		t.Fatalf("expected a MissingError for rg and fd, got %v", err)
+	}
+	if got := Installed(Alternatives("rg")); len(got) != 1 || got[0] != "grep" {
+		t.Errorf("unexpected installed alternatives to rg: %v", got)
+	}
+	if got := Installed(Alternatives("/usr/local/bin/fd")); len(got) != 1 || got[0] != "find" {
+		t.Errorf("unexpected installed alternatives to fd: %v", got)
+	}
+	if got := Alternatives("frobnicate"); got != nil {
+		t.Errorf("expected no alternatives for an unknown tool, got %v", got)
+	}
+
+	remote := &MissingError{Names: []string{"ss"}, Host: "web1"}
+	if remote.Error() != "command not found on web1: ss" {
+		t.Errorf("unexpected remote message: %q", remote.Error())
+	}
+}
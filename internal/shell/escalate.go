@@ -0,0 +1,32 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Escalators are the commands that run another as root, most common first.
+var Escalators = []string{"sudo", "doas", "sudo-rs"}
+
+// ChooseEscalator returns the command to prefix commands that need root
+// with: "" for root itself, else the first of Escalators that has reports
+// installed. ok is false when there's none to use.
+func ChooseEscalator(root bool, has func(name string) bool) (name string, ok bool) {
+	if root {
+		return "", true
+	}
+	for _, e := range Escalators {
+		if has(e) {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// Escalator is ChooseEscalator for this machine.
+func Escalator() (string, bool) {
+	return ChooseEscalator(os.Geteuid() == 0, func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	})
+}
@@ -0,0 +1,27 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChooseEscalator(t *testing.T) {
+	cases := []struct {
+		root      bool
+		installed []string
+		want      string
+		ok        bool
+	}{
+		{false, []string{"sudo", "doas"}, "sudo", true},
+		{false, []string{"doas"}, "doas", true},
+		{false, []string{"sudo-rs"}, "sudo-rs", true},
+		{true, []string{"sudo"}, "", true},
+		{false, nil, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := ChooseEscalator(tc.root, func(name string) bool { return slices.Contains(tc.installed, name) })
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ChooseEscalator(%v, %v) = %q, %v; want %q, %v", tc.root, tc.installed, got, ok, tc.want, tc.ok)
+		}
+	}
+}
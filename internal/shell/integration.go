@@ -0,0 +1,245 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// IntegrationShells lists the shells `how init` supports.
+var IntegrationShells = []string{"bash", "zsh", "fish"}
+
+// maxStderrBytes bounds how much captured error output is read back.
+const maxStderrBytes = 8192
+
+// staleAfter is how long per-session state is kept for shells that have
+// since exited.
+const staleAfter = 7 * 24 * time.Hour
+
+// The hooks record each interactive command to <dir>/<pid>.cmd before it
+// runs and its exit status to <dir>/<pid>.status afterwards. With stderr
+// capture, error output is tee'd to <dir>/<pid>.err; this makes stderr a
+// pipe, so some tools stop colorizing it. `how why` itself is never
+// recorded, so it always sees the command before it. The bash and zsh
+// scripts also alias how so its arguments aren't glob expanded, letting
+// questions end in ? or mention *.log unquoted. The zsh script exports
+// HOW_INTERACTIVE_COMMENTS=1 while INTERACTIVE_COMMENTS is set, since only
+// then can commands carry a trailing provenance comment.
+
+const zshIntegration = `# how shell integration for zsh
+export HOW_SHELL_SESSION=$$
+typeset -g __how_dir=%s __how_capture=%d __how_active= __how_fd=
+[[ -d $__how_dir ]] || mkdir -p -m 700 -- "$__how_dir"
+__how_preexec() {
+  if [[ $1 == 'how why'* ]]; then __how_active=; return 0; fi
+  __how_active=1
+  print -r -- "$1" >| "$__how_dir/$$.cmd"
+  if (( __how_capture )); then
+    exec {__how_fd}>&2
+    exec 2> >(tee -- "$__how_dir/$$.err" >&$__how_fd)
+  fi
+}
+__how_precmd() {
+  local s=$?
+  if [[ -o interactivecomments ]]; then export HOW_INTERACTIVE_COMMENTS=1; else unset HOW_INTERACTIVE_COMMENTS; fi
+  [[ -n $__how_active ]] || return 0
+  __how_active=
+  print -r -- $s >| "$__how_dir/$$.status"
+  if [[ -n $__how_fd ]]; then
+    exec 2>&$__how_fd {__how_fd}>&-
+    __how_fd=
+  fi
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __how_preexec
+precmd_functions=(__how_precmd ${precmd_functions:#__how_precmd})
+alias how='noglob how'
+`
+
+const bashIntegration = `# how shell integration for bash
+export HOW_SHELL_SESSION=$$
+__how_dir=%s
+__how_capture=%d
+__how_armed=
+__how_active=
+__how_fd=
+[[ -d $__how_dir ]] || mkdir -p -m 700 -- "$__how_dir"
+__how_preexec() {
+  # Runs before the arguments are expanded, so how gets them unglobbed
+  if [[ $BASH_COMMAND == __how_noglob* ]]; then
+    __how_opts=$-
+    set -f
+  fi
+  [[ -n $__how_armed && -z ${COMP_LINE:-} && $BASH_COMMAND != __how_* ]] || return 0
+  __how_armed=
+  local cmd
+  cmd=$(HISTTIMEFORMAT= builtin history 1)
+  [[ $cmd =~ ^[[:space:]]*[0-9]+[*]?[[:space:]]+(.*)$ ]] && cmd=${BASH_REMATCH[1]}
+  if [[ $cmd == 'how why'* ]]; then __how_active=; return 0; fi
+  __how_active=1
+  printf '%%s\n' "$cmd" >| "$__how_dir/$$.cmd"
+  if (( __how_capture )); then
+    # The trap runs after a pipeline's pipes are created; close them in the
+    # tee so the pipeline still sees EOF
+    exec {__how_fd}>&2
+    exec 2> >(exec 1>&$__how_fd; for f in /dev/fd/*; do n=${f##*/}; (( n > 2 )) && eval "exec $n>&-"; done 2>/dev/null; exec tee -- "$__how_dir/$$.err")
+  fi
+}
+__how_precmd() {
+  local s=$?
+  if [[ -n $__how_active ]]; then
+    __how_active=
+    printf '%%s\n' "$s" >| "$__how_dir/$$.status"
+    if [[ -n $__how_fd ]]; then
+      exec 2>&$__how_fd {__how_fd}>&-
+      __how_fd=
+    fi
+  fi
+  return $s
+}
+__how_arm() { __how_armed=1; }
+trap '__how_preexec' DEBUG
+PROMPT_COMMAND="__how_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND};__how_arm"
+__how_noglob() {
+  [[ $__how_opts == *f* ]] || set +f
+  command how "$@"
+}
+alias how='__how_noglob'
+`
+
+const fishIntegration = `# how shell integration for fish
+set -gx HOW_SHELL_SESSION $fish_pid
+set -g __how_dir %s
+test -d $__how_dir; or mkdir -p -m 700 -- $__how_dir
+function __how_postexec --on-event fish_postexec
+    set -l s $status
+    string match -q -- 'how why*' $argv[1]; and return
+    printf '%%s\n' $argv[1] >$__how_dir/$fish_pid.cmd
+    printf '%%s\n' $s >$__how_dir/$fish_pid.status
+end
+`
+
+// Integration returns the hook script for shell that records commands to
+// dir. Stderr capture is only available for bash and zsh.
+func Integration(shell, dir string, captureStderr bool) (string, error) {
+	capture := 0
+	if captureStderr {
+		capture = 1
+	}
+	switch shell {
+	case "zsh":
+		return fmt.Sprintf(zshIntegration, Quote(dir), capture), nil
+	case "bash":
+		return fmt.Sprintf(bashIntegration, Quote(dir), capture), nil
+	case "fish":
+		if captureStderr {
+			return "", fmt.Errorf("stderr capture is not supported for fish")
+		}
+		return fmt.Sprintf(fishIntegration, fishQuote(dir)), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (expected %s)", shell, strings.Join(IntegrationShells, ", "))
+}
+
+// Quote single-quotes s for POSIX shells.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// LastRun is the previous command recorded by the shell integration.
+type LastRun struct {
+	Command  string
+	ExitCode int
+	// Stderr is the command's captured error output, empty unless capture
+	// is enabled.
+	Stderr string
+}
+
+// SessionID returns the id of the calling shell: $HOW_SHELL_SESSION as set by
+// the integration, or the parent process id.
+func SessionID() string {
+	if s := os.Getenv("HOW_SHELL_SESSION"); s != "" {
+		return s
+	}
+	return strconv.Itoa(os.Getppid())
+}
+
+// ReadLastRun returns the previous command recorded for session in dir, or
+// nil if none has been recorded. State left by sessions idle for over a
+// week is removed.
+func ReadLastRun(dir, session string) (*LastRun, error) {
+	prune(dir, session)
+
+	base := filepath.Join(dir, session)
+	cmdData, err := os.ReadFile(base + ".cmd")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading last command: %w", err)
+	}
+	cmdInfo, err := os.Stat(base + ".cmd")
+	if err != nil {
+		return nil, err
+	}
+
+	run := &LastRun{Command: strings.TrimRight(string(cmdData), "\n")}
+	if data, err := os.ReadFile(base + ".status"); err == nil {
+		run.ExitCode, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	// Error output older than the command is left over from an earlier one
+	if info, err := os.Stat(base + ".err"); err == nil && !info.ModTime().Before(cmdInfo.ModTime()) {
+		if data, err := readTail(base+".err", maxStderrBytes); err == nil {
+			run.Stderr = data
+		}
+	}
+	return run, nil
+}
+
+// readTail returns up to limit bytes from the end of the file at path, where
+// the most relevant error output usually is.
+func readTail(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(info.Size()-limit, 0)
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		return "", err
+	}
+	// Don't start in the middle of a multi-byte character
+	for len(buf) > 0 && !utf8.RuneStart(buf[0]) {
+		buf = buf[1:]
+	}
+	return string(buf), nil
+}
+
+func prune(dir, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), keep+".") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > staleAfter {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntegration(t *testing.T) {
+	for _, sh := range IntegrationShells {
+		script, err := Integration(sh, "/tmp/it's here", false)
+		if err != nil {
+			t.Fatalf("%s: %v", sh, err)
+		}
+		if !strings.Contains(script, "HOW_SHELL_SESSION") {
+			t.Errorf("%s: script should export HOW_SHELL_SESSION", sh)
+		}
+	}
+	if _, err := Integration("fish", "/tmp", true); err == nil {
+		t.Error("expected stderr capture to be rejected for fish")
+	}
+	if _, err := Integration("tcsh", "/tmp", false); err == nil {
+		t.Error("expected unsupported shell error")
+	}
+}
+
+func TestIntegrationSyntax(t *testing.T) {
+	for _, sh := range []string{"bash", "zsh"} {
+		if _, err := exec.LookPath(sh); err != nil {
+			continue
+		}
+		script, _ := Integration(sh, "/tmp/it's here", true)
+		if out, err := exec.Command(sh, "-n", "-c", script).CombinedOutput(); err != nil {
+			t.Errorf("%s integration has syntax errors: %v\n%s", sh, err, out)
+		}
+	}
+}
+
+func TestBashIntegrationRecordsLastRun(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	script, _ := Integration("bash", dir, true)
+	rc := filepath.Join(dir, "rc")
+	if err := os.WriteFile(rc, []byte("HISTFILE=/dev/null\nset -o history\n"+script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("bash", "--rcfile", rc, "-i")
+	cmd.Stdin = strings.NewReader("echo ok | cat\nls /definitely-missing | cat; (exit 4)\nhow why\n")
+	cmd.Env = append(os.Environ(), "HOW_SHELL_SESSION=")
+	done := make(chan error, 1)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("interactive bash with the integration did not exit")
+	}
+
+	last, err := ReadLastRun(dir, strconv.Itoa(cmd.Process.Pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil {
+		t.Fatal("expected a recorded command")
+	}
+	if last.Command != "ls /definitely-missing | cat; (exit 4)" {
+		t.Errorf("unexpected command (how why must not be recorded): %q", last.Command)
+	}
+	if last.ExitCode != 4 {
+		t.Errorf("expected exit code 4, got %d", last.ExitCode)
+	}
+	if !strings.Contains(last.Stderr, "definitely-missing") {
+		t.Errorf("expected captured stderr, got %q", last.Stderr)
+	}
+}
+
+func TestReadLastRunIgnoresStaleStderr(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "42")
+	_ = os.WriteFile(base+".err", []byte("old error"), 0o600)
+	old := time.Now().Add(-time.Minute)
+	_ = os.Chtimes(base+".err", old, old)
+	_ = os.WriteFile(base+".cmd", []byte("make\n"), 0o600)
+	_ = os.WriteFile(base+".status", []byte("2\n"), 0o600)
+
+	last, err := ReadLastRun(dir, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Command != "make" || last.ExitCode != 2 || last.Stderr != "" {
+		t.Errorf("unexpected last run: %+v", last)
+	}
+
+	if last, _ := ReadLastRun(dir, "43"); last != nil {
+		t.Errorf("expected nil for unknown session, got %+v", last)
+	}
+}
+
+func TestBashIntegrationNoGlob(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	// A fake how that records its arguments, and a file that what? would
+	// match if it were expanded
+	bin := filepath.Join(dir, "bin")
+	args := filepath.Join(dir, "args")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fake := "#!/bin/sh\nprintf '%s\\n' \"$@\" >> " + Quote(args) + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "how"), []byte(fake), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "whatx"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	script, _ := Integration("bash", filepath.Join(dir, "state"), false)
+	rc := filepath.Join(dir, "rc")
+	if err := os.WriteFile(rc, []byte("HISTFILE=/dev/null\nPATH="+Quote(bin)+":$PATH\n"+script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("bash", "--rcfile", rc, "-i")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader("how what?\nfalse && how skipped\ntrue || how skipped\ntrue && how why?\necho *x > globbed\n")
+	cmd.Env = append(os.Environ(), "HOW_SHELL_SESSION=")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("interactive bash with the integration did not exit")
+	}
+
+	if got, _ := os.ReadFile(args); string(got) != "what?\nwhy?\n" {
+		t.Errorf("how got %q, want the unexpanded what? and why?, and nothing after && or || that didn't run", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "globbed")); string(got) != "whatx\n" {
+		t.Errorf("globbing after how = %q, want it restored", got)
+	}
+}
@@ -0,0 +1,194 @@
+package shell
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// prefixCommands run the command that follows their options, so the
+// command after them can be substituted too.
+var prefixCommands = map[string]bool{
+	"sudo": true, "sudo-rs": true, "doas": true, "xargs": true, "env": true, "nohup": true, "time": true,
+	"exec": true, "command": true, "nice": true, "watch": true, "timeout": true,
+}
+
+// prefixValueFlags are the options of prefixCommands that take their
+// value as a separate argument, which mustn't be mistaken for the command
+// they run.
+var prefixValueFlags = map[string][]string{
+	"sudo":    {"-u", "-g", "-C", "-p", "-r", "-t", "-D", "-R", "-T", "-U", "--user", "--group", "--close-from", "--prompt", "--role", "--type", "--chdir", "--chroot", "--command-timeout", "--other-user"},
+	"sudo-rs": {"-u", "-g", "-D", "-U", "--user", "--group", "--chdir", "--other-user"},
+	"doas":    {"-u", "-C"},
+	"xargs":   {"-n", "-I", "-P", "-L", "-s", "-d", "-E", "-a", "--max-args", "--max-procs", "--max-lines", "--max-chars", "--delimiter", "--arg-file"},
+	"env":     {"-u", "-C", "-S", "--unset", "--chdir", "--split-string"},
+	"exec":    {"-a"},
+	"nice":    {"-n", "--adjustment"},
+	"timeout": {"-s", "-k", "--signal", "--kill-after"},
+	"watch":   {"-n", "--interval"},
+	"time":    {"-f", "-o", "--format", "--output"},
+}
+
+// dropIns are the tool pairs Substitute knows to be compatible, keyed by
+// "from to". A nil list means the replacement accepts the original's
+// whole command line; otherwise only calls whose options are all in the
+// list are swapped, since the same flag can mean something else, as with
+// grep -r and rg -r. Pairs that aren't listed are left to the prompt.
+var dropIns = map[string][]string{
+	"docker podman":                 nil,
+	"docker-compose podman-compose": nil,
+	"vi nvim":                       nil,
+	"vim nvim":                      nil,
+	"kubectl kubecolor":             nil,
+	"grep rg":                       {"-i", "-v", "-n", "-w", "-c", "-l", "-F", "-x", "-o", "-q", "-H", "-e", "-A", "-B", "-C", "-m", "--ignore-case", "--invert-match", "--line-number", "--word-regexp", "--count", "--files-with-matches", "--fixed-strings", "--line-regexp", "--only-matching", "--quiet", "--regexp", "--max-count"},
+	"cat bat":                       {"-n", "-A", "--number", "--show-all"},
+	"ls eza":                        {"-l", "-a", "-1", "-R", "-r", "-d", "--long", "--all", "--oneline", "--recurse", "--reverse"},
+	"ls lsd":                        {"-l", "-a", "-A", "-1", "-R", "-r", "-d", "-h", "-t", "-S", "--long", "--all", "--almost-all", "--oneline", "--recursive", "--reverse"},
+	"sudo doas":                     {"-u", "-n", "-s"},
+	"sudo sudo-rs":                  {"-u", "-g", "-i", "-s", "-n", "-k", "-K", "-v", "-l", "-D", "--user", "--group", "--login", "--shell", "--non-interactive", "--validate", "--list", "--chdir"},
+}
+
+// acceptsOptions reports whether every option in args is in allowed,
+// checking combined short options such as -in letter by letter.
+func acceptsOptions(args, allowed []string) bool {
+	for _, a := range args {
+		switch {
+		case a == "--":
+			return true
+		case strings.HasPrefix(a, "--"):
+			key, _, _ := strings.Cut(a, "=")
+			if !slices.Contains(allowed, key) {
+				return false
+			}
+		case strings.HasPrefix(a, "-") && len(a) > 1:
+			for _, c := range a[1:] {
+				if !slices.Contains(allowed, "-"+string(c)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// wrappedCommand returns the index in args of the command run by the
+// prefix command at args[i], as in sudo -u postgres psql or timeout 5 curl,
+// skipping its options, their values, variable assignments and timeout's
+// duration. It returns len(args) when there's no command, and i+1 when
+// args[i] isn't a prefix command. Words that aren't literal are "".
+func wrappedCommand(args []string, i int) int {
+	name := args[i]
+	if !prefixCommands[name] {
+		return i + 1
+	}
+	for i++; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			i++
+			break
+		}
+		if !strings.HasPrefix(a, "-") && !strings.Contains(a, "=") {
+			break
+		}
+		if slices.Contains(prefixValueFlags[name], a) {
+			i++
+		}
+	}
+	if name == "timeout" && i < len(args) {
+		i++
+	}
+	return min(i, len(args))
+}
+
+// Unwrap drops prefix commands such as sudo, env or timeout, with their
+// options, from the front of args and returns the command they run, as
+// in sudo -u postgres psql → psql.
+func Unwrap(args []string) []string {
+	for len(args) > 0 && prefixCommands[args[0]] {
+		args = args[wrappedCommand(args, 0):]
+	}
+	return args
+}
+
+// literals returns the literal text of each word, or "" where it isn't
+// literal.
+func literals(words []*syntax.Word) []string {
+	lits := make([]string, len(words))
+	for i, w := range words {
+		lits[i] = w.Lit()
+	}
+	return lits
+}
+
+// Substitute replaces the commands named in prefer with the preferred tool,
+// as in docker → podman, wherever they're invoked by literal name, the
+// pair is a known drop-in and the call's options mean the same to the
+// preferred tool (see dropIns). Only tools for
+// which installed returns true are substituted, and commands that don't
+// parse are returned unchanged. The replacements made are
+// returned as "from → to".
+func Substitute(command string, prefer map[string]string, installed func(string) bool) (string, []string) {
+	if len(prefer) == 0 {
+		return command, nil
+	}
+	// Mask placeholders without moving offsets
+	src := placeholderRe.ReplaceAllStringFunc(command, func(s string) string {
+		return "_" + s[1:len(s)-1] + "_"
+	})
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return command, nil
+	}
+
+	type edit struct {
+		start, end int
+		to         string
+	}
+	var edits []edit
+	var made []string
+	seen := map[string]bool{}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		lits := literals(call.Args)
+		for i := 0; i < len(call.Args); {
+			w, name := call.Args[i], lits[i]
+			// A prefix command's own options end where the command it
+			// runs starts
+			next := wrappedCommand(lits, i)
+			opts := lits[i+1:]
+			if prefixCommands[name] {
+				opts = lits[i+1 : next]
+			}
+			to, ok := prefer[name]
+			allowed, known := dropIns[name+" "+to]
+			if ok && to != name && known && (allowed == nil || acceptsOptions(opts, allowed)) && installed(to) {
+				edits = append(edits, edit{int(w.Pos().Offset()), int(w.End().Offset()), to})
+				if change := fmt.Sprintf("%s → %s", name, to); !seen[change] {
+					seen[change] = true
+					made = append(made, change)
+				}
+			}
+			if !prefixCommands[name] {
+				break
+			}
+			i = next
+		}
+		return true
+	})
+	if len(edits) == 0 {
+		return command, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := command
+	for _, e := range edits {
+		out = out[:e.start] + e.to + out[e.end:]
+	}
+	return out, made
+}
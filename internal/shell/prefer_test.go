@@ -0,0 +1,56 @@
+package shell
+
+import "testing"
+
+func TestSubstitute(t *testing.T) {
+	prefer := map[string]string{"docker": "podman", "grep": "rg", "cat": "bat", "htop": "btop", "find": "fd"}
+	installed := func(name string) bool { return name != "btop" }
+
+	cases := []struct {
+		command, want string
+		changes       int
+	}{
+		{"docker ps -a", "podman ps -a", 1},
+		{"sudo -E docker run --rm alpine", "sudo -E podman run --rm alpine", 1},
+		{"sudo -u docker docker ps", "sudo -u docker podman ps", 1},
+		{"timeout 5 cat app.log", "timeout 5 bat app.log", 1},
+		{"cat app.log | grep ERROR | grep -v timeout", "bat app.log | rg ERROR | rg -v timeout", 2},
+		{"find . -name '*.go' | xargs -0 grep TODO", "find . -name '*.go' | xargs -0 rg TODO", 1},
+		{"echo docker grep", "echo docker grep", 0},
+		{`git log --grep="cat"`, `git log --grep="cat"`, 0},
+		{"htop", "htop", 0},
+		{"grep -rn TODO .", "grep -rn TODO .", 0},
+		{"grep -in --max-count=3 TODO main.go", "rg -in --max-count=3 TODO main.go", 1},
+		{"cat -v notes.txt", "cat -v notes.txt", 0},
+		{"find . -name '*.go'", "find . -name '*.go'", 0},
+		{"find src", "find src", 0},
+		{"cat <file> | wc -l", "bat <file> | wc -l", 1},
+		{`echo "unterminated`, `echo "unterminated`, 0},
+	}
+	for _, tc := range cases {
+		got, changes := Substitute(tc.command, prefer, installed)
+		if got != tc.want || len(changes) != tc.changes {
+			t.Errorf("Substitute(%q) = %q %v, want %q with %d changes", tc.command, got, changes, tc.want, tc.changes)
+		}
+	}
+}
+
+func TestSubstituteEscalator(t *testing.T) {
+	installed := func(string) bool { return true }
+	cases := []struct {
+		command, to, want string
+	}{
+		{"sudo apt-get -y install htop", "doas", "doas apt-get -y install htop"},
+		{"sudo -u postgres psql", "doas", "doas -u postgres psql"},
+		{"sudo -E make install", "doas", "sudo -E make install"},
+		{"sudo -i", "doas", "sudo -i"},
+		{"sudo --preserve-env=PATH make install", "doas", "sudo --preserve-env=PATH make install"},
+		{"sudo -i", "sudo-rs", "sudo-rs -i"},
+		{"sudo -E make install", "sudo-rs", "sudo -E make install"},
+	}
+	for _, tc := range cases {
+		if got, _ := Substitute(tc.command, map[string]string{"sudo": tc.to}, installed); got != tc.want {
+			t.Errorf("Substitute(%q, sudo → %s) = %q, want %q", tc.command, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package shell
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Session is a long-lived shell process. Commands run in the same process,
+// so state such as the working directory and exported variables carries
+// over from one command to the next.
+type Session struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	marker string
+
+	// Stdout receives the output of each command; defaults to os.Stdout.
+	Stdout io.Writer
+	// CommandStdin is redirected into each command, e.g. /dev/tty for
+	// interactive programs. Empty means /dev/null.
+	CommandStdin string
+}
+
+// NewSession starts a POSIX shell for running commands.
+func NewSession() (*Session, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating marker: %w", err)
+	}
+
+	cmd := exec.Command("sh")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("opening shell stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("opening shell stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting shell: %w", err)
+	}
+
+	return &Session{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		marker: "__HOW_DONE_" + hex.EncodeToString(nonce) + "__",
+		Stdout: os.Stdout,
+	}, nil
+}
+
+// Run executes command in the session and returns its exit status once it
+// finishes. Output is streamed to s.Stdout as it is produced.
+func (s *Session) Run(command string) (int, error) {
+	input := s.CommandStdin
+	if input == "" {
+		input = "/dev/null"
+	}
+
+	// Run in the current shell (not a subshell) so cd/export persist, then
+	// print a marker carrying the exit status.
+	script := fmt.Sprintf("{\n%s\n} <%s\nprintf '%s%%d\\n' $?\n", command, input, s.marker)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		return 0, fmt.Errorf("writing to shell: %w", err)
+	}
+
+	for {
+		line, err := s.stdout.ReadString('\n')
+		if idx := strings.Index(line, s.marker); idx >= 0 {
+			// Output without a trailing newline shares the marker's line.
+			if idx > 0 {
+				if _, werr := io.WriteString(s.Stdout, line[:idx]+"\n"); werr != nil {
+					return 0, werr
+				}
+			}
+			code, convErr := strconv.Atoi(strings.TrimSpace(line[idx+len(s.marker):]))
+			if convErr != nil {
+				return 0, fmt.Errorf("parsing exit status: %w", convErr)
+			}
+			return code, nil
+		}
+		if line != "" {
+			if _, werr := io.WriteString(s.Stdout, line); werr != nil {
+				return 0, werr
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("shell exited: %w", err)
+		}
+	}
+}
+
+// Close ends the shell process.
+func (s *Session) Close() error {
+	_ = s.stdin.Close()
+	return s.cmd.Wait()
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSessionKeepsState(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatalf("NewSession error: %v", err)
+	}
+	defer s.Close()
+
+	var out bytes.Buffer
+	s.Stdout = &out
+
+	dir := t.TempDir()
+	if code, err := s.Run("cd " + dir + " && export HOW_TEST_VAR=kept"); err != nil || code != 0 {
+		t.Fatalf("cd: code=%d err=%v", code, err)
+	}
+	if _, err := s.Run("pwd; echo $HOW_TEST_VAR"); err != nil {
+		t.Fatalf("pwd: %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, dir) {
+		t.Errorf("expected working directory %q to persist, got %q", dir, got)
+	}
+	if !strings.Contains(got, "kept") {
+		t.Errorf("expected exported variable to persist, got %q", got)
+	}
+}
+
+func TestSessionExitCode(t *testing.T) {
+	s, err := NewSession()
+	if err != nil {
+		t.Fatalf("NewSession error: %v", err)
+	}
+	defer s.Close()
+
+	var out bytes.Buffer
+	s.Stdout = &out
+
+	code, err := s.Run("printf partial; false")
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if code != 1 {
+		t.Errorf("exit code: got %d, want 1", code)
+	}
+	if out.String() != "partial\n" {
+		t.Errorf("output: got %q, want %q", out.String(), "partial\n")
+	}
+}
@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Names lists the shells commands can be translated between.
+var Names = []string{"bash", "zsh", "fish", "nushell", "powershell", "cmd"}
+
+// ErrNoValidator is returned when a shell's syntax can't be checked,
+// either because it has no check mode or it isn't installed.
+var ErrNoValidator = errors.New("no syntax validator available")
+
+// Valid reports whether name is a known shell.
+func Valid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize maps common aliases (pwsh, sh, cmd.exe, nu) to the names in Names.
+func Normalize(name string) string {
+	switch strings.ToLower(name) {
+	case "pwsh", "powershell", "ps", "ps1":
+		return "powershell"
+	case "sh", "bash":
+		return "bash"
+	case "cmd", "cmd.exe", "batch":
+		return "cmd"
+	case "nu", "nushell":
+		return "nushell"
+	default:
+		return strings.ToLower(name)
+	}
+}
+
+// Command returns the argv that runs command in the named shell, for the
+// shells that aren't POSIX, or nil for those that run commands with sh.
+func Command(name, command string) []string {
+	switch name {
+	case "nushell":
+		return []string{"nu", "-c", command}
+	case "powershell":
+		return []string{"pwsh", "-NoLogo", "-Command", command}
+	}
+	return nil
+}
+
+// psParseScript parses $env:HOW_SNIPPET with the PowerShell AST parser
+// and prints the first error without executing anything.
+const psParseScript = `$e = $null; $null = [System.Management.Automation.Language.Parser]::ParseInput($env:HOW_SNIPPET, [ref]$null, [ref]$e); if ($e) { Write-Output $e[0].Message; exit 1 }`
+
+// Validate checks command for syntax errors in the given shell without
+// running it. It returns ErrNoValidator if the check can't be performed.
+func Validate(name, command string) error {
+	var args []string
+	env := os.Environ()
+
+	switch name {
+	case "bash", "zsh":
+		args = []string{name, "-n", "-c", command}
+	case "fish":
+		args = []string{"fish", "--no-execute", "-c", command}
+	case "powershell":
+		args = []string{"pwsh", "-NoProfile", "-NonInteractive", "-Command", psParseScript}
+		env = append(env, "HOW_SNIPPET="+command)
+	default:
+		return ErrNoValidator
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return ErrNoValidator
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(out.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s syntax error: %s", name, msg)
+	}
+	return nil
+}
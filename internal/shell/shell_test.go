@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"pwsh":    "powershell",
+		"PS1":     "powershell",
+		"sh":      "bash",
+		"cmd.exe": "cmd",
+		"fish":    "fish",
+		"nu":      "nushell",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	if !Valid("fish") {
+		t.Error("expected fish to be valid")
+	}
+	if Valid("tcsh") {
+		t.Error("expected tcsh to be invalid")
+	}
+}
+
+func TestValidateBash(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+
+	if err := Validate("bash", "for f in *.txt; do echo \"$f\"; done"); err != nil {
+		t.Errorf("expected valid bash, got: %v", err)
+	}
+
+	err := Validate("bash", "if true; then echo hi")
+	if err == nil || !strings.Contains(err.Error(), "syntax error") {
+		t.Errorf("expected syntax error, got: %v", err)
+	}
+}
+
+func TestValidateCmdUnsupported(t *testing.T) {
+	if err := Validate("cmd", "dir /s"); !errors.Is(err, ErrNoValidator) {
+		t.Errorf("expected ErrNoValidator, got: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Stages splits a command that chains steps with && into those steps, in
+// order, as written. It returns nil for commands that aren't a single &&
+// chain, including ones that don't parse.
+func Stages(command string) []string {
+	// Mask placeholders without moving offsets
+	src := placeholderRe.ReplaceAllStringFunc(command, func(s string) string {
+		return "_" + s[1:len(s)-1] + "_"
+	})
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return nil
+	}
+	stmt := file.Stmts[0]
+	if stmt.Background || stmt.Negated || len(stmt.Redirs) > 0 {
+		return nil
+	}
+
+	var stmts []*syntax.Stmt
+	var flatten func(s *syntax.Stmt)
+	flatten = func(s *syntax.Stmt) {
+		if bin, ok := s.Cmd.(*syntax.BinaryCmd); ok && bin.Op == syntax.AndStmt && !s.Negated && len(s.Redirs) == 0 {
+			flatten(bin.X)
+			flatten(bin.Y)
+			return
+		}
+		stmts = append(stmts, s)
+	}
+	flatten(stmt)
+	if len(stmts) < 2 {
+		return nil
+	}
+
+	stages := make([]string, len(stmts))
+	for i, s := range stmts {
+		stages[i] = command[s.Pos().Offset():s.End().Offset()]
+	}
+	return stages
+}
+
+// StagedScript runs stages in order in one shell, like joining them with
+// &&, after changing to dir if it isn't empty. When a stage fails, its
+// number (from 1) and the working directory are written to stateFile for
+// ReadStageFailure, and the script exits with the stage's status.
+func StagedScript(stages []string, dir, stateFile string) string {
+	var b strings.Builder
+	if dir != "" {
+		fmt.Fprintf(&b, "cd -- %s || exit\n", Quote(dir))
+	}
+	for i, stage := range stages {
+		fmt.Fprintf(&b, "{\n%s\n}\n", stage)
+		fmt.Fprintf(&b, "__how_status=$?; if [ $__how_status -ne 0 ]; then printf '%%d %%s' %d \"$PWD\" >%s; exit $__how_status; fi\n", i+1, Quote(stateFile))
+	}
+	return b.String()
+}
+
+// ReadStageFailure returns the stage that failed in a StagedScript, from 1,
+// and the directory it failed in, or 0 if every stage that ran succeeded.
+func ReadStageFailure(stateFile string) (int, string, error) {
+	data, err := os.ReadFile(stateFile)
+	if os.IsNotExist(err) || len(data) == 0 {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("reading stage status: %w", err)
+	}
+	num, dir, _ := strings.Cut(string(data), " ")
+	stage, err := strconv.Atoi(num)
+	if err != nil {
+		return 0, "", fmt.Errorf("parsing stage status: %w", err)
+	}
+	return stage, dir, nil
+}
@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStages(t *testing.T) {
+	cases := []struct {
+		command string
+		want    []string
+	}{
+		{"cd app && npm ci && npm test", []string{"cd app", "npm ci", "npm test"}},
+		{"make || make clean && make", []string{"make || make clean", "make"}},
+		{"ls | grep x && echo 'a && b'", []string{"ls | grep x", `echo 'a && b'`}},
+		{"cat <file> && wc -l <file>", []string{"cat <file>", "wc -l <file>"}},
+		{"ls -la", nil},
+		{"a && b; c", nil},
+		{"(a && b) > log", nil},
+		{`echo "unterminated && x`, nil},
+	}
+	for _, tc := range cases {
+		if got := Stages(tc.command); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Stages(%q) = %q, want %q", tc.command, got, tc.want)
+		}
+	}
+}
+
+func TestStagedScript(t *testing.T) {
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state")
+	script := StagedScript([]string{"mkdir sub", "cd sub", "false", "touch never"}, dir, state)
+
+	err := exec.Command("sh", "-c", script).Run()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("script error = %v, want exit status 1", err)
+	}
+	stage, at, err := ReadStageFailure(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stage != 3 || filepath.Base(at) != "sub" {
+		t.Errorf("ReadStageFailure = %d, %q, want stage 3 in sub", stage, at)
+	}
+
+	ok := filepath.Join(dir, "ok")
+	if err := exec.Command("sh", "-c", StagedScript([]string{"true", "true"}, "", ok)).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if stage, _, err := ReadStageFailure(ok); stage != 0 || err != nil {
+		t.Errorf("ReadStageFailure = %d, %v after success", stage, err)
+	}
+}
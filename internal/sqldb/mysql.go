@@ -0,0 +1,42 @@
+//go:build mysql
+
+package sqldb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	drivers[MySQL] = driver{name: "mysql", source: mysqlSource}
+}
+
+// mysqlSource converts a mysql:// URL to the driver's DSN format. Query
+// parameters such as tls=true are the driver's own, parsed as the driver
+// does.
+func mysqlSource(dsn string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parsing database URL: %w", err)
+	}
+	params := "/"
+	if u.RawQuery != "" {
+		params += "?" + u.RawQuery
+	}
+	cfg, err := mysql.ParseDSN(params)
+	if err != nil {
+		return "", fmt.Errorf("parsing database URL: %w", err)
+	}
+	cfg.User = u.User.Username()
+	cfg.Passwd, _ = u.User.Password()
+	cfg.Net = "tcp"
+	cfg.Addr = u.Host
+	if u.Port() == "" {
+		cfg.Addr = u.Hostname() + ":3306"
+	}
+	cfg.DBName = strings.TrimPrefix(u.Path, "/")
+	return cfg.FormatDSN(), nil
+}
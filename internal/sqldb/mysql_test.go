@@ -0,0 +1,36 @@
+//go:build mysql
+
+package sqldb
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestParseDSNMySQL(t *testing.T) {
+	dialect, driver, source, err := parseDSN("mysql://u:p@db/app")
+	if err != nil || dialect != MySQL || driver != "mysql" || source != "u:p@tcp(db:3306)/app" {
+		t.Errorf("parseDSN = %s, %s, %q, %v", dialect, driver, source, err)
+	}
+}
+
+func TestMySQLSourceParams(t *testing.T) {
+	source, err := mysqlSource("mysql://u:p@db/app?tls=true&parseTime=true&autocommit=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := mysql.ParseDSN(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLSConfig != "true" || cfg.TLS == nil || cfg.TLS.ServerName != "db" {
+		t.Errorf("tls=true was lost: %q", source)
+	}
+	if !cfg.ParseTime || cfg.Params["autocommit"] != "true" || cfg.Addr != "db:3306" || cfg.DBName != "app" {
+		t.Errorf("unexpected source %q", source)
+	}
+	if _, err := mysqlSource("mysql://u:p@db/app?tls=bogus"); err == nil {
+		t.Error("expected an unknown TLS config to be rejected")
+	}
+}
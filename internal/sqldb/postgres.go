@@ -0,0 +1,9 @@
+//go:build postgres
+
+package sqldb
+
+import _ "github.com/jackc/pgx/v5/stdlib"
+
+func init() {
+	drivers[Postgres] = driver{name: "pgx", source: func(dsn string) (string, error) { return dsn, nil }}
+}
@@ -0,0 +1,12 @@
+//go:build postgres
+
+package sqldb
+
+import "testing"
+
+func TestParseDSNPostgres(t *testing.T) {
+	dialect, driver, source, err := parseDSN("postgres://u:p@db:5432/app")
+	if err != nil || dialect != Postgres || driver != "pgx" || source != "postgres://u:p@db:5432/app" {
+		t.Errorf("parseDSN = %s, %s, %q, %v", dialect, driver, source, err)
+	}
+}
@@ -0,0 +1,235 @@
+// Package sqldb introspects database schemas and runs read-only queries
+// for `how sql`.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Dialects supported by Open.
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+)
+
+// DB is an open database connection.
+type DB struct {
+	db      *sql.DB
+	Dialect string
+}
+
+// driver is a database/sql driver for a dialect and how to turn a URL
+// into its data source name.
+type driver struct {
+	name   string
+	source func(dsn string) (string, error)
+}
+
+// drivers are the built-in drivers for dialects other than SQLite, which
+// how already needs for its memory. The PostgreSQL and MySQL drivers are
+// only built with the postgres and mysql build tags, keeping them out of
+// the default binary.
+var drivers = map[string]driver{}
+
+// Open connects to the database at dsn: a postgres://, postgresql://,
+// mysql:// or sqlite:// URL, or a path to a SQLite file. SQLite files are
+// opened read-only.
+func Open(dsn string) (*DB, error) {
+	dialect, driver, source, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	return &DB{db: db, Dialect: dialect}, nil
+}
+
+func parseDSN(dsn string) (dialect, driver, source string, err error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		// A bare path is a SQLite file
+		return sqliteSource(dsn)
+	}
+	switch scheme {
+	case "postgres", "postgresql":
+		return withDriver(Postgres, dsn)
+	case "sqlite", "sqlite3", "file":
+		return sqliteSource(rest)
+	case "mysql":
+		return withDriver(MySQL, dsn)
+	}
+	return "", "", "", fmt.Errorf("unsupported database URL scheme %q (expected postgres, mysql or sqlite)", scheme)
+}
+
+// sqliteSource returns parseDSN's result for a SQLite file, opened
+// read-only whatever mode its query parameters ask for.
+func sqliteSource(path string) (string, string, string, error) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", "", "", fmt.Errorf("parsing database URL: %w", err)
+	}
+	query.Set("mode", "ro")
+	return SQLite, "sqlite", "file:" + path + "?" + query.Encode(), nil
+}
+
+// withDriver returns parseDSN's result for dsn with dialect's driver.
+func withDriver(dialect, dsn string) (string, string, string, error) {
+	d, ok := drivers[dialect]
+	if !ok {
+		return "", "", "", fmt.Errorf("this build of how has no %s driver; build it with -tags %s", dialect, dialect)
+	}
+	source, err := d.source(dsn)
+	return dialect, d.name, source, err
+}
+
+// Redact hides the password in a database URL for display.
+func Redact(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return dsn
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// Close closes the connection.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Table is a table's name and column names. No data or column types are
+// read.
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// schemaQueries list tables and columns, in column order.
+var schemaQueries = map[string]string{
+	Postgres: `SELECT table_schema || '.' || table_name, column_name FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY table_schema, table_name, ordinal_position`,
+	MySQL: `SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position`,
+	SQLite: `SELECT m.name, p.name FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%' ORDER BY m.name, p.cid`,
+}
+
+// Schema returns the tables and views in the database.
+func (d *DB) Schema(ctx context.Context) ([]Table, error) {
+	rows, err := d.db.QueryContext(ctx, schemaQueries[d.Dialect])
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	byName := map[string]*Table{}
+	var tables []*Table
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("reading schema: %w", err)
+		}
+		// Postgres tables in the public schema don't need qualifying
+		table = strings.TrimPrefix(table, "public.")
+		t, ok := byName[table]
+		if !ok {
+			t = &Table{Name: table}
+			byName[table] = t
+			tables = append(tables, t)
+		}
+		t.Columns = append(t.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+
+	out := make([]Table, len(tables))
+	for i, t := range tables {
+		out[i] = *t
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// FormatSchema lists tables one per line as name(column, ...).
+func FormatSchema(tables []Table) string {
+	var b strings.Builder
+	for _, t := range tables {
+		fmt.Fprintf(&b, "%s(%s)\n", t.Name, strings.Join(t.Columns, ", "))
+	}
+	return b.String()
+}
+
+// Result is a preview of a query's rows.
+type Result struct {
+	Columns []string
+	Rows    [][]string
+	// More is true when rows beyond the preview limit were left out.
+	More bool
+}
+
+// QueryReadOnly runs query in a read-only transaction that is always rolled
+// back, returning at most limit rows.
+func (d *DB) QueryReadOnly(ctx context.Context, query string, limit int) (*Result, error) {
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("starting read-only transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	rows, err := tx.QueryContext(ctx, strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Columns: cols}
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(res.Rows) == limit {
+			res.More = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = formatValue(v)
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res, rows.Err()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,115 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shop.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close() //nolint:errcheck
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, total REAL)`,
+		`INSERT INTO users (email) VALUES ('a@example.com'), ('b@example.com'), (NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestSchema(t *testing.T) {
+	db, err := Open(testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	tables, err := db.Schema(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].Name != "orders" || strings.Join(tables[1].Columns, ",") != "id,email" {
+		t.Errorf("unexpected schema: %+v", tables)
+	}
+	if got := FormatSchema(tables); got != "orders(id, user_id, total)\nusers(id, email)\n" {
+		t.Errorf("unexpected formatted schema: %q", got)
+	}
+}
+
+func TestQueryReadOnly(t *testing.T) {
+	db, err := Open("sqlite://" + testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close() //nolint:errcheck
+	ctx := context.Background()
+
+	res, err := db.QueryReadOnly(ctx, "SELECT id, email FROM users ORDER BY id;", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rows) != 2 || !res.More || res.Rows[0][1] != "a@example.com" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	res, _ = db.QueryReadOnly(ctx, "SELECT email FROM users WHERE id = 3", 10)
+	if res.Rows[0][0] != "NULL" || res.More {
+		t.Errorf("expected NULL, got %+v", res)
+	}
+
+	if _, err := db.QueryReadOnly(ctx, "DELETE FROM users", 10); err == nil {
+		t.Error("expected writes to fail")
+	}
+	res, _ = db.QueryReadOnly(ctx, "SELECT count(*) FROM users", 10)
+	if res.Rows[0][0] != "3" {
+		t.Errorf("expected rows to be untouched, got %v", res.Rows)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	cases := []struct{ dsn, dialect, source string }{
+		{"./app.db", SQLite, "file:./app.db?mode=ro"},
+		{"sqlite://data/app.db", SQLite, "file:data/app.db?mode=ro"},
+		{"sqlite://app.db?_pragma=busy_timeout(5000)&mode=rwc", SQLite, "file:app.db?_pragma=busy_timeout%285000%29&mode=ro"},
+	}
+	for _, tc := range cases {
+		dialect, _, source, err := parseDSN(tc.dsn)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.dsn, err)
+		}
+		if dialect != tc.dialect || source != tc.source {
+			t.Errorf("parseDSN(%q) = %s, %q; want %s, %q", tc.dsn, dialect, source, tc.dialect, tc.source)
+		}
+	}
+	if _, _, _, err := parseDSN("redis://localhost"); err == nil {
+		t.Error("expected unsupported scheme error")
+	}
+}
+
+func TestParseDSNWithoutDriver(t *testing.T) {
+	defer func(saved map[string]driver) { drivers = saved }(drivers)
+	drivers = map[string]driver{}
+	_, _, _, err := parseDSN("mysql://u:p@db/app")
+	if err == nil || !strings.Contains(err.Error(), "-tags mysql") {
+		t.Errorf("expected a build tag hint, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := Redact("postgres://app:s3cret@db/app"); strings.Contains(got, "s3cret") {
+		t.Errorf("password should be hidden, got %q", got)
+	}
+	if got := Redact("./app.db"); got != "./app.db" {
+		t.Errorf("paths should be unchanged, got %q", got)
+	}
+}
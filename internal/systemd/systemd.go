@@ -0,0 +1,161 @@
+// Package systemd parses generated unit files and works out where and how
+// to install them.
+package systemd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Unit is a generated unit file.
+type Unit struct {
+	Name    string
+	Content string
+}
+
+// execRe matches the directives whose value is a command systemd runs.
+var execRe = regexp.MustCompile(`^\s*(Exec(?:Start|StartPre|StartPost|Stop|StopPost|Reload|Condition))\s*=\s*(.*)$`)
+
+// Commands returns the command lines in u's Exec directives, without the
+// special prefixes (@, -, :, +, !) systemd allows before the executable.
+func (u Unit) Commands() []string {
+	var cmds []string
+	for _, line := range strings.Split(u.Content, "\n") {
+		m := execRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if cmd := strings.TrimSpace(strings.TrimLeft(m[2], "@-:+!")); cmd != "" {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// Plan is a set of units to install and the one to enable afterwards.
+type Plan struct {
+	Units       []Unit
+	Enable      string
+	Explanation string
+}
+
+// unitNameRe matches the unit types it makes sense to generate.
+var unitNameRe = regexp.MustCompile(`^[A-Za-z0-9:_.@-]+\.(?:service|timer|socket|path|mount|target)$`)
+
+// Parse reads units in the format requested by prompt.SystemdPrompt:
+// UNIT: <name> followed by the file's lines, then ENABLE: and EXPLANATION:
+// lines.
+func Parse(response string) (Plan, error) {
+	var (
+		plan    Plan
+		current *strings.Builder
+	)
+	flush := func() {
+		if current != nil {
+			plan.Units[len(plan.Units)-1].Content = strings.TrimSpace(current.String()) + "\n"
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "UNIT:"):
+			flush()
+			plan.Units = append(plan.Units, Unit{Name: strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "UNIT:")), "`")})
+			current = &strings.Builder{}
+		case strings.HasPrefix(trimmed, "ENABLE:"):
+			flush()
+			plan.Enable = strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "ENABLE:")), "`")
+		case strings.HasPrefix(trimmed, "EXPLANATION:"):
+			flush()
+			plan.Explanation = strings.TrimSpace(strings.TrimPrefix(trimmed, "EXPLANATION:"))
+		case strings.HasPrefix(trimmed, "```"):
+			// Models sometimes fence the file despite instructions
+		case current != nil:
+			current.WriteString(line + "\n")
+		}
+	}
+	flush()
+
+	if len(plan.Units) == 0 {
+		return Plan{}, errors.New("no unit files in the response")
+	}
+	names := map[string]bool{}
+	for _, u := range plan.Units {
+		if !unitNameRe.MatchString(u.Name) {
+			return Plan{}, fmt.Errorf("invalid unit name %q", u.Name)
+		}
+		if !strings.Contains(u.Content, "[") {
+			return Plan{}, fmt.Errorf("unit %s has no sections", u.Name)
+		}
+		names[u.Name] = true
+	}
+	if plan.Enable != "" && !names[plan.Enable] {
+		return Plan{}, fmt.Errorf("ENABLE names %q, which is not one of the generated units", plan.Enable)
+	}
+	if plan.Enable == "" {
+		plan.Enable = plan.Units[0].Name
+	}
+	return plan, nil
+}
+
+// UnitDir returns where administrator units are installed:
+// /etc/systemd/system, or ~/.config/systemd/user for user units.
+func UnitDir(user bool) (string, error) {
+	if !user {
+		return "/etc/systemd/system", nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// NeedsSudo reports whether installing system units needs sudo.
+func NeedsSudo(user bool) bool {
+	return !user && os.Geteuid() != 0
+}
+
+// Systemctl returns the systemctl command line for args, with --user or
+// sudo as needed.
+func Systemctl(user bool, args ...string) string {
+	cmd := "systemctl " + strings.Join(args, " ")
+	switch {
+	case user:
+		cmd = "systemctl --user " + strings.Join(args, " ")
+	case NeedsSudo(user):
+		cmd = "sudo " + cmd
+	}
+	return cmd
+}
+
+// Verify checks unit files with systemd-analyze verify when it's
+// installed. It returns nil if they look valid or can't be checked.
+func Verify(paths ...string) error {
+	if _, err := exec.LookPath("systemd-analyze"); err != nil {
+		return nil
+	}
+	args := append([]string{"verify"}, paths...)
+	var out bytes.Buffer
+	cmd := exec.Command("systemd-analyze", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(out.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("systemd-analyze verify: %s", msg)
+	}
+	return nil
+}
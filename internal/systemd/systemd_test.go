@@ -0,0 +1,66 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+)
+
+const response = "UNIT: backup.service\n```ini\n[Unit]\nDescription=Nightly backup\n\n[Service]\nType=oneshot\nExecStart=/usr/local/bin/backup.sh\n```\nUNIT: backup.timer\n[Unit]\nDescription=Run backup nightly\n\n[Timer]\nOnCalendar=*-*-* 02:00:00\nPersistent=true\n\n[Install]\nWantedBy=timers.target\nENABLE: backup.timer\nEXPLANATION: A oneshot service run nightly by a timer.\n"
+
+func TestParse(t *testing.T) {
+	plan, err := Parse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Units) != 2 || plan.Units[0].Name != "backup.service" || plan.Units[1].Name != "backup.timer" {
+		t.Fatalf("unexpected units: %+v", plan.Units)
+	}
+	if !strings.HasPrefix(plan.Units[0].Content, "[Unit]\nDescription=Nightly backup\n\n[Service]") || strings.Contains(plan.Units[0].Content, "```") {
+		t.Errorf("unexpected service content: %q", plan.Units[0].Content)
+	}
+	if plan.Enable != "backup.timer" || plan.Explanation == "" {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	cases := map[string]string{
+		"no units":     "EXPLANATION: nothing",
+		"path in name": "UNIT: ../../etc/passwd.service\n[Unit]\n",
+		"no sections":  "UNIT: a.service\nhello\n",
+		"bad enable":   "UNIT: a.service\n[Service]\nExecStart=/bin/true\nENABLE: b.timer\n",
+	}
+	for name, resp := range cases {
+		if _, err := Parse(resp); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestUnitCommands(t *testing.T) {
+	u := Unit{Name: "a.service", Content: "[Service]\nExecStartPre=-/usr/bin/mkdir -p /var/backup\nExecStart=/usr/local/bin/backup.sh --all\nExecStop=+/bin/kill $MAINPID\nExecStart=\nEnvironment=ExecStart=x\n"}
+	got := u.Commands()
+	want := []string{"/usr/bin/mkdir -p /var/backup", "/usr/local/bin/backup.sh --all", "/bin/kill $MAINPID"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+}
+
+func TestSystemctl(t *testing.T) {
+	if got := Systemctl(true, "daemon-reload"); got != "systemctl --user daemon-reload" {
+		t.Errorf("unexpected user command: %q", got)
+	}
+	if got := Systemctl(false, "enable", "--now", "a.timer"); !strings.HasSuffix(got, "systemctl enable --now a.timer") {
+		t.Errorf("unexpected system command: %q", got)
+	}
+}
+
+func TestUnitDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if dir, _ := UnitDir(true); dir != "/tmp/xdg/systemd/user" {
+		t.Errorf("unexpected user unit dir: %q", dir)
+	}
+	if dir, _ := UnitDir(false); dir != "/etc/systemd/system" {
+		t.Errorf("unexpected system unit dir: %q", dir)
+	}
+}
@@ -0,0 +1,34 @@
+// Package triage decides which questions are simple enough for a small
+// local model, so only the rest are sent to a hosted one.
+package triage
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxWords is the longest question treated as simple.
+const maxWords = 12
+
+// complexRe matches wording that points to several steps, conditions or
+// scripting, which small models get wrong more often.
+var complexRe = regexp.MustCompile(`(?i)\b(?:then|after|before|each|every|loop|if|unless|except|while|until|script|pipeline|parallel|cron|schedule|regex|and also)\b`)
+
+// riskyRe matches requests that change or destroy things, where a wrong
+// answer costs the most, so they always get the stronger model.
+var riskyRe = regexp.MustCompile(`(?i)\b(?:delete|remove|rm|drop|truncate|kill|wipe|erase|purge|overwrite|format|force|reset|rebase|chmod|chown|sudo|root|prod|production|migrate)\b`)
+
+// Simple reports whether query is a short, single-step, non-destructive
+// question, such as "list open ports", that a small local model can be
+// trusted with. Questions that carry context, such as piped input or a
+// previous command's output, are never simple.
+func Simple(query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "" || strings.Contains(query, "\n") {
+		return false
+	}
+	if len(strings.Fields(query)) > maxWords {
+		return false
+	}
+	return !complexRe.MatchString(query) && !riskyRe.MatchString(query)
+}
@@ -0,0 +1,25 @@
+package triage
+
+import "testing"
+
+func TestSimple(t *testing.T) {
+	cases := map[string]bool{
+		"list open ports":                       true,
+		"show disk usage of this directory":     true,
+		"what is my ip address":                 true,
+		"":                                      false,
+		"delete all merged git branches":        false,
+		"kill the process on port 8080":         false,
+		"find large files then gzip each one":   false,
+		"rename every jpg to include its date":  false,
+		"restart nginx if the config is valid":  false,
+		"write a script that backs up postgres": false,
+		"count lines in go files excluding vendor and tests, grouped by package and sorted by size": false,
+		"summarise this output\n<data>\nerror: x\n</data>":                                          false,
+	}
+	for query, want := range cases {
+		if got := Simple(query); got != want {
+			t.Errorf("Simple(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/swibrow/how/internal/shell"
+	"golang.org/x/term"
+)
+
+// CaptureOutput is whether what commands run on this machine print to
+// stdout is kept, for LastOutput. Output is only captured when stdout
+// isn't a terminal, so commands keep their colours, columns and prompts.
+var CaptureOutput bool
+
+// maxCapturedOutput bounds how much of a command's output is kept.
+const maxCapturedOutput = 64 << 10
+
+// fullScreenTools draw on the terminal or hand it to another program, and
+// behave differently when stdout is a pipe, so their output isn't captured.
+var fullScreenTools = map[string]bool{
+	"less": true, "more": true, "most": true, "man": true,
+	"vi": true, "vim": true, "nvim": true, "nano": true, "emacs": true, "hx": true,
+	"top": true, "htop": true, "btop": true, "watch": true,
+	"ssh": true, "mosh": true, "tmux": true, "screen": true,
+	"fzf": true, "k9s": true,
+}
+
+var lastOutput string
+
+// LastOutput returns what the last command run on this machine printed to
+// stdout, up to 64 KiB, or "" when it wasn't captured.
+func LastOutput() string {
+	return lastOutput
+}
+
+// stdoutIsTerminal is replaced in tests.
+var stdoutIsTerminal = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+
+// captures reports whether command's output should be captured.
+func captures(command string) bool {
+	if !CaptureOutput || stdoutIsTerminal() {
+		return false
+	}
+	names, err := shell.ExternalCommands(command)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		if fullScreenTools[filepath.Base(name)] {
+			return false
+		}
+	}
+	return true
+}
+
+// headWriter keeps the first limit bytes written to it and discards the
+// rest without failing, so the command never sees a short write.
+type headWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
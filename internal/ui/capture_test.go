@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/swibrow/how/internal/config"
+	"golang.org/x/term"
+)
+
+// capturing turns on CaptureOutput with stdout redirected away from the
+// terminal, for the rest of the test.
+func capturing(t *testing.T) {
+	t.Helper()
+	CaptureOutput = true
+	stdoutIsTerminal = func() bool { return false }
+	t.Cleanup(func() {
+		CaptureOutput = false
+		stdoutIsTerminal = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+	})
+}
+
+func TestRunLocalCapturesOutput(t *testing.T) {
+	t.Setenv("HISTFILE", "")
+	ShellHistory = config.HistoryNever
+	capturing(t)
+
+	_, _ = runLocal(exec.Command("sh", "-c", "printf 'a\\nb\\n'"), "printf 'a\\nb\\n'")
+	if got := LastOutput(); got != "a\nb\n" {
+		t.Errorf("LastOutput() = %q, want %q", got, "a\nb\n")
+	}
+
+	_, _ = runLocal(exec.Command("sh", "-c", "true | less"), "true | less")
+	if got := LastOutput(); got != "" {
+		t.Errorf("full-screen command output was captured: %q", got)
+	}
+}
+
+func TestCapturesSkipsFullScreenTools(t *testing.T) {
+	capturing(t)
+	cases := map[string]bool{
+		"go test ./...":              true,
+		"grep -r TODO . | sort":      true,
+		"git log | less":             false,
+		"/usr/bin/vim notes.txt":     false,
+		"ssh host uptime":            false,
+		"kubectl get pods -A":        true,
+		"watch -n1 'df -h'":          false,
+		"journalctl -u nginx | tail": true,
+	}
+	for command, want := range cases {
+		if got := captures(command); got != want {
+			t.Errorf("captures(%q) = %v, want %v", command, got, want)
+		}
+	}
+
+	stdoutIsTerminal = func() bool { return true }
+	if captures("ls") {
+		t.Error("captured with stdout on the terminal")
+	}
+	CaptureOutput = false
+	stdoutIsTerminal = func() bool { return false }
+	if captures("ls") {
+		t.Error("captured with CaptureOutput off")
+	}
+}
+
+func TestHeadWriterKeepsPrefix(t *testing.T) {
+	w := &headWriter{limit: 4}
+	for _, s := range []string{"ab", "cdef", "gh"} {
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := w.buf.String(); got != "abcd" {
+		t.Errorf("kept %q, want %q", got, "abcd")
+	}
+}
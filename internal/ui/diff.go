@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	removedStyle lipgloss.Style
+	addedStyle   lipgloss.Style
+)
+
+type diffOp int
+
+const (
+	opEqual diffOp = iota
+	opRemove
+	opAdd
+)
+
+type diffToken struct {
+	op   diffOp
+	text string
+}
+
+// diffWords computes a word-level diff between two command lines using the
+// longest common subsequence of their whitespace-separated tokens.
+func diffWords(before, after string) []diffToken {
+	a, b := strings.Fields(before), strings.Fields(after)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var tokens []diffToken
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			tokens = append(tokens, diffToken{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			tokens = append(tokens, diffToken{opRemove, a[i]})
+			i++
+		default:
+			tokens = append(tokens, diffToken{opAdd, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		tokens = append(tokens, diffToken{opRemove, a[i]})
+	}
+	for ; j < len(b); j++ {
+		tokens = append(tokens, diffToken{opAdd, b[j]})
+	}
+	return tokens
+}
+
+// DisplayDiff shows a before/after comparison of two command lines,
+// highlighting the words that were removed and added.
+func DisplayDiff(before, after string) {
+	if before == after {
+		fmt.Printf("  %s\n\n", explanationStyle.Render("No changes suggested."))
+		return
+	}
+
+	var oldLine, newLine []string
+	for _, t := range diffWords(before, after) {
+		switch t.op {
+		case opEqual:
+			oldLine = append(oldLine, t.text)
+			newLine = append(newLine, t.text)
+		case opRemove:
+			oldLine = append(oldLine, removedStyle.Render(t.text))
+		case opAdd:
+			newLine = append(newLine, addedStyle.Render(t.text))
+		}
+	}
+
+	fmt.Printf("  %s %s\n", removedStyle.UnsetStrikethrough().Render("-"), strings.Join(oldLine, " "))
+	fmt.Printf("  %s %s\n\n", addedStyle.Render("+"), strings.Join(newLine, " "))
+}
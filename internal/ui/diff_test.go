@@ -0,0 +1,42 @@
+package ui
+
+import "testing"
+
+func TestDiffWords(t *testing.T) {
+	tokens := diffWords("find . -name '*.go' | xargs grep TODO", "rg TODO -g '*.go'")
+
+	var removed, added, equal []string
+	for _, tok := range tokens {
+		switch tok.op {
+		case opRemove:
+			removed = append(removed, tok.text)
+		case opAdd:
+			added = append(added, tok.text)
+		case opEqual:
+			equal = append(equal, tok.text)
+		}
+	}
+
+	if len(equal) == 0 {
+		t.Error("expected at least one shared token")
+	}
+	for _, tok := range added {
+		if tok == "find" || tok == "xargs" {
+			t.Errorf("unexpected added token %q", tok)
+		}
+	}
+	if len(removed)+len(equal) != 8 {
+		t.Errorf("removed+equal should cover all 8 original tokens, got %d", len(removed)+len(equal))
+	}
+	if len(added)+len(equal) != 4 {
+		t.Errorf("added+equal should cover all 4 new tokens, got %d", len(added)+len(equal))
+	}
+}
+
+func TestDiffWordsIdentical(t *testing.T) {
+	for _, tok := range diffWords("ls -la", "ls -la") {
+		if tok.op != opEqual {
+			t.Errorf("expected only equal tokens, got %+v", tok)
+		}
+	}
+}
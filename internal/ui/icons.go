@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/swibrow/how/internal/config"
+	"golang.org/x/term"
+)
+
+// iconSet holds the glyphs put before badges and the spinner's frames.
+type iconSet struct {
+	danger, caution, network, warning, error, hint, policy, budget string
+	spinner                                                        []string
+}
+
+var (
+	brailleSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiSpinner   = []string{"|", "/", "-", `\`}
+)
+
+// iconSets maps each name accepted by the icons setting to its glyphs.
+var iconSets = map[string]iconSet{
+	config.IconsNerdFont: {
+		danger:  "\uf05e", // nf-fa-ban
+		caution: "\uf071", // nf-fa-warning
+		network: "\uf0ac", // nf-fa-globe
+		warning: "\uf071", // nf-fa-warning
+		error:   "\uf057", // nf-fa-times_circle
+		hint:    "\uf0eb", // nf-fa-lightbulb_o
+		policy:  "\uf132", // nf-fa-shield
+		budget:  "\uf155", // nf-fa-dollar
+		spinner: brailleSpinner,
+	},
+	config.IconsEmoji: {
+		danger:  "🛑",
+		caution: "⚠️",
+		network: "🌐",
+		warning: "⚠️",
+		error:   "❌",
+		hint:    "💡",
+		policy:  "🛡️",
+		budget:  "💸",
+		spinner: brailleSpinner,
+	},
+	config.IconsASCII: {
+		danger:  "[!!]",
+		caution: "[!]",
+		network: "[net]",
+		warning: "[!]",
+		error:   "[x]",
+		hint:    "[i]",
+		policy:  "[p]",
+		budget:  "[$]",
+		spinner: asciiSpinner,
+	},
+	config.IconsNone: {
+		spinner: asciiSpinner,
+	},
+}
+
+var icons = iconSets[config.IconsNone]
+
+// SetIcons switches the glyphs used in badges and the spinner to the
+// named set; an empty name is the same as "none".
+func SetIcons(name string) error {
+	if name == "" {
+		name = config.IconsNone
+	}
+	set, ok := iconSets[name]
+	if !ok {
+		return fmt.Errorf("unknown icon set %q", name)
+	}
+	icons = set
+	return nil
+}
+
+// badge renders label in style, preceded by icon when the set has one.
+func badge(style lipgloss.Style, icon, label string) string {
+	if icon != "" {
+		label = icon + " " + label
+	}
+	return style.Render(label)
+}
+
+// StartSpinner shows msg with a spinner on stderr while the caller waits,
+// and returns a function that clears it. Nothing is shown when stderr
+// isn't a terminal or errors are reported as JSON.
+func StartSpinner(msg string) (stop func()) {
+	if JSONErrors || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return func() {}
+	}
+	frames := icons.spinner
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(os.Stderr, "\r  %s %s", labelStyle.Render(frames[i%len(frames)]), explanationStyle.Render(msg))
+			select {
+			case <-done:
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
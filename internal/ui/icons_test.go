@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/swibrow/how/internal/config"
+)
+
+func TestSetIcons(t *testing.T) {
+	t.Cleanup(func() { SetIcons(config.IconsNone) })
+
+	for name, set := range iconSets {
+		cfg := config.DefaultConfig()
+		if err := config.Set(cfg, "icons", name); err != nil {
+			t.Errorf("config rejects icons %q: %v", name, err)
+		}
+		if err := SetIcons(name); err != nil {
+			t.Errorf("SetIcons(%q) = %v", name, err)
+		}
+		if len(set.spinner) == 0 {
+			t.Errorf("icon set %q has no spinner frames", name)
+		}
+	}
+
+	if err := SetIcons("wingdings"); err == nil {
+		t.Error("SetIcons(wingdings) succeeded, want an error")
+	}
+}
+
+func TestBadge(t *testing.T) {
+	t.Cleanup(func() { SetIcons(config.IconsNone) })
+
+	if err := SetIcons(config.IconsASCII); err != nil {
+		t.Fatal(err)
+	}
+	if got := badge(explanationStyle, icons.danger, "Dangerous:"); got != "[!!] Dangerous:" {
+		t.Errorf("ascii badge = %q, want %q", got, "[!!] Dangerous:")
+	}
+
+	if err := SetIcons(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := badge(explanationStyle, icons.danger, "Dangerous:"); got != "Dangerous:" {
+		t.Errorf("badge without icons = %q, want %q", got, "Dangerous:")
+	}
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/internal/shell"
+)
+
+// installEnv is what an install hint depends on about this machine.
+type installEnv struct {
+	goos string
+	root bool
+	// has reports whether a command is on the PATH.
+	has func(name string) bool
+	// brewKind returns "formula" or "cask" for what Homebrew installs as
+	// name, or "" when it doesn't know it or can't be asked.
+	brewKind func(name string) string
+}
+
+func currentInstallEnv() installEnv {
+	return installEnv{
+		goos: runtime.GOOS,
+		root: os.Geteuid() == 0,
+		has: func(name string) bool {
+			_, err := exec.LookPath(name)
+			return err == nil
+		},
+		brewKind: brewKind,
+	}
+}
+
+// installSuggestion returns a platform-aware install hint.
+func installSuggestion(cmdName string) string {
+	return installHint(currentInstallEnv(), cmdName)
+}
+
+func installHint(env installEnv, cmdName string) string {
+	switch env.goos {
+	case "darwin":
+		if cask, ok := casks[cmdName]; ok {
+			return fmt.Sprintf("Install with: brew install --cask %s", cask)
+		}
+		if env.has("brew") && env.brewKind(cmdName) == "cask" {
+			return fmt.Sprintf("Install with: brew install --cask %s", cmdName)
+		}
+		return fmt.Sprintf("Install with: brew install %s", cmdName)
+	case "linux":
+		// Without root, sudo or doas, as in CI and distroless containers,
+		// the system package manager is no use
+		escalator, ok := shell.ChooseEscalator(env.root, env.has)
+		if !ok {
+			return userInstallHint(env, cmdName)
+		}
+		if escalator != "" {
+			escalator += " "
+		}
+		switch {
+		case env.has("apt"):
+			return fmt.Sprintf("Install with: %sapt install %s", escalator, cmdName)
+		case env.has("dnf"):
+			return fmt.Sprintf("Install with: %sdnf install %s", escalator, cmdName)
+		case env.has("pacman"):
+			return fmt.Sprintf("Install with: %spacman -S %s", escalator, cmdName)
+		case env.has("apk"):
+			return fmt.Sprintf("Install with: %sapk add %s", escalator, cmdName)
+		case env.has("zypper"):
+			return fmt.Sprintf("Install with: %szypper install %s", escalator, cmdName)
+		}
+		return fmt.Sprintf("Install %s using your system package manager", cmdName)
+	default:
+		return fmt.Sprintf("Install %s using your system package manager", cmdName)
+	}
+}
+
+// userInstall says how a command can be installed without root.
+type userInstall struct {
+	// pipx, goPkg and cargo name the package for each installer, and
+	// release is where prebuilt binaries are published.
+	pipx    string
+	goPkg   string
+	cargo   string
+	release string
+}
+
+// userInstalls covers common commands that can be installed into the home
+// directory.
+var userInstalls = map[string]userInstall{
+	"http":       {pipx: "httpie"},
+	"yt-dlp":     {pipx: "yt-dlp", release: "https://github.com/yt-dlp/yt-dlp/releases"},
+	"aws":        {pipx: "awscli"},
+	"ansible":    {pipx: "ansible-core"},
+	"pgcli":      {pipx: "pgcli"},
+	"mycli":      {pipx: "mycli"},
+	"litecli":    {pipx: "litecli"},
+	"csvlook":    {pipx: "csvkit"},
+	"jc":         {pipx: "jc"},
+	"tldr":       {pipx: "tldr"},
+	"asciinema":  {pipx: "asciinema"},
+	"yq":         {goPkg: "github.com/mikefarah/yq/v4@latest", release: "https://github.com/mikefarah/yq/releases"},
+	"fzf":        {goPkg: "github.com/junegunn/fzf@latest", release: "https://github.com/junegunn/fzf/releases"},
+	"gron":       {goPkg: "github.com/tomnomnom/gron@latest", release: "https://github.com/tomnomnom/gron/releases"},
+	"lazygit":    {goPkg: "github.com/jesseduffield/lazygit@latest", release: "https://github.com/jesseduffield/lazygit/releases"},
+	"duf":        {goPkg: "github.com/muesli/duf@latest", release: "https://github.com/muesli/duf/releases"},
+	"gdu":        {goPkg: "github.com/dundee/gdu/v5/cmd/gdu@latest", release: "https://github.com/dundee/gdu/releases"},
+	"rg":         {cargo: "ripgrep", release: "https://github.com/BurntSushi/ripgrep/releases"},
+	"fd":         {cargo: "fd-find", release: "https://github.com/sharkdp/fd/releases"},
+	"bat":        {cargo: "bat", release: "https://github.com/sharkdp/bat/releases"},
+	"eza":        {cargo: "eza", release: "https://github.com/eza-community/eza/releases"},
+	"hyperfine":  {cargo: "hyperfine", release: "https://github.com/sharkdp/hyperfine/releases"},
+	"jq":         {release: "https://github.com/jqlang/jq/releases"},
+	"shellcheck": {release: "https://github.com/koalaman/shellcheck/releases"},
+	"k9s":        {release: "https://github.com/derailed/k9s/releases"},
+	"helm":       {release: "https://github.com/helm/helm/releases"},
+}
+
+// userInstallHint suggests a way to install cmdName into the home
+// directory: with an installer that's available, else as a prebuilt
+// binary.
+func userInstallHint(env installEnv, cmdName string) string {
+	u, ok := userInstalls[cmdName]
+	if !ok {
+		return fmt.Sprintf("No root access, so install %s with pipx, go install or cargo install, or put a prebuilt binary in ~/.local/bin", cmdName)
+	}
+	var options []string
+	if u.pipx != "" {
+		switch {
+		case env.has("pipx"):
+			options = append(options, "pipx install "+u.pipx)
+		case env.has("python3"):
+			options = append(options, venvInstall(u.pipx, cmdName))
+		}
+	}
+	if u.goPkg != "" && env.has("go") {
+		options = append(options, "go install "+u.goPkg)
+	}
+	if u.cargo != "" && env.has("cargo") {
+		options = append(options, "cargo install --locked "+u.cargo)
+	}
+	if len(options) > 0 {
+		return "No root access, so install with: " + strings.Join(options, " or ")
+	}
+	if u.release != "" {
+		return fmt.Sprintf("No root access, so download a prebuilt %s from %s into ~/.local/bin", cmdName, u.release)
+	}
+	return fmt.Sprintf("No root access, so install %s with pipx", cmdName)
+}
+
+// venvInstall installs pkg into its own virtualenv and links cmdName
+// into ~/.local/bin, as pipx would. pip install --user is refused on
+// distros that mark the system Python as externally managed (PEP 668).
+func venvInstall(pkg, cmdName string) string {
+	venv := "~/.local/venvs/" + pkg
+	return fmt.Sprintf("python3 -m venv %s && %s/bin/pip install %s && mkdir -p ~/.local/bin && ln -s %s/bin/%s ~/.local/bin/", venv, venv, pkg, venv, cmdName)
+}
+
+// casks maps commands to the Homebrew casks that install them, for tools
+// that are packaged as apps rather than formulae.
+var casks = map[string]string{
+	"code":          "visual-studio-code",
+	"cursor":        "cursor",
+	"subl":          "sublime-text",
+	"zed":           "zed",
+	"wezterm":       "wezterm",
+	"alacritty":     "alacritty",
+	"kitty":         "kitty",
+	"ghostty":       "ghostty",
+	"vlc":           "vlc",
+	"inkscape":      "inkscape",
+	"soffice":       "libreoffice",
+	"keepassxc-cli": "keepassxc",
+	"op":            "1password-cli",
+	"ngrok":         "ngrok",
+	"pdflatex":      "mactex",
+	"xelatex":       "mactex",
+	"chromedriver":  "chromedriver",
+}
+
+// brewTimeout bounds asking Homebrew about a name.
+const brewTimeout = 5 * time.Second
+
+// brewKind asks Homebrew whether name is a formula or a cask.
+func brewKind(name string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), brewTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "brew", "info", "--json=v2", name)
+	cmd.Env = append(os.Environ(), "HOMEBREW_NO_AUTO_UPDATE=1", "HOMEBREW_NO_ANALYTICS=1")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return parseBrewInfo(out)
+}
+
+// parseBrewInfo reads brew info --json=v2 output, preferring a formula
+// when a name is both.
+func parseBrewInfo(out []byte) string {
+	var info struct {
+		Formulae []json.RawMessage `json:"formulae"`
+		Casks    []json.RawMessage `json:"casks"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return ""
+	}
+	switch {
+	case len(info.Formulae) > 0:
+		return "formula"
+	case len(info.Casks) > 0:
+		return "cask"
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func fakeInstallEnv(root bool, commands ...string) installEnv {
+	return installEnv{
+		goos: "linux",
+		root: root,
+		has:  func(name string) bool { return slices.Contains(commands, name) },
+	}
+}
+
+func TestInstallHint(t *testing.T) {
+	cases := []struct {
+		name string
+		env  installEnv
+		cmd  string
+		want string
+	}{
+		{"sudo", fakeInstallEnv(false, "sudo", "apt"), "jq", "Install with: sudo apt install jq"},
+		{"doas", fakeInstallEnv(false, "doas", "apk"), "jq", "Install with: doas apk add jq"},
+		{"sudo-rs", fakeInstallEnv(false, "sudo-rs", "pacman"), "jq", "Install with: sudo-rs pacman -S jq"},
+		{"root", fakeInstallEnv(true, "dnf"), "jq", "Install with: dnf install jq"},
+		{"pipx", fakeInstallEnv(false, "apt", "pipx"), "http", "No root access, so install with: pipx install httpie"},
+		{"pip", fakeInstallEnv(false, "python3"), "http", "No root access, so install with: python3 -m venv ~/.local/venvs/httpie && ~/.local/venvs/httpie/bin/pip install httpie && mkdir -p ~/.local/bin && ln -s ~/.local/venvs/httpie/bin/http ~/.local/bin/"},
+		{"venv", fakeInstallEnv(false, "python3"), "csvlook", "No root access, so install with: python3 -m venv ~/.local/venvs/csvkit && ~/.local/venvs/csvkit/bin/pip install csvkit && mkdir -p ~/.local/bin && ln -s ~/.local/venvs/csvkit/bin/csvlook ~/.local/bin/"},
+		{"go", fakeInstallEnv(false, "go", "cargo"), "yq", "No root access, so install with: go install github.com/mikefarah/yq/v4@latest"},
+		{"cargo", fakeInstallEnv(false, "cargo"), "rg", "No root access, so install with: cargo install --locked ripgrep"},
+		{"release", fakeInstallEnv(false), "rg", "No root access, so download a prebuilt rg from https://github.com/BurntSushi/ripgrep/releases into ~/.local/bin"},
+		{"unknown", fakeInstallEnv(false), "frobnicate", "No root access, so install frobnicate with pipx, go install or cargo install, or put a prebuilt binary in ~/.local/bin"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := installHint(tc.env, tc.cmd); got != tc.want {
+				t.Errorf("installHint(%q) = %q, want %q", tc.cmd, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInstallHintBrew(t *testing.T) {
+	env := installEnv{
+		goos: "darwin",
+		has:  func(name string) bool { return name == "brew" },
+		brewKind: func(name string) string {
+			if name == "wireshark-app" {
+				return "cask"
+			}
+			return "formula"
+		},
+	}
+	cases := map[string]string{
+		"code":          "Install with: brew install --cask visual-studio-code",
+		"wireshark-app": "Install with: brew install --cask wireshark-app",
+		"jq":            "Install with: brew install jq",
+	}
+	for cmd, want := range cases {
+		if got := installHint(env, cmd); got != want {
+			t.Errorf("installHint(%q) = %q, want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestParseBrewInfo(t *testing.T) {
+	cases := map[string]string{
+		`{"formulae":[{"name":"jq"}],"casks":[]}`:             "formula",
+		`{"formulae":[],"casks":[{"token":"zed"}]}`:           "cask",
+		`{"formulae":[{"name":"a"}],"casks":[{"token":"a"}]}`: "formula",
+		`not json`: "",
+	}
+	for out, want := range cases {
+		if got := parseBrewInfo([]byte(out)); got != want {
+			t.Errorf("parseBrewInfo(%s) = %q, want %q", out, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/swibrow/how/risk"
+)
+
+// JSONErrors is set with --output json. Errors are then reported once,
+// as an ErrorObject, and the styled messages are left out so stderr stays
+// parseable.
+var JSONErrors bool
+
+// jsonResult is a suggestion as written with --output json.
+type jsonResult struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+	// Danger is the command's risk level: safe, caution or dangerous.
+	Danger        string   `json:"danger"`
+	DangerReasons []string `json:"danger_reasons,omitempty"`
+}
+
+// FormatJSON renders result and its risk level as a single line of JSON.
+func FormatJSON(result Result) (string, error) {
+	a := risk.Classify(result.Command)
+	data, err := json.Marshal(jsonResult{
+		Command:       result.Command,
+		Explanation:   result.Explanation,
+		Warning:       result.Warning,
+		Danger:        a.Level.String(),
+		DangerReasons: a.Reasons,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// ErrorObject describes a failure for programs reading --output json.
+// Type is one of provider, no_command, blocked, declined or error.
+type ErrorObject struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Provider  string `json:"provider,omitempty"`
+	Retryable bool   `json:"retryable"`
+}
+
+// DisplayErrorJSON writes e to stderr as {"error": {...}}.
+func DisplayErrorJSON(e ErrorObject) {
+	data, err := json.Marshal(map[string]ErrorObject{"error": e})
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", data)
+}
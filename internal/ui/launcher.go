@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LauncherFormats are the --output formats for launcher integrations.
+var LauncherFormats = []string{"raycast", "alfred-json", "rofi"}
+
+// alfredItem is an item in Alfred's script filter JSON.
+type alfredItem struct {
+	UID      string            `json:"uid,omitempty"`
+	Title    string            `json:"title"`
+	Subtitle string            `json:"subtitle,omitempty"`
+	Arg      string            `json:"arg,omitempty"`
+	Valid    bool              `json:"valid"`
+	Text     map[string]string `json:"text,omitempty"`
+}
+
+// raycastItem mirrors the props of a Raycast List.Item, with the text to
+// paste or copy when it's selected.
+type raycastItem struct {
+	Title       string              `json:"title"`
+	Subtitle    string              `json:"subtitle,omitempty"`
+	Accessories []map[string]string `json:"accessories,omitempty"`
+	Paste       string              `json:"paste,omitempty"`
+	Copy        string              `json:"copy,omitempty"`
+}
+
+// FormatLauncher renders result for a launcher: Alfred and Raycast get a
+// JSON list with one item whose selection pastes the command, and rofi
+// gets script-mode rows with the explanation as the message.
+func FormatLauncher(format string, result Result) (string, error) {
+	subtitle := result.Explanation
+	if result.Warning != "" {
+		subtitle = strings.TrimSpace("⚠ " + result.Warning + " " + subtitle)
+	}
+
+	switch format {
+	case "alfred-json":
+		return marshalItems(alfredItem{
+			UID:      "how",
+			Title:    result.Command,
+			Subtitle: subtitle,
+			Arg:      result.Command,
+			Valid:    true,
+			Text: map[string]string{
+				"copy":      result.Command,
+				"largetype": strings.TrimSpace(result.Command + "\n\n" + result.Explanation),
+			},
+		})
+	case "raycast":
+		item := raycastItem{
+			Title:    result.Command,
+			Subtitle: result.Explanation,
+			Paste:    result.Command,
+			Copy:     result.Command,
+		}
+		if result.Warning != "" {
+			item.Accessories = []map[string]string{{"text": "⚠ " + result.Warning}}
+		}
+		return marshalItems(item)
+	case "rofi":
+		var b strings.Builder
+		rofiOption(&b, "prompt", "how")
+		if subtitle != "" {
+			rofiOption(&b, "message", pangoEscape(subtitle))
+		}
+		// Rows are single lines; the full command is passed back as info
+		fmt.Fprintf(&b, "%s\x00info\x1f%s\n", strings.ReplaceAll(result.Command, "\n", " "), result.Command)
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("unknown output format %q (expected text, %s)", format, strings.Join(LauncherFormats, ", "))
+}
+
+// FormatLauncherError renders an error as a launcher item that can't be
+// selected, so it shows up where the suggestion would have.
+func FormatLauncherError(format, msg string) string {
+	switch format {
+	case "alfred-json":
+		s, _ := marshalItems(alfredItem{Title: "Error", Subtitle: msg})
+		return s
+	case "raycast":
+		s, _ := marshalItems(raycastItem{Title: "Error", Subtitle: msg})
+		return s
+	case "rofi":
+		var b strings.Builder
+		rofiOption(&b, "prompt", "how")
+		rofiOption(&b, "message", pangoEscape("Error: "+msg))
+		return b.String()
+	}
+	return msg + "\n"
+}
+
+func marshalItems[T any](items ...T) (string, error) {
+	data, err := json.Marshal(map[string][]T{"items": items})
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// rofiOption writes a rofi script-mode option line.
+func rofiOption(b *strings.Builder, name, value string) {
+	fmt.Fprintf(b, "\x00%s\x1f%s\n", name, strings.ReplaceAll(value, "\n", " "))
+}
+
+var pangoReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// pangoEscape escapes text for rofi's message, which is Pango markup.
+func pangoEscape(s string) string {
+	return pangoReplacer.Replace(s)
+}
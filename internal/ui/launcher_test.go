@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatLauncherAlfred(t *testing.T) {
+	out, err := FormatLauncher("alfred-json", Result{Command: "du -sh *", Explanation: "Size of each entry", Warning: "Slow on large trees"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Items []struct {
+			Title, Subtitle, Arg string
+			Valid                bool
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Arg != "du -sh *" || !got.Items[0].Valid {
+		t.Fatalf("unexpected items: %+v", got.Items)
+	}
+	if got.Items[0].Subtitle != "⚠ Slow on large trees Size of each entry" {
+		t.Errorf("unexpected subtitle: %q", got.Items[0].Subtitle)
+	}
+}
+
+func TestFormatLauncherRaycast(t *testing.T) {
+	out, err := FormatLauncher("raycast", Result{Command: "ls", Explanation: "List files"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"items":[{"title":"ls","subtitle":"List files","paste":"ls","copy":"ls"}]}`+"\n" {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestFormatLauncherRofi(t *testing.T) {
+	out, err := FormatLauncher("rofi", Result{Command: "grep -c '<a>' x.html", Explanation: "Count <a> tags & links"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x00prompt\x1fhow\n\x00message\x1fCount &lt;a&gt; tags &amp; links\ngrep -c '<a>' x.html\x00info\x1fgrep -c '<a>' x.html\n"
+	if out != want {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	if _, err := FormatLauncher("xml", Result{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestFormatLauncherError(t *testing.T) {
+	if got := FormatLauncherError("alfred-json", "no API key"); !strings.Contains(got, `"title":"Error","subtitle":"no API key","valid":false`) {
+		t.Errorf("unexpected Alfred error: %s", got)
+	}
+	if got := FormatLauncherError("rofi", "no API key"); !strings.Contains(got, "\x00message\x1fError: no API key\n") {
+		t.Errorf("unexpected rofi error: %q", got)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	got, err := FormatJSON(Result{Command: "df -h", Explanation: "Disk usage"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"command":"df -h","explanation":"Disk usage","danger":"safe"}` + "\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got, _ = FormatJSON(Result{Command: "rm -rf /tmp/build"})
+	if !strings.Contains(got, `"danger":"dangerous","danger_reasons":[`) {
+		t.Errorf("expected the danger level and reasons, got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ListFormats are the --output formats of commands that print lists,
+// besides their own "text" layout.
+var ListFormats = []string{"jsonl", "yaml", "csv", "table"}
+
+// CheckListFormat returns an error naming the valid formats unless format
+// is "text" or one of ListFormats.
+func CheckListFormat(format string) error {
+	if format == "text" {
+		return nil
+	}
+	if slices.Contains(ListFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (expected text, %s)", format, strings.Join(ListFormats, ", "))
+}
+
+// ListWriter writes records one at a time in one of ListFormats. Records
+// are any values that marshal to JSON objects; their keys, in order of
+// first appearance, are the YAML keys and the CSV and table columns.
+type ListWriter struct {
+	w      io.Writer
+	format string
+
+	// CSV and table output needs every column before the first row, so
+	// records are held until Flush.
+	columns []string
+	rows    []map[string]string
+}
+
+// NewListWriter returns a ListWriter for format, one of ListFormats.
+func NewListWriter(w io.Writer, format string) *ListWriter {
+	return &ListWriter{w: w, format: format}
+}
+
+// Streams reports whether records are written as they're added, rather
+// than held until Flush.
+func (l *ListWriter) Streams() bool {
+	return l.format == "jsonl" || l.format == "yaml"
+}
+
+// Write adds a record. JSON lines and YAML are written straight away.
+func (l *ListWriter) Write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", l.format, err)
+	}
+	switch l.format {
+	case "jsonl":
+		_, err := fmt.Fprintf(l.w, "%s\n", data)
+		return err
+	case "yaml":
+		return l.writeYAML(data)
+	}
+
+	// JSON is valid YAML, and decoding it as a node keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("encoding %s: %w", l.format, err)
+	}
+	row := map[string]string{}
+	if m := node.Content[0]; m.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			key, value := m.Content[i].Value, m.Content[i+1]
+			if !slices.Contains(l.columns, key) {
+				l.columns = append(l.columns, key)
+			}
+			row[key] = cell(value)
+		}
+	}
+	l.rows = append(l.rows, row)
+	return nil
+}
+
+// writeYAML writes a JSON record as one item of a YAML list.
+func (l *ListWriter) writeYAML(data []byte) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("encoding yaml: %w", err)
+	}
+	blockStyle(&node)
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node.Content[0]}})
+	if err != nil {
+		return fmt.Errorf("encoding yaml: %w", err)
+	}
+	_, err = l.w.Write(out)
+	return err
+}
+
+// Flush writes the held rows for CSV and table output. An empty list is
+// written as nothing, since it has no columns.
+func (l *ListWriter) Flush() error {
+	if len(l.columns) == 0 {
+		return nil
+	}
+	switch l.format {
+	case "csv":
+		w := csv.NewWriter(l.w)
+		_ = w.Write(l.columns)
+		for _, row := range l.rows {
+			_ = w.Write(l.fields(row))
+		}
+		w.Flush()
+		return w.Error()
+	case "table":
+		w := tabwriter.NewWriter(l.w, 0, 0, 2, ' ', 0)
+		header := make([]string, len(l.columns))
+		for i, c := range l.columns {
+			header[i] = strings.ToUpper(c)
+		}
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range l.rows {
+			fields := l.fields(row)
+			for i, f := range fields {
+				fields[i] = strings.ReplaceAll(f, "\n", " ")
+			}
+			fmt.Fprintln(w, strings.Join(fields, "\t"))
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func (l *ListWriter) fields(row map[string]string) []string {
+	fields := make([]string, len(l.columns))
+	for i, c := range l.columns {
+		fields[i] = row[c]
+	}
+	return fields
+}
+
+// cell renders a value for a CSV or table cell: scalars as they are and
+// lists or objects as compact JSON.
+func cell(n *yaml.Node) string {
+	if n.Kind == yaml.ScalarNode {
+		if n.Tag == "!!null" {
+			return ""
+		}
+		return n.Value
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return ""
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// blockStyle clears the flow and quoting styles a node decoded from JSON
+// carries, so it's written as ordinary block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+)
+
+type listRecord struct {
+	Query   string   `json:"query"`
+	Command string   `json:"command,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+var listRecords = []listRecord{
+	{Query: "disk usage", Command: "df -h", Tags: []string{"disk"}},
+	{Query: "true", Error: "timed out, retry"},
+}
+
+func writeList(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewListWriter(&buf, format)
+	for _, r := range listRecords {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestListWriter(t *testing.T) {
+	for format, want := range map[string]string{
+		"jsonl": `{"query":"disk usage","command":"df -h","tags":["disk"]}` + "\n" +
+			`{"query":"true","error":"timed out, retry"}` + "\n",
+		"yaml": "- query: disk usage\n  command: df -h\n  tags:\n    - disk\n" +
+			"- query: \"true\"\n  error: timed out, retry\n",
+		"csv": "query,command,tags,error\n" +
+			"disk usage,df -h,\"[\"\"disk\"\"]\",\n" +
+			"true,,,\"timed out, retry\"\n",
+		"table": "QUERY       COMMAND  TAGS      ERROR\n" +
+			"disk usage  df -h    [\"disk\"]  \n" +
+			"true                           timed out, retry\n",
+	} {
+		if got := writeList(t, format); got != want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", format, got, want)
+		}
+	}
+}
+
+func TestCheckListFormat(t *testing.T) {
+	for _, f := range []string{"text", "jsonl", "yaml", "csv", "table"} {
+		if err := CheckListFormat(f); err != nil {
+			t.Errorf("CheckListFormat(%q) = %v", f, err)
+		}
+	}
+	if err := CheckListFormat("xml"); err == nil || err.Error() != `unknown output format "xml" (expected text, jsonl, yaml, csv, table)` {
+		t.Errorf("unexpected error for xml: %v", err)
+	}
+}
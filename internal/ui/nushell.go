@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// nushellConfigDir returns where Nushell keeps its config and history:
+// $XDG_CONFIG_HOME/nushell, or the platform config directory.
+func nushellConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "nushell")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support", "nushell")
+	}
+	return filepath.Join(home, ".config", "nushell")
+}
+
+// addToNushellHistory appends command to Nushell's history: the SQLite
+// database when history.file_format is sqlite, or else the plain text
+// file. Neither is created if Nushell hasn't.
+func addToNushellHistory(command string, start time.Time, elapsed time.Duration) {
+	dir := nushellConfigDir()
+	if dir == "" {
+		return
+	}
+	if db := filepath.Join(dir, "history.sqlite3"); fileExists(db) {
+		_ = addToNushellDB(db, command, start, elapsed)
+		return
+	}
+	txt := filepath.Join(dir, "history.txt")
+	if !fileExists(txt) || historyIgnores(txt, command) {
+		return
+	}
+	f, err := os.OpenFile(txt, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close() //nolint:errcheck
+	// Each entry is one line; Nushell treats newlines in it as separators
+	_, _ = f.WriteString(strings.ReplaceAll(command, "\n", "; ") + "\n")
+}
+
+// addToNushellDB inserts command into the history table of reedline, the
+// line editor Nushell uses.
+func addToNushellDB(path, command string, start time.Time, elapsed time.Duration) error {
+	if SpacePrefix {
+		return nil
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close() //nolint:errcheck
+
+	host, _ := os.Hostname()
+	cwd, _ := os.Getwd()
+	_, err = db.Exec(
+		`INSERT INTO history (command_line, start_timestamp, hostname, cwd, duration_ms, exit_status) VALUES (?, ?, ?, ?, ?, 0)`,
+		command, start.UnixMilli(), host, cwd, elapsed.Milliseconds(),
+	)
+	return err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// psReadLineHistoryFile returns PSReadLine's history file for PowerShell 7
+// on macOS and Linux, under $XDG_DATA_HOME or ~/.local/share.
+func psReadLineHistoryFile() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "powershell", "PSReadLine", "ConsoleHost_history.txt")
+}
+
+// addToPSReadLineHistory appends command to PSReadLine's history file if
+// PowerShell has created it. Like PSReadLine, lines of a multi-line
+// command end with a backtick, which continues the entry.
+func addToPSReadLineHistory(command string) {
+	histFile := psReadLineHistoryFile()
+	if histFile == "" || !fileExists(histFile) || historyIgnores(histFile, command) {
+		return
+	}
+	f, err := os.OpenFile(histFile, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close() //nolint:errcheck
+	_, _ = f.WriteString(strings.ReplaceAll(command, "\n", "`\n") + "\n")
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProvenanceMarker is the comment appended to commands how generates when
+// Provenance is set, so they can be told apart in history and scripts.
+const ProvenanceMarker = "# via how"
+
+// Provenance marks commands written to the shell history, and printed by
+// --quiet, with ProvenanceMarker.
+var Provenance bool
+
+// markProvenance appends ProvenanceMarker to command when Provenance is
+// set. Multi-line commands are left alone, since a trailing comment could
+// land inside a heredoc, as are all commands in zsh unless it allows
+// comments at the prompt.
+func markProvenance(command string) string {
+	if !Provenance || !commentsAllowed() || strings.Contains(command, "\n") || hasProvenance(command) {
+		return command
+	}
+	return command + " " + ProvenanceMarker
+}
+
+// commentsAllowed reports whether the user's shell treats # as a comment
+// in commands typed or recalled at the prompt. zsh only does with
+// INTERACTIVE_COMMENTS set, which its shell integration reports in
+// HOW_INTERACTIVE_COMMENTS; without it, "# via how" would be passed to
+// the command as arguments.
+func commentsAllowed() bool {
+	if filepath.Base(os.Getenv("SHELL")) != "zsh" {
+		return true
+	}
+	return os.Getenv("HOW_INTERACTIVE_COMMENTS") == "1"
+}
+
+func hasProvenance(entry string) bool {
+	return strings.HasSuffix(strings.TrimSpace(entry), ProvenanceMarker)
+}
+
+// HistoryProvenance counts the entries among the last limit in the user's
+// shell history that carry ProvenanceMarker, and returns the history file
+// it read.
+func HistoryProvenance(limit int) (marked, total int, file string, err error) {
+	entries, file, err := historyEntries()
+	if err != nil {
+		return 0, 0, file, err
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	for _, e := range entries {
+		if hasProvenance(e) {
+			marked++
+		}
+	}
+	return marked, len(entries), file, nil
+}
+
+// historyEntries reads every entry, oldest first, from the history file
+// addToShellHistory writes to for the user's shell.
+func historyEntries() ([]string, string, error) {
+	shell := os.Getenv("SHELL")
+	var file string
+	switch filepath.Base(shell) {
+	case "nu":
+		dir := nushellConfigDir()
+		if db := filepath.Join(dir, "history.sqlite3"); fileExists(db) {
+			entries, err := nushellDBEntries(db)
+			return entries, db, err
+		}
+		file = filepath.Join(dir, "history.txt")
+	case "pwsh":
+		file = psReadLineHistoryFile()
+	default:
+		file = shellHistoryFile(shell)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, file, err
+	}
+	return parseHistory(string(data)), file, nil
+}
+
+// parseHistory splits a history file into entries, dropping bash
+// timestamp lines and zsh extended-history prefixes, and joining lines
+// continued with a trailing backslash (zsh) or backtick (PSReadLine).
+func parseHistory(data string) []string {
+	var entries []string
+	var cur strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		if cur.Len() == 0 {
+			if line == "" || bashTimestampRe.MatchString(line) {
+				continue
+			}
+			if loc := zshExtendedRe.FindStringIndex(line); loc != nil && loc[0] == 0 {
+				line = line[loc[1]:]
+			}
+		}
+		if strings.HasSuffix(line, "\\") || strings.HasSuffix(line, "`") {
+			cur.WriteString(line[:len(line)-1])
+			cur.WriteByte('\n')
+			continue
+		}
+		cur.WriteString(line)
+		entries = append(entries, cur.String())
+		cur.Reset()
+	}
+	if cur.Len() > 0 {
+		entries = append(entries, cur.String())
+	}
+	return entries
+}
+
+// nushellDBEntries reads the commands in Nushell's SQLite history, oldest
+// first.
+func nushellDBEntries(path string) ([]string, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close() //nolint:errcheck
+
+	rows, err := db.Query(`SELECT command_line FROM history ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+	var entries []string
+	for rows.Next() {
+		var e string
+		if err := rows.Scan(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
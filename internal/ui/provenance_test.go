@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarkProvenance(t *testing.T) {
+	t.Cleanup(func() { Provenance = false })
+	t.Setenv("SHELL", "/bin/bash")
+
+	if got := markProvenance("ls -la"); got != "ls -la" {
+		t.Errorf("markProvenance() with Provenance off = %q, want it unchanged", got)
+	}
+	Provenance = true
+	cases := map[string]string{
+		"ls -la":                 "ls -la # via how",
+		"ls -la # via how":       "ls -la # via how",
+		"cat <<EOF\nhi\nEOF":     "cat <<EOF\nhi\nEOF",
+		"grep -c x f # count it": "grep -c x f # count it # via how",
+	}
+	for command, want := range cases {
+		if got := markProvenance(command); got != want {
+			t.Errorf("markProvenance(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestMarkProvenanceZsh(t *testing.T) {
+	t.Cleanup(func() { Provenance = false })
+	Provenance = true
+	t.Setenv("SHELL", "/bin/zsh")
+
+	t.Setenv("HOW_INTERACTIVE_COMMENTS", "")
+	if got := markProvenance("ls -la"); got != "ls -la" {
+		t.Errorf("markProvenance() in zsh without interactivecomments = %q, want it unchanged", got)
+	}
+	t.Setenv("HOW_INTERACTIVE_COMMENTS", "1")
+	if got := markProvenance("ls -la"); got != "ls -la # via how" {
+		t.Errorf("markProvenance() in zsh with interactivecomments = %q, want it marked", got)
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	data := "#1700000000\nls\n: 1700000001:0;echo a\\\nb\nkubectl get pods # via how\n\n"
+	want := []string{"ls", "echo a\nb", "kubectl get pods # via how"}
+	if got := parseHistory(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHistory() = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryProvenance(t *testing.T) {
+	t.Cleanup(func() { Provenance = false })
+	histFile := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(histFile, []byte("ls\ncd /tmp\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("HISTFILE", histFile)
+
+	Provenance = true
+	addToShellHistory("df -h", time.Now(), 0)
+	addToShellHistory("du -sh .", time.Now(), 0)
+
+	marked, total, file, err := HistoryProvenance(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if marked != 2 || total != 3 || file != histFile {
+		t.Errorf("HistoryProvenance(3) = %d, %d, %s, want 2, 3, %s", marked, total, file, histFile)
+	}
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/swibrow/how/risk"
+)
+
+// FormatRunbook renders result as an entry for a file written with --out:
+// the command on its own line, marked with ProvenanceMarker when
+// Provenance is set. With comments, the entry is preceded by the question,
+// the time it was asked, the explanation, any warning and the risk level
+// as shell comments, and followed by a blank line so entries appended one
+// after another stay apart.
+func FormatRunbook(question string, result Result, comments bool, at time.Time) string {
+	command := markProvenance(result.Command)
+	if !comments {
+		return command + "\n"
+	}
+	var b strings.Builder
+	writeComment(&b, "", fmt.Sprintf("%s (%s)", strings.TrimSpace(question), at.Format("2006-01-02 15:04")))
+	writeComment(&b, "", result.Explanation)
+	writeComment(&b, "Warning: ", result.Warning)
+	if a := risk.Classify(result.Command); a.Level != risk.Safe {
+		writeComment(&b, "Risk: ", fmt.Sprintf("%s, %s", a.Level, strings.Join(a.Reasons, "; ")))
+	}
+	b.WriteString(command + "\n\n")
+	return b.String()
+}
+
+// writeComment writes text as shell comment lines, the first one after
+// label.
+func writeComment(b *strings.Builder, label, text string) {
+	if text == "" {
+		return
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			line = label + line
+		}
+		fmt.Fprintf(b, "# %s\n", strings.TrimRight(line, " "))
+	}
+}
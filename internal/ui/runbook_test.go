@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatRunbook(t *testing.T) {
+	t.Cleanup(func() { Provenance = false })
+	at := time.Date(2026, 10, 15, 14, 3, 0, 0, time.UTC)
+	result := Result{Command: "rm -rf /tmp/cache", Explanation: "Deletes the cache.", Warning: "Can't be undone."}
+
+	if got := FormatRunbook("clear the cache", result, false, at); got != "rm -rf /tmp/cache\n" {
+		t.Errorf("without comments = %q", got)
+	}
+
+	want := `# clear the cache (2026-10-15 14:03)
+# Deletes the cache.
+# Warning: Can't be undone.
+# Risk: dangerous, recursively deletes files
+rm -rf /tmp/cache
+
+`
+	if got := FormatRunbook("clear the cache", result, true, at); got != want {
+		t.Errorf("with comments =\n%s\nwant\n%s", got, want)
+	}
+
+	Provenance = true
+	want = "# which pods are failing\n# across namespaces (2026-10-15 14:03)\nkubectl get pods -A # via how\n\n"
+	if got := FormatRunbook("which pods are failing\nacross namespaces", Result{Command: "kubectl get pods -A"}, true, at); got != want {
+		t.Errorf("multi-line question = %q, want %q", got, want)
+	}
+}
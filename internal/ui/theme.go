@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/swibrow/how/internal/config"
+)
+
+// palette is the set of colours a theme gives how's output.
+type palette struct {
+	command     lipgloss.TerminalColor
+	explanation lipgloss.TerminalColor
+	label       lipgloss.TerminalColor
+	error       lipgloss.TerminalColor
+	hint        lipgloss.TerminalColor
+}
+
+var (
+	// Catppuccin Mocha
+	mocha = palette{
+		command:     lipgloss.Color("#a6e3a1"), // Green
+		explanation: lipgloss.Color("#a6adc8"), // Subtext0
+		label:       lipgloss.Color("#f5c2e7"), // Pink
+		error:       lipgloss.Color("#f38ba8"), // Red
+		hint:        lipgloss.Color("#f9e2af"), // Yellow
+	}
+	// Catppuccin Latte
+	latte = palette{
+		command:     lipgloss.Color("#40a02b"), // Green
+		explanation: lipgloss.Color("#6c6f85"), // Subtext0
+		label:       lipgloss.Color("#8839ef"), // Mauve
+		error:       lipgloss.Color("#d20f39"), // Red
+		hint:        lipgloss.Color("#df8e1d"), // Yellow
+	}
+	solarizedDark = palette{
+		command:     lipgloss.Color("#859900"), // green
+		explanation: lipgloss.Color("#93a1a1"), // base1
+		label:       lipgloss.Color("#d33682"), // magenta
+		error:       lipgloss.Color("#dc322f"), // red
+		hint:        lipgloss.Color("#b58900"), // yellow
+	}
+	solarizedLight = palette{
+		command:     lipgloss.Color("#859900"), // green
+		explanation: lipgloss.Color("#586e75"), // base01
+		label:       lipgloss.Color("#6c71c4"), // violet
+		error:       lipgloss.Color("#dc322f"), // red
+		hint:        lipgloss.Color("#cb4b16"), // orange
+	}
+	mono = palette{
+		command:     lipgloss.NoColor{},
+		explanation: lipgloss.NoColor{},
+		label:       lipgloss.NoColor{},
+		error:       lipgloss.NoColor{},
+		hint:        lipgloss.NoColor{},
+	}
+)
+
+// adaptive picks between a light and a dark palette once the terminal's
+// background is known; lipgloss asks the terminal (OSC 11) the first time
+// a style is rendered, so nothing is queried when nothing is printed.
+func adaptive(light, dark palette) palette {
+	pick := func(l, d lipgloss.TerminalColor) lipgloss.TerminalColor {
+		return lipgloss.AdaptiveColor{Light: string(l.(lipgloss.Color)), Dark: string(d.(lipgloss.Color))}
+	}
+	return palette{
+		command:     pick(light.command, dark.command),
+		explanation: pick(light.explanation, dark.explanation),
+		label:       pick(light.label, dark.label),
+		error:       pick(light.error, dark.error),
+		hint:        pick(light.hint, dark.hint),
+	}
+}
+
+// themes maps each name accepted by the theme setting to its palette.
+var themes = map[string]palette{
+	config.ThemeAuto:           adaptive(latte, mocha),
+	config.ThemeDark:           mocha,
+	config.ThemeLight:          latte,
+	config.ThemeMocha:          mocha,
+	config.ThemeLatte:          latte,
+	config.ThemeSolarizedDark:  solarizedDark,
+	config.ThemeSolarizedLight: solarizedLight,
+	config.ThemeMono:           mono,
+}
+
+func init() {
+	applyPalette(themes[config.ThemeAuto])
+}
+
+// SetTheme switches how's output to the named theme; an empty name is
+// the same as "auto".
+func SetTheme(name string) error {
+	if name == "" {
+		name = config.ThemeAuto
+	}
+	p, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	applyPalette(p)
+	return nil
+}
+
+func applyPalette(p palette) {
+	commandStyle = lipgloss.NewStyle().Bold(true).Foreground(p.command)
+	explanationStyle = lipgloss.NewStyle().Foreground(p.explanation)
+	labelStyle = lipgloss.NewStyle().Bold(true).Foreground(p.label)
+	errorStyle = lipgloss.NewStyle().Bold(true).Foreground(p.error)
+	hintStyle = lipgloss.NewStyle().Bold(true).Foreground(p.hint)
+	matchStyle = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(p.hint)
+	removedStyle = lipgloss.NewStyle().Foreground(p.error).Strikethrough(true)
+	addedStyle = lipgloss.NewStyle().Bold(true).Foreground(p.command)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/swibrow/how/internal/config"
+)
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() { SetTheme(config.ThemeAuto) })
+
+	for name := range themes {
+		cfg := config.DefaultConfig()
+		if err := config.Set(cfg, "theme", name); err != nil {
+			t.Errorf("config rejects theme %q: %v", name, err)
+		}
+		if err := SetTheme(name); err != nil {
+			t.Errorf("SetTheme(%q) = %v", name, err)
+		}
+	}
+
+	if err := SetTheme("neon"); err == nil {
+		t.Error("SetTheme(neon) succeeded, want an error")
+	}
+}
+
+func TestSetThemeColours(t *testing.T) {
+	t.Cleanup(func() { SetTheme(config.ThemeAuto) })
+
+	if err := SetTheme(config.ThemeSolarizedLight); err != nil {
+		t.Fatal(err)
+	}
+	if got := commandStyle.GetForeground(); got != lipgloss.Color("#859900") {
+		t.Errorf("command colour = %v, want solarized green", got)
+	}
+
+	if err := SetTheme(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := labelStyle.GetForeground().(lipgloss.AdaptiveColor); !ok {
+		t.Errorf("auto label colour = %T, want lipgloss.AdaptiveColor", labelStyle.GetForeground())
+	}
+
+	if err := SetTheme(config.ThemeMono); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := errorStyle.GetForeground().(lipgloss.NoColor); !ok {
+		t.Errorf("mono error colour = %T, want lipgloss.NoColor", errorStyle.GetForeground())
+	}
+}
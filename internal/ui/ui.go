@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
@@ -9,25 +10,40 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/swibrow/how/internal/clipboard"
+	"github.com/swibrow/how/internal/config"
+	"github.com/swibrow/how/internal/preview"
+	"github.com/swibrow/how/internal/prompt"
+	"github.com/swibrow/how/internal/regex"
+	"github.com/swibrow/how/risk"
 	"golang.org/x/term"
 )
 
-// Catppuccin Mocha palette
+// Styles are set from the active theme by SetTheme.
 var (
-	commandStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#a6e3a1")) // Green
-	explanationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#a6adc8"))            // Subtext0
-	labelStyle       = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#f5c2e7")) // Pink
-	errorStyle       = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#f38ba8")) // Red
+	commandStyle     lipgloss.Style
+	explanationStyle lipgloss.Style
+	labelStyle       lipgloss.Style
+	errorStyle       lipgloss.Style
+	hintStyle        lipgloss.Style
+	matchStyle       lipgloss.Style
 )
 
 type Result struct {
 	Command     string
 	Explanation string
+	Warning     string
+	// Question is a clarifying question the model asked instead of
+	// answering, when it was allowed to.
+	Question string
+	// Injections lists text in the prompt's context that looked like
+	// instructions to the model; such commands always need confirmation.
+	Injections []string
 }
 
 // ParseResponse extracts command and explanation from the LLM response.
@@ -40,6 +56,10 @@ func ParseResponse(response string) Result {
 			result.Command = strings.TrimSpace(strings.TrimPrefix(line, "COMMAND:"))
 		} else if strings.HasPrefix(line, "EXPLANATION:") {
 			result.Explanation = strings.TrimSpace(strings.TrimPrefix(line, "EXPLANATION:"))
+		} else if strings.HasPrefix(line, "WARNING:") {
+			result.Warning = strings.TrimSpace(strings.TrimPrefix(line, "WARNING:"))
+		} else if strings.HasPrefix(line, "QUESTION:") {
+			result.Question = strings.TrimSpace(strings.TrimPrefix(line, "QUESTION:"))
 		}
 	}
 
@@ -47,6 +67,80 @@ func ParseResponse(response string) Result {
 	return result
 }
 
+// refusalPrefixes are openings that mark a COMMAND line as the model
+// declining rather than answering.
+var refusalPrefixes = []string{
+	"i can't", "i cannot", "i can not", "i won't", "i will not", "i'm sorry", "i am sorry",
+	"i'm not able", "i am not able", "i'm unable", "i am unable", "sorry", "as an ai",
+	"unfortunately",
+}
+
+// IsRefusal reports whether a parsed command is really prose from the model
+// declining to answer.
+func IsRefusal(command string) bool {
+	lower := strings.ToLower(strings.ReplaceAll(command, "’", "'"))
+	for _, p := range refusalPrefixes {
+		if strings.HasPrefix(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reasoning returns the prose of a response that did not yield a command,
+// with any format labels removed.
+func Reasoning(response string) string {
+	var lines []string
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		for _, label := range []string{"COMMAND:", "EXPLANATION:", "WARNING:"} {
+			line = strings.TrimSpace(strings.TrimPrefix(line, label))
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseSteps extracts a sequence of STEP/EXPLANATION pairs from a
+// tutorial-style response. Each STEP starts a new result.
+func ParseSteps(response string) []Result {
+	var steps []Result
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "STEP:"):
+			cmd := stripBackticks(strings.TrimSpace(strings.TrimPrefix(line, "STEP:")))
+			steps = append(steps, Result{Command: cmd})
+		case strings.HasPrefix(line, "EXPLANATION:") && len(steps) > 0:
+			steps[len(steps)-1].Explanation = strings.TrimSpace(strings.TrimPrefix(line, "EXPLANATION:"))
+		}
+	}
+	return steps
+}
+
+// ParseAlternatives extracts each COMMAND line in response, with the
+// EXPLANATION and WARNING lines that follow it.
+func ParseAlternatives(response string) []Result {
+	var results []Result
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "COMMAND:"):
+			cmd := stripBackticks(strings.TrimSpace(strings.TrimPrefix(line, "COMMAND:")))
+			if cmd != "" {
+				results = append(results, Result{Command: cmd})
+			}
+		case strings.HasPrefix(line, "EXPLANATION:") && len(results) > 0:
+			results[len(results)-1].Explanation = strings.TrimSpace(strings.TrimPrefix(line, "EXPLANATION:"))
+		case strings.HasPrefix(line, "WARNING:") && len(results) > 0:
+			results[len(results)-1].Warning = strings.TrimSpace(strings.TrimPrefix(line, "WARNING:"))
+		}
+	}
+	return results
+}
+
 // stripBackticks removes backtick wrapping that LLMs sometimes add.
 func stripBackticks(cmd string) string {
 	switch {
@@ -68,30 +162,470 @@ func Display(result Result) {
 	if result.Explanation != "" {
 		fmt.Printf("  %s\n", explanationStyle.Render(result.Explanation))
 	}
+	if result.Warning != "" {
+		fmt.Printf("  %s %s\n", badge(errorStyle, icons.warning, "Warning:"), result.Warning)
+	}
+	fmt.Println()
+}
+
+// DisplayStep shows one step of a guided sequence with its position.
+func DisplayStep(n, total int, step Result) {
+	fmt.Printf("  %s\n", labelStyle.Render(fmt.Sprintf("Step %d of %d", n, total)))
+	if step.Explanation != "" {
+		fmt.Printf("  %s\n", explanationStyle.Render(step.Explanation))
+	}
+	fmt.Printf("  %s %s\n\n", labelStyle.Render("$"), commandStyle.Render(step.Command))
+}
+
+// DisplayRisk shows a badge and reasons for commands that modify state,
+// and the hosts the command would contact. Safe, offline commands display
+// nothing.
+func DisplayRisk(a risk.Assessment) {
+	switch a.Level {
+	case risk.Dangerous:
+		fmt.Printf("  %s %s\n\n", badge(errorStyle, icons.danger, "Dangerous:"), strings.Join(a.Reasons, "; "))
+	case risk.Caution:
+		fmt.Printf("  %s %s\n\n", badge(hintStyle, icons.caution, "Caution:"), strings.Join(a.Reasons, "; "))
+	}
+	if len(a.Network) > 0 {
+		hosts := make([]string, len(a.Network))
+		for i, d := range a.Network {
+			hosts[i] = d.String()
+		}
+		fmt.Printf("  %s %s\n\n", badge(hintStyle, icons.network, "Network:"), strings.Join(hosts, "; "))
+	}
+}
+
+// DisplayWatchHeader clears the screen when stdout is a terminal and shows
+// what is being watched, like watch(1). status is the previous run's exit
+// status.
+func DisplayWatchHeader(command string, interval time.Duration, at time.Time, where string, status int) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print("\033[H\033[2J")
+	}
+	right := at.Format("15:04:05")
+	if where != "" {
+		right = where + ": " + right
+	}
+	fmt.Printf("%s %s  %s\n", labelStyle.Render(fmt.Sprintf("Every %s:", interval)), commandStyle.Render(command), explanationStyle.Render(right))
+	if status != 0 {
+		fmt.Printf("%s\n", errorStyle.Render(fmt.Sprintf("Last run exited with status %d", status)))
+	}
 	fmt.Println()
 }
 
-// DisplayQuiet shows only the command (for piping).
+// DisplayStages lists the stages of a command chained with &&, which run
+// one at a time.
+func DisplayStages(stages []string) {
+	fmt.Printf("  %s\n", labelStyle.Render("Stages:"))
+	for i, stage := range stages {
+		fmt.Printf("    %d. %s\n", i+1, commandStyle.Render(stage))
+	}
+	fmt.Println()
+}
+
+// DisplayStageFailure shows which stage of a chained command failed.
+func DisplayStageFailure(n, total int, stage string, status int) {
+	fmt.Fprintf(os.Stderr, "\n  %s %s (exit %d)\n", badge(errorStyle, icons.error, fmt.Sprintf("Stage %d of %d failed:", n, total)), stage, status)
+}
+
+// DisplayCopied confirms the command was put on the clipboard. With OSC 52
+// the terminal may refuse silently, so that's said too.
+func DisplayCopied(method string) {
+	msg := "Copied to the clipboard."
+	if method == clipboard.MethodOSC52 {
+		msg = "Sent to your terminal's clipboard with OSC 52 (the terminal must allow it)."
+	}
+	fmt.Fprintf(os.Stderr, "  %s\n\n", hintStyle.Render(msg))
+}
+
+// DisplayRewrite shows a command changed by policy and why.
+func DisplayRewrite(command string, notes []string) {
+	fmt.Printf("  %s %s\n", badge(hintStyle, icons.policy, "Policy:"), strings.Join(notes, "; "))
+	fmt.Printf("  %s %s\n\n", labelStyle.Render("$"), commandStyle.Render(command))
+}
+
+// maxShownPaths bounds how many paths DisplayPaths lists per kind.
+const maxShownPaths = 10
+
+// DisplayPaths shows the existing files and directories a command would
+// delete, modify or read.
+func DisplayPaths(p risk.Paths) {
+	if p.Empty() {
+		return
+	}
+	line := func(label string, style lipgloss.Style, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		shown := strings.Join(paths[:min(len(paths), maxShownPaths)], ", ")
+		if len(paths) > maxShownPaths {
+			shown += fmt.Sprintf(" (+%d more)", len(paths)-maxShownPaths)
+		}
+		fmt.Printf("  %s %s\n", style.Render(label), shown)
+	}
+	line("Deletes:", errorStyle, p.Deleted)
+	line("Modifies:", hintStyle, p.Modified)
+	line("Reads:", explanationStyle, p.Read)
+	fmt.Println()
+}
+
+// DisplayEdits shows what an in-place edit would change in each file, from
+// a run against copies of them. err is why the preview failed, if it did.
+func DisplayEdits(changes []preview.Change, err error) {
+	if err != nil {
+		fmt.Printf("  %s %v\n\n", badge(hintStyle, icons.hint, "Preview:"), err)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Printf("  %s %s\n\n", badge(hintStyle, icons.hint, "Preview:"), "the edit changes nothing.")
+		return
+	}
+	fmt.Printf("  %s\n", labelStyle.Render("Preview:"))
+	for _, c := range changes {
+		displayUnified(c.Diff)
+	}
+	fmt.Println()
+}
+
+// maxRehearsalOutput bounds how many lines of a rehearsal's output are
+// shown.
+const maxRehearsalOutput = 20
+
+// DisplayRehearsal shows what a command did when run against copies of
+// its files: its output and exit status, then the files it created,
+// removed and changed.
+func DisplayRehearsal(r *preview.Rehearsal) {
+	status := "exited 0"
+	if r.ExitCode != 0 {
+		status = errorStyle.Render(fmt.Sprintf("exited %d", r.ExitCode))
+	}
+	fmt.Printf("  %s ran on copies, %s\n", labelStyle.Render("Rehearsal:"), status)
+	if out := strings.TrimRight(r.Output, "\n"); out != "" {
+		lines := strings.Split(out, "\n")
+		for _, line := range lines[:min(len(lines), maxRehearsalOutput)] {
+			fmt.Printf("    %s\n", explanationStyle.Render(line))
+		}
+		if len(lines) > maxRehearsalOutput {
+			fmt.Printf("    %s\n", explanationStyle.Render(fmt.Sprintf("(+%d more lines)", len(lines)-maxRehearsalOutput)))
+		}
+	}
+	if len(r.Changes) == 0 && len(r.Created) == 0 && len(r.Removed) == 0 {
+		fmt.Printf("  %s\n\n", explanationStyle.Render("No files changed."))
+		return
+	}
+	for _, p := range r.Created {
+		fmt.Printf("  %s %s\n", commandStyle.Render("Creates:"), p)
+	}
+	for _, p := range r.Removed {
+		fmt.Printf("  %s %s\n", errorStyle.Render("Removes:"), p)
+	}
+	for _, c := range r.Changes {
+		displayUnified(c.Diff)
+	}
+	fmt.Println()
+}
+
+// displayUnified shows a unified diff with removed and added lines
+// coloured.
+func displayUnified(diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		style := explanationStyle
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			style = labelStyle
+		case strings.HasPrefix(line, "@@"):
+			style = hintStyle
+		case strings.HasPrefix(line, "-"):
+			style = errorStyle
+		case strings.HasPrefix(line, "+"):
+			style = commandStyle
+		}
+		fmt.Printf("    %s\n", style.Render(line))
+	}
+}
+
+// DisplayFile shows a generated file's path and contents.
+func DisplayFile(path, content string) {
+	fmt.Printf("  %s\n", labelStyle.Render(path))
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		fmt.Printf("    %s\n", commandStyle.Render(line))
+	}
+	fmt.Println()
+}
+
+// DisplayTable shows rows under column headers, noting when more rows
+// were left out.
+func DisplayTable(columns []string, rows [][]string, more bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\n", strings.Join(columns, "\t"))
+	for _, row := range rows {
+		fmt.Fprintf(w, "  %s\n", strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+	if len(rows) == 0 {
+		fmt.Printf("  %s\n", explanationStyle.Render("(no rows)"))
+	}
+	if more {
+		fmt.Printf("  %s\n", explanationStyle.Render(fmt.Sprintf("(showing the first %d rows)", len(rows))))
+	}
+	fmt.Println()
+}
+
+// WithRisk is set with --with-risk, so quiet output carries the command's
+// risk level for wrapper scripts to gate on.
+var WithRisk bool
+
+// ExplainToStderr makes quiet output write the explanation and any
+// warning to stderr, so they're seen while the command is piped.
+var ExplainToStderr bool
+
+// DisplayQuiet shows only the command (for piping), after a space when
+// SpacePrefix is set and followed by ProvenanceMarker when Provenance is.
+// With WithRisk, the command's risk level comes first, separated by a tab,
+// so the rest of the line is the command as it is. With ExplainToStderr,
+// the explanation and warning follow on stderr.
 func DisplayQuiet(result Result) {
-	fmt.Println(result.Command)
+	command := markProvenance(result.Command)
+	switch {
+	case WithRisk:
+		fmt.Printf("%s\t%s\n", risk.Classify(result.Command).Level, command)
+	case SpacePrefix:
+		fmt.Println(" " + command)
+	default:
+		fmt.Println(command)
+	}
+	if ExplainToStderr {
+		if result.Explanation != "" {
+			fmt.Fprintf(os.Stderr, "  %s\n", explanationStyle.Render(result.Explanation))
+		}
+		if result.Warning != "" {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", badge(errorStyle, icons.warning, "Warning:"), result.Warning)
+		}
+	}
+}
+
+// DisplayEstimate shows the estimated size and cost of a request before
+// it's sent, as a warning when it's over the configured threshold. An
+// unknown price is shown as such rather than as free.
+func DisplayEstimate(tokens int64, cost float64, priced, over bool) {
+	price := "unknown cost"
+	if priced {
+		price = fmt.Sprintf("$%.4f", cost)
+	}
+	if over {
+		fmt.Fprintf(os.Stderr, "  %s this request is ~%d input tokens, about %s\n", badge(errorStyle, icons.warning, "Warning:"), tokens, price)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %s\n", explanationStyle.Render(fmt.Sprintf("~%d input tokens, about %s", tokens, price)))
+}
+
+// DisplayRoute shows which model answered and why, when triage chooses
+// between a local and a hosted one.
+func DisplayRoute(route string) {
+	fmt.Fprintf(os.Stderr, "  %s\n", explanationStyle.Render("answered by "+route))
+}
+
+// DisplayNotice shows a status line on stderr, such as where a server is
+// listening or that a new version is out.
+func DisplayNotice(msg string) {
+	if JSONErrors {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %s\n", explanationStyle.Render(msg))
+}
+
+// DisplayBudget shows that the monthly budget has been reached and what
+// happens instead.
+func DisplayBudget(msg string) {
+	fmt.Fprintf(os.Stderr, "\n  %s %s\n", badge(errorStyle, icons.budget, "Budget:"), msg)
+}
+
+// DisplayReasoning shows what the model said instead of a command.
+func DisplayReasoning(text string) {
+	if JSONErrors {
+		return
+	}
+	fmt.Fprintln(os.Stderr)
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(os.Stderr, "  %s\n", explanationStyle.Render(line))
+	}
 }
 
 // DisplayError shows a formatted error message.
 func DisplayError(msg string) {
-	fmt.Fprintf(os.Stderr, "\n  %s %s\n\n", errorStyle.Render("Error:"), msg)
+	if JSONErrors {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n  %s %s\n\n", badge(errorStyle, icons.error, "Error:"), msg)
+}
+
+// Unattended names the CI service how is running under, if any. Prompts
+// fail there rather than wait for an answer nobody will give.
+var Unattended string
+
+// ErrUnattended is returned by prompts when Unattended is set.
+var ErrUnattended = errors.New("confirmation required")
+
+// unattended reports, and displays, the error for a prompt that can't be
+// answered, or nil when someone is there to answer it.
+func unattended() error {
+	if Unattended == "" {
+		return nil
+	}
+	err := fmt.Errorf("%w, but running unattended in %s; pass --yes to run without asking", ErrUnattended, Unattended)
+	DisplayError(err.Error())
+	return err
 }
 
 // ConfirmAndRun prompts the user to run the command and executes it.
 // Returns (true, nil) if confirmed and succeeded, (true, err) if confirmed
 // but the command failed, and (false, nil) if the user declined.
 func ConfirmAndRun(command string) (bool, error) {
-	fmt.Printf("  Run this command? [y/N] ")
+	confirmed, err := Confirm("Run this command?")
+	if !confirmed || err != nil {
+		return false, err
+	}
+	return true, RunCommand(command)
+}
+
+// Confirm asks a yes/no question and reads a single keypress.
+// It returns false without prompting if stdin is not a terminal, and an
+// ErrUnattended error in CI.
+func Confirm(question string) (bool, error) {
+	if err := unattended(); err != nil {
+		return false, err
+	}
+	key, err := ReadKey(question + " [y/N]")
+	if err != nil {
+		return false, err
+	}
+	return key == 'y' || key == 'Y', nil
+}
+
+// ConfirmOrAlternatives is Confirm with a third answer, a, for seeing
+// other commands instead. It returns 'y', 'a' or 'n'.
+func ConfirmOrAlternatives(question string) (byte, error) {
+	if err := unattended(); err != nil {
+		return 'n', err
+	}
+	key, err := ReadKey(question + " [y/N/a=alternatives]")
+	if err != nil {
+		return 'n', err
+	}
+	switch key {
+	case 'y', 'Y':
+		return 'y', nil
+	case 'a', 'A':
+		return 'a', nil
+	}
+	return 'n', nil
+}
+
+// ChooseAlternative shows numbered alternatives and reads which to use.
+// It returns -1 when none is chosen.
+func ChooseAlternative(alternatives []Result) (int, error) {
+	for i, alt := range alternatives {
+		fmt.Println()
+		fmt.Printf("  %s %s\n", labelStyle.Render(fmt.Sprintf("%d.", i+1)), commandStyle.Render(alt.Command))
+		if alt.Explanation != "" {
+			fmt.Printf("     %s\n", explanationStyle.Render(alt.Explanation))
+		}
+		if alt.Warning != "" {
+			fmt.Printf("     %s %s\n", badge(errorStyle, icons.warning, "Warning:"), alt.Warning)
+		}
+	}
+	fmt.Println()
+	key, err := ReadKey(fmt.Sprintf("Use which? [1-%d, any other key to cancel]", len(alternatives)))
+	if err != nil {
+		return -1, err
+	}
+	if n := int(key - '0'); key >= '1' && n <= len(alternatives) {
+		return n - 1, nil
+	}
+	return -1, nil
+}
+
+// ConfirmTyped asks the user to type want to confirm a risky action.
+// It returns false without prompting if stdin is not a terminal, and an
+// ErrUnattended error in CI.
+func ConfirmTyped(question, want string) (bool, error) {
+	if err := unattended(); err != nil {
+		return false, err
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, nil
+	}
+	fmt.Printf("  %s Type %s to confirm: ", question, want)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, fmt.Errorf("reading input: %w", err)
+	}
+	return strings.TrimSpace(line) == want, nil
+}
+
+// Clarify shows the model's clarifying question and reads the user's
+// one-line answer.
+func Clarify(question string) (string, error) {
+	fmt.Printf("\n  %s %s\n  > ", labelStyle.Render("Question:"), question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// DisplayTranscript shows the clarifying questions asked before the
+// suggestion and the answers given, for --verbose.
+func DisplayTranscript(rounds []prompt.Clarification) {
+	fmt.Fprintf(os.Stderr, "\n  %s\n", labelStyle.Render("Clarifications:"))
+	for _, c := range rounds {
+		answer := c.Answer
+		if answer == "" {
+			answer = "(no answer)"
+		}
+		fmt.Fprintf(os.Stderr, "    %s %s\n", explanationStyle.Render("Q:"), c.Question)
+		fmt.Fprintf(os.Stderr, "    %s %s\n", explanationStyle.Render("A:"), answer)
+	}
+}
 
+// ReadLines prints prompt and reads lines from stdin until an empty line
+// or end of input, returning them joined with newlines.
+func ReadLines(prompt string) (string, error) {
+	fmt.Printf("  %s\n", labelStyle.Render(prompt))
+	var lines []string
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("  > ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			lines = append(lines, line)
+		}
+		if line == "" || err == io.EOF {
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+}
+
+// ReadKey prints a prompt and reads a single keypress in raw mode.
+// It returns 0 without prompting if stdin is not a terminal.
+func ReadKey(prompt string) (byte, error) {
 	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// Not a terminal (e.g. piped input) — can't use raw mode
+		return 0, nil
+	}
+
+	fmt.Printf("  %s ", prompt)
 	oldState, err := term.MakeRaw(fd)
 	if err != nil {
-		// Not a terminal (e.g. piped input) — can't use raw mode
-		return false, nil
+		return 0, nil
 	}
 
 	var buf [1]byte
@@ -100,49 +634,121 @@ func ConfirmAndRun(command string) (bool, error) {
 	fmt.Println() // move to next line after the keypress
 
 	if err != nil {
-		return false, fmt.Errorf("reading input: %w", err)
+		return 0, fmt.Errorf("reading input: %w", err)
 	}
-
-	if buf[0] != 'y' && buf[0] != 'Y' {
-		return false, nil
-	}
-
-	return true, RunCommand(command)
+	return buf[0], nil
 }
 
 // RunCommand executes a command via the shell.
 // If the command is not found (exit code 127), it suggests how to install it.
+// The command is added to the shell history as ShellHistory says.
 func RunCommand(command string) error {
 	fmt.Println()
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Stdout = os.Stdout
+	_, err := runLocal(exec.Command("sh", "-c", command), command)
+	return err
+}
+
+// RunStaged runs script, a shell.StagedScript for command, and returns the
+// error output along with any error. command is added to the shell history
+// as ShellHistory says.
+func RunStaged(script, command string) (string, error) {
+	fmt.Println()
+	return runLocal(exec.Command("sh", "-c", script), command)
+}
+
+// RunWith is RunCommand for shells that aren't POSIX, running argv, such
+// as nu -c command or pwsh -Command command, instead of sh -c command.
+func RunWith(argv []string, command string) error {
+	fmt.Println()
+	_, err := runLocal(exec.Command(argv[0], argv[1:]...), command)
+	return err
+}
+
+// RunInteractive is RunCommand in an interactive instance of shellPath, so
+// the aliases and functions from the user's rc files resolve.
+func RunInteractive(shellPath, command string) error {
+	fmt.Println()
+	_, err := runLocal(exec.Command(shellPath, "-i", "-c", command), command)
+	return err
+}
+
+// RunRemote runs command through cmd, an ssh invocation that executes it on
+// another host. installHint suggests how to install a missing command there.
+func RunRemote(cmd *exec.Cmd, command string, installHint func(string) string) error {
+	fmt.Println()
+	lastOutput = ""
+	_, err := run(cmd, command, installHint)
+	return err
+}
+
+// ShellHistory is when commands run on this machine are appended to the
+// shell's history file, one of the config.History values.
+var ShellHistory = config.HistoryOnSuccess
+
+// runLocal is run for commands on this machine, adding command to the
+// shell history when ShellHistory says to and capturing its output when
+// CaptureOutput does.
+func runLocal(cmd *exec.Cmd, command string) (string, error) {
+	start := time.Now()
+	if ShellHistory == config.HistoryOnAccept {
+		addToShellHistory(command, start, 0)
+	}
+	lastOutput = ""
+	var out *headWriter
+	if captures(command) {
+		out = &headWriter{limit: maxCapturedOutput}
+		cmd.Stdout = io.MultiWriter(os.Stdout, out)
+	}
+	stderr, err := run(cmd, command, installSuggestion)
+	if out != nil {
+		lastOutput = out.buf.String()
+	}
+	if err == nil && ShellHistory == config.HistoryOnSuccess {
+		addToShellHistory(command, start, time.Since(start))
+	}
+	return stderr, err
+}
+
+// run runs cmd on the terminal, returning what it wrote to stderr, and
+// hints at how to install a command that wasn't found.
+func run(cmd *exec.Cmd, command string, installHint func(string) string) (string, error) {
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
 	cmd.Stdin = os.Stdin
 
 	var stderrBuf bytes.Buffer
 	cmd.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
 
 	err := cmd.Run()
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && exitErr.ExitCode() == 127 {
-			cmdName := parseNotFoundCommand(stderrBuf.String(), command)
-			if cmdName != "" {
-				fmt.Fprintln(os.Stderr)
-				fmt.Fprintf(os.Stderr, "  %s %s is not installed.\n", hintStyle.Render("Hint:"), cmdName)
-				fmt.Fprintf(os.Stderr, "  %s\n", installSuggestion(cmdName))
-			}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 127 {
+		cmdName := parseNotFoundCommand(stderrBuf.String(), command)
+		if cmdName != "" {
+			fmt.Fprintln(os.Stderr)
+			fmt.Fprintf(os.Stderr, "  %s %s is not installed.\n", badge(hintStyle, icons.hint, "Hint:"), cmdName)
+			fmt.Fprintf(os.Stderr, "  %s\n", installHint(cmdName))
 		}
-	} else {
-		addToShellHistory(command)
 	}
-	return err
+	return stderrBuf.String(), err
 }
 
-// addToShellHistory appends the command to the user's shell history file.
-func addToShellHistory(command string) {
+// addToShellHistory appends command, which started at start and ran for
+// elapsed, to the user's shell history file, marked with ProvenanceMarker
+// when Provenance is set.
+func addToShellHistory(command string, start time.Time, elapsed time.Duration) {
+	command = markProvenance(command)
 	shell := os.Getenv("SHELL")
+	switch filepath.Base(shell) {
+	case "nu":
+		addToNushellHistory(command, start, elapsed)
+		return
+	case "pwsh":
+		addToPSReadLineHistory(command)
+		return
+	}
 	histFile := shellHistoryFile(shell)
-	if histFile == "" {
+	if histFile == "" || historyIgnores(histFile, command) {
 		return
 	}
 
@@ -152,13 +758,123 @@ func addToShellHistory(command string) {
 	}
 	defer f.Close() //nolint:errcheck
 
-	if strings.Contains(shell, "zsh") && isZshExtendedHistory(histFile) {
-		_, _ = fmt.Fprintf(f, ": %d:0;%s\n", time.Now().Unix(), command)
-	} else {
+	switch {
+	case strings.Contains(shell, "zsh") && isZshExtendedHistory(histFile):
+		_, _ = fmt.Fprintf(f, ": %d:%d;%s\n", start.Unix(), int64(elapsed.Seconds()), zshHistoryLine(command))
+	case strings.Contains(shell, "zsh"):
+		_, _ = fmt.Fprintf(f, "%s\n", zshHistoryLine(command))
+	case strings.Contains(shell, "bash") && isBashTimestampedHistory(histFile):
+		_, _ = fmt.Fprintf(f, "#%d\n%s\n", start.Unix(), command)
+	default:
 		_, _ = fmt.Fprintf(f, "%s\n", command)
 	}
 }
 
+// SpacePrefix marks commands how generates as private, the way a leading
+// space does in shells set to ignore such lines: they're never added to
+// the shell history, and --quiet prints them with a leading space.
+var SpacePrefix bool
+
+// historyIgnores reports whether command should be left out of the
+// history file, as the user's shell would leave it out: because of
+// SpacePrefix, HISTCONTROL's ignorespace and ignoredups, or the patterns in
+// HISTIGNORE (bash) or HISTORY_IGNORE (zsh). The settings are only seen
+// when they're exported.
+func historyIgnores(histFile, command string) bool {
+	if SpacePrefix {
+		return true
+	}
+	control := map[string]bool{}
+	for _, c := range strings.Split(os.Getenv("HISTCONTROL"), ":") {
+		control[c] = true
+	}
+	if strings.HasPrefix(command, " ") && (control["ignorespace"] || control["ignoreboth"]) {
+		return true
+	}
+	if control["ignoredups"] || control["ignoreboth"] || control["erasedups"] {
+		if last, ok := lastHistoryEntry(histFile); ok && last == command {
+			return true
+		}
+	}
+	var patterns []string
+	if p := os.Getenv("HISTIGNORE"); p != "" {
+		patterns = append(patterns, strings.Split(p, ":")...)
+	}
+	if p := os.Getenv("HISTORY_IGNORE"); p != "" {
+		// A zsh pattern, usually an alternation like (ls|cd *)
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "("), ")")
+		patterns = append(patterns, strings.Split(p, "|")...)
+	}
+	for _, p := range patterns {
+		if globMatch(p, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch reports whether s matches the shell pattern, in which * and ?
+// match any characters including /.
+func globMatch(pattern, s string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		case '[':
+			if end := strings.IndexByte(pattern[i+1:], ']'); end >= 0 {
+				re.WriteString("[" + strings.Replace(pattern[i+1:i+1+end], "!", "^", 1) + "]")
+				i += end + 1
+				continue
+			}
+			re.WriteString(`\[`)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	matched, err := regexp.MatchString(re.String()+"$", s)
+	return err == nil && matched
+}
+
+// lastHistoryEntry returns the last command in histFile, without bash
+// timestamp lines or the zsh extended-history prefix.
+func lastHistoryEntry(histFile string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(string(historyTail(histFile)), "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if line == "" || bashTimestampRe.MatchString(line) {
+			continue
+		}
+		if loc := zshExtendedRe.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			line = line[loc[1]:]
+		}
+		return line, true
+	}
+	return "", false
+}
+
+// zshHistoryLine escapes command the way zsh writes it to its history
+// file: each newline follows a backslash, so the entry continues onto the
+// next line, and a backslash that ends a line is doubled so it isn't read
+// as a continuation.
+func zshHistoryLine(command string) string {
+	var b strings.Builder
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		if c == '\\' && (i+1 == len(command) || command[i+1] == '\n') {
+			b.WriteByte('\\')
+		}
+		if c == '\n' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
 // shellHistoryFile returns the path to the shell history file,
 // using $HISTFILE if set, otherwise falling back to shell-specific defaults.
 func shellHistoryFile(shell string) string {
@@ -182,15 +898,34 @@ func shellHistoryFile(shell string) string {
 // isZshExtendedHistory checks whether the history file uses zsh extended
 // history format (": timestamp:duration;command") by sampling the tail.
 func isZshExtendedHistory(histFile string) bool {
+	return historyTailMatches(histFile, zshExtendedRe)
+}
+
+// isBashTimestampedHistory checks whether bash writes a "#<epoch>" comment
+// line before each command, as it does when HISTTIMEFORMAT is set, from
+// the environment or by sampling the tail of the history file.
+func isBashTimestampedHistory(histFile string) bool {
+	return os.Getenv("HISTTIMEFORMAT") != "" || historyTailMatches(histFile, bashTimestampRe)
+}
+
+// historyTailMatches reports whether the last 1KB of histFile, where the
+// current format shows, matches re.
+func historyTailMatches(histFile string, re *regexp.Regexp) bool {
+	return re.Match(historyTail(histFile))
+}
+
+// historyTail returns up to the last 1KB of histFile, or nil if it can't be
+// read.
+func historyTail(histFile string) []byte {
 	f, err := os.Open(histFile)
 	if err != nil {
-		return false
+		return nil
 	}
 	defer f.Close() //nolint:errcheck
 
 	info, err := f.Stat()
 	if err != nil || info.Size() == 0 {
-		return false
+		return nil
 	}
 
 	offset := info.Size() - 1024
@@ -201,17 +936,17 @@ func isZshExtendedHistory(histFile string) bool {
 	buf := make([]byte, 1024)
 	n, err := f.ReadAt(buf, offset)
 	if err != nil && n == 0 {
-		return false
+		return nil
 	}
-
-	return zshExtendedRe.Match(buf[:n])
+	return buf[:n]
 }
 
-var zshExtendedRe = regexp.MustCompile(`(?m)^: \d+:\d+;`)
-
 var (
-	hintStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#f9e2af")) // Yellow
+	zshExtendedRe   = regexp.MustCompile(`(?m)^: \d+:\d+;`)
+	bashTimestampRe = regexp.MustCompile(`(?m)^#\d{9,}$`)
+)
 
+var (
 	// Matches patterns like "sh: ss: command not found" or "bash: ss: command not found"
 	notFoundRe = regexp.MustCompile(`(?:sh|bash):\s*(?:line \d+:\s*)?(\S+):\s*(?:command )?not found`)
 	// Matches zsh pattern: "zsh: command not found: ss"
@@ -234,23 +969,28 @@ func parseNotFoundCommand(stderr, command string) string {
 	return ""
 }
 
-// installSuggestion returns a platform-aware install hint.
-func installSuggestion(cmdName string) string {
-	switch runtime.GOOS {
-	case "darwin":
-		return fmt.Sprintf("Install with: brew install %s", cmdName)
-	case "linux":
-		if _, err := exec.LookPath("apt"); err == nil {
-			return fmt.Sprintf("Install with: sudo apt install %s", cmdName)
-		}
-		if _, err := exec.LookPath("dnf"); err == nil {
-			return fmt.Sprintf("Install with: sudo dnf install %s", cmdName)
+// DisplayMatches shows the sample input lines matched by a regex,
+// highlighting each match and listing capture groups.
+func DisplayMatches(matches []regex.Match) {
+	if len(matches) == 0 {
+		fmt.Printf("  %s\n\n", explanationStyle.Render("No matches in sample input."))
+		return
+	}
+
+	for _, m := range matches {
+		var b strings.Builder
+		prev := 0
+		for _, loc := range m.Matches {
+			b.WriteString(m.Text[prev:loc[0]])
+			b.WriteString(matchStyle.Render(m.Text[loc[0]:loc[1]]))
+			prev = loc[1]
 		}
-		if _, err := exec.LookPath("pacman"); err == nil {
-			return fmt.Sprintf("Install with: sudo pacman -S %s", cmdName)
+		b.WriteString(m.Text[prev:])
+
+		fmt.Printf("  %s %s\n", labelStyle.Render(fmt.Sprintf("%4d:", m.Line)), b.String())
+		if len(m.Groups) > 0 {
+			fmt.Printf("        %s\n", explanationStyle.Render("groups: "+strings.Join(m.Groups, ", ")))
 		}
-		return fmt.Sprintf("Install %s using your system package manager", cmdName)
-	default:
-		return fmt.Sprintf("Install %s using your system package manager", cmdName)
 	}
+	fmt.Println()
 }
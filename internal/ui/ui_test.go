@@ -2,12 +2,18 @@ package ui
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/swibrow/how/internal/config"
 )
 
 func TestParseResponse(t *testing.T) {
@@ -34,6 +40,17 @@ func TestParseResponseCommandOnly(t *testing.T) {
 	}
 }
 
+func TestParseResponseQuestion(t *testing.T) {
+	result := ParseResponse("QUESTION: Which branch should the commits be moved to?")
+
+	if result.Command != "" {
+		t.Errorf("command: got %q, want empty", result.Command)
+	}
+	if result.Question != "Which branch should the commits be moved to?" {
+		t.Errorf("question: got %q", result.Question)
+	}
+}
+
 func TestParseResponseEmpty(t *testing.T) {
 	result := ParseResponse("")
 
@@ -122,6 +139,42 @@ func TestDisplayQuiet(t *testing.T) {
 	if strings.Contains(output, "Print hello") {
 		t.Error("quiet mode should not include explanation")
 	}
+
+	WithRisk = true
+	t.Cleanup(func() { WithRisk = false })
+	r, w, _ = os.Pipe()
+	os.Stdout = w
+	DisplayQuiet(Result{Command: "rm -rf build"})
+	w.Close()
+	os.Stdout = old
+	buf.Reset()
+	io.Copy(&buf, r)
+	if got := buf.String(); got != "dangerous\trm -rf build\n" {
+		t.Errorf("expected the risk level before the command, got %q", got)
+	}
+}
+
+func TestDisplayQuietExplainToStderr(t *testing.T) {
+	ExplainToStderr = true
+	t.Cleanup(func() { ExplainToStderr = false })
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+	DisplayQuiet(Result{Command: "rm -rf build", Explanation: "Delete the build directory", Warning: "Can't be undone"})
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var out, errOut bytes.Buffer
+	io.Copy(&out, outR)
+	io.Copy(&errOut, errR)
+	if out.String() != "rm -rf build\n" {
+		t.Errorf("expected only the command on stdout, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Delete the build directory") || !strings.Contains(errOut.String(), "Can't be undone") {
+		t.Errorf("expected the explanation and warning on stderr, got %q", errOut.String())
+	}
 }
 
 func TestParseNotFoundCommandBash(t *testing.T) {
@@ -227,7 +280,7 @@ func TestAddToShellHistory(t *testing.T) {
 	t.Setenv("SHELL", "/bin/bash")
 	t.Setenv("HISTFILE", tmpFile.Name())
 
-	addToShellHistory("echo hello")
+	addToShellHistory("echo hello", time.Now(), 0)
 
 	data, err := os.ReadFile(tmpFile.Name())
 	if err != nil {
@@ -250,15 +303,19 @@ func TestAddToShellHistoryZshExtended(t *testing.T) {
 	t.Setenv("SHELL", "/bin/zsh")
 	t.Setenv("HISTFILE", tmpFile.Name())
 
-	addToShellHistory("git status")
+	addToShellHistory("git status", time.Unix(1700000100, 0), 42*time.Second)
+	addToShellHistory("for f in *; do\n  echo $f \\\ndone", time.Unix(1700000200, 0), 0)
 
 	data, err := os.ReadFile(tmpFile.Name())
 	if err != nil {
 		t.Fatal(err)
 	}
 	content := string(data)
-	if !strings.Contains(content, ":0;git status\n") {
-		t.Errorf("expected extended zsh history entry, got: %q", content)
+	if !strings.Contains(content, ": 1700000100:42;git status\n") {
+		t.Errorf("expected extended zsh history entry with start and duration, got: %q", content)
+	}
+	if !strings.Contains(content, ": 1700000200:0;for f in *; do\\\n  echo $f \\\\\\\ndone\n") {
+		t.Errorf("expected multi-line entry with escaped newlines, got: %q", content)
 	}
 }
 
@@ -319,3 +376,237 @@ func TestRunCommandNotFound(t *testing.T) {
 		t.Errorf("expected 'not installed' hint in stderr, got: %q", output)
 	}
 }
+
+func TestParseResponseWarning(t *testing.T) {
+	response := "COMMAND: git reset --hard HEAD@{1}\nEXPLANATION: Move back to the previous HEAD\nWARNING: Uncommitted changes will be lost"
+	result := ParseResponse(response)
+
+	if result.Warning != "Uncommitted changes will be lost" {
+		t.Errorf("warning: got %q", result.Warning)
+	}
+	if result.Command != "git reset --hard HEAD@{1}" {
+		t.Errorf("command: got %q", result.Command)
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	response := `STEP: git fetch origin
+EXPLANATION: Download the latest commits
+STEP: ` + "`git rebase origin/main`" + `
+EXPLANATION: Replay your commits on top
+STEP: git push --force-with-lease`
+
+	steps := ParseSteps(response)
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(steps))
+	}
+	if steps[1].Command != "git rebase origin/main" {
+		t.Errorf("step 2 command: got %q", steps[1].Command)
+	}
+	if steps[0].Explanation != "Download the latest commits" {
+		t.Errorf("step 1 explanation: got %q", steps[0].Explanation)
+	}
+	if steps[2].Explanation != "" {
+		t.Errorf("step 3 explanation: got %q, want empty", steps[2].Explanation)
+	}
+}
+
+func TestParseAlternatives(t *testing.T) {
+	response := `COMMAND: ` + "`find . -size +100M`" + `
+EXPLANATION: List files over 100 MB
+COMMAND: du -ah . | sort -rh | head
+EXPLANATION: Show the largest files and directories
+WARNING: Slow on big trees
+COMMAND:`
+
+	alts := ParseAlternatives(response)
+	if len(alts) != 2 {
+		t.Fatalf("expected 2 alternatives, got %d: %+v", len(alts), alts)
+	}
+	if alts[0].Command != "find . -size +100M" || alts[0].Explanation != "List files over 100 MB" {
+		t.Errorf("alternative 1: got %+v", alts[0])
+	}
+	if alts[1].Warning != "Slow on big trees" {
+		t.Errorf("alternative 2 warning: got %q", alts[1].Warning)
+	}
+}
+
+func TestIsRefusal(t *testing.T) {
+	for _, cmd := range []string{"I can't help with that.", "I’m sorry, but I cannot do that", "Unfortunately there is no command"} {
+		if !IsRefusal(cmd) {
+			t.Errorf("expected %q to be a refusal", cmd)
+		}
+	}
+	for _, cmd := range []string{"ls -la", "id -u", "ip addr"} {
+		if IsRefusal(cmd) {
+			t.Errorf("expected %q not to be a refusal", cmd)
+		}
+	}
+}
+
+func TestReasoning(t *testing.T) {
+	got := Reasoning("COMMAND: I can't do that.\nEXPLANATION: It would wipe the disk.\n\n")
+	want := "I can't do that.\nIt would wipe the disk."
+	if got != want {
+		t.Errorf("Reasoning() = %q, want %q", got, want)
+	}
+}
+
+func TestRunLocalShellHistory(t *testing.T) {
+	orig := ShellHistory
+	t.Cleanup(func() { ShellHistory = orig })
+	t.Setenv("SHELL", "/bin/bash")
+
+	cases := []struct {
+		mode, command string
+		want          bool
+	}{
+		{config.HistoryOnSuccess, "true", true},
+		{config.HistoryOnSuccess, "false", false},
+		{config.HistoryOnAccept, "false", true},
+		{config.HistoryNever, "true", false},
+	}
+	for _, tc := range cases {
+		histFile := filepath.Join(t.TempDir(), "history")
+		if err := os.WriteFile(histFile, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("HISTFILE", histFile)
+		ShellHistory = tc.mode
+
+		_, _ = runLocal(exec.Command("sh", "-c", tc.command), tc.command)
+		data, _ := os.ReadFile(histFile)
+		if got := strings.Contains(string(data), tc.command+"\n"); got != tc.want {
+			t.Errorf("%s with %q: recorded = %v, want %v", tc.mode, tc.command, got, tc.want)
+		}
+	}
+}
+
+func TestAddToShellHistoryBashTimestamps(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "bash_history")
+	if err := os.WriteFile(histFile, []byte("#1700000000\nls -la\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("HISTFILE", histFile)
+	t.Setenv("HISTTIMEFORMAT", "")
+
+	addToShellHistory("git status", time.Unix(1700000100, 0), time.Second)
+
+	data, err := os.ReadFile(histFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#1700000000\nls -la\n#1700000100\ngit status\n"; string(data) != want {
+		t.Errorf("history = %q, want %q", data, want)
+	}
+
+	plain := filepath.Join(t.TempDir(), "plain_history")
+	if err := os.WriteFile(plain, []byte("ls -la\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if isBashTimestampedHistory(plain) {
+		t.Error("expected plain history without HISTTIMEFORMAT to have no timestamps")
+	}
+	t.Setenv("HISTTIMEFORMAT", "%F %T ")
+	if !isBashTimestampedHistory(plain) {
+		t.Error("expected HISTTIMEFORMAT to turn on timestamps")
+	}
+}
+
+func TestHistoryIgnores(t *testing.T) {
+	histFile := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(histFile, []byte("#1700000000\ngit status\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{"HISTCONTROL", "HISTIGNORE", "HISTORY_IGNORE"} {
+		t.Setenv(env, "")
+	}
+
+	if historyIgnores(histFile, "git status") {
+		t.Error("expected duplicates to be kept without ignoredups")
+	}
+	t.Setenv("HISTCONTROL", "ignoreboth")
+	if !historyIgnores(histFile, "git status") {
+		t.Error("expected a duplicate of the last entry to be ignored")
+	}
+	if !historyIgnores(histFile, " secret-command") {
+		t.Error("expected a space-prefixed command to be ignored")
+	}
+	if historyIgnores(histFile, "git log") {
+		t.Error("expected a new command to be kept")
+	}
+
+	t.Setenv("HISTIGNORE", "ls:cd *")
+	if !historyIgnores(histFile, "cd /tmp/build") || historyIgnores(histFile, "lsof -i") {
+		t.Error("expected HISTIGNORE patterns to match whole commands")
+	}
+	t.Setenv("HISTIGNORE", "")
+	t.Setenv("HISTORY_IGNORE", "(pwd|rm *)")
+	if !historyIgnores(histFile, "rm -rf build/") {
+		t.Error("expected HISTORY_IGNORE alternatives to match")
+	}
+
+	orig := SpacePrefix
+	t.Cleanup(func() { SpacePrefix = orig })
+	SpacePrefix = true
+	if !historyIgnores(histFile, "git log") {
+		t.Error("expected space_prefix to keep every command out of history")
+	}
+}
+
+func TestAddToShellHistoryNushell(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("SHELL", "/usr/bin/nu")
+	dir := filepath.Join(configHome, "nushell")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	txt := filepath.Join(dir, "history.txt")
+	if err := os.WriteFile(txt, []byte("ls\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	addToShellHistory("ls | where size > 1mb", time.Now(), 0)
+	if data, _ := os.ReadFile(txt); string(data) != "ls\nls | where size > 1mb\n" {
+		t.Errorf("history.txt = %q", data)
+	}
+
+	path := filepath.Join(dir, "history.sqlite3")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close() //nolint:errcheck
+	if _, err := db.Exec(`CREATE TABLE history (id INTEGER PRIMARY KEY AUTOINCREMENT, command_line TEXT NOT NULL, start_timestamp INTEGER, session_id INTEGER, hostname TEXT, cwd TEXT, duration_ms INTEGER, exit_status INTEGER, more_info TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	addToShellHistory("ps | where cpu > 10", time.UnixMilli(1700000000123), 1500*time.Millisecond)
+	var command string
+	var start, duration int64
+	if err := db.QueryRow(`SELECT command_line, start_timestamp, duration_ms FROM history`).Scan(&command, &start, &duration); err != nil {
+		t.Fatal(err)
+	}
+	if command != "ps | where cpu > 10" || start != 1700000000123 || duration != 1500 {
+		t.Errorf("history row = %q, %d, %d", command, start, duration)
+	}
+}
+
+func TestAddToShellHistoryPowerShell(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("SHELL", "/usr/local/bin/pwsh")
+	histFile := filepath.Join(dataHome, "powershell", "PSReadLine", "ConsoleHost_history.txt")
+	if err := os.MkdirAll(filepath.Dir(histFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(histFile, []byte("Get-Date\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	addToShellHistory("Get-ChildItem |\n  Sort-Object Length", time.Now(), 0)
+	if data, _ := os.ReadFile(histFile); string(data) != "Get-Date\nGet-ChildItem |`\n  Sort-Object Length\n" {
+		t.Errorf("PSReadLine history = %q", data)
+	}
+}
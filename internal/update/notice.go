@@ -0,0 +1,62 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckInterval is how often the background check queries GitHub.
+var CheckInterval = 7 * 24 * time.Hour
+
+const stateFile = "update-check.json"
+
+type state struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+func readState(dir string) state {
+	var s state
+	if data, err := os.ReadFile(filepath.Join(dir, stateFile)); err == nil {
+		_ = json.Unmarshal(data, &s)
+	}
+	return s
+}
+
+// Due reports whether the last background check in dir is older than
+// CheckInterval.
+func Due(dir string) bool {
+	return time.Since(readState(dir).CheckedAt) >= CheckInterval
+}
+
+// Refresh fetches the latest release and records it in dir for Available.
+// The check time is recorded even on failure so that an offline machine
+// doesn't retry on every run.
+func (c *Client) Refresh(ctx context.Context, dir string) error {
+	s := readState(dir)
+	s.CheckedAt = time.Now()
+	rel, err := c.Latest(ctx)
+	if err == nil {
+		s.Latest = rel.Version()
+	}
+
+	data, _ := json.Marshal(s)
+	_ = os.MkdirAll(dir, 0o755)
+	if werr := os.WriteFile(filepath.Join(dir, stateFile), data, 0o600); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+// Available returns the newer version recorded by the last Refresh, or ""
+// if current is up to date.
+func Available(dir, current string) string {
+	latest := readState(dir).Latest
+	if Newer(latest, current) {
+		return latest
+	}
+	return ""
+}
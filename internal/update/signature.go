@@ -0,0 +1,56 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PublicKey is the minisign public key release checksums are signed with.
+// It is set at build time via -ldflags "-X .../internal/update.PublicKey=...";
+// builds without it refuse to upgrade rather than trust an unsigned release.
+var PublicKey = ""
+
+// verifySignature checks a minisign signature over data with the base64
+// minisign public key pub. Only the non-prehashed "Ed" algorithm is
+// accepted, so releases must be signed with `minisign -S -l`.
+func verifySignature(pub string, data, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(pub))
+	if err != nil || len(key) != 2+8+ed25519.PublicKeySize || string(key[:2]) != "Ed" {
+		return fmt.Errorf("invalid release signing key")
+	}
+	keyID, pk := key[2:10], ed25519.PublicKey(key[10:])
+
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed signature file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature file")
+	}
+	if string(raw[:2]) != "Ed" {
+		return fmt.Errorf("unsupported signature algorithm %q", raw[:2])
+	}
+	if !bytes.Equal(raw[2:10], keyID) {
+		return fmt.Errorf("signature was made with a different key")
+	}
+	signature := raw[10:]
+	if !ed25519.Verify(pk, data, signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	// The global signature covers the trusted comment, so it can't be
+	// edited without detection either.
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature file")
+	}
+	comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pk, append(append([]byte{}, signature...), comment...), global) {
+		return fmt.Errorf("trusted comment signature verification failed")
+	}
+	return nil
+}
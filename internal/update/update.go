@@ -0,0 +1,288 @@
+// Package update checks GitHub releases for newer versions of how and
+// replaces the running binary.
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPI is the GitHub releases endpoint for how.
+	DefaultAPI = "https://api.github.com/repos/swibrow/how/releases/latest"
+
+	maxArchiveBytes = 100 << 20
+)
+
+// Release is the subset of a GitHub release used for upgrading.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release tag without its leading "v".
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Client fetches releases and their assets. Key is the minisign public
+// key the release checksums must be signed with.
+type Client struct {
+	API  string
+	HTTP *http.Client
+	Key  string
+}
+
+// NewClient returns a client for the public GitHub API that trusts the
+// signing key embedded at build time.
+func NewClient() *Client {
+	return &Client{API: DefaultAPI, HTTP: &http.Client{Timeout: 30 * time.Second}, Key: PublicKey}
+}
+
+// Latest returns the most recent published release.
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	body, err := c.get(ctx, c.API, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("fetching latest release: %w", err)
+	}
+	var rel Release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return nil, fmt.Errorf("decoding release: %w", err)
+	}
+	if rel.Tag == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+	return &rel, nil
+}
+
+// Download fetches the archive for the current platform from rel, verifies
+// the minisign signature over the release's checksums file, checks the
+// archive against it and returns the binary inside.
+func (c *Client) Download(ctx context.Context, rel *Release) ([]byte, error) {
+	if c.Key == "" {
+		return nil, fmt.Errorf("this build has no release signing key; download how from https://github.com/swibrow/how/releases instead")
+	}
+	name := ArchiveName(rel.Version(), runtime.GOOS, runtime.GOARCH)
+	archive, ok := rel.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no archive for %s/%s", rel.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	sumsName := fmt.Sprintf("how_%s_checksums.txt", rel.Version())
+	sums, ok := rel.asset(sumsName)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no checksums file", rel.Tag)
+	}
+	sig, ok := rel.asset(sumsName + ".minisig")
+	if !ok {
+		return nil, fmt.Errorf("release %s has no checksums signature", rel.Tag)
+	}
+
+	sumData, err := c.get(ctx, sums.URL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("fetching checksums: %w", err)
+	}
+	sigData, err := c.get(ctx, sig.URL, 1<<16)
+	if err != nil {
+		return nil, fmt.Errorf("fetching checksums signature: %w", err)
+	}
+	if err := verifySignature(c.Key, sumData, sigData); err != nil {
+		return nil, fmt.Errorf("verifying %s: %w", sumsName, err)
+	}
+	want, err := checksumFor(sumData, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.get(ctx, archive.URL, maxArchiveBytes)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", name, err)
+	}
+	if got := sha256.Sum256(data); hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch for %s", name)
+	}
+
+	return extractBinary(data, name)
+}
+
+func (c *Client) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ArchiveName returns the release archive name for a platform, matching
+// the goreleaser name template.
+func ArchiveName(version, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("how_%s_%s_%s.%s", version, goos, goarch, ext)
+}
+
+// checksumFor finds name in a sha256sum-style checksums file.
+func checksumFor(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// extractBinary returns the how executable from a release archive.
+func extractBinary(data []byte, name string) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == "how.exe" {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close() //nolint:errcheck
+				return io.ReadAll(io.LimitReader(rc, maxArchiveBytes))
+			}
+		}
+		return nil, fmt.Errorf("how.exe not found in %s", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == "how" {
+			return io.ReadAll(io.LimitReader(tr, maxArchiveBytes))
+		}
+	}
+	return nil, fmt.Errorf("how binary not found in %s", name)
+}
+
+// Replace atomically swaps the executable at path for binary, keeping its
+// file mode.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".how-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close() //nolint:errcheck
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// Windows cannot overwrite a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("moving old binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing binary: %w", err)
+	}
+	return nil
+}
+
+// Newer reports whether version a is newer than b. Versions are compared as
+// dot-separated numbers; a non-numeric b (e.g. "dev") is never older.
+func Newer(a, b string) bool {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range 3 {
+		if pa[i] != pb[i] {
+			return pa[i] > pb[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
@@ -0,0 +1,258 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.10.0", "1.9.0", true},
+		{"1.2.0", "1.2.0", false},
+		{"1.1.0", "1.2.0", false},
+		{"2.0.0", "dev", false},
+		{"", "1.0.0", false},
+		{"1.2.1-rc1", "1.2.0", true},
+	}
+	for _, tc := range cases {
+		if got := Newer(tc.a, tc.b); got != tc.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	if got := ArchiveName("1.2.3", "linux", "amd64"); got != "how_1.2.3_linux_amd64.tar.gz" {
+		t.Errorf("unexpected linux archive name: %s", got)
+	}
+	if got := ArchiveName("1.2.3", "windows", "arm64"); got != "how_1.2.3_windows_arm64.zip" {
+		t.Errorf("unexpected windows archive name: %s", got)
+	}
+}
+
+func archive(t *testing.T, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if runtime.GOOS == "windows" {
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("how.exe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(binary)
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0o644, Size: 2, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("hi"))
+	_ = tw.WriteHeader(&tar.Header{Name: "how", Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(binary)
+	_ = tw.Close()
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+// signer is a minisign-style key pair for signing fake releases.
+type signer struct {
+	pub  string
+	priv ed25519.PrivateKey
+}
+
+func newSigner(t *testing.T) signer {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := append([]byte("Ed12345678"), pub...)
+	return signer{pub: base64.StdEncoding.EncodeToString(key), priv: priv}
+}
+
+// sign returns a legacy minisign signature file for data.
+func (s signer) sign(data []byte) string {
+	sig := ed25519.Sign(s.priv, data)
+	comment := "timestamp:1700000000\tfile:checksums.txt"
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), comment...))
+	return fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append([]byte("Ed12345678"), sig...)), comment,
+		base64.StdEncoding.EncodeToString(global))
+}
+
+// releaseServer serves a fake release for version with the given archive,
+// its checksums signed by key. If badSum is true the checksums file lists
+// the wrong digest.
+func releaseServer(t *testing.T, key signer, version string, data []byte, badSum bool) *httptest.Server {
+	t.Helper()
+	name := ArchiveName(version, runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(data)
+	if badSum {
+		sum = sha256.Sum256([]byte("tampered"))
+	}
+	sums := fmt.Sprintf("%s  other_file.tar.gz\n%s  %s\n", strings.Repeat("0", 64), hex.EncodeToString(sum[:]), name)
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Release{
+			Tag: "v" + version,
+			Assets: []Asset{
+				{Name: name, URL: srv.URL + "/archive"},
+				{Name: "how_" + version + "_checksums.txt", URL: srv.URL + "/sums"},
+				{Name: "how_" + version + "_checksums.txt.minisig", URL: srv.URL + "/sig"},
+			},
+		})
+	})
+	mux.HandleFunc("/archive", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sums))
+	})
+	mux.HandleFunc("/sig", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(key.sign([]byte(sums))))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho new\n")
+	key := newSigner(t)
+	srv := releaseServer(t, key, "1.2.3", archive(t, binary), false)
+	c := &Client{API: srv.URL + "/latest", HTTP: srv.Client(), Key: key.pub}
+
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version() != "1.2.3" {
+		t.Errorf("expected version 1.2.3, got %s", rel.Version())
+	}
+
+	got, err := c.Download(context.Background(), rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Errorf("unexpected binary contents: %q", got)
+	}
+}
+
+func TestDownloadRejectsChecksumMismatch(t *testing.T) {
+	key := newSigner(t)
+	srv := releaseServer(t, key, "1.2.3", archive(t, []byte("evil")), true)
+	c := &Client{API: srv.URL + "/latest", HTTP: srv.Client(), Key: key.pub}
+
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Download(context.Background(), rel); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got: %v", err)
+	}
+}
+
+func TestDownloadRejectsBadSignature(t *testing.T) {
+	srv := releaseServer(t, newSigner(t), "1.2.3", archive(t, []byte("evil")), false)
+	c := &Client{API: srv.URL + "/latest", HTTP: srv.Client(), Key: newSigner(t).pub}
+
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Download(context.Background(), rel); err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+		t.Errorf("expected signature failure, got: %v", err)
+	}
+}
+
+func TestDownloadRequiresKey(t *testing.T) {
+	srv := releaseServer(t, newSigner(t), "1.2.3", archive(t, []byte("new")), false)
+	c := &Client{API: srv.URL + "/latest", HTTP: srv.Client()}
+
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Download(context.Background(), rel); err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Errorf("expected missing key error, got: %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "how")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("expected new contents, got %q", data)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+		t.Errorf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+}
+
+func TestRefreshAndAvailable(t *testing.T) {
+	srv := releaseServer(t, newSigner(t), "1.3.0", nil, false)
+	c := &Client{API: srv.URL + "/latest", HTTP: srv.Client()}
+	dir := t.TempDir()
+
+	if !Due(dir) {
+		t.Error("expected check to be due with no state")
+	}
+	if err := c.Refresh(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if Due(dir) {
+		t.Error("expected check not to be due right after refresh")
+	}
+	if got := Available(dir, "1.2.0"); got != "1.3.0" {
+		t.Errorf("expected 1.3.0 available, got %q", got)
+	}
+	if got := Available(dir, "1.3.0"); got != "" {
+		t.Errorf("expected no update for current version, got %q", got)
+	}
+}
+
+func TestRefreshRecordsFailedCheck(t *testing.T) {
+	c := &Client{API: "http://127.0.0.1:1/latest", HTTP: &http.Client{Timeout: time.Second}}
+	dir := t.TempDir()
+
+	if err := c.Refresh(context.Background(), dir); err == nil {
+		t.Error("expected error from unreachable API")
+	}
+	if Due(dir) {
+		t.Error("expected failed check to be recorded")
+	}
+}
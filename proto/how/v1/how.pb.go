@@ -0,0 +1,525 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: how/v1/how.proto
+
+// The how gRPC API, served by `how serve --grpc-listen`. Send the bearer
+// token for your tenant as "authorization: Bearer <token>" metadata.
+
+package howv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Danger is how much harm a command can do if it's wrong.
+type Danger int32
+
+const (
+	Danger_DANGER_UNSPECIFIED Danger = 0
+	// Safe commands only read state.
+	Danger_DANGER_SAFE Danger = 1
+	// Caution commands change state in ways that can be undone.
+	Danger_DANGER_CAUTION Danger = 2
+	// Dangerous commands can destroy data or are hard to undo.
+	Danger_DANGER_DANGEROUS Danger = 3
+)
+
+// Enum value maps for Danger.
+var (
+	Danger_name = map[int32]string{
+		0: "DANGER_UNSPECIFIED",
+		1: "DANGER_SAFE",
+		2: "DANGER_CAUTION",
+		3: "DANGER_DANGEROUS",
+	}
+	Danger_value = map[string]int32{
+		"DANGER_UNSPECIFIED": 0,
+		"DANGER_SAFE":        1,
+		"DANGER_CAUTION":     2,
+		"DANGER_DANGEROUS":   3,
+	}
+)
+
+func (x Danger) Enum() *Danger {
+	p := new(Danger)
+	*p = x
+	return p
+}
+
+func (x Danger) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Danger) Descriptor() protoreflect.EnumDescriptor {
+	return file_how_v1_how_proto_enumTypes[0].Descriptor()
+}
+
+func (Danger) Type() protoreflect.EnumType {
+	return &file_how_v1_how_proto_enumTypes[0]
+}
+
+func (x Danger) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Danger.Descriptor instead.
+func (Danger) EnumDescriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{0}
+}
+
+type SuggestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestRequest) Reset() {
+	*x = SuggestRequest{}
+	mi := &file_how_v1_how_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestRequest) ProtoMessage() {}
+
+func (x *SuggestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestRequest.ProtoReflect.Descriptor instead.
+func (*SuggestRequest) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SuggestRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type SuggestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Answer        *Answer                `protobuf:"bytes,1,opt,name=answer,proto3" json:"answer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestResponse) Reset() {
+	*x = SuggestResponse{}
+	mi := &file_how_v1_how_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestResponse) ProtoMessage() {}
+
+func (x *SuggestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestResponse.ProtoReflect.Descriptor instead.
+func (*SuggestResponse) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SuggestResponse) GetAnswer() *Answer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+type ExplainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainRequest) Reset() {
+	*x = ExplainRequest{}
+	mi := &file_how_v1_how_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainRequest) ProtoMessage() {}
+
+func (x *ExplainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainRequest.ProtoReflect.Descriptor instead.
+func (*ExplainRequest) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExplainRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type ExplainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Answer        *Answer                `protobuf:"bytes,1,opt,name=answer,proto3" json:"answer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainResponse) Reset() {
+	*x = ExplainResponse{}
+	mi := &file_how_v1_how_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainResponse) ProtoMessage() {}
+
+func (x *ExplainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainResponse.ProtoReflect.Descriptor instead.
+func (*ExplainResponse) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExplainResponse) GetAnswer() *Answer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+type FixRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Command string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// The failed command's error output, if any.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FixRequest) Reset() {
+	*x = FixRequest{}
+	mi := &file_how_v1_how_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FixRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FixRequest) ProtoMessage() {}
+
+func (x *FixRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FixRequest.ProtoReflect.Descriptor instead.
+func (*FixRequest) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FixRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *FixRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type FixResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Answer        *Answer                `protobuf:"bytes,1,opt,name=answer,proto3" json:"answer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FixResponse) Reset() {
+	*x = FixResponse{}
+	mi := &file_how_v1_how_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FixResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FixResponse) ProtoMessage() {}
+
+func (x *FixResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FixResponse.ProtoReflect.Descriptor instead.
+func (*FixResponse) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FixResponse) GetAnswer() *Answer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+// Answer is a command with its explanation and risk.
+type Answer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Explanation   string                 `protobuf:"bytes,2,opt,name=explanation,proto3" json:"explanation,omitempty"`
+	Warning       string                 `protobuf:"bytes,3,opt,name=warning,proto3" json:"warning,omitempty"`
+	Danger        Danger                 `protobuf:"varint,4,opt,name=danger,proto3,enum=how.v1.Danger" json:"danger,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Answer) Reset() {
+	*x = Answer{}
+	mi := &file_how_v1_how_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Answer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Answer) ProtoMessage() {}
+
+func (x *Answer) ProtoReflect() protoreflect.Message {
+	mi := &file_how_v1_how_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Answer.ProtoReflect.Descriptor instead.
+func (*Answer) Descriptor() ([]byte, []int) {
+	return file_how_v1_how_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Answer) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *Answer) GetExplanation() string {
+	if x != nil {
+		return x.Explanation
+	}
+	return ""
+}
+
+func (x *Answer) GetWarning() string {
+	if x != nil {
+		return x.Warning
+	}
+	return ""
+}
+
+func (x *Answer) GetDanger() Danger {
+	if x != nil {
+		return x.Danger
+	}
+	return Danger_DANGER_UNSPECIFIED
+}
+
+var File_how_v1_how_proto protoreflect.FileDescriptor
+
+const file_how_v1_how_proto_rawDesc = "" +
+	"\n" +
+	"\x10how/v1/how.proto\x12\x06how.v1\"&\n" +
+	"\x0eSuggestRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\"9\n" +
+	"\x0fSuggestResponse\x12&\n" +
+	"\x06answer\x18\x01 \x01(\v2\x0e.how.v1.AnswerR\x06answer\"*\n" +
+	"\x0eExplainRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\"9\n" +
+	"\x0fExplainResponse\x12&\n" +
+	"\x06answer\x18\x01 \x01(\v2\x0e.how.v1.AnswerR\x06answer\"<\n" +
+	"\n" +
+	"FixRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"5\n" +
+	"\vFixResponse\x12&\n" +
+	"\x06answer\x18\x01 \x01(\v2\x0e.how.v1.AnswerR\x06answer\"\x86\x01\n" +
+	"\x06Answer\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12 \n" +
+	"\vexplanation\x18\x02 \x01(\tR\vexplanation\x12\x18\n" +
+	"\awarning\x18\x03 \x01(\tR\awarning\x12&\n" +
+	"\x06danger\x18\x04 \x01(\x0e2\x0e.how.v1.DangerR\x06danger*[\n" +
+	"\x06Danger\x12\x16\n" +
+	"\x12DANGER_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vDANGER_SAFE\x10\x01\x12\x12\n" +
+	"\x0eDANGER_CAUTION\x10\x02\x12\x14\n" +
+	"\x10DANGER_DANGEROUS\x10\x032\xb4\x01\n" +
+	"\n" +
+	"HowService\x12:\n" +
+	"\aSuggest\x12\x16.how.v1.SuggestRequest\x1a\x17.how.v1.SuggestResponse\x12:\n" +
+	"\aExplain\x12\x16.how.v1.ExplainRequest\x1a\x17.how.v1.ExplainResponse\x12.\n" +
+	"\x03Fix\x12\x12.how.v1.FixRequest\x1a\x13.how.v1.FixResponseB+Z)github.com/swibrow/how/proto/how/v1;howv1b\x06proto3"
+
+var (
+	file_how_v1_how_proto_rawDescOnce sync.Once
+	file_how_v1_how_proto_rawDescData []byte
+)
+
+func file_how_v1_how_proto_rawDescGZIP() []byte {
+	file_how_v1_how_proto_rawDescOnce.Do(func() {
+		file_how_v1_how_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_how_v1_how_proto_rawDesc), len(file_how_v1_how_proto_rawDesc)))
+	})
+	return file_how_v1_how_proto_rawDescData
+}
+
+var file_how_v1_how_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_how_v1_how_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_how_v1_how_proto_goTypes = []any{
+	(Danger)(0),             // 0: how.v1.Danger
+	(*SuggestRequest)(nil),  // 1: how.v1.SuggestRequest
+	(*SuggestResponse)(nil), // 2: how.v1.SuggestResponse
+	(*ExplainRequest)(nil),  // 3: how.v1.ExplainRequest
+	(*ExplainResponse)(nil), // 4: how.v1.ExplainResponse
+	(*FixRequest)(nil),      // 5: how.v1.FixRequest
+	(*FixResponse)(nil),     // 6: how.v1.FixResponse
+	(*Answer)(nil),          // 7: how.v1.Answer
+}
+var file_how_v1_how_proto_depIdxs = []int32{
+	7, // 0: how.v1.SuggestResponse.answer:type_name -> how.v1.Answer
+	7, // 1: how.v1.ExplainResponse.answer:type_name -> how.v1.Answer
+	7, // 2: how.v1.FixResponse.answer:type_name -> how.v1.Answer
+	0, // 3: how.v1.Answer.danger:type_name -> how.v1.Danger
+	1, // 4: how.v1.HowService.Suggest:input_type -> how.v1.SuggestRequest
+	3, // 5: how.v1.HowService.Explain:input_type -> how.v1.ExplainRequest
+	5, // 6: how.v1.HowService.Fix:input_type -> how.v1.FixRequest
+	2, // 7: how.v1.HowService.Suggest:output_type -> how.v1.SuggestResponse
+	4, // 8: how.v1.HowService.Explain:output_type -> how.v1.ExplainResponse
+	6, // 9: how.v1.HowService.Fix:output_type -> how.v1.FixResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_how_v1_how_proto_init() }
+func file_how_v1_how_proto_init() {
+	if File_how_v1_how_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_how_v1_how_proto_rawDesc), len(file_how_v1_how_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_how_v1_how_proto_goTypes,
+		DependencyIndexes: file_how_v1_how_proto_depIdxs,
+		EnumInfos:         file_how_v1_how_proto_enumTypes,
+		MessageInfos:      file_how_v1_how_proto_msgTypes,
+	}.Build()
+	File_how_v1_how_proto = out.File
+	file_how_v1_how_proto_goTypes = nil
+	file_how_v1_how_proto_depIdxs = nil
+}
@@ -0,0 +1,206 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: how/v1/how.proto
+
+// The how gRPC API, served by `how serve --grpc-listen`. Send the bearer
+// token for your tenant as "authorization: Bearer <token>" metadata.
+
+package howv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	HowService_Suggest_FullMethodName = "/how.v1.HowService/Suggest"
+	HowService_Explain_FullMethodName = "/how.v1.HowService/Explain"
+	HowService_Fix_FullMethodName     = "/how.v1.HowService/Fix"
+)
+
+// HowServiceClient is the client API for HowService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HowServiceClient interface {
+	// Suggest answers a natural-language question with a shell command.
+	Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestResponse, error)
+	// Explain describes what a command does.
+	Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error)
+	// Fix corrects a command that failed, given its error output.
+	Fix(ctx context.Context, in *FixRequest, opts ...grpc.CallOption) (*FixResponse, error)
+}
+
+type howServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHowServiceClient(cc grpc.ClientConnInterface) HowServiceClient {
+	return &howServiceClient{cc}
+}
+
+func (c *howServiceClient) Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestResponse)
+	err := c.cc.Invoke(ctx, HowService_Suggest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *howServiceClient) Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExplainResponse)
+	err := c.cc.Invoke(ctx, HowService_Explain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *howServiceClient) Fix(ctx context.Context, in *FixRequest, opts ...grpc.CallOption) (*FixResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FixResponse)
+	err := c.cc.Invoke(ctx, HowService_Fix_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HowServiceServer is the server API for HowService service.
+// All implementations must embed UnimplementedHowServiceServer
+// for forward compatibility.
+type HowServiceServer interface {
+	// Suggest answers a natural-language question with a shell command.
+	Suggest(context.Context, *SuggestRequest) (*SuggestResponse, error)
+	// Explain describes what a command does.
+	Explain(context.Context, *ExplainRequest) (*ExplainResponse, error)
+	// Fix corrects a command that failed, given its error output.
+	Fix(context.Context, *FixRequest) (*FixResponse, error)
+	mustEmbedUnimplementedHowServiceServer()
+}
+
+// UnimplementedHowServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHowServiceServer struct{}
+
+func (UnimplementedHowServiceServer) Suggest(context.Context, *SuggestRequest) (*SuggestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Suggest not implemented")
+}
+func (UnimplementedHowServiceServer) Explain(context.Context, *ExplainRequest) (*ExplainResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Explain not implemented")
+}
+func (UnimplementedHowServiceServer) Fix(context.Context, *FixRequest) (*FixResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Fix not implemented")
+}
+func (UnimplementedHowServiceServer) mustEmbedUnimplementedHowServiceServer() {}
+func (UnimplementedHowServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeHowServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HowServiceServer will
+// result in compilation errors.
+type UnsafeHowServiceServer interface {
+	mustEmbedUnimplementedHowServiceServer()
+}
+
+func RegisterHowServiceServer(s grpc.ServiceRegistrar, srv HowServiceServer) {
+	// If the following call panics, it indicates UnimplementedHowServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&HowService_ServiceDesc, srv)
+}
+
+func _HowService_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HowServiceServer).Suggest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HowService_Suggest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HowServiceServer).Suggest(ctx, req.(*SuggestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HowService_Explain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HowServiceServer).Explain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HowService_Explain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HowServiceServer).Explain(ctx, req.(*ExplainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HowService_Fix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HowServiceServer).Fix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HowService_Fix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HowServiceServer).Fix(ctx, req.(*FixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HowService_ServiceDesc is the grpc.ServiceDesc for HowService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HowService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "how.v1.HowService",
+	HandlerType: (*HowServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Suggest",
+			Handler:    _HowService_Suggest_Handler,
+		},
+		{
+			MethodName: "Explain",
+			Handler:    _HowService_Explain_Handler,
+		},
+		{
+			MethodName: "Fix",
+			Handler:    _HowService_Fix_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "how/v1/how.proto",
+}
@@ -0,0 +1,92 @@
+package risk
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/swibrow/how/internal/shell"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// shells run the script passed to -c.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// maxNesting bounds how deep calls follows sh -c scripts.
+const maxNesting = 4
+
+// calls returns each simple command in command as text, without wrappers
+// such as sudo, env or timeout, including those in subshells and command
+// substitutions, scripts passed to sh -c and the commands find -exec
+// runs. Rules are matched against these as well as the whole command, so
+// wrapping or nesting a command doesn't hide it. Commands that can't be
+// parsed have none.
+func calls(command string) []string {
+	var out []string
+	eachCall(command, 0, func(args []string) {
+		words := make([]string, len(args))
+		for i, a := range args {
+			words[i] = a
+			if a == "" || strings.ContainsAny(a, " \t\n;&|<>()$`'\"\\*?") {
+				words[i] = shell.Quote(a)
+			}
+		}
+		out = append(out, strings.Join(words, " "))
+	})
+	return out
+}
+
+// eachCall calls fn with the arguments of each simple command in command,
+// as described for calls.
+func eachCall(command string, depth int, fn func(args []string)) {
+	src := maskRe.ReplaceAllString(command, "HOWPH_${1}_HOWPH")
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, w := range call.Args {
+			args[i] = unmaskRe.ReplaceAllString(wordText(w), "<$1>")
+		}
+		eachArgs(args, depth, fn)
+		return true
+	})
+}
+
+// eachArgs calls fn with the simple command args, and any command it runs.
+func eachArgs(args []string, depth int, fn func(args []string)) {
+	args = shell.Unwrap(args)
+	if len(args) == 0 {
+		return
+	}
+	// /bin/rm and \rm, which skips aliases, run rm all the same
+	args = append([]string{filepath.Base(strings.TrimPrefix(args[0], `\`))}, args[1:]...)
+	fn(args)
+
+	switch name := args[0]; {
+	case shells[name] && depth < maxNesting:
+		for i := 1; i < len(args)-1; i++ {
+			a := args[i]
+			if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "c") {
+				eachCall(args[i+1], depth+1, fn)
+				return
+			}
+		}
+	case name == "find":
+		for i := 1; i < len(args); i++ {
+			switch args[i] {
+			case "-exec", "-execdir", "-ok", "-okdir":
+				j := i + 1
+				for j < len(args) && args[j] != ";" && args[j] != `\;` && args[j] != "+" {
+					j++
+				}
+				eachArgs(args[i+1:j], depth, fn)
+				i = j
+			}
+		}
+	}
+}
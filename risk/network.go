@@ -0,0 +1,356 @@
+package risk
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/swibrow/how/internal/shell"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Destination is a host a command would contact and the tools that
+// contact it.
+type Destination struct {
+	Host  string
+	Tools []string
+}
+
+func (d Destination) String() string {
+	return d.Host + " (" + strings.Join(d.Tools, ", ") + ")"
+}
+
+// registries maps package managers to the subcommands that download and
+// where they download from.
+var registries = map[string]struct {
+	subcommands []string
+	host        string
+}{
+	"apt":     {[]string{"install", "update", "upgrade", "full-upgrade", "dist-upgrade", "source", "download"}, "package repositories"},
+	"apt-get": {[]string{"install", "update", "upgrade", "dist-upgrade", "source", "download"}, "package repositories"},
+	"dnf":     {[]string{"install", "update", "upgrade", "makecache", "download"}, "package repositories"},
+	"yum":     {[]string{"install", "update", "upgrade", "makecache"}, "package repositories"},
+	"zypper":  {[]string{"install", "in", "update", "up", "refresh", "ref", "dup"}, "package repositories"},
+	"apk":     {[]string{"add", "update", "upgrade", "fetch"}, "package repositories"},
+	"brew":    {[]string{"install", "reinstall", "upgrade", "update", "tap", "fetch"}, "Homebrew"},
+	"snap":    {[]string{"install", "refresh"}, "snapcraft.io"},
+	"flatpak": {[]string{"install", "update", "remote-add"}, "Flatpak remotes"},
+	"pip":     {[]string{"install", "download"}, "pypi.org"},
+	"pip3":    {[]string{"install", "download"}, "pypi.org"},
+	"pipx":    {[]string{"install", "upgrade", "run"}, "pypi.org"},
+	"uv":      {[]string{"add", "sync", "pip"}, "pypi.org"},
+	"npm":     {[]string{"install", "i", "ci", "add", "update", "publish"}, "registry.npmjs.org"},
+	"npx":     {nil, "registry.npmjs.org"},
+	"yarn":    {[]string{"add", "install", "upgrade", "dlx"}, "registry.npmjs.org"},
+	"pnpm":    {[]string{"add", "install", "i", "update", "dlx"}, "registry.npmjs.org"},
+	"cargo":   {[]string{"install", "fetch", "update", "publish"}, "crates.io"},
+	"go":      {[]string{"get", "install"}, "proxy.golang.org"},
+	"gem":     {[]string{"install", "update", "push"}, "rubygems.org"},
+	"helm":    {[]string{"install", "upgrade", "pull", "repo"}, "chart repositories"},
+}
+
+// valueFlags lists the options that take a separate value, so the value
+// isn't mistaken for a host or path.
+var valueFlags = map[string]string{
+	"curl":  "-o -O -H -d -X -u -A -e -F -T -b -c -K -w -x -E --output --header --data --data-raw --data-binary --request --user --user-agent --referer --form --upload-file --cookie --cookie-jar --config --write-out --proxy --cert --key --cacert --connect-timeout --max-time --retry",
+	"wget":  "-O -o -a -P -U -e -t -T --output-document --output-file --directory-prefix --user-agent --header --user --password --tries --timeout",
+	"ssh":   "-b -c -D -E -e -F -I -i -J -L -l -m -O -o -p -Q -R -S -W -w",
+	"scp":   "-c -F -i -J -l -o -P -S",
+	"sftp":  "-B -b -c -D -F -i -J -l -o -P -R -S",
+	"mosh":  "-p --ssh",
+	"nc":    "-e -i -p -s -w -X -x",
+	"ncat":  "-e -i -p -s -w",
+	"ping":  "-c -i -I -s -t -W -w",
+	"dig":   "-b -c -f -k -p -q -t -x -y",
+	"rsync": "-e --rsh --exclude --include --filter --port",
+	"git":   "-C -c",
+	"grep":  "-e -f -m -A -B -C",
+	"rg":    "-e -f -g -m -A -B -C -t -T",
+	"sed":   "-e -f",
+	"perl":  "-e -E",
+	"awk":   "-f -v -F -i --include",
+	"gawk":  "-f -v -F -i --include",
+	"head":  "-n -c",
+	"tail":  "-n -c",
+	"sort":  "-o -k -t -S",
+	"cut":   "-d -f -c -b",
+	"cp":    "-t -S",
+	"mv":    "-t -S",
+	"chmod": "--reference",
+	"chown": "--reference",
+	"jq":    "--indent",
+	"yq":    "-o -I",
+}
+
+// hostTools take the host as their first operand.
+var hostTools = map[string]bool{
+	"ssh": true, "sftp": true, "mosh": true, "telnet": true, "nc": true, "ncat": true,
+	"ping": true, "ping6": true, "traceroute": true, "mtr": true, "dig": true, "host": true,
+	"nslookup": true, "whois": true,
+}
+
+// urlTools reach every URL or hostname among their operands.
+var urlTools = map[string]bool{
+	"curl": true, "wget": true, "http": true, "https": true, "xh": true, "aria2c": true,
+}
+
+var (
+	schemeRe   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+	hostnameRe = regexp.MustCompile(`^(?:[a-zA-Z0-9-]+\.)+[a-zA-Z]{2,}(?::[0-9]+)?(?:/.*)?$`)
+	// remotePathRe matches scp-style [user@]host:path operands
+	remotePathRe = regexp.MustCompile(`^(?:[^@/:\s]+@)?([^@/:\s]+):`)
+	maskRe       = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9_.-]*)>`)
+	unmaskRe     = regexp.MustCompile(`HOWPH_([A-Za-z0-9_.-]+?)_HOWPH`)
+)
+
+// Network statically finds the hosts command would contact: downloads,
+// remote shells and copies, git remotes, package installs and URLs passed
+// to any command. Hosts that come from variables are shown as written.
+// Commands that can't be parsed report nothing.
+func Network(command string) []Destination {
+	src := maskRe.ReplaceAllString(command, "HOWPH_${1}_HOWPH")
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return nil
+	}
+
+	var dests []Destination
+	index := map[string]int{}
+	add := func(host, tool string) {
+		host = unmaskRe.ReplaceAllString(host, "<$1>")
+		i, ok := index[host]
+		if !ok {
+			index[host] = len(dests)
+			dests = append(dests, Destination{Host: host, Tools: []string{tool}})
+			return
+		}
+		for _, t := range dests[i].Tools {
+			if t == tool {
+				return
+			}
+		}
+		dests[i].Tools = append(dests[i].Tools, tool)
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		args := make([]string, len(call.Args))
+		for i, w := range call.Args {
+			args[i] = wordText(w)
+		}
+		args = shell.Unwrap(args)
+		if len(args) == 0 {
+			return true
+		}
+		for _, d := range callDestinations(args) {
+			add(d[0], d[1])
+		}
+		return true
+	})
+	return dests
+}
+
+// callDestinations returns the (host, tool) pairs for one simple command.
+func callDestinations(args []string) [][2]string {
+	name := args[0]
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	operands := operands(name, args[1:])
+	var out [][2]string
+
+	switch {
+	case hostTools[name]:
+		if (name == "nc" || name == "ncat") && hasFlag(args[1:], "-l") {
+			return nil
+		}
+		if len(operands) > 0 {
+			out = append(out, [2]string{stripUser(operands[0]), name})
+		}
+		return out
+	case name == "scp" || name == "rsync":
+		for _, op := range operands {
+			if h := urlHost(op); h != "" {
+				out = append(out, [2]string{h, name})
+			} else if m := remotePathRe.FindStringSubmatch(op); m != nil {
+				out = append(out, [2]string{m[1], name})
+			}
+		}
+		return out
+	case urlTools[name]:
+		for _, op := range operands {
+			if h := urlHost(op); h != "" {
+				out = append(out, [2]string{h, name})
+			} else if hostnameRe.MatchString(op) {
+				out = append(out, [2]string{strings.SplitN(op, "/", 2)[0], name})
+			}
+		}
+		return out
+	case name == "git" && len(operands) > 0:
+		return gitDestinations(operands)
+	case name == "pacman":
+		for _, a := range args[1:] {
+			if strings.HasPrefix(a, "-S") && !strings.ContainsAny(a[2:], "sicgl") {
+				out = append(out, [2]string{"package repositories", name})
+				break
+			}
+		}
+	case name == "docker" || name == "podman":
+		if len(operands) > 1 && (operands[0] == "pull" || operands[0] == "push") {
+			out = append(out, [2]string{imageRegistry(operands[1]), name + " " + operands[0]})
+		}
+	}
+
+	if reg, ok := registries[name]; ok && (reg.subcommands == nil || downloads(name, operands, reg.subcommands)) {
+		out = append(out, [2]string{reg.host, name})
+	}
+	// URLs given to anything else, e.g. kubectl apply -f https://...
+	for _, op := range args[1:] {
+		if h := urlHost(op); h != "" {
+			out = append(out, [2]string{h, name})
+		}
+	}
+	return out
+}
+
+// downloads reports whether a package manager invocation fetches anything.
+func downloads(name string, operands, subcommands []string) bool {
+	if len(operands) == 0 {
+		// A bare yarn installs the project's dependencies
+		return name == "yarn"
+	}
+	for _, s := range subcommands {
+		if operands[0] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func gitDestinations(operands []string) [][2]string {
+	sub := operands[0]
+	switch sub {
+	case "clone", "fetch", "pull", "push", "ls-remote", "remote", "submodule":
+	default:
+		return nil
+	}
+	tool := "git " + sub
+	var out [][2]string
+	for _, op := range operands[1:] {
+		if h := urlHost(op); h != "" {
+			out = append(out, [2]string{h, tool})
+		} else if m := remotePathRe.FindStringSubmatch(op); m != nil && strings.Contains(op, "@") {
+			out = append(out, [2]string{m[1], tool})
+		}
+	}
+	if len(out) == 0 && sub != "remote" && sub != "clone" {
+		out = append(out, [2]string{"the git remote", tool})
+	}
+	return out
+}
+
+// operands drops options (and the values of options that take one) from
+// args.
+func operands(name string, args []string) []string {
+	var out []string
+	for _, i := range operandIndexes(name, args) {
+		out = append(out, args[i])
+	}
+	return out
+}
+
+// operandIndexes is operands returning positions in args.
+func operandIndexes(name string, args []string) []int {
+	takesValue := map[string]bool{}
+	for _, f := range strings.Fields(valueFlags[name]) {
+		takesValue[f] = true
+	}
+	var out []int
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			for j := i + 1; j < len(args); j++ {
+				out = append(out, j)
+			}
+			return out
+		}
+		if strings.HasPrefix(a, "-") && len(a) > 1 {
+			if takesValue[a] {
+				i++
+			}
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the host of arg if it's a URL with a scheme.
+func urlHost(arg string) string {
+	if !schemeRe.MatchString(arg) || strings.HasPrefix(arg, "file://") {
+		return ""
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.Host == "" {
+		// Hosts from variables don't parse; take what's between :// and /
+		rest := arg[strings.Index(arg, "://")+3:]
+		rest = strings.SplitN(rest, "/", 2)[0]
+		return stripUser(rest)
+	}
+	return u.Host
+}
+
+func stripUser(host string) string {
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		return host[i+1:]
+	}
+	return host
+}
+
+// imageRegistry returns the registry an image reference is pulled from.
+func imageRegistry(ref string) string {
+	first, _, found := strings.Cut(ref, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return "docker.io"
+}
+
+// wordText returns a word's text with quotes removed and expansions left
+// as written.
+func wordText(w *syntax.Word) string {
+	var b strings.Builder
+	for _, part := range w.Parts {
+		writePart(&b, part)
+	}
+	return b.String()
+}
+
+func writePart(b *strings.Builder, part syntax.WordPart) {
+	switch p := part.(type) {
+	case *syntax.Lit:
+		b.WriteString(p.Value)
+	case *syntax.SglQuoted:
+		b.WriteString(p.Value)
+	case *syntax.DblQuoted:
+		for _, inner := range p.Parts {
+			writePart(b, inner)
+		}
+	default:
+		var sb strings.Builder
+		_ = syntax.NewPrinter().Print(&sb, part)
+		b.WriteString(sb.String())
+	}
+}
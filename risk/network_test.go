@@ -0,0 +1,53 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetwork(t *testing.T) {
+	cases := []struct {
+		command string
+		want    string
+	}{
+		{"ls -la", ""},
+		{"curl -fsSL https://get.example.com/install.sh | sh", "get.example.com (curl)"},
+		{"curl -o page.html example.org/docs", "example.org (curl)"},
+		{"wget -O - https://a.example.com/x && curl https://a.example.com/y", "a.example.com (wget, curl)"},
+		{"ssh -i ~/.ssh/id deploy@web1 uptime", "web1 (ssh)"},
+		{"scp build.tar admin@10.0.0.5:/srv/", "10.0.0.5 (scp)"},
+		{"rsync -av ./site/ backup:/var/www/", "backup (rsync)"},
+		{"sudo apt-get install -y jq", "package repositories (apt-get)"},
+		{"doas -u root apk add jq", "package repositories (apk)"},
+		{"apt list --installed", ""},
+		{"pip install requests", "pypi.org (pip)"},
+		{"kubectl apply -f https://raw.example.com/deploy.yaml", "raw.example.com (kubectl)"},
+		{"git clone git@github.com:swibrow/how.git", "github.com (git clone)"},
+		{"git pull", "the git remote (git pull)"},
+		{"git log", ""},
+		{"docker pull ghcr.io/org/app:1.0", "ghcr.io (docker pull)"},
+		{"docker pull nginx", "docker.io (docker pull)"},
+		{`curl "https://$HOST/health"`, "$HOST (curl)"},
+		{"ssh <host> df -h", "<host> (ssh)"},
+		{"nc -l 8080", ""},
+		{"pacman -Syu", "package repositories (pacman)"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.command, func(t *testing.T) {
+			var got []string
+			for _, d := range Network(tc.command) {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "; ") != tc.want {
+				t.Errorf("Network(%q) = %q, want %q", tc.command, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClassifyIncludesNetwork(t *testing.T) {
+	a := Classify("curl https://example.com")
+	if a.Level != Safe || len(a.Network) != 1 || a.Network[0].Host != "example.com" {
+		t.Errorf("unexpected assessment: %+v", a)
+	}
+}
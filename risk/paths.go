@@ -0,0 +1,382 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/swibrow/how/internal/shell"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxGlobMatches bounds how many files one glob expands to.
+const maxGlobMatches = 1000
+
+// tarBundleRe matches tar's old-style leading option bundle, as in
+// "tar czf out.tgz dir".
+var tarBundleRe = regexp.MustCompile(`^[A-Za-z]*[ctxru][A-Za-z]*$`)
+
+// Paths lists the existing files and directories a command would touch.
+// Directories end in a slash.
+type Paths struct {
+	Read     []string
+	Modified []string
+	Deleted  []string
+}
+
+// Empty reports whether the command touches no existing paths.
+func (p Paths) Empty() bool {
+	return len(p.Read) == 0 && len(p.Modified) == 0 && len(p.Deleted) == 0
+}
+
+type effect int
+
+const (
+	reads effect = iota
+	modifies
+	deletes
+)
+
+// pathArg is a word that may name a path.
+type pathArg struct {
+	text string
+	// glob is set when the word has unquoted glob characters
+	glob bool
+	// dynamic is set when the word contains expansions that can't be
+	// resolved statically
+	dynamic bool
+}
+
+// notPaths are commands whose operands aren't worth reporting as reads.
+var notPaths = map[string]bool{
+	"cd": true, "pushd": true, "echo": true, "printf": true, "mkdir": true, "test": true,
+	"[": true, "[[": true, "export": true, "which": true, "type": true,
+	"man": true,
+}
+
+// AffectedPaths statically finds the existing files and directories command
+// would read, modify or delete when run in dir, expanding globs against the
+// filesystem. It knows common file commands (rm, mv, cp, sed -i, tee, tar,
+// chmod, find -delete, redirections, ...); operands of other commands that
+// exist are reported as read. Commands that can't be parsed report nothing.
+func AffectedPaths(command, dir string) Paths {
+	found := map[string]effect{}
+	walkPaths(command, func(arg pathArg, e effect) {
+		for _, p := range resolve(arg, dir) {
+			if prev, ok := found[p]; !ok || e > prev {
+				found[p] = e
+			}
+		}
+	})
+
+	var p Paths
+	for path, e := range found {
+		switch e {
+		case reads:
+			p.Read = append(p.Read, path)
+		case modifies:
+			p.Modified = append(p.Modified, path)
+		case deletes:
+			p.Deleted = append(p.Deleted, path)
+		}
+	}
+	sort.Strings(p.Read)
+	sort.Strings(p.Modified)
+	sort.Strings(p.Deleted)
+	return p
+}
+
+// walkPaths calls touch with each word of command that names a path, and
+// what the command does to it, without looking at the filesystem.
+func walkPaths(command string, touch func(pathArg, effect)) {
+	src := maskRe.ReplaceAllString(command, "HOWPH_${1}_HOWPH")
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			for _, r := range n.Redirs {
+				if r.Word == nil {
+					continue
+				}
+				switch r.Op {
+				case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+					touch(wordArg(r.Word), modifies)
+				case syntax.RdrIn:
+					touch(wordArg(r.Word), reads)
+				}
+			}
+		case *syntax.CallExpr:
+			args := make([]pathArg, len(n.Args))
+			for i, w := range n.Args {
+				args[i] = wordArg(w)
+			}
+			args = args[len(args)-len(shell.Unwrap(texts(args))):]
+			if len(args) > 0 {
+				callPaths(args, touch)
+			}
+		}
+		return true
+	})
+}
+
+// callPaths reports the paths one simple command touches.
+func callPaths(args []pathArg, touch func(pathArg, effect)) {
+	name := filepath.Base(args[0].text)
+	rest := args[1:]
+	flags := texts(rest)
+	var ops []pathArg
+	for _, i := range operandIndexes(name, flags) {
+		ops = append(ops, rest[i])
+	}
+	all := func(args []pathArg, e effect) {
+		for _, a := range args {
+			touch(a, e)
+		}
+	}
+	// afterFirst skips a script or pattern operand unless one was given as
+	// an option
+	afterFirst := func(given ...string) []pathArg {
+		for _, f := range given {
+			if hasFlag(flags, f) {
+				return ops
+			}
+		}
+		if len(ops) == 0 {
+			return nil
+		}
+		return ops[1:]
+	}
+
+	switch name {
+	case "rm", "rmdir", "unlink", "shred":
+		all(ops, deletes)
+	case "mv":
+		all(ops, modifies)
+	case "cp", "install", "rsync", "scp":
+		// Remote sources and destinations are left to Network
+		local := func(a pathArg) bool {
+			return name == "cp" || name == "install" || (urlHost(a.text) == "" && remotePathRe.FindString(a.text) == "")
+		}
+		for i, a := range ops {
+			switch {
+			case !local(a):
+			case i == len(ops)-1 && i > 0:
+				touch(a, modifies)
+			default:
+				touch(a, reads)
+			}
+		}
+	case "tee", "touch", "truncate":
+		all(ops, modifies)
+	case "chmod", "chown", "chgrp":
+		if hasFlag(flags, "--reference") {
+			all(ops, modifies)
+		} else if len(ops) > 0 {
+			all(ops[1:], modifies)
+		}
+	case "sed", "perl":
+		e := reads
+		for _, f := range flags {
+			if f == "--in-place" || strings.HasPrefix(f, "-i") || (name == "perl" && strings.HasPrefix(f, "-") && !strings.HasPrefix(f, "--") && strings.Contains(f, "i")) {
+				e = modifies
+			}
+		}
+		if name == "perl" {
+			all(afterFirst("-e", "-E"), e)
+		} else {
+			all(afterFirst("-e", "-f"), e)
+		}
+	case "grep", "egrep", "fgrep", "rg", "ag":
+		all(afterFirst("-e", "-f"), reads)
+	case "awk", "gawk":
+		// gawk edits in place with its inplace extension
+		e := reads
+		for i, f := range flags {
+			if f == "-iinplace" || f == "--include=inplace" || ((f == "-i" || f == "--include") && i+1 < len(flags) && flags[i+1] == "inplace") {
+				e = modifies
+			}
+		}
+		all(afterFirst("-f"), e)
+	case "jq":
+		all(afterFirst("-f"), reads)
+	case "yq":
+		e := reads
+		if hasFlag(flags, "-i") || hasFlag(flags, "--inplace") {
+			e = modifies
+		}
+		all(afterFirst(), e)
+	case "sort":
+		for i, f := range flags {
+			if f == "-o" && i+1 < len(rest) {
+				touch(rest[i+1], modifies)
+			}
+		}
+		all(ops, reads)
+	case "dd":
+		for _, a := range rest {
+			if v, ok := strings.CutPrefix(a.text, "if="); ok {
+				touch(pathArg{text: v, dynamic: a.dynamic}, reads)
+			} else if v, ok := strings.CutPrefix(a.text, "of="); ok {
+				touch(pathArg{text: v, dynamic: a.dynamic}, modifies)
+			}
+		}
+	case "tar":
+		tarPaths(rest, touch)
+	case "find":
+		e := reads
+		if hasFlag(flags, "-delete") {
+			e = deletes
+		}
+		for i, f := range flags {
+			if f == "-exec" && i+1 < len(flags) && (flags[i+1] == "rm" || flags[i+1] == "shred") {
+				e = deletes
+			}
+		}
+		for _, a := range rest {
+			if strings.HasPrefix(a.text, "-") || a.text == "(" || a.text == "!" {
+				break
+			}
+			touch(a, e)
+		}
+	case "git":
+		if len(ops) > 1 && ops[0].text == "rm" && !hasFlag(flags, "--cached") {
+			all(ops[1:], deletes)
+		} else if len(ops) > 1 && ops[0].text == "restore" {
+			all(ops[1:], modifies)
+		}
+	default:
+		if !notPaths[name] {
+			all(ops, reads)
+		}
+	}
+}
+
+// tarPaths handles tar's bundled options, where f takes the archive name
+// and C a directory. Members named when extracting aren't local paths.
+func tarPaths(args []pathArg, touch func(pathArg, effect)) {
+	var archive, files []pathArg
+	create := false
+	for i := 0; i < len(args); i++ {
+		a := args[i].text
+		switch {
+		case strings.HasPrefix(a, "--file="):
+			f := args[i]
+			f.text = strings.TrimPrefix(a, "--file=")
+			archive = append(archive, f)
+		case strings.HasPrefix(a, "--"):
+			create = create || a == "--create"
+		case strings.HasPrefix(a, "-") || (i == 0 && tarBundleRe.MatchString(a)):
+			bundle := strings.TrimPrefix(a, "-")
+			create = create || strings.ContainsAny(bundle, "cru")
+			for _, c := range bundle {
+				if (c == 'f' || c == 'C') && i+1 < len(args) {
+					i++
+					if c == 'f' {
+						archive = append(archive, args[i])
+					}
+				}
+			}
+		default:
+			files = append(files, args[i])
+		}
+	}
+	for _, a := range archive {
+		if create {
+			touch(a, modifies)
+		} else {
+			touch(a, reads)
+		}
+	}
+	if create {
+		for _, f := range files {
+			touch(f, reads)
+		}
+	}
+}
+
+// resolve returns the existing paths arg refers to relative to dir, with
+// directories ending in a slash.
+func resolve(arg pathArg, dir string) []string {
+	if arg.dynamic || arg.text == "" || arg.text == "-" || strings.HasPrefix(arg.text, "/dev/") || strings.Contains(arg.text, "HOWPH_") {
+		return nil
+	}
+	text := arg.text
+	if text == "~" || strings.HasPrefix(text, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		text = home + text[1:]
+	}
+	abs := text
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(dir, text)
+	}
+
+	var matches []string
+	if arg.glob {
+		m, err := filepath.Glob(abs)
+		if err != nil {
+			return nil
+		}
+		matches = m[:min(len(m), maxGlobMatches)]
+	} else {
+		matches = []string{abs}
+	}
+
+	var out []string
+	for _, m := range matches {
+		info, err := os.Lstat(m)
+		if err != nil {
+			continue
+		}
+		shown := m
+		if !filepath.IsAbs(text) {
+			if rel, err := filepath.Rel(dir, m); err == nil {
+				shown = rel
+			}
+		}
+		if info.IsDir() {
+			shown = strings.TrimSuffix(shown, "/") + "/"
+		}
+		out = append(out, shown)
+	}
+	return out
+}
+
+// wordArg converts a shell word to a pathArg.
+func wordArg(w *syntax.Word) pathArg {
+	arg := pathArg{text: wordText(w)}
+	for _, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			if strings.ContainsAny(p.Value, "*?[") {
+				arg.glob = true
+			}
+		case *syntax.SglQuoted:
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				if _, ok := inner.(*syntax.Lit); !ok {
+					arg.dynamic = true
+				}
+			}
+		default:
+			arg.dynamic = true
+		}
+	}
+	return arg
+}
+
+func texts(args []pathArg) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = a.text
+	}
+	return out
+}
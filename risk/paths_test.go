@@ -0,0 +1,51 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAffectedPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "notes.txt", "config.yml", "site/index.html"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		command string
+		want    Paths
+	}{
+		{"rm -f *.log missing.txt", Paths{Deleted: []string{"a.log", "b.log"}}},
+		{"rm '*.log'", Paths{}},
+		{"sed -i 's/a/b/' config.yml", Paths{Modified: []string{"config.yml"}}},
+		{"gawk -i inplace '{ sub(/a/, \"b\") } 1' config.yml", Paths{Modified: []string{"config.yml"}}},
+		{"awk '{ print $1 }' config.yml", Paths{Read: []string{"config.yml"}}},
+		{"sed 's/a/b/' config.yml > notes.txt", Paths{Read: []string{"config.yml"}, Modified: []string{"notes.txt"}}},
+		{"grep -r TODO site", Paths{Read: []string{"site/"}}},
+		{"cp notes.txt site", Paths{Read: []string{"notes.txt"}, Modified: []string{"site/"}}},
+		{"tar czf backup.tgz site notes.txt", Paths{Read: []string{"notes.txt", "site/"}}},
+		{"find . -name '*.log' -delete", Paths{Deleted: []string{"./"}}},
+		{"chmod 600 config.yml", Paths{Modified: []string{"config.yml"}}},
+		{"cat notes.txt | tee -a a.log", Paths{Read: []string{"notes.txt"}, Modified: []string{"a.log"}}},
+		{"sudo rm -rf site", Paths{Deleted: []string{"site/"}}},
+		{"doas rm -rf site", Paths{Deleted: []string{"site/"}}},
+		{`rm "$FILE"`, Paths{}},
+		{"scp notes.txt host:/tmp/", Paths{Read: []string{"notes.txt"}}},
+		{"echo notes.txt", Paths{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.command, func(t *testing.T) {
+			if got := AffectedPaths(tc.command, dir); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("AffectedPaths(%q) = %+v, want %+v", tc.command, got, tc.want)
+			}
+		})
+	}
+}
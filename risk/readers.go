@@ -0,0 +1,76 @@
+package risk
+
+import "strings"
+
+// readers are commands known to only read state whatever their
+// arguments.
+var readers = map[string]bool{
+	"cat": true, "head": true, "tail": true, "wc": true,
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "ag": true,
+	"ls": true, "stat": true, "du": true, "df": true,
+	"ps": true, "pgrep": true, "top": true, "free": true, "uptime": true,
+	"vmstat": true, "iostat": true, "mpstat": true, "sensors": true,
+	"who": true, "w": true, "whoami": true, "id": true, "uname": true,
+	"netstat": true, "lsof": true, "ping": true, "dig": true, "nslookup": true,
+	"echo": true, "printf": true, "true": true, "test": true, "[": true,
+	"printenv": true, "jq": true, "cut": true, "tr": true, "column": true, "nl": true,
+	"md5sum": true, "sha256sum": true,
+}
+
+// subReaders are commands that only read state when run with one of the
+// listed subcommands.
+var subReaders = map[string][]string{
+	"kubectl":   {"get", "describe", "logs", "top", "events", "explain", "version", "cluster-info", "api-resources"},
+	"git":       {"status", "log", "diff", "show", "ls-files", "rev-parse", "describe", "shortlog"},
+	"docker":    {"ps", "images", "logs", "inspect", "stats", "top", "version", "info"},
+	"podman":    {"ps", "images", "logs", "inspect", "stats", "top", "version", "info"},
+	"systemctl": {"status", "is-active", "is-failed", "list-units", "list-timers", "show"},
+	"helm":      {"list", "ls", "status", "history"},
+}
+
+// OnlyReads reports whether every simple command in command is known to
+// only read state, so it's safe to repeat. Unlike Assessment.ReadOnly,
+// which holds when no rule matches, this holds only for known readers,
+// such as ls, kubectl get or git status; anything else, or a command
+// that can't be parsed, doesn't qualify. Redirects aren't considered, so
+// callers should check ReadOnly as well.
+func OnlyReads(command string) bool {
+	known, all := false, true
+	eachCall(command, 0, func(args []string) {
+		known = true
+		if !isReader(args) {
+			all = false
+		}
+	})
+	return known && all
+}
+
+// isReader reports whether the simple command args only reads state.
+func isReader(args []string) bool {
+	if readers[args[0]] {
+		return true
+	}
+	subs, ok := subReaders[args[0]]
+	if !ok {
+		return false
+	}
+	// The subcommand is the first word that isn't an option, or the
+	// value of one, as in kubectl -n prod get pods
+	afterOption := false
+	for _, a := range args[1:] {
+		if strings.HasPrefix(a, "-") {
+			afterOption = !strings.Contains(a, "=")
+			continue
+		}
+		for _, sub := range subs {
+			if a == sub {
+				return true
+			}
+		}
+		if !afterOption {
+			return false
+		}
+		afterOption = false
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+// Package risk classifies shell commands by how much damage they could do,
+// what they change and which hosts they contact. It's used by how before
+// running a suggestion and by how check, and can be imported by other tools.
+package risk
+
+import "fmt"
+
+// Level is how much damage a command could do if it was the wrong one.
+type Level int
+
+const (
+	// Safe commands only read state.
+	Safe Level = iota
+	// Caution commands modify state in ways that are usually recoverable.
+	Caution
+	// Dangerous commands can destroy data or disrupt systems irreversibly.
+	Dangerous
+)
+
+func (l Level) String() string {
+	switch l {
+	case Caution:
+		return "caution"
+	case Dangerous:
+		return "dangerous"
+	default:
+		return "safe"
+	}
+}
+
+// ParseLevel parses the name of a level, as returned by String.
+func ParseLevel(s string) (Level, error) {
+	for _, l := range []Level{Safe, Caution, Dangerous} {
+		if s == l.String() {
+			return l, nil
+		}
+	}
+	return Safe, fmt.Errorf("unknown risk level %q (want safe, caution or dangerous)", s)
+}
+
+// MarshalText encodes the level by name, in rules files and JSON.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a level by name.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Assessment is the classification of a command with the reasons behind it.
+type Assessment struct {
+	Level   Level
+	Reasons []string
+	// Network lists the hosts the command would contact.
+	Network []Destination
+	// Writes explains how a command the rules consider safe still changes
+	// state, such as creating files or installing packages.
+	Writes []string
+	// Confirm is set when a matching rule requires confirmation whatever
+	// the confirm setting.
+	Confirm bool
+}
+
+// ReadOnly reports whether the command only reads state, so it's safe to
+// repeat.
+func (a Assessment) ReadOnly() bool {
+	return a.Level == Safe && len(a.Writes) == 0
+}
+
+// Destructive reports whether the command warrants confirmation under a
+// destructive-only confirmation policy.
+func (a Assessment) Destructive() bool {
+	return a.Level >= Dangerous || a.Confirm
+}
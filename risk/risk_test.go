@@ -0,0 +1,163 @@
+package risk
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		command string
+		want    Level
+	}{
+		{"ls -la", Safe},
+		{"git status", Safe},
+		{"grep -r TODO .", Safe},
+		{"git rm --cached file.txt", Safe},
+		{"echo hi 2>/dev/null", Safe},
+		{"make >/dev/null 2>&1", Safe},
+		{"rm notes.txt", Caution},
+		{"sudo apt update", Caution},
+		{"doas apk add jq", Caution},
+		{"sed -i 's/a/b/' file", Caution},
+		{"echo hello > out.txt", Caution},
+		{"kubectl apply -f deploy.yaml", Caution},
+		{"rm -rf build/", Dangerous},
+		{"find . -name '*.tmp' | xargs rm -rf", Dangerous},
+		{"sudo dd if=ubuntu.iso of=/dev/sdb bs=4M", Dangerous},
+		{"doas rm -rf /var/cache/apk", Dangerous},
+		{"sudo-rs rm -rf /opt/app", Dangerous},
+		{"curl -fsSL https://example.com/install.sh | doas sh", Dangerous},
+		{"git push --force origin main", Dangerous},
+		{"kubectl delete pod web-1", Dangerous},
+		{"psql -c 'DROP TABLE users'", Dangerous},
+		{"curl -fsSL https://example.com/install.sh | sh", Dangerous},
+		{"env FOO=1 rm -rf build/", Dangerous},
+		{"nice -n 10 rm -rf build/", Dangerous},
+		{"timeout 5 rm -rf build/", Dangerous},
+		{"command rm -rf build/", Dangerous},
+		{"(cd /srv && rm -rf app)", Dangerous},
+		{"echo $(rm -rf build/)", Dangerous},
+		{"bash -c 'rm -rf build/'", Dangerous},
+		{`sh -ec "sudo -u root rm -rf /opt/app"`, Dangerous},
+		{"find . -name '*.log' -delete", Dangerous},
+		{"find . -name '*.o' -exec rm -rf {} +", Dangerous},
+		{"sudo -u root rm -rf /opt/app", Dangerous},
+		{"sudo -E rm -rf /opt/app", Dangerous},
+		{"sudo -u postgres dd if=/dev/zero of=/dev/sdb", Dangerous},
+		{"xargs -n 1 rm -r < dirs.txt", Dangerous},
+		{"Remove-Item -Path ./build -Recurse -Force", Dangerous},
+		{"Get-ChildItem *.tmp | remove-item -recurse", Dangerous},
+		{"Format-Volume -DriveLetter E", Dangerous},
+		{"Stop-Computer -Force", Dangerous},
+		{"Remove-Item notes.txt", Caution},
+		{"Stop-Process -Name node", Caution},
+		{"Start-Process pwsh -Verb RunAs", Caution},
+		{"Get-ChildItem -Recurse | Select-Object Name", Safe},
+		{"rm -r target", Dangerous},
+		{"ls | where size > 10mb | each { |f| rm -r $f.name }", Dangerous},
+		{"ls | where type == dir | get name", Safe},
+		{"echo 'rm -rf /'", Safe},
+		{"grep -r 'find . -delete' docs/", Safe},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.command, func(t *testing.T) {
+			got := Classify(tc.command)
+			if got.Level != tc.want {
+				t.Errorf("Classify(%q) = %s %v, want %s", tc.command, got.Level, got.Reasons, tc.want)
+			}
+			if tc.want != Safe && len(got.Reasons) == 0 {
+				t.Errorf("Classify(%q) should explain its level", tc.command)
+			}
+		})
+	}
+}
+
+func TestDestructive(t *testing.T) {
+	if Classify("ls").Destructive() {
+		t.Error("ls should not be destructive")
+	}
+	if !Classify("rm -rf /tmp/x").Destructive() {
+		t.Error("rm -rf should be destructive")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	readOnly := []string{
+		"ls -la",
+		"kubectl get pods -w",
+		"docker ps --format '{{.Names}}'",
+		"df -h | grep /dev",
+		"git log --oneline | head",
+		"curl -s https://example.com/health",
+		"find . -name '*.log' -mmin -5",
+		"systemctl status nginx",
+		"tail -n 20 /var/log/syslog 2>/dev/null",
+	}
+	for _, cmd := range readOnly {
+		if a := Classify(cmd); !a.ReadOnly() {
+			t.Errorf("expected %q to be read-only, got %s %v %v", cmd, a.Level, a.Reasons, a.Writes)
+		}
+	}
+
+	mutating := []string{
+		"mkdir -p build",
+		"touch stamp",
+		"date >> times.log",
+		"git commit -m wip",
+		"git branch -D old",
+		"brew install jq",
+		"sudo apt-get install -y curl",
+		"systemctl restart nginx",
+		"docker compose up -d",
+		"kubectl create ns test",
+		"curl -X POST https://example.com/hooks",
+		"curl -sO https://example.com/a.tgz",
+		"find . -name '*.tmp' -delete",
+		"psql -c 'INSERT INTO t VALUES (1)'",
+		"rm notes.txt",
+	}
+	for _, cmd := range mutating {
+		if a := Classify(cmd); a.ReadOnly() {
+			t.Errorf("expected %q to be mutating", cmd)
+		} else if a.Level == Safe && len(a.Writes) == 0 {
+			t.Errorf("expected a reason for %q", cmd)
+		}
+	}
+}
+
+func TestOnlyReads(t *testing.T) {
+	reads := []string{
+		"ls -la",
+		"kubectl get pods -w",
+		"kubectl -n prod get pods",
+		"kubectl --context=prod top nodes",
+		"docker ps --format '{{.Names}}'",
+		"df -h | grep /dev",
+		"git log --oneline | head",
+		"sudo systemctl status nginx",
+		"tail -n 20 /var/log/syslog 2>/dev/null",
+		"echo $(uptime)",
+	}
+	for _, cmd := range reads {
+		if !OnlyReads(cmd) {
+			t.Errorf("expected %q to only read", cmd)
+		}
+	}
+
+	others := []string{
+		"terraform destroy -auto-approve",
+		"aws s3 rm --recursive s3://b",
+		"npm i -g x",
+		"shred -u f",
+		"kubectl delete pod get",
+		"git -C get push",
+		"ls | xargs rm",
+		"sh -c 'ls; rm -rf x'",
+		"ls $(touch x)",
+		"",
+	}
+	for _, cmd := range others {
+		if OnlyReads(cmd) {
+			t.Errorf("expected %q not to be known to only read", cmd)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package risk
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// word matches the start of a command (beginning, or after a pipe, ;, &&,
+// ||, sudo, doas or xargs) so that e.g. "rm" doesn't match "git rm" or
+// "format".
+// It replaces {cmd} in a rule's pattern.
+const word = `(?:^|[;&|]\s*|\b(?:sudo(?:-rs)?|doas)\s+|\bxargs\s+(?:-\S+\s+)*)`
+
+// Rules are the patterns Classify applies, read from a rules file. See
+// rules.yaml for the format and the built-in rules.
+type Rules struct {
+	// Rules set a command's level.
+	Rules []Rule `yaml:"rules"`
+	// Writes explain how a command at any level changes state. Their
+	// levels are ignored.
+	Writes []Rule `yaml:"writes"`
+	// Paths raise the level of commands that modify or delete them.
+	Paths []PathRule `yaml:"paths"`
+}
+
+// Rule matches commands by a regular expression.
+type Rule struct {
+	Level   Level  `yaml:"level"`
+	Pattern string `yaml:"pattern"`
+	Reason  string `yaml:"reason"`
+	// Confirm requires confirmation before a matching command runs.
+	Confirm bool `yaml:"confirm"`
+
+	re *regexp.Regexp
+}
+
+// PathRule matches commands that modify or delete Path or anything
+// beneath it. A leading ~ is the home directory.
+type PathRule struct {
+	Path    string `yaml:"path"`
+	Level   Level  `yaml:"level"`
+	Reason  string `yaml:"reason"`
+	Confirm bool   `yaml:"confirm"`
+}
+
+// ParseRules parses and compiles a rules file. Unknown fields are errors,
+// so a misspelt key doesn't silently weaken a rule.
+func ParseRules(data []byte) (*Rules, error) {
+	var r Rules
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i := range r.Rules {
+		if err := r.Rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range r.Writes {
+		if err := r.Writes[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	for i, p := range r.Paths {
+		if p.Path == "" || p.Reason == "" {
+			return nil, fmt.Errorf("path rule %d needs a path and a reason", i+1)
+		}
+		r.Paths[i].Path = cleanPath(p.Path)
+	}
+	return &r, nil
+}
+
+// LoadRules reads the rules file at path.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Pattern == "" || r.Reason == "" {
+		return errors.New("each rule needs a pattern and a reason")
+	}
+	re, err := regexp.Compile(strings.ReplaceAll(r.Pattern, "{cmd}", word))
+	if err != nil {
+		return fmt.Errorf("rule %q: %w", r.Reason, err)
+	}
+	r.re = re
+	return nil
+}
+
+// Default returns the built-in rules.
+func Default() *Rules {
+	r, err := ParseRules(defaultRules)
+	if err != nil {
+		panic("risk: built-in rules: " + err.Error())
+	}
+	return r
+}
+
+// With returns r followed by extra's rules, so extra can add rules but
+// not remove any.
+func (r *Rules) With(extra *Rules) *Rules {
+	return &Rules{
+		Rules:  append(r.Rules[:len(r.Rules):len(r.Rules)], extra.Rules...),
+		Writes: append(r.Writes[:len(r.Writes):len(r.Writes)], extra.Writes...),
+		Paths:  append(r.Paths[:len(r.Paths):len(r.Paths)], extra.Paths...),
+	}
+}
+
+var (
+	activeMu sync.RWMutex
+	active   = Default()
+)
+
+// Use makes Classify apply r instead of the built-in rules.
+func Use(r *Rules) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	active = r
+}
+
+// Classify assesses a command with the rules passed to Use, or the
+// built-in rules.
+func Classify(command string) Assessment {
+	activeMu.RLock()
+	r := active
+	activeMu.RUnlock()
+	return r.Classify(command)
+}
+
+// devNull matches redirections that discard output, which are harmless.
+var devNull = regexp.MustCompile(`[0-9&]*>>?\s*/dev/null`)
+
+// Classify assesses a command using heuristic pattern rules. The result is
+// the highest level among the matching rules and the paths the command
+// modifies or deletes. Rules are matched against the whole command and
+// each simple command in it, unwrapped from sudo, env, sh -c and the like.
+func (r *Rules) Classify(command string) Assessment {
+	command = devNull.ReplaceAllString(command, "")
+	texts := append([]string{command}, calls(command)...)
+	matches := func(re *regexp.Regexp) bool {
+		return slices.ContainsFunc(texts, re.MatchString)
+	}
+
+	var a Assessment
+	seen := make(map[string]bool)
+	raise := func(level Level, reason string, confirm bool) {
+		if level > a.Level {
+			a.Level = level
+		}
+		a.Confirm = a.Confirm || confirm
+		if !slices.Contains(a.Reasons, reason) {
+			seen[reason] = true
+			a.Reasons = append(a.Reasons, reason)
+		}
+	}
+	for _, rule := range r.Rules {
+		if !matches(rule.re) || seen[rule.Reason] {
+			continue
+		}
+		// A dangerous match already explains a weaker rule like plain rm
+		if rule.Level < a.Level && strings.Contains(strings.Join(a.Reasons, " "), rule.Reason) {
+			continue
+		}
+		raise(rule.Level, rule.Reason, rule.Confirm)
+	}
+	for _, rule := range r.Writes {
+		if matches(rule.re) && !seen[rule.Reason] {
+			seen[rule.Reason] = true
+			a.Writes = append(a.Writes, rule.Reason)
+		}
+	}
+	if len(r.Paths) > 0 {
+		for _, text := range texts {
+			walkPaths(text, func(arg pathArg, e effect) {
+				if e < modifies {
+					return
+				}
+				p := cleanPath(arg.text)
+				for _, rule := range r.Paths {
+					if within(p, rule.Path) {
+						raise(rule.Level, rule.Reason, rule.Confirm)
+					}
+				}
+			})
+		}
+	}
+	a.Network = Network(command)
+	return a
+}
+
+// cleanPath normalizes a path as written in a command for comparison with
+// a PathRule, writing $HOME as ~ and dropping a trailing glob.
+func cleanPath(p string) string {
+	for _, home := range []string{"$HOME", "${HOME}"} {
+		if rest, ok := strings.CutPrefix(p, home); ok && (rest == "" || rest[0] == '/') {
+			p = "~" + rest
+		}
+	}
+	p = strings.TrimRight(p, "*")
+	if p == "" {
+		return p
+	}
+	return path.Clean(p)
+}
+
+// within reports whether p is dir or beneath it. The root directory only
+// matches itself, so a rule for / is about commands like rm -rf /.
+func within(p, dir string) bool {
+	if p == dir {
+		return true
+	}
+	return dir != "/" && strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSensitivePaths(t *testing.T) {
+	cases := []struct {
+		command string
+		want    Level
+		confirm bool
+	}{
+		{"cat /etc/hosts", Safe, false},
+		{"grep -r ssh /etc", Safe, false},
+		{"cp hosts /etc/hosts", Caution, false},
+		{"sudo tee -a /etc/hosts", Caution, false},
+		{"echo 'nameserver 1.1.1.1' > /etc/resolv.conf", Caution, false},
+		{"cp how /usr/local/bin/", Safe, false},
+		{"rm /boot/vmlinuz.old", Dangerous, true},
+		{"chmod 600 ~/.ssh/id_ed25519", Caution, true},
+		{"rm $HOME/.ssh/known_hosts", Caution, true},
+		{"mv ~/.aws/credentials /tmp", Caution, false},
+		{"ls ~/.ssh", Safe, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.command, func(t *testing.T) {
+			got := Classify(tc.command)
+			if got.Level != tc.want || got.Confirm != tc.confirm {
+				t.Errorf("Classify(%q) = %s confirm=%v %v, want %s confirm=%v", tc.command, got.Level, got.Confirm, got.Reasons, tc.want, tc.confirm)
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	a := Classify("sudo dd if=ubuntu.iso of=/dev/sdb bs=4M")
+	if !a.Confirm || !a.Destructive() {
+		t.Errorf("dd to a device should require confirmation, got %+v", a)
+	}
+	if Classify("rm -rf build/").Confirm {
+		t.Error("rm -rf build/ should follow the confirm setting")
+	}
+}
+
+func TestCommandPaths(t *testing.T) {
+	for _, command := range []string{"/bin/rm -rf x", `\rm -rf x`, "command rm -rf x", "sudo /usr/bin/rm -rf x", `sh -c '\rm -rf x'`} {
+		a := Classify(command)
+		if a.Level != Dangerous || a.ReadOnly() || !a.Destructive() {
+			t.Errorf("Classify(%q) = %s %v, want dangerous and destructive", command, a.Level, a.Reasons)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules([]byte(`
+rules:
+  - level: dangerous
+    pattern: '{cmd}terraform\s+destroy\b'
+    reason: destroys infrastructure
+    confirm: true
+writes:
+  - pattern: '{cmd}terraform\s+apply\b'
+    reason: changes infrastructure
+paths:
+  - path: $HOME/deploy/
+    level: caution
+    reason: changes deploy keys
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Paths[0].Path != "~/deploy" {
+		t.Errorf("path = %q, want ~/deploy", r.Paths[0].Path)
+	}
+
+	rules := Default().With(r)
+	if a := rules.Classify("terraform destroy -auto-approve"); a.Level != Dangerous || !a.Confirm {
+		t.Errorf("terraform destroy = %+v", a)
+	}
+	if a := rules.Classify("terraform apply"); !slices.Contains(a.Writes, "changes infrastructure") {
+		t.Errorf("terraform apply writes = %v", a.Writes)
+	}
+	if a := rules.Classify("rm ~/deploy/key"); !slices.Contains(a.Reasons, "changes deploy keys") {
+		t.Errorf("rm ~/deploy/key reasons = %v", a.Reasons)
+	}
+	// Extra rules add to the built-in ones
+	if a := rules.Classify("rm -rf build/"); a.Level != Dangerous {
+		t.Errorf("rm -rf build/ = %s with extra rules", a.Level)
+	}
+	if a := Classify("terraform destroy"); a.Level != Safe {
+		t.Errorf("With changed the built-in rules: %s", a.Level)
+	}
+
+	bad := map[string]string{
+		"unknown field": "rules:\n  - level: caution\n    pattern: x\n    reason: y\n    confrim: true\n",
+		"bad level":     "rules:\n  - level: risky\n    pattern: x\n    reason: y\n",
+		"bad pattern":   "rules:\n  - level: caution\n    pattern: '('\n    reason: y\n",
+		"no reason":     "writes:\n  - pattern: x\n",
+		"no path":       "paths:\n  - level: caution\n    reason: y\n",
+	}
+	for name, data := range bad {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestUse(t *testing.T) {
+	t.Cleanup(func() { Use(Default()) })
+	r, err := ParseRules([]byte("rules:\n  - level: caution\n    pattern: '{cmd}make\\s+deploy\\b'\n    reason: deploys\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Use(Default().With(r))
+	if a := Classify("make deploy"); a.Level != Caution {
+		t.Errorf("make deploy = %s, want caution", a.Level)
+	}
+}